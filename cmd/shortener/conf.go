@@ -1,14 +1,26 @@
 package main
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/vagafonov/shortener/internal/config"
+	"github.com/vagafonov/shortener/pkg/encrypting"
+	"github.com/vagafonov/shortener/pkg/entity"
 )
 
+// randomCryptoKeySize is size of key generated when no key is configured.
+const randomCryptoKeySize = 16
+
+var errInvalidRetiredKey = errors.New("invalid retired key, expected id:hex")
+
 //nolint:tagliatelle
 type conf struct {
 	ServerAddress   string `json:"server_address"`
@@ -16,6 +28,13 @@ type conf struct {
 	FileStoragePath string `json:"file_storage_path"`
 	DatabaseDSN     string `json:"database_dsn"`
 	EnableHTTPS     bool   `json:"enable_https"`
+	DailyURLsQuota  int    `json:"daily_urls_quota"`
+	ActiveURLsQuota int    `json:"active_urls_quota"`
+	// CryptoKey and other keys for user token are used when options do not set them.
+	CryptoKey         string `json:"crypto_key"`
+	CryptoKeyID       string `json:"crypto_key_id"`
+	RetiredCryptoKeys string `json:"retired_crypto_keys"`
+	CryptoKeyFile     string `json:"crypto_key_file"`
 }
 
 func parseConfFile(opt *options) *config.Config { //nolint:cyclop
@@ -36,16 +55,74 @@ func parseConfFile(opt *options) *config.Config { //nolint:cyclop
 		opt.FileStoragePath,
 		opt.DatabaseDSN,
 		enableHTTPS,
-		[]byte("0123456789abcdef"),
+		nil,
 		10, //nolint:mnd,gomnd
 		2,  //nolint:mnd,gomnd
 		config.ModeDev,
 	)
-
-	if opt.ConfigFile == "" {
-		return cfg
+	cfg.DailyURLsQuota = opt.DailyURLsQuota
+	cfg.ActiveURLsQuota = opt.ActiveURLsQuota
+	if opt.UserTokenTTL > 0 {
+		cfg.UserTokenTTL = opt.UserTokenTTL
+	}
+	if opt.SessionTTL > 0 {
+		cfg.SessionTTL = opt.SessionTTL
+	}
+	if opt.LinkAccessTTL > 0 {
+		cfg.LinkAccessTTL = opt.LinkAccessTTL
+	}
+	if opt.LinkPasswordTries > 0 {
+		cfg.LinkPasswordAttempts = opt.LinkPasswordTries
+	}
+	if opt.RedirectStatus != 0 {
+		if !entity.IsRedirectStatus(opt.RedirectStatus) {
+			log.Fatalf("unsupported redirect status %d", opt.RedirectStatus)
+		}
+		cfg.RedirectStatus = opt.RedirectStatus
+	}
+	if opt.RedirectCacheTTL > 0 {
+		cfg.RedirectCacheTTL = opt.RedirectCacheTTL
+	}
+	cfg.GeoIPFile = opt.GeoIPFile
+	if opt.GeoIPReload > 0 {
+		cfg.GeoIPReloadInterval = opt.GeoIPReload
+	}
+	if cfg.TrustedProxies, err = parseTrustedProxies(opt.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	cfg.Domains = parseDomains(opt.Domains)
+	if opt.PreviewWorkers != 0 {
+		cfg.PreviewWorkers = max(opt.PreviewWorkers, 0)
+	}
+	if opt.PreviewTimeout > 0 {
+		cfg.PreviewTimeout = opt.PreviewTimeout
 	}
+	if opt.LinkCheckWorkers != 0 {
+		cfg.LinkCheckWorkers = max(opt.LinkCheckWorkers, 0)
+	}
+	if opt.LinkCheckInterval > 0 {
+		cfg.LinkCheckInterval = opt.LinkCheckInterval
+	}
+	if opt.IdempotencyTTL != 0 {
+		cfg.IdempotencyTTL = max(opt.IdempotencyTTL, 0)
+	}
+	cfg.OIDCIssuer = opt.OIDCIssuer
+	cfg.OIDCClientID = opt.OIDCClientID
+	cfg.OIDCClientSecret = opt.OIDCClientSecret
+	cfg.OIDCRedirectURL = opt.OIDCRedirectURL
+	if opt.ConfigFile != "" {
+		readConfFile(cfg, opt)
+	}
+	// Keyring is built after config file is read, so keys of config file are not ignored.
+	if err = setKeyring(cfg, opt); err != nil {
+		log.Fatal(err)
+	}
+
+	return cfg
+}
 
+// readConfFile fill settings not set by options from config file.
+func readConfFile(cfg *config.Config, opt *options) {
 	fBytes, err := os.ReadFile(opt.ConfigFile)
 	if err != nil {
 		log.Fatal(err)
@@ -70,6 +147,107 @@ func parseConfFile(opt *options) *config.Config { //nolint:cyclop
 	if !cfg.EnableHTTPS {
 		cfg.EnableHTTPS = conf.EnableHTTPS
 	}
+	if cfg.DailyURLsQuota == 0 {
+		cfg.DailyURLsQuota = conf.DailyURLsQuota
+	}
+	if cfg.ActiveURLsQuota == 0 {
+		cfg.ActiveURLsQuota = conf.ActiveURLsQuota
+	}
+	if opt.CryptoKey == "" && opt.CryptoKeyFile == "" {
+		opt.CryptoKey = conf.CryptoKey
+		opt.CryptoKeyID = conf.CryptoKeyID
+		opt.RetiredCryptoKeys = conf.RetiredCryptoKeys
+		opt.CryptoKeyFile = conf.CryptoKeyFile
+	}
+}
 
-	return cfg
+// parseTrustedProxies parse comma separated networks, single address is network of one address.
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	res := make([]netip.Prefix, 0)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse trusted proxy: %w", err)
+			}
+			res = append(res, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse trusted proxy: %w", err)
+		}
+		res = append(res, prefix.Masked())
+	}
+
+	return res, nil
+}
+
+// parseDomains parse comma separated hosts of branded domains.
+func parseDomains(s string) []string {
+	res := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+// setKeyring set keys for user token from key file or from options.
+func setKeyring(cfg *config.Config, opt *options) error {
+	if opt.CryptoKeyFile != "" {
+		kr, err := encrypting.LoadKeyring(opt.CryptoKeyFile)
+		if err != nil {
+			return err
+		}
+		cfg.Keyring = kr
+
+		return nil
+	}
+
+	if opt.CryptoKey == "" {
+		// Ключ генерируется на каждый запуск, поэтому токены пользователей не переживают рестарт.
+		key, err := encrypting.GenerateRandom(randomCryptoKeySize)
+		if err != nil {
+			return fmt.Errorf("cannot generate crypto key: %w", err)
+		}
+		cfg.CryptoKey = key
+		cfg.Keyring = encrypting.NewKeyring(config.DefaultCryptoKeyID, key)
+
+		return nil
+	}
+
+	key, err := hex.DecodeString(opt.CryptoKey)
+	if err != nil {
+		return fmt.Errorf("cannot decode crypto key: %w", err)
+	}
+	keyID := opt.CryptoKeyID
+	if keyID == "" {
+		keyID = config.DefaultCryptoKeyID
+	}
+	cfg.CryptoKey = key
+	cfg.Keyring = encrypting.NewKeyring(keyID, key)
+
+	if opt.RetiredCryptoKeys != "" {
+		for _, v := range strings.Split(opt.RetiredCryptoKeys, ",") {
+			id, hexKey, ok := strings.Cut(strings.TrimSpace(v), ":")
+			if !ok {
+				return errInvalidRetiredKey
+			}
+			retired, err := hex.DecodeString(hexKey)
+			if err != nil {
+				return fmt.Errorf("cannot decode retired key %q: %w", id, err)
+			}
+			cfg.Keyring.AddRetired(id, retired)
+		}
+	}
+
+	return cfg.Keyring.Validate()
 }
@@ -1,6 +1,9 @@
 package main
 
-import "flag"
+import (
+	"flag"
+	"time"
+)
 
 func parseFlags(opt *options) {
 	flag.StringVar(&opt.ServerURL, "a", "127.0.0.1:8080", "address and port to run server")
@@ -9,5 +12,30 @@ func parseFlags(opt *options) {
 	flag.StringVar(&opt.DatabaseDSN, "d", "", "database dsn")
 	flag.StringVar(&opt.EnableHTTPS, "s", "", "enable https")
 	flag.StringVar(&opt.ConfigFile, "c", "", "config file path")
+	flag.IntVar(&opt.DailyURLsQuota, "daily-quota", 0, "max URLs created by user per day, 0 means unlimited")
+	flag.IntVar(&opt.ActiveURLsQuota, "active-quota", 0, "max active URLs of user, 0 means unlimited")
+	flag.StringVar(&opt.CryptoKey, "crypto-key", "", "hex encoded active key for user token")
+	flag.StringVar(&opt.CryptoKeyID, "crypto-key-id", "", "id of active key for user token")
+	flag.StringVar(&opt.RetiredCryptoKeys, "retired-crypto-keys", "", "decrypt-only keys in format id:hex,id:hex")
+	flag.StringVar(&opt.CryptoKeyFile, "crypto-key-file", "", "JSON file with keys for user token")
+	flag.DurationVar(&opt.UserTokenTTL, "user-token-ttl", time.Hour, "lifetime of user token")
+	flag.DurationVar(&opt.SessionTTL, "session-ttl", 30*24*time.Hour, "lifetime of account session") //nolint:mnd,gomnd
+	flag.StringVar(&opt.OIDCIssuer, "oidc-issuer", "", "issuer of OpenID Connect provider, empty disables SSO")
+	flag.StringVar(&opt.OIDCClientID, "oidc-client-id", "", "client id of OpenID Connect provider")
+	flag.StringVar(&opt.OIDCClientSecret, "oidc-client-secret", "", "client secret of OpenID Connect provider")
+	flag.StringVar(&opt.OIDCRedirectURL, "oidc-redirect-url", "", "absolute URL of /api/user/oidc/callback")
+	flag.DurationVar(&opt.LinkAccessTTL, "link-access-ttl", 10*time.Minute, "access after password") //nolint:mnd,gomnd
+	flag.IntVar(&opt.LinkPasswordTries, "link-password-attempts", 5, "password tries per IP in 15m") //nolint:mnd,gomnd
+	flag.IntVar(&opt.RedirectStatus, "redirect-status", 0, "default redirect status: 301, 302, 307 or 308")
+	flag.DurationVar(&opt.RedirectCacheTTL, "redirect-cache-ttl", 0, "cache lifetime of permanent redirects")
+	flag.StringVar(&opt.GeoIPFile, "geoip-file", "", "CSV file of networks and countries for country rules")
+	flag.DurationVar(&opt.GeoIPReload, "geoip-reload-interval", 0, "how often geo database file is checked")
+	flag.StringVar(&opt.TrustedProxies, "trusted-proxies", "", "networks of trusted proxies, comma separated")
+	flag.StringVar(&opt.Domains, "domains", "", "hosts of branded domains, comma separated")
+	flag.IntVar(&opt.PreviewWorkers, "preview-workers", 0, "workers fetching previews, negative disables fetching")
+	flag.DurationVar(&opt.PreviewTimeout, "preview-timeout", 0, "timeout of fetching preview of destination")
+	flag.IntVar(&opt.LinkCheckWorkers, "link-check-workers", 0, "simultaneous checks of destinations, negative disables")
+	flag.DurationVar(&opt.LinkCheckInterval, "link-check-interval", 0, "how often every live URL is checked")
+	flag.DurationVar(&opt.IdempotencyTTL, "idempotency-ttl", 0, "how long responses by idempotency keys are kept")
 	flag.Parse()
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/rs/zerolog"
@@ -15,6 +16,7 @@ import (
 	"github.com/vagafonov/shortener/internal/logger"
 	"github.com/vagafonov/shortener/internal/service"
 	"github.com/vagafonov/shortener/internal/storage"
+	"github.com/vagafonov/shortener/pkg/geoip"
 	"github.com/vagafonov/shortener/pkg/hasher"
 )
 
@@ -31,6 +33,38 @@ type options struct {
 	DatabaseDSN     string `env:"DATABASE_DSN"`
 	EnableHTTPS     string `env:"ENABLE_HTTPS"`
 	ConfigFile      string `env:"CONFIG_FILE"`
+	DailyURLsQuota  int    `env:"DAILY_URLS_QUOTA"`
+	ActiveURLsQuota int    `env:"ACTIVE_URLS_QUOTA"`
+	// CryptoKey hex encoded active key for user token.
+	CryptoKey   string `env:"CRYPTO_KEY"`
+	CryptoKeyID string `env:"CRYPTO_KEY_ID"`
+	// RetiredCryptoKeys decrypt-only keys in format "id:hex,id:hex".
+	RetiredCryptoKeys string        `env:"RETIRED_CRYPTO_KEYS"`
+	CryptoKeyFile     string        `env:"CRYPTO_KEY_FILE"`
+	UserTokenTTL      time.Duration `env:"USER_TOKEN_TTL"`
+	SessionTTL        time.Duration `env:"SESSION_TTL"`
+	LinkAccessTTL     time.Duration `env:"LINK_ACCESS_TTL"`
+	LinkPasswordTries int           `env:"LINK_PASSWORD_ATTEMPTS"`
+	OIDCIssuer        string        `env:"OIDC_ISSUER"`
+	OIDCClientID      string        `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret  string        `env:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL   string        `env:"OIDC_REDIRECT_URL"`
+	RedirectStatus    int           `env:"REDIRECT_STATUS"`
+	RedirectCacheTTL  time.Duration `env:"REDIRECT_CACHE_TTL"`
+	GeoIPFile         string        `env:"GEOIP_FILE"`
+	GeoIPReload       time.Duration `env:"GEOIP_RELOAD_INTERVAL"`
+	// TrustedProxies networks or addresses of proxies in format "10.0.0.0/8,192.0.2.1".
+	TrustedProxies string `env:"TRUSTED_PROXIES"`
+	// Domains hosts of branded domains in format "go.brand-a.com,brand-b.link".
+	Domains string `env:"BRANDED_DOMAINS"`
+	// PreviewWorkers number of workers fetching previews of destinations, negative value disables fetching.
+	PreviewWorkers int           `env:"PREVIEW_WORKERS"`
+	PreviewTimeout time.Duration `env:"PREVIEW_TIMEOUT"`
+	// LinkCheckWorkers number of simultaneous checks of destinations, negative value disables checking.
+	LinkCheckWorkers  int           `env:"LINK_CHECK_WORKERS"`
+	LinkCheckInterval time.Duration `env:"LINK_CHECK_INTERVAL"`
+	// IdempotencyTTL how long responses of requests with idempotency key are kept, negative value disables keys.
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL"`
 }
 
 func main() {
@@ -46,6 +80,9 @@ func main() {
 	cfg := parseConfFile(opt)
 
 	lr := logger.CreateLogger(cfg.LogLevel)
+	if opt.CryptoKey == "" && opt.CryptoKeyFile == "" {
+		lr.Warn().Msg("crypto key is not configured, random key is generated, user tokens are invalid after restart")
+	}
 	var strg contract.Storage
 	var err error
 	var db *sql.DB
@@ -77,6 +114,14 @@ func main() {
 	setBackupStorage(cnt, lr)
 	setServiceStorage(cnt, lr)
 	setHealthCheckService(cnt, lr)
+	setAPIKeyService(cnt, lr)
+	setAccountService(cnt, lr)
+	setOIDCService(cnt, lr)
+	setTeamService(cnt, lr)
+	setGeoLocator(ctx, cnt, lr)
+	setPreviewService(ctx, cnt, lr)
+	setLinkCheckService(ctx, cnt, lr)
+	setIdempotencyService(cnt, lr)
 
 	app := application.NewApplication(cnt)
 	err = runServer(ctx, cfg.EnableHTTPS, app)
@@ -118,6 +163,102 @@ func setServiceStorage(cnt *container.Container, lr *zerolog.Logger) {
 	cnt.SetServiceURL(servURL)
 }
 
+func setAPIKeyService(cnt *container.Container, lr *zerolog.Logger) {
+	servAPIKey, err := service.ServiceAPIKeyFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+	}
+	cnt.SetServiceAPIKey(servAPIKey)
+}
+
+func setAccountService(cnt *container.Container, lr *zerolog.Logger) {
+	servAccount, err := service.ServiceAccountFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+	}
+	cnt.SetServiceAccount(servAccount)
+}
+
+func setOIDCService(cnt *container.Container, lr *zerolog.Logger) {
+	if cnt.GetConfig().OIDCIssuer == "" {
+		return
+	}
+	servOIDC, err := service.ServiceOIDCFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+	}
+	cnt.SetServiceOIDC(servOIDC)
+}
+
+func setTeamService(cnt *container.Container, lr *zerolog.Logger) {
+	servTeam, err := service.ServiceTeamFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+	}
+	cnt.SetServiceTeam(servTeam)
+}
+
+// setGeoLocator load geo database and reload it when file changes.
+func setGeoLocator(ctx context.Context, cnt *container.Container, lr *zerolog.Logger) {
+	cfg := cnt.GetConfig()
+	if cfg.GeoIPFile == "" {
+		return
+	}
+	db, err := geoip.Open(cfg.GeoIPFile)
+	if err != nil {
+		lr.Err(err).Msg("cannot load geo database, country rules are ignored")
+
+		return
+	}
+	cnt.SetGeoLocator(db)
+	go db.Watch(ctx, cfg.GeoIPReloadInterval, func(err error) {
+		lr.Warn().Err(err).Msg("cannot reload geo database")
+	})
+}
+
+// setPreviewService start workers fetching previews of destinations.
+func setPreviewService(ctx context.Context, cnt *container.Container, lr *zerolog.Logger) {
+	if cnt.GetConfig().PreviewWorkers <= 0 {
+		return
+	}
+	servPreview, err := service.ServicePreviewFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+
+		return
+	}
+	cnt.SetServicePreview(servPreview)
+	go servPreview.Run(ctx)
+}
+
+// setLinkCheckService start periodic checks of destinations of live URLs.
+func setLinkCheckService(ctx context.Context, cnt *container.Container, lr *zerolog.Logger) {
+	if cnt.GetConfig().LinkCheckWorkers <= 0 {
+		return
+	}
+	servLinkCheck, err := service.ServiceLinkCheckFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+
+		return
+	}
+	go servLinkCheck.Run(ctx)
+}
+
+// setIdempotencyService enable idempotency keys on creating endpoints.
+func setIdempotencyService(cnt *container.Container, lr *zerolog.Logger) {
+	if cnt.GetConfig().IdempotencyTTL <= 0 {
+		return
+	}
+	servIdempotency, err := service.ServiceIdempotencyFactory(cnt, "real")
+	if err != nil {
+		lr.Err(err).Send()
+
+		return
+	}
+	cnt.SetServiceIdempotency(servIdempotency)
+}
+
 //nolint:forbidigo
 func printBuildInfo() {
 	fmt.Printf("Build version: %s\n", buildVersion)
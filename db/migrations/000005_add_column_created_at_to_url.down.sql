@@ -0,0 +1 @@
+alter table urls drop column created_at;
@@ -0,0 +1 @@
+alter table urls add created_at timestamp not null default now();
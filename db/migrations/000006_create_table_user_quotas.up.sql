@@ -0,0 +1,7 @@
+create table user_quotas
+(
+    user_id uuid    not null,
+    day     date    not null,
+    created integer not null default 0,
+    primary key (user_id, day)
+);
@@ -0,0 +1,12 @@
+create table api_keys
+(
+    id         uuid         not null primary key,
+    user_id    uuid         not null,
+    name       varchar(255) not null,
+    prefix     varchar(16)  not null,
+    hash       varchar(64)  not null unique,
+    scopes     varchar(255) not null,
+    created_at timestamp    not null default now(),
+    revoked_at timestamp
+);
+create index api_keys_user_id_idx on api_keys (user_id);
@@ -0,0 +1,16 @@
+create table accounts
+(
+    id            uuid         not null primary key,
+    login         varchar(64)  not null unique,
+    password_hash varchar(72)  not null,
+    created_at    timestamp    not null default now()
+);
+create table sessions
+(
+    hash       varchar(64) not null primary key,
+    user_id    uuid        not null,
+    created_at timestamp   not null default now(),
+    expires_at timestamp   not null,
+    deleted_at timestamp
+);
+create index sessions_user_id_idx on sessions (user_id);
@@ -0,0 +1,5 @@
+drop index urls_team_id_idx;
+alter table urls drop column team_id;
+drop table team_members;
+drop table teams;
+drop table organizations;
@@ -0,0 +1,25 @@
+create table organizations
+(
+    id         uuid         not null primary key,
+    name       varchar(255) not null,
+    owner_id   uuid         not null,
+    created_at timestamp    not null default now()
+);
+create table teams
+(
+    id              uuid         not null primary key,
+    organization_id uuid         not null references organizations (id),
+    name            varchar(255) not null,
+    created_at      timestamp    not null default now()
+);
+create table team_members
+(
+    team_id    uuid        not null references teams (id),
+    user_id    uuid        not null,
+    role       varchar(16) not null,
+    created_at timestamp   not null default now(),
+    primary key (team_id, user_id)
+);
+create index team_members_user_id_idx on team_members (user_id);
+alter table urls add column team_id uuid;
+create index urls_team_id_idx on urls (team_id);
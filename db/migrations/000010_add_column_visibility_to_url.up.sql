@@ -0,0 +1 @@
+alter table urls add visibility varchar(16) not null default 'public';
@@ -0,0 +1 @@
+alter table urls add password_hash varchar(72) not null default '';
@@ -0,0 +1,2 @@
+alter table urls drop column clicks;
+alter table urls drop column max_clicks;
@@ -0,0 +1,2 @@
+alter table urls add max_clicks bigint not null default 0;
+alter table urls add clicks bigint not null default 0;
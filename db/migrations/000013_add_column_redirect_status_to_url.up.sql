@@ -0,0 +1 @@
+alter table urls add redirect_status smallint not null default 0;
@@ -0,0 +1,2 @@
+alter table urls drop column utm;
+alter table urls drop column query_mode;
@@ -0,0 +1,2 @@
+alter table urls add query_mode varchar(16) not null default '';
+alter table urls add utm jsonb not null default '{}';
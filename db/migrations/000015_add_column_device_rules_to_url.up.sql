@@ -0,0 +1 @@
+alter table urls add device_rules jsonb not null default '[]';
@@ -0,0 +1,2 @@
+drop table url_variant_clicks;
+alter table urls drop column variants;
@@ -0,0 +1,9 @@
+alter table urls add variants jsonb not null default '[]';
+
+create table url_variant_clicks
+(
+    short   varchar(255) not null,
+    variant varchar(64)  not null,
+    clicks  bigint       not null default 0,
+    primary key (short, variant)
+);
@@ -0,0 +1 @@
+alter table urls add country_rules jsonb not null default '[]';
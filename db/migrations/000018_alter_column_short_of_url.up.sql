@@ -0,0 +1 @@
+alter table urls alter column short type varchar(255);
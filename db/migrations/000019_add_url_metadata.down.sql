@@ -0,0 +1,4 @@
+alter table urls drop column tags;
+alter table urls drop column notes;
+alter table urls drop column description;
+alter table urls drop column title;
@@ -0,0 +1,4 @@
+alter table urls add title varchar(255) not null default '';
+alter table urls add description text not null default '';
+alter table urls add notes text not null default '';
+alter table urls add tags jsonb not null default '[]';
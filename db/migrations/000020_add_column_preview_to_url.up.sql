@@ -0,0 +1 @@
+alter table urls add preview jsonb;
@@ -0,0 +1 @@
+alter table urls add link_check jsonb;
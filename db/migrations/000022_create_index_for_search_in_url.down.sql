@@ -0,0 +1 @@
+drop index if exists urls_search_idx;
@@ -0,0 +1,3 @@
+create extension if not exists pg_trgm;
+create index urls_search_idx on urls
+    using gin ((lower(short || ' ' || original || ' ' || title || ' ' || description || ' ' || notes)) gin_trgm_ops);
@@ -0,0 +1,13 @@
+create table idempotency_keys
+(
+    user_id      uuid         not null,
+    key          varchar(255) not null,
+    request_hash varchar(64)  not null,
+    status       integer      not null default 0,
+    content_type varchar(255) not null default '',
+    body         bytea,
+    created_at   timestamp    not null default now(),
+    expires_at   timestamp    not null,
+    primary key (user_id, key)
+);
+create index idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
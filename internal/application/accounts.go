@@ -0,0 +1,114 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/cookie"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/request"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+)
+
+func (a *Application) register(res http.ResponseWriter, req *http.Request) {
+	validatedRequest, ok := a.readAccountRequest(res, req)
+	if !ok {
+		return
+	}
+
+	_, err := a.cnt.GetServiceAccount().Register(req.Context(), validatedRequest.Login, validatedRequest.Password)
+	if err != nil {
+		if errors.Is(err, customerror.ErrAccountAlreadyExists) {
+			a.writeError(res, req, http.StatusConflict, err)
+
+			return
+		}
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot register account")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	a.login(res, req, validatedRequest, http.StatusCreated)
+}
+
+func (a *Application) signIn(res http.ResponseWriter, req *http.Request) {
+	validatedRequest, ok := a.readAccountRequest(res, req)
+	if !ok {
+		return
+	}
+
+	a.login(res, req, validatedRequest, http.StatusOK)
+}
+
+// login open session, links of anonymous user from cookie are moved to account on its first login.
+func (a *Application) login(
+	res http.ResponseWriter,
+	req *http.Request,
+	accountRequest *request.AccountRequest,
+	statusCode int,
+) {
+	anonymousUserID, err := a.getUserIDFromCookie(req)
+	if err != nil {
+		a.cnt.GetLogger().Info().Err(err).Msg("cannot get anonymous user from cookie")
+	}
+
+	token, account, err := a.cnt.GetServiceAccount().Login(
+		req.Context(),
+		accountRequest.Login,
+		accountRequest.Password,
+		anonymousUserID,
+	)
+	if err != nil {
+		if errors.Is(err, customerror.ErrInvalidCredentials) {
+			a.writeError(res, req, http.StatusUnauthorized, err)
+
+			return
+		}
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot login")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	http.SetCookie(res, cookie.NewSessionCookie(token, a.cnt.GetConfig().SessionTTL))
+	a.writeJSON(res, req, statusCode, response.NewAccountResponse(account))
+}
+
+func (a *Application) logout(res http.ResponseWriter, req *http.Request) {
+	if sessionCookie, err := req.Cookie(cookie.SessionName); err == nil && sessionCookie.Value != "" {
+		if err = a.cnt.GetServiceAccount().Logout(req.Context(), sessionCookie.Value); err != nil {
+			a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot logout")
+			a.writeError(res, req, http.StatusInternalServerError, nil)
+
+			return
+		}
+	}
+
+	http.SetCookie(res, cookie.ExpiredSessionCookie())
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (a *Application) readAccountRequest(
+	res http.ResponseWriter,
+	req *http.Request,
+) (*request.AccountRequest, bool) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return nil, false
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).AccountRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return nil, false
+	}
+
+	return validatedRequest, true
+}
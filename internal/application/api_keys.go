@@ -0,0 +1,126 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/middleware"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+func (a *Application) createAPIKey(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).CreateAPIKeyRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	// API ключ не может выпустить ключ с большими правами, чем у него самого
+	if apiKey := middleware.APIKeyFromContext(req.Context()); apiKey != nil {
+		scopes := validatedRequest.Scopes
+		if len(scopes) == 0 {
+			scopes = entity.AllScopes
+		}
+		for _, v := range scopes {
+			if !slices.Contains(apiKey.Scopes, v) {
+				a.writeError(res, req, http.StatusForbidden, customerror.ErrAPIKeyScope)
+
+				return
+			}
+		}
+	}
+
+	plain, key, err := a.cnt.GetServiceAPIKey().CreateAPIKey(
+		req.Context(),
+		userID,
+		validatedRequest.Name,
+		validatedRequest.Scopes,
+	)
+	if err != nil {
+		if errors.Is(err, customerror.ErrAPIKeyUnknownScope) {
+			a.writeError(res, req, http.StatusBadRequest, err)
+
+			return
+		}
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot create api key")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	resp := response.NewAPIKeyResponse(key)
+	resp.Key = plain
+	a.writeJSON(res, req, http.StatusCreated, resp)
+}
+
+func (a *Application) userAPIKeys(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	keys, err := a.cnt.GetServiceAPIKey().GetUserAPIKeys(req.Context(), userID)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot get user api keys")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	keysResp := make([]response.APIKeyResponse, len(keys))
+	for k, v := range keys {
+		keysResp[k] = response.NewAPIKeyResponse(v)
+	}
+
+	a.writeJSON(res, req, http.StatusOK, keysResp)
+}
+
+func (a *Application) revokeAPIKey(res http.ResponseWriter, req *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(req, "id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeDelete)
+	if !ok {
+		return
+	}
+
+	err = a.cnt.GetServiceAPIKey().RevokeAPIKey(req.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, customerror.ErrAPIKeyNotFound) {
+			a.writeError(res, req, http.StatusNotFound, err)
+
+			return
+		}
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot revoke api key")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
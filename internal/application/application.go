@@ -11,6 +11,7 @@ import (
 	_ "net/http/pprof" //nolint:gosec
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,28 +23,37 @@ import (
 	"github.com/vagafonov/shortener/internal/cookie"
 	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/internal/middleware"
+	"github.com/vagafonov/shortener/internal/problem"
+	"github.com/vagafonov/shortener/internal/request"
 	"github.com/vagafonov/shortener/internal/response"
 	"github.com/vagafonov/shortener/internal/validate"
 	"github.com/vagafonov/shortener/pkg/encrypting"
 	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/ratelimit"
 )
 
 // Application Contains routes and starts the server.
 type Application struct {
 	cnt *container.Container
 	srv *http.Server
+	// linkPasswordLimiter limits attempts to enter password of link per code and IP.
+	linkPasswordLimiter *ratelimit.Limiter
 }
 
 // Constructor for application.
 func NewApplication(cnt *container.Container) *Application {
 	return &Application{
 		cnt: cnt,
+		linkPasswordLimiter: ratelimit.NewLimiter(
+			cnt.GetConfig().LinkPasswordAttempts,
+			cnt.GetConfig().LinkPasswordAttemptsIn,
+		),
 	}
 }
 
 // Serve run server.
 func (a *Application) Serve(ctx context.Context) error {
-	a.restoreURLs(ctx)
+	a.restore(ctx)
 	//nolint:gosec
 	a.srv = &http.Server{
 		Addr:    a.cnt.GetConfig().ServerURL,
@@ -73,7 +83,7 @@ func (a *Application) Serve(ctx context.Context) error {
 
 // ServeHTTPS Serve run HTTPS server.
 func (a *Application) ServeHTTPS(ctx context.Context) error {
-	a.restoreURLs(ctx)
+	a.restore(ctx)
 	//nolint:gosec
 	a.srv = &http.Server{
 		Addr:    a.cnt.GetConfig().ServerURL,
@@ -107,13 +117,32 @@ func (a *Application) ServeHTTPS(ctx context.Context) error {
 	return nil
 }
 
-func (a *Application) restoreURLs(ctx context.Context) {
+// restore fill main storage with URLs, API keys, accounts and teams from backup storage.
+func (a *Application) restore(ctx context.Context) {
 	if a.cnt.GetConfig().FileStoragePath != "" {
 		restored, err := a.cnt.GetServiceURL().RestoreURLs(ctx, a.cnt.GetConfig().FileStoragePath)
 		if err != nil {
 			a.cnt.GetLogger().Info().Msgf("cannot restore URLs: %s", err.Error())
 		}
 		a.cnt.GetLogger().Info().Msgf("restored urls %v", restored)
+
+		restored, err = a.cnt.GetServiceAPIKey().RestoreAPIKeys(ctx)
+		if err != nil {
+			a.cnt.GetLogger().Info().Msgf("cannot restore API keys: %s", err.Error())
+		}
+		a.cnt.GetLogger().Info().Msgf("restored api keys %v", restored)
+
+		restored, err = a.cnt.GetServiceAccount().RestoreAccounts(ctx)
+		if err != nil {
+			a.cnt.GetLogger().Info().Msgf("cannot restore accounts: %s", err.Error())
+		}
+		a.cnt.GetLogger().Info().Msgf("restored accounts %v", restored)
+
+		restored, err = a.cnt.GetServiceTeam().RestoreTeams(ctx)
+		if err != nil {
+			a.cnt.GetLogger().Info().Msgf("cannot restore teams: %s", err.Error())
+		}
+		a.cnt.GetLogger().Info().Msgf("restored teams %v", restored)
 	}
 }
 
@@ -122,24 +151,69 @@ func (a *Application) Routes() *chi.Mux {
 	r := chi.NewRouter()
 	// Middleware для логирования запросов
 	mw := middleware.NewMiddleware(a.cnt.GetLogger())
+	r.Use(mw.WithRequestID)
 	r.Use(mw.WithLogging)
 	r.Use(mw.WithCompress)
 	r.Use(func(handler http.Handler) http.Handler {
-		return mw.WithUserIDCookie(handler, a.cnt.GetConfig().CryptoKey)
+		return mw.WithUserIDCookie(
+			handler,
+			a.cnt.GetConfig().Keyring,
+			a.cnt.GetConfig().UserTokenTTL,
+			a.cnt.GetConfig().UserTokenRefreshBefore,
+		)
+	})
+	r.Use(func(handler http.Handler) http.Handler {
+		return mw.WithAPIKey(handler, a.cnt.GetServiceAPIKey())
+	})
+	r.Use(func(handler http.Handler) http.Handler {
+		return mw.WithSession(handler, a.cnt.GetServiceAccount())
 	})
 	if a.cnt.GetConfig().Mode == config.ModeDev {
 		r.Mount("/debug", chimiddleware.Profiler())
 	}
+	r.NotFound(func(res http.ResponseWriter, req *http.Request) {
+		a.writeError(res, req, http.StatusNotFound, nil)
+	})
 	r.Get("/{short_url}", a.getShortURL)
-	r.Post("/", a.createShortURL)
-	r.Post("/api/", a.createShortURL)
+	r.Post("/{short_url}", a.unlockShortURL)
+	r.Post("/", a.idempotent(a.createShortURL))
+	r.Post("/api/", a.idempotent(a.createShortURL))
 	r.Get("/ping", a.ping)
 
 	r.Route("/api", func(r chi.Router) {
-		r.Post("/shorten", a.shorten)
-		r.Post("/shorten/batch", a.shortenBatch)
+		r.Post("/shorten", a.idempotent(a.shorten))
+		r.Post("/shorten/batch", a.idempotent(a.shortenBatch))
+		r.Get("/expand/{short_url}", a.expandURL)
+		r.Post("/expand", a.expandURLs)
 		r.Get("/user/urls", a.userUrls)
+		r.Get("/user/urls/broken", a.userBrokenURLs)
+		r.Get("/user/urls/search", a.searchUserURLs)
+		r.Get("/user/urls/export", a.exportUserURLs)
+		r.Post("/user/urls/import", a.importUserURLs)
 		r.Delete("/user/urls", a.deleteUserURLs)
+		r.Patch("/user/urls/{short_url}", a.updateUserURL)
+		r.Get("/user/quota", a.userQuota)
+		r.Post("/user/keys", a.createAPIKey)
+		r.Get("/user/keys", a.userAPIKeys)
+		r.Delete("/user/keys/{id}", a.revokeAPIKey)
+		r.Post("/user/register", a.register)
+		r.Post("/user/login", a.signIn)
+		r.Post("/user/logout", a.logout)
+		r.Get("/user/teams", a.userTeams)
+		r.Post("/user/urls/{short_url}/transfer", a.transferURL)
+		r.Get("/user/urls/{short_url}/variants", a.userURLVariants)
+		r.Get("/user/tags", a.userTags)
+		r.Patch("/user/tags/{tag}", a.renameTag)
+		r.Post("/user/tags/merge", a.mergeTags)
+		r.Post("/orgs", a.createOrganization)
+		r.Post("/orgs/{id}/teams", a.createTeam)
+		r.Get("/teams/{id}/members", a.teamMembers)
+		r.Put("/teams/{id}/members/{user_id}", a.setTeamMember)
+		r.Delete("/teams/{id}/members/{user_id}", a.removeTeamMember)
+		if a.cnt.GetServiceOIDC() != nil {
+			r.Get("/user/oidc/login", a.oidcLogin)
+			r.Get("/user/oidc/callback", a.oidcCallback)
+		}
 	})
 
 	return r
@@ -149,22 +223,19 @@ func (a *Application) createShortURL(res http.ResponseWriter, req *http.Request)
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		a.cnt.GetLogger().Err(err).Msg("cannot get read body")
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, err)
 
 		return
 	}
 
 	if len(body) == 0 {
-		res.WriteHeader(http.StatusBadRequest)
+		a.writeError(res, req, http.StatusBadRequest, validate.ErrValidateEmpty)
 
 		return
 	}
 
-	userID, err := a.getUserIDFromCookie(req)
-	if err != nil {
-		a.cnt.GetLogger().Err(err).Msg("cannot get cookie with userID")
-		http.Error(res, err.Error(), http.StatusInternalServerError)
-
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
 		return
 	}
 
@@ -173,20 +244,30 @@ func (a *Application) createShortURL(res http.ResponseWriter, req *http.Request)
 		string(body),
 		a.cnt.GetConfig().ShortURLLength,
 		userID,
+		"",
+		nil,
 	)
 	statusCode := http.StatusCreated
 	if err != nil {
+		if a.writeQuotaError(res, req, err) {
+			return
+		}
 		if errors.Is(err, customerror.ErrURLAlreadyExists) {
 			statusCode = http.StatusConflict
 		} else {
-			a.cnt.GetLogger().Err(err).Msg("cannot read body")
-			http.Error(res, err.Error(), http.StatusInternalServerError)
+			a.cnt.GetLogger().Err(err).Msg("cannot make short url")
+			a.writeError(res, req, http.StatusInternalServerError, err)
+
+			return
 		}
 	}
 
+	if statusCode == http.StatusCreated {
+		a.enqueuePreview(shortURL)
+	}
 	res.WriteHeader(statusCode)
-	if _, err := fmt.Fprintf(res, "%s/%s", a.cnt.GetConfig().ResultURL, shortURL.Short); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+	if _, err := fmt.Fprint(res, a.shortURL(shortURL)); err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot write result to response")
 	}
 }
 
@@ -196,23 +277,26 @@ func (a *Application) shorten(res http.ResponseWriter, req *http.Request) {
 	_, err := buf.ReadFrom(req.Body)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
 
-	validatedRequest := validate.NewValidator(a.cnt.GetLogger()).ShortenRequest(buf)
-	if validatedRequest == nil {
-		res.WriteHeader(http.StatusBadRequest)
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).ShortenRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
 
 		return
 	}
+	validatedRequest.Domain = strings.ToLower(validatedRequest.Domain)
+	if validatedRequest.Domain != "" && !a.cnt.GetConfig().HasDomain(validatedRequest.Domain) {
+		a.writeError(res, req, http.StatusBadRequest, validate.ErrValidateDomain)
 
-	userID, err := a.getUserIDFromCookie(req)
-	if err != nil {
-		a.cnt.GetLogger().Err(err).Msg("cannot get cookie with userID")
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
 		return
 	}
 
@@ -221,61 +305,129 @@ func (a *Application) shorten(res http.ResponseWriter, req *http.Request) {
 		validatedRequest.URL,
 		a.cnt.GetConfig().ShortURLLength,
 		userID,
+		validatedRequest.Domain,
+		newShortenPatch(validatedRequest),
 	)
 	statusCode := http.StatusCreated
 	if err != nil {
+		if a.writeQuotaError(res, req, err) {
+			return
+		}
 		if errors.Is(err, customerror.ErrURLAlreadyExists) {
 			statusCode = http.StatusConflict
 		} else {
-			a.cnt.GetLogger().Err(err).Msg("cannot read body")
-			http.Error(res, err.Error(), http.StatusInternalServerError)
+			a.cnt.GetLogger().Err(err).Msg("cannot make short url")
+			a.writeError(res, req, http.StatusInternalServerError, err)
 
 			return
 		}
 	}
 
-	res.Header().Set("Content-Type", "application/json")
-	res.WriteHeader(statusCode)
-
-	jsonRes, err := json.Marshal(response.ShortenResponse{
-		Result: fmt.Sprintf("%s/%s", a.cnt.GetConfig().ResultURL, shortURL.Short),
-	})
-	if err != nil {
-		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot encode response to JSON")
-		res.WriteHeader(http.StatusInternalServerError)
-
-		return
+	if statusCode == http.StatusCreated {
+		a.enqueuePreview(shortURL)
 	}
 
-	_, err = res.Write(jsonRes)
-	if err != nil {
-		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot encode response to JSON")
-
-		return
-	}
+	a.writeJSON(res, req, statusCode, response.ShortenResponse{Result: a.shortURL(shortURL)})
 }
 
 func (a *Application) getShortURL(res http.ResponseWriter, req *http.Request) {
-	shortURL, err := a.cnt.GetServiceURL().GetShortURL(req.Context(), chi.URLParam(req, "short_url"))
+	shortURL, err := a.cnt.GetServiceURL().GetShortURL(
+		req.Context(),
+		a.requestShortKey(req),
+		a.currentUserID(req),
+	)
 	if err != nil {
 		if errors.Is(err, customerror.ErrURLDeleted) {
 			a.cnt.GetLogger().Info().Msg("trying to get deleted address")
-			res.WriteHeader(http.StatusGone)
+			a.writeError(res, req, http.StatusGone, err)
+
+			return
 		}
 
 		a.cnt.GetLogger().Error().Err(err).Msg("cannot get short url")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
 
+	// приватная ссылка для посторонних не существует
 	if shortURL == nil {
-		res.WriteHeader(http.StatusNotFound)
+		a.writeError(res, req, http.StatusNotFound, customerror.ErrURLNotFound)
+
+		return
+	}
+
+	if !a.hasLinkAccess(req, shortURL) {
+		a.writeLinkPasswordForm(res, http.StatusOK, "")
+
+		return
+	}
+	if !a.consumeClick(res, req, shortURL) {
+		return
+	}
+	a.redirect(res, req, shortURL)
+}
 
+// redirect write redirect to original URL with status of URL or default status of server.
+// Only permanent redirects of links which server does not need to see every time may be cached.
+func (a *Application) redirect(res http.ResponseWriter, req *http.Request, url *entity.URL) {
+	destination, ok := a.destination(res, req, url)
+	if !ok {
 		return
 	}
-	res.Header().Set("Location", shortURL.Original)
-	res.WriteHeader(http.StatusTemporaryRedirect)
+
+	status := url.RedirectStatus
+	if status == 0 {
+		status = a.cnt.GetConfig().RedirectStatus
+	}
+
+	cacheTTL := a.cnt.GetConfig().RedirectCacheTTL
+	// Destination of URL with country rules depends on address of client, shared caches cannot vary by it.
+	cacheable := entity.IsPermanentRedirect(status) && !url.IsPrivate() && !url.HasPassword() &&
+		!url.HasClicksLimit() && len(url.Variants) == 0 && len(url.CountryRules) == 0
+	if cacheable && cacheTTL > 0 {
+		res.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cacheTTL.Seconds())))
+		res.Header().Set("Expires", time.Now().Add(cacheTTL).UTC().Format(http.TimeFormat))
+	} else {
+		setNoStore(res)
+	}
+	if len(url.DeviceRules) > 0 {
+		res.Header().Set("Vary", "User-Agent")
+	}
+	res.Header().Set("Location", destination)
+	res.WriteHeader(status)
+}
+
+// enqueuePreview schedule fetching of preview of created URL when fetching is enabled.
+func (a *Application) enqueuePreview(url *entity.URL) {
+	if s := a.cnt.GetServicePreview(); s != nil {
+		s.Enqueue(url)
+	}
+}
+
+// setNoStore forbid browsers and proxies to cache response.
+func setNoStore(res http.ResponseWriter) {
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+}
+
+// consumeClick count click on URL before redirect. If URL cannot be followed, response is written.
+func (a *Application) consumeClick(res http.ResponseWriter, req *http.Request, url *entity.URL) bool {
+	err := a.cnt.GetServiceURL().ConsumeURLClick(req.Context(), url)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, customerror.ErrURLExhausted) {
+		a.cnt.GetLogger().Info().Str("short", url.Short).Msg("trying to follow exhausted address")
+		a.writeError(res, req, http.StatusGone, err)
+
+		return false
+	}
+
+	a.cnt.GetLogger().Error().Err(err).Msg("cannot consume click")
+	a.writeError(res, req, http.StatusInternalServerError, nil)
+
+	return false
 }
 
 func (a *Application) ping(res http.ResponseWriter, req *http.Request) {
@@ -283,31 +435,38 @@ func (a *Application) ping(res http.ResponseWriter, req *http.Request) {
 	defer cancel()
 	if err := a.cnt.GetServiceHealthCheck().Ping(ctx); err != nil { //nolint:contextcheck
 		a.cnt.GetLogger().Error().Err(err).Send()
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
 	}
 	res.WriteHeader(http.StatusOK)
 }
 
 func (a *Application) shortenBatch(res http.ResponseWriter, req *http.Request) {
+	if isNDJSON(req) {
+		a.shortenBatchStream(res, req)
+
+		return
+	}
+
 	var buf bytes.Buffer
 	_, err := buf.ReadFrom(req.Body)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
 
 	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).ShortenBatchRequest(req.Context(), buf)
 	if err != nil {
-		if errors.Is(err, validate.ErrValidateEmpty) {
-			res.WriteHeader(http.StatusBadRequest)
-
-			return
-		}
+		a.writeError(res, req, http.StatusBadRequest, err)
 
-		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
 		return
 	}
 
@@ -323,18 +482,25 @@ func (a *Application) shortenBatch(res http.ResponseWriter, req *http.Request) {
 		req.Context(),
 		URLs,
 		a.cnt.GetConfig().ResultURL,
+		userID,
 	)
 	if err != nil {
+		if a.writeQuotaError(res, req, err) {
+			return
+		}
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot make shorten batch")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
+	for _, v := range URLs {
+		a.enqueuePreview(v)
+	}
 
 	jsonRes, err := json.Marshal(shortenBatchResponse)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot encode response to JSON")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
@@ -350,44 +516,36 @@ func (a *Application) shortenBatch(res http.ResponseWriter, req *http.Request) {
 }
 
 func (a *Application) userUrls(res http.ResponseWriter, req *http.Request) {
-	userID, err := a.getUserIDFromCookie(req)
-	if err != nil {
-		a.cnt.GetLogger().Err(err).Msg("cannot get cookie with userID")
-		http.Error(res, err.Error(), http.StatusInternalServerError)
-
-		return
-	}
-
-	if userID == uuid.Nil {
-		a.cnt.GetLogger().Err(err).Msg("cookie with userID is empty")
-		res.WriteHeader(http.StatusUnauthorized)
-
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
 		return
 	}
 
-	userURLs, err := a.cnt.GetServiceURL().GetUserURLs(req.Context(), userID, a.cnt.GetConfig().ResultURL)
+	tag := strings.ToLower(strings.TrimSpace(req.URL.Query().Get("tag")))
+	userURLs, err := a.cnt.GetServiceURL().GetUserURLs(req.Context(), userID, a.cnt.GetConfig().ResultURL, tag)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot get user URLs")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
 
-	if len(userURLs) == 0 {
-		res.WriteHeader(http.StatusUnauthorized)
+	// пустой результат фильтра по тегу не означает, что у пользователя нет ссылок
+	if len(userURLs) == 0 && tag == "" {
+		a.writeError(res, req, http.StatusUnauthorized, nil)
 
 		return
 	}
 
 	userURLsResp := make([]response.UserURLResponse, len(userURLs))
 	for k, v := range userURLs {
-		userURLsResp[k] = response.NewUserURLResponse(v.Short, v.Original)
+		userURLsResp[k] = newUserURLResponse(a.shortURL(v), v)
 	}
 
 	jsonRes, err := json.Marshal(userURLsResp)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot encode get user URLs response to JSON")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
@@ -407,36 +565,20 @@ func (a *Application) deleteUserURLs(res http.ResponseWriter, req *http.Request)
 	_, err := buf.ReadFrom(req.Body)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
 
 	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).DeleteUserURLsRequest(req.Context(), buf)
 	if err != nil {
-		if errors.Is(err, validate.ErrValidateEmpty) {
-			res.WriteHeader(http.StatusBadRequest)
-
-			return
-		}
-
-		res.WriteHeader(http.StatusInternalServerError)
-
-		return
-	}
-
-	userID, err := a.getUserIDFromCookie(req)
-	if err != nil {
-		a.cnt.GetLogger().Err(err).Msg("cannot get cookie with userID")
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusBadRequest, err)
 
 		return
 	}
 
-	if userID == uuid.Nil {
-		a.cnt.GetLogger().Err(err).Msg("cookie with userID is empty")
-		res.WriteHeader(http.StatusUnauthorized)
-
+	userID, ok := a.authorize(res, req, entity.ScopeDelete)
+	if !ok {
 		return
 	}
 
@@ -449,7 +591,7 @@ func (a *Application) deleteUserURLs(res http.ResponseWriter, req *http.Request)
 	)
 	if err != nil {
 		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot delete user URLs")
-		res.WriteHeader(http.StatusInternalServerError)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
 
 		return
 	}
@@ -457,8 +599,264 @@ func (a *Application) deleteUserURLs(res http.ResponseWriter, req *http.Request)
 	res.WriteHeader(http.StatusAccepted)
 }
 
+func (a *Application) updateUserURL(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	patch, err := validate.NewValidator(a.cnt.GetLogger()).UpdateURLRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	url, err := a.cnt.GetServiceURL().UpdateUserURL(req.Context(), userID, userURLKey(req), patch)
+	if err != nil {
+		a.writeTeamError(res, req, err, "cannot update user url")
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusOK, newUserURLResponse(a.shortURL(url), url))
+}
+
+func (a *Application) userQuota(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	usage, err := a.cnt.GetServiceURL().GetUserQuotaUsage(req.Context(), userID)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot get user quota usage")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusOK, response.UserQuotaResponse{
+		DailyLimit:  a.cnt.GetConfig().DailyURLsQuota,
+		DailyUsed:   usage.CreatedToday,
+		ActiveLimit: a.cnt.GetConfig().ActiveURLsQuota,
+		ActiveUsed:  usage.Active,
+	})
+}
+
+// writeQuotaError write response for exceeded quota and report whether err was a quota error.
+func (a *Application) writeQuotaError(res http.ResponseWriter, req *http.Request, err error) bool {
+	var statusCode int
+	switch {
+	case errors.Is(err, customerror.ErrDailyQuotaExceeded):
+		statusCode = http.StatusTooManyRequests
+	case errors.Is(err, customerror.ErrActiveQuotaExceeded):
+		statusCode = http.StatusForbidden
+	default:
+		return false
+	}
+
+	a.writeError(res, req, statusCode, err)
+
+	return true
+}
+
+// batchErrorStatus status of response for error of creating batch of URLs, it is the same as for
+// single URL: quota errors have own status, invalid URLs are error of client.
+func batchErrorStatus(err error) int {
+	var fieldErr *validate.FieldError
+	switch {
+	case errors.Is(err, customerror.ErrDailyQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, customerror.ErrActiveQuotaExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, validate.ErrValidateJSON), errors.As(err, &fieldErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// newUserURLResponse create response with URL and its settings.
+func newUserURLResponse(shortURL string, url *entity.URL) response.UserURLResponse {
+	resp := response.NewUserURLResponse(shortURL, url.Original)
+	resp.Domain = url.Domain()
+	resp.Title = url.Title
+	resp.Description = url.Description
+	resp.Notes = url.Notes
+	resp.Tags = url.Tags
+	if url.Preview != nil {
+		preview := response.NewPreviewResponse(url.Preview)
+		resp.Preview = &preview
+	}
+	if url.LinkCheck != nil {
+		linkCheck := response.NewLinkCheckResponse(url.LinkCheck)
+		resp.LinkCheck = &linkCheck
+	}
+	if url.OwnedByTeam() {
+		resp.TeamID = &url.TeamID
+	}
+	resp.Visibility = url.Visibility
+	resp.Protected = url.HasPassword()
+	if url.HasClicksLimit() {
+		remaining := url.RemainingClicks()
+		resp.RemainingClicks = &remaining
+	}
+	resp.RedirectStatus = url.RedirectStatus
+	resp.QueryMode = url.QueryMode
+	resp.UTM = url.UTM
+	resp.DeviceRules = url.DeviceRules
+	resp.CountryRules = url.CountryRules
+	resp.Variants = url.Variants
+
+	return resp
+}
+
+// newShortenPatch return settings of URL from shorten request, nil when nothing is set.
+func newShortenPatch(r *request.ShortenRequest) *entity.URLPatch {
+	patch := &entity.URLPatch{}
+	if r.Title != "" {
+		patch.Title = &r.Title
+	}
+	if r.Description != "" {
+		patch.Description = &r.Description
+	}
+	if r.Notes != "" {
+		patch.Notes = &r.Notes
+	}
+	if len(r.Tags) > 0 {
+		patch.Tags = r.Tags
+	}
+	if r.Visibility != "" {
+		patch.Visibility = &r.Visibility
+	}
+	if r.Password != "" {
+		patch.Password = &r.Password
+	}
+	if r.MaxClicks > 0 {
+		patch.MaxClicks = &r.MaxClicks
+	}
+	if r.Redirect != 0 {
+		patch.RedirectStatus = &r.Redirect
+	}
+	if r.QueryMode != "" {
+		patch.QueryMode = &r.QueryMode
+	}
+	if len(r.UTM) > 0 {
+		patch.UTM = r.UTM
+	}
+	if len(r.DeviceRules) > 0 {
+		patch.DeviceRules = r.DeviceRules
+	}
+	if len(r.CountryRules) > 0 {
+		patch.CountryRules = r.CountryRules
+	}
+	if len(r.Variants) > 0 {
+		patch.Variants = r.Variants
+	}
+	if patch.IsEmpty() {
+		return nil
+	}
+
+	return patch
+}
+
+// writeError write response with problem details of error.
+func (a *Application) writeError(res http.ResponseWriter, req *http.Request, statusCode int, err error) {
+	problem.Write(res, req, statusCode, err)
+}
+
+// writeJSON write v as JSON response.
+func (a *Application) writeJSON(res http.ResponseWriter, req *http.Request, statusCode int, v any) {
+	jsonRes, err := json.Marshal(v)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot encode response to JSON")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+	if _, err = res.Write(jsonRes); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot write result to response")
+	}
+}
+
+// authorize resolve user by API key, session of named account or cookie. API key must be granted with scope.
+// Request without user is rejected, so handlers always get user.
+func (a *Application) authorize(res http.ResponseWriter, req *http.Request, scope string) (uuid.UUID, bool) {
+	if apiKey := middleware.APIKeyFromContext(req.Context()); apiKey != nil {
+		if !apiKey.HasScope(scope) {
+			a.cnt.GetLogger().Info().Str("scope", scope).Msg("api key has no required scope")
+			a.writeError(res, req, http.StatusForbidden, customerror.ErrAPIKeyScope)
+
+			return uuid.Nil, false
+		}
+
+		return apiKey.UserID, true
+	}
+
+	if session := middleware.SessionFromContext(req.Context()); session != nil {
+		return session.UserID, true
+	}
+
+	userID, err := a.getUserIDFromCookie(req)
+	if err != nil {
+		a.cnt.GetLogger().Err(err).Msg("cannot get cookie with userID")
+		a.writeError(res, req, http.StatusInternalServerError, err)
+
+		return uuid.Nil, false
+	}
+	if userID == uuid.Nil {
+		a.cnt.GetLogger().Warn().Msg("cookie with userID is empty")
+		a.writeError(res, req, http.StatusUnauthorized, nil)
+
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// currentUserID resolve user like authorize, but anonymous user is returned instead of errors.
+// API key without read scope is treated as anonymous.
+func (a *Application) currentUserID(req *http.Request) uuid.UUID {
+	if apiKey := middleware.APIKeyFromContext(req.Context()); apiKey != nil {
+		if !apiKey.HasScope(entity.ScopeRead) {
+			return uuid.Nil
+		}
+
+		return apiKey.UserID
+	}
+
+	if session := middleware.SessionFromContext(req.Context()); session != nil {
+		return session.UserID
+	}
+
+	userID, err := a.getUserIDFromCookie(req)
+	if err != nil {
+		a.cnt.GetLogger().Info().Err(err).Msg("cannot get userID from cookie, continue as anonymous")
+
+		return uuid.Nil
+	}
+
+	return userID
+}
+
 func (a *Application) getUserIDFromCookie(req *http.Request) (uuid.UUID, error) {
-	userIDCoockie, err := req.Cookie("userID")
+	if userID, ok := middleware.UserIDFromContext(req.Context()); ok {
+		return userID, nil
+	}
+
+	userIDCoockie, err := req.Cookie(cookie.Name)
 	if err != nil {
 		if !errors.Is(err, http.ErrNoCookie) {
 			a.cnt.GetLogger().Err(err).Msg("cannot get cookie with userID")
@@ -471,15 +869,10 @@ func (a *Application) getUserIDFromCookie(req *http.Request) (uuid.UUID, error)
 		return uuid.Nil, nil
 	}
 
-	decr, err := cookie.Decrypt(userIDCoockie.Value, a.cnt.GetConfig().CryptoKey)
-	if err != nil {
-		return uuid.Nil, err
-	}
-
-	decryptedUUID, err := uuid.Parse(*decr)
+	token, err := cookie.ParseToken(a.cnt.GetConfig().Keyring, userIDCoockie.Value, time.Now())
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	return decryptedUUID, err
+	return token.UserID, nil
 }
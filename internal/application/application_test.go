@@ -2,22 +2,33 @@
 package application
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
 	"github.com/vagafonov/shortener/internal/config"
 	"github.com/vagafonov/shortener/internal/container"
+	"github.com/vagafonov/shortener/internal/contract"
 	"github.com/vagafonov/shortener/internal/cookie"
 	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/internal/logger"
@@ -26,6 +37,8 @@ import (
 	"github.com/vagafonov/shortener/internal/storage"
 	"github.com/vagafonov/shortener/pkg/encrypting"
 	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/geoip"
+	"github.com/vagafonov/shortener/pkg/useragent"
 )
 
 const fileStoragePath = "short-url-db-test.json"
@@ -36,6 +49,9 @@ type FunctionalTestSuite struct {
 	app                *Application
 	serviceURL         *service.URLServiceMock
 	serviceHealthCheck *service.HealthCheckServiceMock
+	serviceAPIKey      *service.APIKeyServiceMock
+	serviceAccount     *service.AccountServiceMock
+	serviceTeam        *service.TeamServiceMock
 }
 
 func TestFunctionalTestSuite(t *testing.T) {
@@ -82,11 +98,36 @@ func (s *FunctionalTestSuite) SetupSuite() {
 	s.serviceHealthCheck, _ = servHealthcheck.(*service.HealthCheckServiceMock)
 	s.cnt.SetServiceHealthCheck(s.serviceHealthCheck)
 
+	servAPIKey, err := service.ServiceAPIKeyFactory(s.cnt, "mock")
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.serviceAPIKey, _ = servAPIKey.(*service.APIKeyServiceMock)
+	s.cnt.SetServiceAPIKey(s.serviceAPIKey)
+
+	servAccount, err := service.ServiceAccountFactory(s.cnt, "mock")
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.serviceAccount, _ = servAccount.(*service.AccountServiceMock)
+	s.cnt.SetServiceAccount(s.serviceAccount)
+
+	servTeam, err := service.ServiceTeamFactory(s.cnt, "mock")
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.serviceTeam, _ = servTeam.(*service.TeamServiceMock)
+	s.cnt.SetServiceTeam(s.serviceTeam)
+
 	s.app = NewApplication(
 		s.cnt,
 	)
 }
 
+func (s *FunctionalTestSuite) userCookie() *http.Cookie {
+	return cookie.CreateCookieWithUserID(s.cnt.GetLogger(), s.cnt.GetConfig().Keyring, s.cnt.GetConfig().UserTokenTTL)
+}
+
 func (s *FunctionalTestSuite) TearDownSuite() {
 	os.Remove(fileStoragePath)
 }
@@ -115,11 +156,12 @@ func (s *FunctionalTestSuite) TestCreateURL() {
 			expected: "http://test:8080/********",
 		},
 		{
-			method:   http.MethodPost,
-			body:     "",
-			code:     http.StatusBadRequest,
-			init:     func(s *FunctionalTestSuite) {},
-			expected: "",
+			method: http.MethodPost,
+			body:   "",
+			code:   http.StatusBadRequest,
+			init:   func(s *FunctionalTestSuite) {},
+			expected: `{"type":"about:blank","title":"Bad Request","status":400,` +
+				`"detail":"request must not be empty","instance":"/","code":"empty_request","request_id":"test"}`,
 		},
 		{
 			method: http.MethodPost,
@@ -140,7 +182,8 @@ func (s *FunctionalTestSuite) TestCreateURL() {
 		s.Run(test.method, func() {
 			test.init(s)
 			r := httptest.NewRequest(test.method, srv.URL+"/", strings.NewReader(test.body))
-			ck := cookie.CreateCookieWithUserID(s.cnt.GetLogger(), s.cnt.GetConfig().CryptoKey)
+			r.Header.Set("X-Request-Id", "test")
+			ck := s.userCookie()
 			r.RequestURI = ""
 			r.AddCookie(ck)
 			resp, err := http.DefaultClient.Do(r)
@@ -159,11 +202,12 @@ func (s *FunctionalTestSuite) TestApiShorten() {
 	srv := httptest.NewServer(s.app.Routes())
 	defer srv.Close()
 	tests := []struct {
-		method   string
-		body     string
-		code     int
-		init     func(s *FunctionalTestSuite)
-		expected string
+		method      string
+		body        string
+		code        int
+		init        func(s *FunctionalTestSuite)
+		contentType string
+		expected    string
 	}{
 		{
 			method: http.MethodPost,
@@ -176,7 +220,8 @@ func (s *FunctionalTestSuite) TestApiShorten() {
 					Original: "2",
 				}, nil)
 			},
-			expected: `{"result":"http://test:8080/********"}`,
+			contentType: "application/json",
+			expected:    `{"result":"http://test:8080/********"}`,
 		},
 		{
 			method: http.MethodPost,
@@ -184,7 +229,9 @@ func (s *FunctionalTestSuite) TestApiShorten() {
 			code:   http.StatusBadRequest,
 			init: func(s *FunctionalTestSuite) {
 			},
-			expected: `{"result":"http://test:8080/********"}`,
+			contentType: "application/problem+json",
+			expected: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"request body must be valid json",` +
+				`"instance":"/api/shorten","code":"invalid_json","request_id":"test"}`,
 		},
 		{
 			method: http.MethodPost,
@@ -197,7 +244,8 @@ func (s *FunctionalTestSuite) TestApiShorten() {
 					Original: "http://test.local",
 				}, customerror.ErrURLAlreadyExists)
 			},
-			expected: `{"result":"http://test:8080/********"}`,
+			contentType: "application/json",
+			expected:    `{"result":"http://test:8080/********"}`,
 		},
 	}
 
@@ -205,7 +253,8 @@ func (s *FunctionalTestSuite) TestApiShorten() {
 		s.Run(test.method, func() {
 			test.init(s)
 			r := httptest.NewRequest(test.method, srv.URL+"/api/shorten", strings.NewReader(test.body))
-			ck := cookie.CreateCookieWithUserID(s.cnt.GetLogger(), s.cnt.GetConfig().CryptoKey)
+			r.Header.Set("X-Request-Id", "test")
+			ck := s.userCookie()
 			r.RequestURI = ""
 			r.AddCookie(ck)
 			resp, err := http.DefaultClient.Do(r)
@@ -215,10 +264,8 @@ func (s *FunctionalTestSuite) TestApiShorten() {
 			b, err := io.ReadAll(resp.Body)
 			s.Require().NoError(err)
 
-			if string(b) != "" {
-				s.Require().Equal(`application/json`, resp.Header.Get("Content-Type"))
-				s.Require().JSONEq(test.expected, string(b))
-			}
+			s.Require().Equal(test.contentType, resp.Header.Get("Content-Type"))
+			s.Require().JSONEq(test.expected, string(b))
 		})
 	}
 }
@@ -311,7 +358,7 @@ func (s *FunctionalTestSuite) TestCompress() {
 		s.Require().NoError(err)
 		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten", buf)
 
-		ck := cookie.CreateCookieWithUserID(s.cnt.GetLogger(), s.cnt.GetConfig().CryptoKey)
+		ck := s.userCookie()
 		r.AddCookie(ck)
 		r.RequestURI = ""
 		r.Header.Set("Content-Encoding", "gzip")
@@ -458,6 +505,97 @@ func (s *FunctionalTestSuite) TestShortenBatch() { //nolint:funlen
 	})
 }
 
+func (s *FunctionalTestSuite) TestShortenBatchStream() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	post := func(body io.Reader) *http.Response {
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten/batch", body)
+		r.RequestURI = ""
+		r.Header.Set("Content-Type", "application/x-ndjson")
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+
+		return resp
+	}
+	s.serviceURL.SetMakeShortURLBatchResult([]response.ShortenBatchResponse{
+		{CorrelationID: "1", ShortURL: "http://test:8080/a"},
+	}, nil)
+
+	s.Run("results of chunk are sent before end of body", func() {
+		pr, pw := io.Pipe()
+		go func() {
+			for i := 0; i < contract.BatchInsertSize; i++ {
+				fmt.Fprintf(pw, "{\"correlation_id\":\"%d\",\"original_url\":\"https://example.com/%d\"}\n", i, i)
+			}
+		}()
+		resp := post(pr)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		lines := bufio.NewReader(resp.Body)
+		line, err := lines.ReadString('\n')
+		s.Require().NoError(err)
+		s.Require().JSONEq(`{"correlation_id":"1","short_url":"http://test:8080/a"}`, line)
+
+		_, err = io.WriteString(pw, `{"correlation_id":"","original_url":"https://example.com"}`+"\n")
+		s.Require().NoError(err)
+		s.Require().NoError(pw.Close())
+		rest, err := io.ReadAll(lines)
+		s.Require().NoError(err)
+		s.Require().JSONEq(
+			`{"correlation_id":"","error":"item must be object with correlation_id and original_url"}`,
+			string(rest),
+		)
+	})
+
+	s.Run("broken line stops batch", func() {
+		resp := post(strings.NewReader(`{"correlation_id":"1","original_url":"https://example.com"}` + "\n{broken\n"))
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		s.Require().Len(lines, 2)
+		s.Require().JSONEq(`{"correlation_id":"1","short_url":"http://test:8080/a"}`, lines[0])
+		s.Require().Contains(lines[1], `"code":"invalid_json"`)
+	})
+
+	s.Run("error before results", func() {
+		resp := post(strings.NewReader(""))
+		resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+		s.serviceURL.SetMakeShortURLBatchResult(nil, customerror.ErrDailyQuotaExceeded)
+		resp = post(strings.NewReader(`{"correlation_id":"1","original_url":"https://example.com"}`))
+		resp.Body.Close()
+		s.Require().Equal(http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	s.Run("quota error after results", func() {
+		s.serviceURL.SetMakeShortURLBatchResult([]response.ShortenBatchResponse{
+			{CorrelationID: "1", ShortURL: "http://test:8080/a"},
+		}, nil)
+		s.serviceURL.SetMakeShortURLBatchErrors(nil, customerror.ErrDailyQuotaExceeded)
+		var body strings.Builder
+		for i := 0; i <= contract.BatchInsertSize; i++ {
+			fmt.Fprintf(&body, "{\"correlation_id\":\"%d\",\"original_url\":\"https://example.com/%d\"}\n", i, i)
+		}
+		resp := post(strings.NewReader(body.String()))
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		s.Require().Len(lines, 2)
+		s.Require().JSONEq(`{"correlation_id":"1","short_url":"http://test:8080/a"}`, lines[0])
+		s.Require().Contains(lines[1], `"status":429`)
+		s.Require().Contains(lines[1], `"code":"daily_quota_exceeded"`)
+	})
+}
+
 func (s *FunctionalTestSuite) TestCheckUserIDInCookie() {
 	srv := httptest.NewServer(s.app.Routes())
 	defer srv.Close()
@@ -506,6 +644,21 @@ func (s *FunctionalTestSuite) TestCheckUserIDInCookie() {
 	})
 }
 
+func (s *FunctionalTestSuite) TestPingError() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	s.serviceHealthCheck.SetPingResult(errors.New("storage is not available"))
+	defer s.serviceHealthCheck.SetPingResult(nil)
+
+	r := httptest.NewRequest(http.MethodGet, srv.URL+"/ping", strings.NewReader(""))
+	r.RequestURI = ""
+	resp, err := http.DefaultClient.Do(r)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+	s.Require().Equal(response.ProblemContentType, resp.Header.Get("Content-Type"))
+}
+
 func (s *FunctionalTestSuite) TestApiUserURLs() { //nolint:funlen
 	srv := httptest.NewServer(s.app.Routes())
 	defer srv.Close()
@@ -534,7 +687,7 @@ func (s *FunctionalTestSuite) TestApiUserURLs() { //nolint:funlen
 		b, err := io.ReadAll(resp.Body)
 		s.Require().NoError(err)
 		s.Require().Equal(`application/json`, resp.Header.Get("Content-Type"))
-		s.Require().JSONEq(`[{"short_url":"********","original_url":"2"}]`, string(b))
+		s.Require().JSONEq(`[{"short_url":"http://test:8080/********","original_url":"2"}]`, string(b))
 	})
 
 	// Если кука не содержит ID пользователя, хендлер должен возвращать HTTP-статус 401 Unauthorized.
@@ -621,3 +774,1648 @@ func (s *FunctionalTestSuite) TestDeleteUserURLs() {
 		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
 	})
 }
+
+func (s *FunctionalTestSuite) TestQuota() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		err      error
+		code     int
+		expected string
+	}{
+		{
+			name: "daily quota exceeded",
+			err:  customerror.ErrDailyQuotaExceeded,
+			code: http.StatusTooManyRequests,
+			expected: `{"type":"about:blank","title":"Too Many Requests","status":429,` +
+				`"detail":"daily links quota exceeded","instance":"/api/shorten","code":"daily_quota_exceeded",` +
+				`"request_id":"test"}`,
+		},
+		{
+			name: "active quota exceeded",
+			err:  customerror.ErrActiveQuotaExceeded,
+			code: http.StatusForbidden,
+			expected: `{"type":"about:blank","title":"Forbidden","status":403,` +
+				`"detail":"active links quota exceeded","instance":"/api/shorten","code":"active_quota_exceeded",` +
+				`"request_id":"test"}`,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.serviceURL.SetMakeShortURLResult(nil, test.err)
+			r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten", strings.NewReader(`{"url":"https://ya.ru"}`))
+			r.Header.Set("X-Request-Id", "test")
+			r.RequestURI = ""
+			r.AddCookie(s.userCookie())
+			resp, err := http.DefaultClient.Do(r)
+			s.Require().NoError(err)
+			defer resp.Body.Close()
+			s.Require().Equal(test.code, resp.StatusCode)
+			b, err := io.ReadAll(resp.Body)
+			s.Require().NoError(err)
+			s.Require().JSONEq(test.expected, string(b))
+		})
+	}
+
+	s.Run("get user quota", func() {
+		s.serviceURL.SetGetUserQuotaUsageResult(&entity.QuotaUsage{CreatedToday: 3, Active: 7}, nil)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/quota", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`{"daily_limit":0,"daily_used":3,"active_limit":0,"active_used":7}`, string(b))
+	})
+}
+
+func (s *FunctionalTestSuite) TestAPIKey() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	userID := uuid.New()
+
+	s.Run("get user URLs with api key", func() {
+		s.serviceAPIKey.SetAuthenticateResult(&entity.APIKey{
+			UserID: userID,
+			Scopes: []string{entity.ScopeRead},
+		}, nil)
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+			{Short: "********", Original: "2", UserID: userID},
+		}, nil)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls", nil)
+		r.RequestURI = ""
+		r.Header.Set("Authorization", "Bearer shk_key")
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Empty(resp.Cookies())
+	})
+
+	s.Run("api key without required scope", func() {
+		s.serviceAPIKey.SetAuthenticateResult(&entity.APIKey{
+			UserID: userID,
+			Scopes: []string{entity.ScopeRead},
+		}, nil)
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten", strings.NewReader(`{"url":"https://ya.ru"}`))
+		r.RequestURI = ""
+		r.Header.Set("Authorization", "Bearer shk_key")
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	s.Run("invalid api key", func() {
+		s.serviceAPIKey.SetAuthenticateResult(nil, customerror.ErrAPIKeyInvalid)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls", nil)
+		r.RequestURI = ""
+		r.Header.Set("Authorization", "Bearer shk_key")
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	s.Run("create api key", func() {
+		s.serviceAPIKey.SetCreateAPIKeyResult("shk_plain", &entity.APIKey{
+			ID:     uuid.Nil,
+			Name:   "backend",
+			Prefix: "shk_plain",
+			Scopes: []string{entity.ScopeRead},
+		}, nil)
+		r := httptest.NewRequest(
+			http.MethodPost,
+			srv.URL+"/api/user/keys",
+			strings.NewReader(`{"name":"backend","scopes":["read"]}`),
+		)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`{
+			"id":"00000000-0000-0000-0000-000000000000",
+			"name":"backend",
+			"prefix":"shk_plain",
+			"scopes":["read"],
+			"created_at":"0001-01-01T00:00:00Z",
+			"key":"shk_plain"
+		}`, string(b))
+	})
+
+	s.Run("api key cannot create key with wider scopes", func() {
+		s.serviceAPIKey.SetAuthenticateResult(&entity.APIKey{
+			UserID: userID,
+			Scopes: []string{entity.ScopeCreate},
+		}, nil)
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/user/keys", strings.NewReader(`{"name":"backend"}`))
+		r.RequestURI = ""
+		r.Header.Set("Authorization", "Bearer shk_key")
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	s.Run("revoke unknown api key", func() {
+		s.serviceAPIKey.SetRevokeAPIKeyResult(customerror.ErrAPIKeyNotFound)
+		r := httptest.NewRequest(http.MethodDelete, srv.URL+"/api/user/keys/"+uuid.NewString(), nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestUserTokenRefresh() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	s.Run("legacy cookie is replaced with versioned token for same user", func() {
+		userID := uuid.New()
+		encrypted, err := encrypting.Encrypt(userID.String(), s.cnt.GetConfig().CryptoKey)
+		s.Require().NoError(err)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/ping", nil)
+		r.RequestURI = ""
+		r.AddCookie(&http.Cookie{Name: "userID", Value: hex.EncodeToString(encrypted)})
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(resp.Cookies(), 1)
+
+		token, err := cookie.ParseToken(s.cnt.GetConfig().Keyring, resp.Cookies()[0].Value, time.Now())
+		s.Require().NoError(err)
+		s.Require().Equal(userID, token.UserID)
+		s.Require().False(token.Legacy)
+	})
+
+	s.Run("fresh token is not reissued", func() {
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/ping", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Empty(resp.Cookies())
+	})
+
+	s.Run("malformed token is replaced", func() {
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/ping", nil)
+		r.RequestURI = ""
+		r.AddCookie(&http.Cookie{Name: "userID", Value: "v1.default.AA"})
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(resp.Cookies(), 1)
+	})
+}
+
+func (s *FunctionalTestSuite) TestAccount() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	account := &entity.Account{ID: uuid.New(), Login: "alice"}
+
+	s.Run("register account", func() {
+		s.serviceAccount.SetRegisterResult(account, nil)
+		s.serviceAccount.SetLoginResult("token", account, nil)
+		r := httptest.NewRequest(
+			http.MethodPost,
+			srv.URL+"/api/user/register",
+			strings.NewReader(`{"login":"alice","password":"password"}`),
+		)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Len(resp.Cookies(), 1)
+		s.Require().Equal(cookie.SessionName, resp.Cookies()[0].Name)
+		s.Require().Equal("token", resp.Cookies()[0].Value)
+		s.Require().True(resp.Cookies()[0].HttpOnly)
+	})
+
+	s.Run("register with existing login", func() {
+		s.serviceAccount.SetRegisterResult(nil, customerror.ErrAccountAlreadyExists)
+		r := httptest.NewRequest(
+			http.MethodPost,
+			srv.URL+"/api/user/register",
+			strings.NewReader(`{"login":"alice","password":"password"}`),
+		)
+		r.RequestURI = ""
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("register with short password", func() {
+		r := httptest.NewRequest(
+			http.MethodPost,
+			srv.URL+"/api/user/register",
+			strings.NewReader(`{"login":"alice","password":"short"}`),
+		)
+		r.RequestURI = ""
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("login with invalid credentials", func() {
+		s.serviceAccount.SetLoginResult("", nil, customerror.ErrInvalidCredentials)
+		r := httptest.NewRequest(
+			http.MethodPost,
+			srv.URL+"/api/user/login",
+			strings.NewReader(`{"login":"alice","password":"password"}`),
+		)
+		r.RequestURI = ""
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	s.Run("user URLs follow session", func() {
+		s.serviceAccount.SetAuthenticateResult(&entity.Session{UserID: account.ID}, nil)
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+			{Short: "********", Original: "2", UserID: account.ID},
+		}, nil)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		r.AddCookie(cookie.NewSessionCookie("token", time.Hour))
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+	})
+
+	s.Run("invalid session is removed", func() {
+		s.serviceAccount.SetAuthenticateResult(nil, customerror.ErrSessionInvalid)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/ping", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		r.AddCookie(cookie.NewSessionCookie("token", time.Hour))
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(resp.Cookies(), 1)
+		s.Require().Equal(-1, resp.Cookies()[0].MaxAge)
+	})
+
+	s.Run("logout", func() {
+		s.serviceAccount.SetAuthenticateResult(&entity.Session{UserID: account.ID}, nil)
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/user/logout", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		r.AddCookie(cookie.NewSessionCookie("token", time.Hour))
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		s.Require().Len(resp.Cookies(), 1)
+		s.Require().Equal(-1, resp.Cookies()[0].MaxAge)
+	})
+}
+
+func (s *FunctionalTestSuite) TestTeams() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	teamID := uuid.New()
+
+	do := func(method string, path string, body string) *http.Response {
+		r := httptest.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+
+		return resp
+	}
+
+	s.Run("create team", func() {
+		s.serviceTeam.SetCreateTeamResult(&entity.Team{ID: teamID, Name: "marketing"}, nil)
+		resp := do(http.MethodPost, "/api/orgs/"+uuid.NewString()+"/teams", `{"name":" marketing "}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`{
+			"id":"`+teamID.String()+`",
+			"organization_id":"00000000-0000-0000-0000-000000000000",
+			"name":"marketing",
+			"created_at":"0001-01-01T00:00:00Z"
+		}`, string(b))
+	})
+
+	s.Run("create team in foreign organization", func() {
+		s.serviceTeam.SetCreateTeamResult(nil, customerror.ErrTeamForbidden)
+		resp := do(http.MethodPost, "/api/orgs/"+uuid.NewString()+"/teams", `{"name":"marketing"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	s.Run("create organization with empty name", func() {
+		resp := do(http.MethodPost, "/api/orgs", `{"name":"  "}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("set member of unknown team", func() {
+		s.serviceTeam.SetSetTeamMemberResult(customerror.ErrTeamNotFound)
+		resp := do(http.MethodPut, "/api/teams/"+teamID.String()+"/members/"+uuid.NewString(), `{"role":"editor"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	s.Run("remove last admin", func() {
+		s.serviceTeam.SetRemoveTeamMemberResult(customerror.ErrTeamLastAdmin)
+		resp := do(http.MethodDelete, "/api/teams/"+teamID.String()+"/members/"+uuid.NewString(), "")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("transfer url", func() {
+		s.serviceTeam.SetTransferURLResult(nil)
+		resp := do(http.MethodPost, "/api/user/urls/short/transfer", `{"team_id":"`+teamID.String()+`"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+	})
+
+	s.Run("transfer url to user and team at once", func() {
+		body := `{"team_id":"` + teamID.String() + `","user_id":"` + uuid.NewString() + `"}`
+		resp := do(http.MethodPost, "/api/user/urls/short/transfer", body)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("team urls in user urls", func() {
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+			{Short: "short", Original: "https://ya.ru", TeamID: teamID},
+		}, nil)
+		resp := do(http.MethodGet, "/api/user/urls", "")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`[{
+			"short_url":"http://test:8080/short",
+			"original_url":"https://ya.ru",
+			"team_id":"`+teamID.String()+`"
+		}]`, string(b))
+	})
+}
+
+func (s *FunctionalTestSuite) TestUpdateUserURL() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	do := func(method string, path string, body string) *http.Response {
+		r := httptest.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+
+		return resp
+	}
+
+	s.Run("make url private", func() {
+		s.serviceURL.SetUpdateUserURLResult(&entity.URL{
+			Short:      "short",
+			Original:   "https://ya.ru",
+			Visibility: entity.VisibilityPrivate,
+		}, nil)
+		resp := do(http.MethodPatch, "/api/user/urls/short", `{"visibility":"private"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`{
+			"short_url":"http://test:8080/short",
+			"original_url":"https://ya.ru",
+			"visibility":"private"
+		}`, string(b))
+	})
+
+	s.Run("unknown visibility", func() {
+		resp := do(http.MethodPatch, "/api/user/urls/short", `{"visibility":"secret"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+		resp = do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru","visibility":"secret"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("url of another user", func() {
+		s.serviceURL.SetUpdateUserURLResult(nil, customerror.ErrURLNotFound)
+		resp := do(http.MethodPatch, "/api/user/urls/short", `{"visibility":"public"}`)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	s.Run("private url is not found for stranger", func() {
+		s.serviceURL.SetGetShortURLResult(nil, nil)
+		cli := srv.Client()
+		cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		resp, err := cli.Get(srv.URL + "/short")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestLinkPassword() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	jar, err := cookiejar.New(nil)
+	s.Require().NoError(err)
+	cli := srv.Client()
+	cli.Jar = jar
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	s.serviceURL.SetGetShortURLResult(&entity.URL{
+		Short:        "protected",
+		Original:     "https://ya.ru",
+		PasswordHash: "hash",
+	}, nil)
+	defer s.serviceURL.SetGetShortURLResult(nil, nil)
+
+	enter := func(password string) *http.Response {
+		resp, err := cli.PostForm(srv.URL+"/protected", url.Values{"password": {password}})
+		s.Require().NoError(err)
+
+		return resp
+	}
+
+	s.Run("password form", func() {
+		resp, err := cli.Get(srv.URL + "/protected")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Empty(resp.Header.Get("Location"))
+		s.Require().Contains(resp.Header.Get("Content-Type"), "text/html")
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().Contains(string(b), `name="password"`)
+	})
+
+	s.Run("wrong password", func() {
+		s.serviceURL.SetVerifyURLPasswordResult(false)
+		resp := enter("wrong")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	s.Run("right password", func() {
+		s.serviceURL.SetVerifyURLPasswordResult(true)
+		resp := enter("secret")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusSeeOther, resp.StatusCode)
+		s.Require().Equal("https://ya.ru", resp.Header.Get("Location"))
+
+		resp, err := cli.Get(srv.URL + "/protected")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusTemporaryRedirect, resp.StatusCode)
+		s.Require().Equal("https://ya.ru", resp.Header.Get("Location"))
+	})
+
+	s.Run("attempts are limited", func() {
+		s.serviceURL.SetVerifyURLPasswordResult(false)
+		attempts := s.cnt.GetConfig().LinkPasswordAttempts
+		for i := 0; i < attempts; i++ {
+			resp := enter("wrong")
+			resp.Body.Close()
+			s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		s.serviceURL.SetVerifyURLPasswordResult(true)
+		resp := enter("secret")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusTooManyRequests, resp.StatusCode)
+		s.Require().NotEmpty(resp.Header.Get("Retry-After"))
+	})
+}
+
+func (s *FunctionalTestSuite) TestMaxClicks() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	defer s.serviceURL.SetConsumeURLClickResult(nil)
+
+	cli := srv.Client()
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	s.serviceURL.SetGetShortURLResult(&entity.URL{
+		Short:     "once",
+		Original:  "https://ya.ru",
+		MaxClicks: 1,
+	}, nil)
+
+	s.Run("first click is redirected", func() {
+		s.serviceURL.SetConsumeURLClickResult(nil)
+		resp, err := cli.Get(srv.URL + "/once")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusTemporaryRedirect, resp.StatusCode)
+	})
+
+	s.Run("exhausted url is gone", func() {
+		s.serviceURL.SetConsumeURLClickResult(customerror.ErrURLExhausted)
+		resp, err := cli.Get(srv.URL + "/once")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusGone, resp.StatusCode)
+		s.Require().Empty(resp.Header.Get("Location"))
+	})
+
+	s.Run("owner sees remaining clicks", func() {
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+			{Short: "once", Original: "https://ya.ru", MaxClicks: 5, Clicks: 2},
+		}, nil)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`[{
+			"short_url":"http://test:8080/once",
+			"original_url":"https://ya.ru",
+			"remaining_clicks":3
+		}]`, string(b))
+	})
+}
+
+func (s *FunctionalTestSuite) TestRedirectStatus() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	cli := srv.Client()
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	tests := []struct {
+		name         string
+		url          *entity.URL
+		code         int
+		cacheControl string
+	}{
+		{
+			name:         "default status of server",
+			url:          &entity.URL{Short: "short", Original: "https://ya.ru"},
+			code:         http.StatusTemporaryRedirect,
+			cacheControl: "no-store",
+		},
+		{
+			name:         "permanent redirect is cached",
+			url:          &entity.URL{Short: "short", Original: "https://ya.ru", RedirectStatus: http.StatusMovedPermanently},
+			code:         http.StatusMovedPermanently,
+			cacheControl: "public, max-age=86400",
+		},
+		{
+			name: "permanent redirect of limited url is not cached",
+			url: &entity.URL{
+				Short:          "short",
+				Original:       "https://ya.ru",
+				RedirectStatus: http.StatusPermanentRedirect,
+				MaxClicks:      10,
+			},
+			code:         http.StatusPermanentRedirect,
+			cacheControl: "no-store",
+		},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.serviceURL.SetGetShortURLResult(test.url, nil)
+			resp, err := cli.Get(srv.URL + "/short")
+			s.Require().NoError(err)
+			defer resp.Body.Close()
+			s.Require().Equal(test.code, resp.StatusCode)
+			s.Require().Equal("https://ya.ru", resp.Header.Get("Location"))
+			s.Require().Equal(test.cacheControl, resp.Header.Get("Cache-Control"))
+			s.Require().NotEmpty(resp.Header.Get("Expires"))
+		})
+	}
+
+	s.Run("unsupported status", func() {
+		body := strings.NewReader(`{"redirect_status":303}`)
+		r := httptest.NewRequest(http.MethodPatch, srv.URL+"/api/user/urls/short", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestQueryPassthrough() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	cli := srv.Client()
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	utm := map[string]string{"utm_source": "newsletter", "utm_campaign": "{short}"}
+	tests := []struct {
+		name     string
+		url      *entity.URL
+		query    string
+		location string
+	}{
+		{
+			name:     "query is dropped by default",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/?a=1"},
+			query:    "?a=2&b=3",
+			location: "https://ya.ru/?a=1",
+		},
+		{
+			name:     "original parameters win",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/?a=1", QueryMode: entity.QueryModeKeep},
+			query:    "?a=2&b=3",
+			location: "https://ya.ru/?a=1&b=3",
+		},
+		{
+			name:     "request parameters win",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/?a=1", QueryMode: entity.QueryModeOverride},
+			query:    "?a=2&b=3",
+			location: "https://ya.ru/?a=2&b=3",
+		},
+		{
+			name:     "utm template",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/path", UTM: utm},
+			location: "https://ya.ru/path?utm_campaign=short&utm_source=newsletter",
+		},
+		{
+			name:     "utm template on branded domain",
+			url:      &entity.URL{Short: "brand-b.link/abc", Original: "https://ya.ru/path", UTM: utm},
+			location: "https://ya.ru/path?utm_campaign=abc&utm_source=newsletter",
+		},
+		{
+			name:     "utm of original url is not replaced",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/?utm_source=site", UTM: utm},
+			location: "https://ya.ru/?utm_source=site&utm_campaign=short",
+		},
+		{
+			name:     "query of original url is kept as stored",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/?b=2&a=%7E1&b=1", QueryMode: entity.QueryModeKeep},
+			query:    "?c=3",
+			location: "https://ya.ru/?b=2&a=%7E1&b=1&c=3",
+		},
+		{
+			name:     "overridden parameter is removed from stored query",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/?b=2&a=1&c", QueryMode: entity.QueryModeOverride},
+			query:    "?a=3",
+			location: "https://ya.ru/?b=2&c&a=3",
+		},
+		{
+			name:     "parameters are encoded",
+			url:      &entity.URL{Short: "short", Original: "https://ya.ru/", QueryMode: entity.QueryModeKeep},
+			query:    "?x=%0D%0ASet-Cookie:%20a=b",
+			location: "https://ya.ru/?x=%0D%0ASet-Cookie%3A+a%3Db",
+		},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.serviceURL.SetGetShortURLResult(test.url, nil)
+			resp, err := cli.Get(srv.URL + "/short" + test.query)
+			s.Require().NoError(err)
+			defer resp.Body.Close()
+			s.Require().Equal(http.StatusTemporaryRedirect, resp.StatusCode)
+			s.Require().Equal(test.location, resp.Header.Get("Location"))
+			for _, c := range resp.Cookies() {
+				s.Require().NotEqual("a", c.Name)
+			}
+		})
+	}
+
+	s.Run("unknown utm parameter", func() {
+		body := strings.NewReader(`{"utm":{"ref":"x"}}`)
+		r := httptest.NewRequest(http.MethodPatch, srv.URL+"/api/user/urls/short", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestDeviceRules() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	cli := srv.Client()
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	bot := true
+	s.serviceURL.SetGetShortURLResult(&entity.URL{
+		Short:    "app",
+		Original: "https://example.com/app",
+		DeviceRules: []entity.DeviceRule{
+			{Bot: &bot, URL: "https://example.com/preview"},
+			{OS: useragent.OSIOS, URL: "https://apps.apple.com/app/id1"},
+			{OS: useragent.OSAndroid, URL: "market://details?id=com.example"},
+		},
+	}, nil)
+
+	tests := []struct {
+		name     string
+		ua       string
+		location string
+	}{
+		{name: "ios", ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4)", location: "https://apps.apple.com/app/id1"},
+		{name: "android", ua: "Mozilla/5.0 (Linux; Android 14; Pixel 8) Mobile", location: "market://details?id=com.example"},
+		{name: "desktop", ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", location: "https://example.com/app"},
+		{name: "bot", ua: "Googlebot/2.1", location: "https://example.com/preview"},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			r, err := http.NewRequest(http.MethodGet, srv.URL+"/app", nil)
+			s.Require().NoError(err)
+			r.Header.Set("User-Agent", test.ua)
+			resp, err := cli.Do(r)
+			s.Require().NoError(err)
+			defer resp.Body.Close()
+			s.Require().Equal(http.StatusTemporaryRedirect, resp.StatusCode)
+			s.Require().Equal(test.location, resp.Header.Get("Location"))
+			s.Require().Equal("User-Agent", resp.Header.Get("Vary"))
+		})
+	}
+
+	s.Run("rule without condition", func() {
+		body := strings.NewReader(`{"device_rules":[{"url":"https://example.com"}]}`)
+		r := httptest.NewRequest(http.MethodPatch, srv.URL+"/api/user/urls/app", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("executable destination", func() {
+		body := strings.NewReader(`{"device_rules":[{"os":"ios","url":"javascript:alert(1)"}]}`)
+		r := httptest.NewRequest(http.MethodPatch, srv.URL+"/api/user/urls/app", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestCountryRules() { //nolint:funlen
+	geoFile := filepath.Join(s.T().TempDir(), "geoip.csv")
+	s.Require().NoError(os.WriteFile(geoFile, []byte("network,country\n"+
+		"192.0.2.0/24,DE\n198.51.100.0/24,US\n2001:db8::/32,JP\n"), 0o600))
+	geo, err := geoip.Open(geoFile)
+	s.Require().NoError(err)
+	s.cnt.SetGeoLocator(geo)
+	s.cnt.GetConfig().TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	defer func() {
+		s.cnt.SetGeoLocator(nil)
+		s.cnt.GetConfig().TrustedProxies = nil
+	}()
+
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	cli := srv.Client()
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	s.serviceURL.SetGetShortURLResult(&entity.URL{
+		Short:          "geo",
+		Original:       "https://example.com",
+		RedirectStatus: http.StatusMovedPermanently,
+		CountryRules: []entity.CountryRule{
+			{Countries: []string{entity.CountryGroupEU}, URL: "https://example.com/eu-privacy"},
+			{Countries: []string{"US", "JP"}, URL: "https://example.com/intl"},
+		},
+	}, nil)
+
+	tests := []struct {
+		name      string
+		forwarded string
+		location  string
+	}{
+		{name: "eu", forwarded: "192.0.2.10", location: "https://example.com/eu-privacy"},
+		{name: "us behind two proxies", forwarded: "198.51.100.7, 127.0.0.2", location: "https://example.com/intl"},
+		{name: "ipv6", forwarded: "2001:db8::1", location: "https://example.com/intl"},
+		{name: "unknown country", forwarded: "203.0.113.1", location: "https://example.com"},
+		{name: "spoofed by client", forwarded: "192.0.2.10, 203.0.113.1", location: "https://example.com"},
+		{name: "without header", location: "https://example.com"},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			r, err := http.NewRequest(http.MethodGet, srv.URL+"/geo", nil)
+			s.Require().NoError(err)
+			if test.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+			resp, err := cli.Do(r)
+			s.Require().NoError(err)
+			defer resp.Body.Close()
+			s.Require().Equal(http.StatusMovedPermanently, resp.StatusCode)
+			s.Require().Equal(test.location, resp.Header.Get("Location"))
+			s.Require().Equal("no-store", resp.Header.Get("Cache-Control"))
+		})
+	}
+
+	s.Run("untrusted proxy", func() {
+		s.cnt.GetConfig().TrustedProxies = nil
+		defer func() {
+			s.cnt.GetConfig().TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+		}()
+		r, err := http.NewRequest(http.MethodGet, srv.URL+"/geo", nil)
+		s.Require().NoError(err)
+		r.Header.Set("X-Forwarded-For", "192.0.2.10")
+		resp, err := cli.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal("https://example.com", resp.Header.Get("Location"))
+	})
+
+	s.Run("invalid country code", func() {
+		body := strings.NewReader(`{"country_rules":[{"countries":["de"],"url":"https://example.com"}]}`)
+		r := httptest.NewRequest(http.MethodPatch, srv.URL+"/api/user/urls/geo", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestBrandedDomains() { //nolint:funlen
+	s.cnt.GetConfig().Domains = []string{"go.brand-a.com", "brand-b.link"}
+	defer func() { s.cnt.GetConfig().Domains = nil }()
+
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	s.Run("lookup is keyed by host", func() {
+		tests := []struct {
+			host string
+			key  string
+		}{
+			{host: "go.brand-a.com", key: "go.brand-a.com/abc"},
+			{host: "Brand-B.link:8080", key: "brand-b.link/abc"},
+			{host: "test:8080", key: "abc"},
+		}
+		for _, test := range tests {
+			r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			r.Host = test.host
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("short_url", "abc")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			s.Require().Equal(test.key, s.app.requestShortKey(r))
+		}
+	})
+
+	s.Run("create url on branded domain", func() {
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "go.brand-a.com/abc", Original: "https://ya.ru"}, nil)
+		body := strings.NewReader(`{"url":"https://ya.ru","domain":"Go.Brand-A.com"}`)
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`{"result":"http://go.brand-a.com/abc"}`, string(b))
+	})
+
+	s.Run("settings are passed to creation of url", func() {
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "abc", Original: "https://ya.ru"}, nil)
+		s.serviceURL.SetUpdateUserURLResult(nil, errors.New("must not be called"))
+		defer s.serviceURL.SetUpdateUserURLResult(nil, nil)
+		body := strings.NewReader(`{"url":"https://ya.ru","visibility":"private","password":"secret","max_clicks":5}`)
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		settings := s.serviceURL.MakeShortURLSettings()
+		s.Require().NotNil(settings)
+		s.Require().Equal(entity.VisibilityPrivate, *settings.Visibility)
+		s.Require().Equal("secret", *settings.Password)
+		s.Require().Equal(int64(5), *settings.MaxClicks)
+	})
+
+	s.Run("unknown domain", func() {
+		body := strings.NewReader(`{"url":"https://ya.ru","domain":"evil.example"}`)
+		r := httptest.NewRequest(http.MethodPost, srv.URL+"/api/shorten", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("user urls are rendered with own domains", func() {
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+			{Short: "brand-b.link/abc", Original: "https://ya.ru/b"},
+			{Short: "abc", Original: "https://ya.ru"},
+		}, nil)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`[
+			{"short_url":"http://brand-b.link/abc","original_url":"https://ya.ru/b","domain":"brand-b.link"},
+			{"short_url":"http://test:8080/abc","original_url":"https://ya.ru"}
+		]`, string(b))
+	})
+}
+
+func (s *FunctionalTestSuite) TestTags() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	do := func(method string, target string, body string) (int, string) {
+		r := httptest.NewRequest(method, srv.URL+target, strings.NewReader(body))
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	s.Run("filter user urls by tag", func() {
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+			{Short: "a", Original: "https://ya.ru/a", Title: "Spring sale", Tags: []string{"promo", "q1"}},
+		}, nil)
+		status, body := do(http.MethodGet, "/api/user/urls?tag=Promo", "")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Equal("promo", s.serviceURL.GetUserURLsTag())
+		s.Require().JSONEq(`[{
+			"short_url":"http://test:8080/a",
+			"original_url":"https://ya.ru/a",
+			"title":"Spring sale",
+			"tags":["promo","q1"]
+		}]`, body)
+
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{}, nil)
+		status, body = do(http.MethodGet, "/api/user/urls?tag=unknown", "")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().JSONEq(`[]`, body)
+	})
+
+	s.Run("list tags", func() {
+		s.serviceURL.SetGetUserTagsResult([]entity.TagStats{{Tag: "docs", URLs: 1}, {Tag: "promo", URLs: 3}}, nil)
+		status, body := do(http.MethodGet, "/api/user/tags", "")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().JSONEq(`[{"tag":"docs","urls":1},{"tag":"promo","urls":3}]`, body)
+	})
+
+	s.Run("rename and merge tags", func() {
+		s.serviceURL.SetRenameUserTagsResult(2, nil)
+		status, body := do(http.MethodPatch, "/api/user/tags/promo", `{"name":" Marketing "}`)
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().JSONEq(`{"updated":2}`, body)
+
+		status, _ = do(http.MethodPost, "/api/user/tags/merge", `{"tags":["promo","sale"],"into":"marketing"}`)
+		s.Require().Equal(http.StatusOK, status)
+	})
+
+	s.Run("invalid tags", func() {
+		status, _ := do(http.MethodPatch, "/api/user/tags/promo", `{"name":"a,b"}`)
+		s.Require().Equal(http.StatusBadRequest, status)
+		status, _ = do(http.MethodPost, "/api/user/tags/merge", `{"tags":[],"into":"marketing"}`)
+		s.Require().Equal(http.StatusBadRequest, status)
+		status, _ = do(http.MethodPatch, "/api/user/urls/a", `{"tags":["`+strings.Repeat("t", 51)+`"]}`)
+		s.Require().Equal(http.StatusBadRequest, status)
+		status, _ = do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru","title":"`+strings.Repeat("t", 256)+`"}`)
+		s.Require().Equal(http.StatusBadRequest, status)
+	})
+}
+
+func (s *FunctionalTestSuite) TestLinkPreview() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	previews, _ := service.NewPreviewServiceMock().(*service.PreviewServiceMock)
+	s.cnt.SetServicePreview(previews)
+	defer s.cnt.SetServicePreview(nil)
+
+	do := func(method string, target string, body string) (int, string) {
+		r := httptest.NewRequest(method, srv.URL+target, strings.NewReader(body))
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	s.Run("created url is enqueued", func() {
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "prev", Original: "https://ya.ru"}, nil)
+		status, _ := do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusCreated, status)
+		s.Require().Equal([]string{"prev"}, previews.Enqueued())
+	})
+
+	s.Run("existing url is not enqueued", func() {
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "prev"}, customerror.ErrURLAlreadyExists)
+		status, _ := do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusConflict, status)
+		s.Require().Empty(previews.Enqueued())
+	})
+
+	s.Run("preview in user urls", func() {
+		fetchedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		s.serviceURL.SetGetUserURLsResult([]*entity.URL{{
+			Short:    "prev",
+			Original: "https://ya.ru",
+			Preview: &entity.LinkPreview{
+				Title:     "Yandex",
+				Image:     "https://ya.ru/logo.png",
+				SiteName:  "Ya",
+				Favicon:   "https://ya.ru/favicon.ico",
+				FetchedAt: fetchedAt,
+			},
+		}}, nil)
+		status, body := do(http.MethodGet, "/api/user/urls", "")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().JSONEq(`[{
+			"short_url":"http://test:8080/prev",
+			"original_url":"https://ya.ru",
+			"preview":{
+				"title":"Yandex",
+				"image":"https://ya.ru/logo.png",
+				"site_name":"Ya",
+				"favicon":"https://ya.ru/favicon.ico",
+				"fetched_at":"2024-01-02T03:04:05Z"
+			}
+		}]`, body)
+	})
+}
+
+func (s *FunctionalTestSuite) TestBrokenURLs() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	checkedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.serviceURL.SetGetUserURLsResult([]*entity.URL{
+		{Short: "ok", Original: "https://ya.ru", LinkCheck: &entity.LinkCheck{Status: 200, CheckedAt: checkedAt}},
+		{Short: "unchecked", Original: "https://ya.ru/new"},
+		{Short: "gone", Original: "https://ya.ru/old", LinkCheck: &entity.LinkCheck{
+			Status:    404,
+			CheckedAt: checkedAt,
+			Failures:  3,
+			Broken:    true,
+		}},
+	}, nil)
+
+	r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls/broken", nil)
+	r.RequestURI = ""
+	r.AddCookie(s.userCookie())
+	resp, err := http.DefaultClient.Do(r)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().JSONEq(`[{
+		"short_url":"http://test:8080/gone",
+		"original_url":"https://ya.ru/old",
+		"link_check":{"status":404,"checked_at":"2024-01-02T03:04:05Z","failures":3,"broken":true}
+	}]`, string(body))
+}
+
+func (s *FunctionalTestSuite) TestSearchURLs() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	get := func(target string) (int, string) {
+		r := httptest.NewRequest(http.MethodGet, srv.URL+target, nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	s.serviceURL.SetSearchUserURLsResult([]*entity.URL{
+		{Short: "spring", Original: "https://shop.test/sale", Title: "Spring sale"},
+	}, 3, nil)
+	status, body := get("/api/user/urls/search?q=sale&limit=1&offset=1")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().JSONEq(`{
+		"total":3,
+		"urls":[{"short_url":"http://test:8080/spring","original_url":"https://shop.test/sale","title":"Spring sale"}]
+	}`, body)
+
+	for _, target := range []string{
+		"/api/user/urls/search",
+		"/api/user/urls/search?q=%20./%20",
+		"/api/user/urls/search?q=" + strings.Repeat("a", 201),
+		"/api/user/urls/search?q=sale&limit=0",
+		"/api/user/urls/search?q=sale&limit=101",
+		"/api/user/urls/search?q=sale&offset=-1",
+	} {
+		status, _ = get(target)
+		s.Require().Equal(http.StatusBadRequest, status, target)
+	}
+}
+
+func (s *FunctionalTestSuite) TestExportURLs() {
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	get := func(target string) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, srv.URL+target, nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+
+		return resp
+	}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.serviceURL.SetExportUserURLsResult([]*entity.URL{
+		{Short: "spring", Original: "https://shop.test/sale", Title: "Spring sale", CreatedAt: createdAt},
+	}, nil)
+	resp := get("/api/user/urls/export?format=csv")
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	s.Require().Equal(`attachment; filename="links.csv"`, resp.Header.Get("Content-Disposition"))
+	s.Require().Equal(`code,short_url,original_url,title,created_at,deleted_at
+spring,http://test:8080/spring,https://shop.test/sale,Spring sale,2024-01-02T03:04:05Z,
+`, string(body))
+
+	resp = get("/api/user/urls/export?format=xml")
+	resp.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+	s.serviceURL.SetExportUserURLsResult(nil, errors.New("storage is down"))
+	resp = get("/api/user/urls/export?format=ndjson")
+	resp.Body.Close()
+	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+	s.Require().Empty(resp.Header.Get("Content-Disposition"))
+}
+
+func (s *FunctionalTestSuite) TestImportURLs() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	upload := func(target string, fileName string, file string) (int, string) {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		if fileName != "" {
+			fw, err := mw.CreateFormFile("file", fileName)
+			s.Require().NoError(err)
+			_, err = fw.Write([]byte(file))
+			s.Require().NoError(err)
+		}
+		s.Require().NoError(mw.Close())
+
+		r := httptest.NewRequest(http.MethodPost, srv.URL+target, body)
+		r.RequestURI = ""
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp.StatusCode, string(b)
+	}
+	file := `Long URL,Keyword,Title
+https://example.com/docs,docs,Docs
+ftp://example.com/file,file,
+https://example.com/home,,
+`
+
+	s.serviceURL.SetImportURLsResult([]entity.ImportResult{
+		{URL: &entity.URL{Short: "docs", Original: "https://example.com/docs"}, Status: entity.ImportCreated},
+		{URL: &entity.URL{Short: "home", Original: "https://example.com/home"}, Status: entity.ImportExists},
+	}, nil)
+	status, body := upload("/api/user/urls/import", "bitly.csv", file)
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().JSONEq(`{
+		"created":1,
+		"exists":1,
+		"failed":1,
+		"rows":[
+			{"line":2,"original_url":"https://example.com/docs","short_url":"http://test:8080/docs","status":"created"},
+			{"line":3,"original_url":"ftp://example.com/file","status":"failed","error":"url must be http or https url"},
+			{"line":4,"original_url":"https://example.com/home","short_url":"http://test:8080/home","status":"exists"}
+		]
+	}`, body)
+
+	s.serviceURL.SetImportURLsResult(nil, customerror.ErrDailyQuotaExceeded)
+	status, body = upload("/api/user/urls/import?format=csv", "links.txt", file)
+	s.Require().Equal(http.StatusTooManyRequests, status)
+	s.Require().Contains(body, `"created":0,"exists":0,"failed":3`)
+	s.Require().Contains(body, `"error":"daily links quota exceeded"`)
+
+	s.serviceURL.SetImportURLsResult(nil, errors.New("pq: connection refused"))
+	status, body = upload("/api/user/urls/import", "links.csv", file)
+	s.Require().Equal(http.StatusInternalServerError, status)
+	s.Require().Contains(body, `"error":"Internal Server Error"`)
+	s.Require().NotContains(body, "pq:")
+
+	for _, tc := range []struct {
+		target   string
+		fileName string
+		file     string
+	}{
+		{target: "/api/user/urls/import"},
+		{target: "/api/user/urls/import", fileName: "links.txt", file: file},
+		{target: "/api/user/urls/import", fileName: "links.csv", file: "code,title\nabc,Docs\n"},
+		{target: "/api/user/urls/import?domain=unknown.test", fileName: "links.csv", file: file},
+	} {
+		status, _ = upload(tc.target, tc.fileName, tc.file)
+		s.Require().Equal(http.StatusBadRequest, status, tc)
+	}
+}
+
+func (s *FunctionalTestSuite) TestVariants() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	abURL := &entity.URL{
+		Short:    "ab",
+		Original: "https://example.com",
+		Variants: []entity.Variant{
+			{ID: "a", URL: "https://example.com/a", Weight: 70},
+			{ID: "b", URL: "https://example.com/b", Weight: 30},
+		},
+	}
+	s.serviceURL.SetGetShortURLResult(abURL, nil)
+	get := func(cli *http.Client) *http.Response {
+		cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		resp, err := cli.Get(srv.URL + "/ab")
+		s.Require().NoError(err)
+
+		return resp
+	}
+
+	s.Run("visitor stays with variant", func() {
+		jar, err := cookiejar.New(nil)
+		s.Require().NoError(err)
+		cli := &http.Client{Jar: jar}
+
+		resp := get(cli)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusTemporaryRedirect, resp.StatusCode)
+		first := resp.Header.Get("Location")
+		s.Require().Contains([]string{"https://example.com/a", "https://example.com/b"}, first)
+		s.Require().Equal("no-store", resp.Header.Get("Cache-Control"))
+
+		for i := 0; i < 5; i++ {
+			resp := get(cli)
+			defer resp.Body.Close()
+			s.Require().Equal(first, resp.Header.Get("Location"))
+		}
+	})
+
+	s.Run("visitor without cookies stays with variant", func() {
+		cli := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		expected := abURL.ChooseVariant("127.0.0.1|test-agent")
+		for i := 0; i < 10; i++ {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/ab", nil)
+			s.Require().NoError(err)
+			req.Header.Set("User-Agent", "test-agent")
+			resp, err := cli.Do(req)
+			s.Require().NoError(err)
+			resp.Body.Close()
+			s.Require().Equal(expected.URL, resp.Header.Get("Location"))
+		}
+	})
+
+	s.Run("variant from cookie", func() {
+		srvURL, err := url.Parse(srv.URL + "/ab")
+		s.Require().NoError(err)
+		jar, err := cookiejar.New(nil)
+		s.Require().NoError(err)
+		jar.SetCookies(srvURL, []*http.Cookie{{Name: "variant", Value: "b", Path: "/ab"}})
+
+		resp := get(&http.Client{Jar: jar})
+		defer resp.Body.Close()
+		s.Require().Equal("https://example.com/b", resp.Header.Get("Location"))
+	})
+
+	s.Run("clicks per variant", func() {
+		s.serviceURL.SetGetURLVariantStatsResult([]entity.VariantStats{
+			{Variant: entity.Variant{ID: "a", URL: "https://example.com/a", Weight: 70}, Clicks: 12},
+			{Variant: entity.Variant{ID: "b", URL: "https://example.com/b", Weight: 30}, Clicks: 5},
+		}, nil)
+		r := httptest.NewRequest(http.MethodGet, srv.URL+"/api/user/urls/ab/variants", nil)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().JSONEq(`[
+			{"id":"a","url":"https://example.com/a","weight":70,"clicks":12},
+			{"id":"b","url":"https://example.com/b","weight":30,"clicks":5}
+		]`, string(b))
+	})
+
+	s.Run("invalid variants", func() {
+		body := strings.NewReader(`{"variants":[{"id":"a","url":"https://example.com/a","weight":0}]}`)
+		r := httptest.NewRequest(http.MethodPatch, srv.URL+"/api/user/urls/ab", body)
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestIdempotencyKey() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+	s.cnt.SetServiceIdempotency(service.NewIdempotencyService(s.cnt.GetLogger(), storage.NewMemoryStorage(), time.Hour))
+	defer s.cnt.SetServiceIdempotency(nil)
+	userCookie := s.userCookie()
+
+	post := func(target string, key string, contentType string, body string) (*http.Response, string) {
+		r := httptest.NewRequest(http.MethodPost, srv.URL+target, strings.NewReader(body))
+		r.RequestURI = ""
+		r.AddCookie(userCookie)
+		r.Header.Set("Idempotency-Key", key)
+		r.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp, string(b)
+	}
+
+	s.Run("retry gets the first response", func() {
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "first", Original: "https://ya.ru"}, nil)
+		resp, body := post("/api/shorten", "retry", "application/json", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().JSONEq(`{"result":"http://test:8080/first"}`, body)
+		s.Require().Empty(resp.Header.Get("Idempotent-Replayed"))
+
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "second", Original: "https://ya.ru"}, nil)
+		resp, body = post("/api/shorten", "retry", "application/json", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().JSONEq(`{"result":"http://test:8080/first"}`, body)
+		s.Require().Equal("application/json", resp.Header.Get("Content-Type"))
+		s.Require().Equal("true", resp.Header.Get("Idempotent-Replayed"))
+
+		resp, body = post("/", "plain", "text/plain", "https://ya.ru")
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("http://test:8080/second", body)
+		resp, body = post("/", "plain", "text/plain", "https://ya.ru")
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("http://test:8080/second", body)
+		s.Require().Equal("true", resp.Header.Get("Idempotent-Replayed"))
+	})
+
+	s.Run("key reused for other payload", func() {
+		resp, body := post("/api/shorten", "retry", "application/json", `{"url":"https://ya.ru/other"}`)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Contains(body, "idempotency key is already used for other request")
+
+		resp, _ = post("/api/shorten/batch", "retry", "application/json", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("rate limited request is not saved", func() {
+		s.serviceURL.SetMakeShortURLResult(nil, customerror.ErrDailyQuotaExceeded)
+		resp, _ := post("/api/shorten", "limited", "application/json", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusTooManyRequests, resp.StatusCode)
+
+		s.serviceURL.SetMakeShortURLResult(&entity.URL{Short: "later", Original: "https://ya.ru"}, nil)
+		resp, body := post("/api/shorten", "limited", "application/json", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().JSONEq(`{"result":"http://test:8080/later"}`, body)
+		s.Require().Empty(resp.Header.Get("Idempotent-Replayed"))
+	})
+
+	s.Run("invalid key and streamed batch", func() {
+		resp, _ := post("/api/shorten", strings.Repeat("k", 256), "application/json", `{"url":"https://ya.ru"}`)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+		item := `{"correlation_id":"1","original_url":"https://ya.ru"}`
+		resp, _ = post("/api/shorten/batch", "stream", "application/x-ndjson", item)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func (s *FunctionalTestSuite) TestExpandURLs() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	do := func(method string, target string, body string) (int, string) {
+		r := httptest.NewRequest(method, srv.URL+target, strings.NewReader(body))
+		r.RequestURI = ""
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	s.Run("active url", func() {
+		s.serviceURL.SetGetShortURLResult(&entity.URL{
+			Short:    "abc",
+			Original: "https://ya.ru",
+			Title:    "Yandex",
+			Notes:    "private notes",
+			Preview:  &entity.LinkPreview{SiteName: "Ya", FetchedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		}, nil)
+		status, body := do(http.MethodGet, "/api/expand/abc", "")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().JSONEq(`{
+			"short_url":"http://test:8080/abc",
+			"original_url":"https://ya.ru",
+			"status":"active",
+			"title":"Yandex",
+			"preview":{"site_name":"Ya","fetched_at":"2024-01-02T03:04:05Z"}
+		}`, body)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		url    *entity.URL
+		err    error
+		status int
+		body   string
+	}{
+		{
+			name:   "missing or private url",
+			status: http.StatusNotFound,
+			body:   `{"short_url":"http://test:8080/abc","status":"not_found"}`,
+		},
+		{
+			name:   "deleted url",
+			err:    customerror.ErrURLDeleted,
+			status: http.StatusGone,
+			body:   `{"short_url":"http://test:8080/abc","status":"deleted"}`,
+		},
+		{
+			name:   "exhausted url",
+			url:    &entity.URL{Short: "abc", Original: "https://ya.ru", MaxClicks: 2, Clicks: 2},
+			status: http.StatusGone,
+			body:   `{"short_url":"http://test:8080/abc","status":"exhausted"}`,
+		},
+		{
+			name:   "protected url",
+			url:    &entity.URL{Short: "abc", Original: "https://ya.ru", PasswordHash: "hash"},
+			status: http.StatusOK,
+			body:   `{"short_url":"http://test:8080/abc","status":"password_protected"}`,
+		},
+	} {
+		s.Run(tc.name, func() {
+			s.serviceURL.SetGetShortURLResult(tc.url, tc.err)
+			status, body := do(http.MethodGet, "/api/expand/abc", "")
+			s.Require().Equal(tc.status, status)
+			s.Require().JSONEq(tc.body, body)
+		})
+	}
+
+	s.Run("batch", func() {
+		s.serviceURL.SetGetShortURLResult(&entity.URL{Short: "abc", Original: "https://ya.ru"}, nil)
+		status, body := do(http.MethodPost, "/api/expand", `["abc","http://test:8080/abc","https://other.test/abc"]`)
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().JSONEq(`[
+			{"short_url":"http://test:8080/abc","original_url":"https://ya.ru","status":"active"},
+			{"short_url":"http://test:8080/abc","original_url":"https://ya.ru","status":"active"},
+			{"short_url":"https://other.test/abc","status":"not_found"}
+		]`, body)
+
+		for _, body := range []string{`[]`, `{"url":"abc"}`, `[` + strings.Repeat(`"abc",`, 100) + `"abc"]`} {
+			status, _ = do(http.MethodPost, "/api/expand", body)
+			s.Require().Equal(http.StatusBadRequest, status, body)
+		}
+	})
+}
+
+func (s *FunctionalTestSuite) TestProblemDetails() { //nolint:funlen
+	srv := httptest.NewServer(s.app.Routes())
+	defer srv.Close()
+
+	do := func(method string, target string, body string, requestID string) (*http.Response, string) {
+		r := httptest.NewRequest(method, srv.URL+target, strings.NewReader(body))
+		r.RequestURI = ""
+		if requestID != "" {
+			r.Header.Set("X-Request-Id", requestID)
+		}
+		r.AddCookie(s.userCookie())
+		resp, err := http.DefaultClient.Do(r)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+
+		return resp, string(b)
+	}
+
+	s.Run("field error", func() {
+		resp, body := do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru","max_clicks":-1}`, "req-1")
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+		s.Require().Equal("application/problem+json", resp.Header.Get("Content-Type"))
+		s.Require().Equal("req-1", resp.Header.Get("X-Request-Id"))
+		s.Require().JSONEq(`{
+			"type":"about:blank",
+			"title":"Bad Request",
+			"status":400,
+			"detail":"max_clicks must not be negative",
+			"instance":"/api/shorten",
+			"code":"invalid_max_clicks",
+			"request_id":"req-1",
+			"errors":[{"field":"max_clicks","code":"invalid_max_clicks","detail":"max_clicks must not be negative"}]
+		}`, body)
+	})
+
+	s.Run("server error is not described", func() {
+		s.serviceURL.SetMakeShortURLResult(nil, errors.New("pq: connection refused"))
+		resp, body := do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru"}`, "req-2")
+		s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+		s.Require().JSONEq(`{
+			"type":"about:blank",
+			"title":"Internal Server Error",
+			"status":500,
+			"instance":"/api/shorten",
+			"code":"internal_server_error",
+			"request_id":"req-2"
+		}`, body)
+	})
+
+	s.Run("unknown route", func() {
+		resp, body := do(http.MethodGet, "/api/unknown/route", "", "")
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+		s.Require().Equal("application/problem+json", resp.Header.Get("Content-Type"))
+		requestID := resp.Header.Get("X-Request-Id")
+		s.Require().NotEmpty(requestID)
+		s.Require().Contains(body, `"code":"not_found"`)
+		s.Require().Contains(body, `"request_id":"`+requestID+`"`)
+	})
+}
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientAddr return IP address of client. Headers X-Forwarded-For and X-Real-IP are trusted
+// only when request comes from trusted proxy, otherwise any client could spoof its address.
+// X-Forwarded-For is read from right to left, the first address which is not trusted proxy is client.
+func (a *Application) clientAddr(req *http.Request) netip.Addr {
+	addr := remoteAddr(req)
+	if !a.isTrustedProxy(addr) {
+		return addr
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !a.isTrustedProxy(addr) {
+			return addr
+		}
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap()
+	}
+
+	return addr
+}
+
+// clientIP return IP address of client as string, address of connection when it cannot be parsed.
+func (a *Application) clientIP(req *http.Request) string {
+	if addr := a.clientAddr(req); addr.IsValid() {
+		return addr.String()
+	}
+
+	return req.RemoteAddr
+}
+
+// clientCountry return country of client, empty when geo database is not configured or address is unknown.
+func (a *Application) clientCountry(req *http.Request) string {
+	locator := a.cnt.GetGeoLocator()
+	if locator == nil {
+		return ""
+	}
+
+	return locator.Country(a.clientAddr(req))
+}
+
+func (a *Application) isTrustedProxy(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, p := range a.cnt.GetConfig().TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remoteAddr return address of connection, invalid address when it cannot be parsed.
+func remoteAddr(req *http.Request) netip.Addr {
+	if addrPort, err := netip.ParseAddrPort(req.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	if addr, err := netip.ParseAddr(req.RemoteAddr); err == nil {
+		return addr.Unmap()
+	}
+
+	return netip.Addr{}
+}
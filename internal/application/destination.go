@@ -0,0 +1,41 @@
+package application
+
+import (
+	"net/http"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/useragent"
+)
+
+// destination build URL to redirect visitor to. If it cannot be built, response is written.
+// Device rules go first, because application links must not depend on country or experiment,
+// country rules go before variants, because regional pages must not depend on experiment.
+func (a *Application) destination(res http.ResponseWriter, req *http.Request, url *entity.URL) (string, bool) {
+	target := url.Original
+	if rule := url.MatchDeviceRule(useragent.Parse(req.UserAgent())); rule != nil {
+		target = rule.URL
+	} else if rule := a.matchCountryRule(req, url); rule != nil {
+		target = rule.URL
+	} else if variant := a.chooseVariant(res, req, url); variant != nil {
+		target = variant.URL
+	}
+
+	destination, err := url.Destination(target, req.URL.Query())
+	if err != nil {
+		a.cnt.GetLogger().Error().Err(err).Str("short", url.Short).Msg("cannot build destination of url")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return "", false
+	}
+
+	return destination, true
+}
+
+// matchCountryRule return country rule matched by country of client or nil.
+func (a *Application) matchCountryRule(req *http.Request, url *entity.URL) *entity.CountryRule {
+	if len(url.CountryRules) == 0 {
+		return nil
+	}
+
+	return url.MatchCountryRule(a.clientCountry(req))
+}
@@ -0,0 +1,39 @@
+package application
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// requestDomain return branded domain which request is sent to, empty for default domain.
+// Unknown hosts are served from namespace of default domain.
+func (a *Application) requestDomain(req *http.Request) string {
+	host := strings.ToLower(req.Host)
+	if a.cnt.GetConfig().HasDomain(host) {
+		return host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil && a.cnt.GetConfig().HasDomain(h) {
+		return h
+	}
+
+	return ""
+}
+
+// requestShortKey return key of URL followed by request.
+func (a *Application) requestShortKey(req *http.Request) string {
+	return entity.ShortKey(a.requestDomain(req), chi.URLParam(req, "short_url"))
+}
+
+// userURLKey return key of URL managed by API, URL of branded domain is selected with ?domain=host.
+func userURLKey(req *http.Request) string {
+	return entity.ShortKey(strings.ToLower(req.URL.Query().Get("domain")), chi.URLParam(req, "short_url"))
+}
+
+// shortURL return short URL with base of its domain.
+func (a *Application) shortURL(url *entity.URL) string {
+	return a.cnt.GetConfig().BaseURL(url.Domain()) + "/" + url.Code()
+}
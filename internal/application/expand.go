@@ -0,0 +1,137 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// expandURL return destination of short URL without redirect. URL of branded domain is selected with ?domain=host.
+// Click is not counted, statuses of response are the same as statuses of redirect.
+func (a *Application) expandURL(res http.ResponseWriter, req *http.Request) {
+	key := userURLKey(req)
+	result, err := a.expand(req, key, a.currentUserID(req))
+	if err != nil {
+		a.cnt.GetLogger().Error().Err(err).Msg("cannot expand short url")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	status := http.StatusOK
+	switch result.Status {
+	case entity.ExpandNotFound:
+		status = http.StatusNotFound
+	case entity.ExpandDeleted, entity.ExpandExhausted:
+		status = http.StatusGone
+	}
+	a.writeJSON(res, req, status, result)
+}
+
+// expandURLs return destinations of batch of short URLs or codes, result of every URL is in its status.
+func (a *Application) expandURLs(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+	urls, err := validate.NewValidator(a.cnt.GetLogger()).ExpandURLsRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID := a.currentUserID(req)
+	results := make([]response.ExpandResponse, len(urls))
+	for i, v := range urls {
+		key, ok := a.expandKey(v)
+		if !ok {
+			results[i] = response.ExpandResponse{ShortURL: v, Status: entity.ExpandNotFound}
+
+			continue
+		}
+		result, err := a.expand(req, key, userID)
+		if err != nil {
+			a.cnt.GetLogger().Error().Err(err).Msg("cannot expand short url")
+			a.writeError(res, req, http.StatusInternalServerError, nil)
+
+			return
+		}
+		results[i] = *result
+	}
+	a.writeJSON(res, req, http.StatusOK, results)
+}
+
+// expand resolve URL by key like redirect does, but without counting click.
+func (a *Application) expand(req *http.Request, key string, userID uuid.UUID) (*response.ExpandResponse, error) {
+	domain, code := "", key
+	if i := strings.LastIndexByte(key, '/'); i >= 0 {
+		domain, code = key[:i], key[i+1:]
+	}
+	result := &response.ExpandResponse{ShortURL: a.cnt.GetConfig().BaseURL(domain) + "/" + code}
+
+	shortURL, err := a.cnt.GetServiceURL().GetShortURL(req.Context(), key, userID)
+	switch {
+	case errors.Is(err, customerror.ErrURLDeleted):
+		result.Status = entity.ExpandDeleted
+	case err != nil:
+		return nil, err
+	// приватная ссылка для посторонних не существует
+	case shortURL == nil:
+		result.Status = entity.ExpandNotFound
+	case shortURL.HasClicksLimit() && shortURL.RemainingClicks() == 0:
+		result.Status = entity.ExpandExhausted
+	case !a.hasLinkAccess(req, shortURL):
+		result.Status = entity.ExpandProtected
+	default:
+		result.Status = entity.ExpandActive
+		result.OriginalURL = shortURL.Original
+		result.Title = shortURL.Title
+		result.Description = shortURL.Description
+		if shortURL.Preview != nil {
+			preview := response.NewPreviewResponse(shortURL.Preview)
+			result.Preview = &preview
+		}
+	}
+
+	return result, nil
+}
+
+// expandKey return key of URL from short URL or code. Short URL must belong to default or branded domain.
+func (a *Application) expandKey(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		return s, s != ""
+	}
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", false
+	}
+
+	domain := strings.ToLower(u.Hostname())
+	if base, err := url.Parse(a.cnt.GetConfig().ResultURL); err == nil && strings.EqualFold(base.Hostname(), domain) {
+		domain = ""
+	} else if !a.cnt.GetConfig().HasDomain(domain) {
+		return "", false
+	}
+	code := strings.Trim(u.Path, "/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", false
+	}
+
+	return entity.ShortKey(domain, code), true
+}
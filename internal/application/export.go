@@ -0,0 +1,67 @@
+package application
+
+import (
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/export"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// exportUserURLs stream personal URLs of user as file in csv, ndjson or html bookmarks format.
+func (a *Application) exportUserURLs(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	format := req.URL.Query().Get("format")
+	out := &countingWriter{w: res}
+	w, err := export.NewWriter(format, out)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	res.Header().Set("Content-Type", export.ContentType(format))
+	res.Header().Set("Content-Disposition", `attachment; filename="links.`+format+`"`)
+	err = a.cnt.GetServiceURL().ExportUserURLs(req.Context(), userID, func(url *entity.URL) error {
+		return w.Write(&export.Link{
+			Code:      url.Code(),
+			ShortURL:  a.shortURL(url),
+			Original:  url.Original,
+			Title:     url.Title,
+			Tags:      url.Tags,
+			CreatedAt: url.CreatedAt,
+			DeletedAt: url.DeletedAt,
+		})
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Int64("written", out.n).Msg("cannot export user URLs")
+		if !out.sent {
+			res.Header().Del("Content-Disposition")
+			a.writeError(res, req, http.StatusInternalServerError, nil)
+		}
+	}
+}
+
+// countingWriter send status 200 before first bytes of body and count written bytes.
+type countingWriter struct {
+	w    http.ResponseWriter
+	n    int64
+	sent bool
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if !c.sent {
+		c.sent = true
+		c.w.WriteHeader(http.StatusOK)
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,164 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// headers of idempotent requests.
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// errIdempotencyStream streamed batch is not kept whole, so its response cannot be replayed.
+var errIdempotencyStream = customerror.New(
+	"idempotency_key_unsupported",
+	"idempotency key is not supported for streamed batch",
+)
+
+// idempotent make creating handler safe for retries. Response of request with Idempotency-Key header
+// is saved and sent again for retries with the same key, the key cannot be used for request with other body.
+// Server errors and rate limits are not saved, so request with them can be retried.
+func (a *Application) idempotent(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get(idempotencyKeyHeader)
+		srv := a.cnt.GetServiceIdempotency()
+		if key == "" || srv == nil {
+			handler(res, req)
+
+			return
+		}
+		if isNDJSON(req) {
+			a.writeError(res, req, http.StatusBadRequest, errIdempotencyStream)
+
+			return
+		}
+
+		userID, ok := a.authorize(res, req, entity.ScopeCreate)
+		if !ok {
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			a.cnt.GetLogger().Warn().Err(err).Msg("cannot read body")
+			a.writeError(res, req, http.StatusInternalServerError, nil)
+
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(req, body)
+		reservation, err := srv.Begin(req.Context(), userID, key, hash)
+		if err != nil {
+			a.writeError(res, req, idempotencyErrorStatus(err), err)
+
+			return
+		}
+		if reservation.IsCompleted() {
+			a.replay(res, reservation)
+
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: res}
+		stop := srv.Hold(req.Context(), reservation)
+		handler(rec, req)
+		stop()
+		a.saveResponse(context.WithoutCancel(req.Context()), reservation, rec)
+	}
+}
+
+// replay send saved response.
+func (a *Application) replay(res http.ResponseWriter, saved *entity.IdempotencyRecord) {
+	if saved.ContentType != "" {
+		res.Header().Set("Content-Type", saved.ContentType)
+	}
+	res.Header().Set(idempotentReplayedHeader, "true")
+	res.WriteHeader(saved.Status)
+	if _, err := res.Write(saved.Body); err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot write saved response")
+	}
+}
+
+// saveResponse save recorded response into reservation of key or release key when request can be retried.
+func (a *Application) saveResponse(ctx context.Context, reservation *entity.IdempotencyRecord, rec *responseRecorder) {
+	srv := a.cnt.GetServiceIdempotency()
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	var err error
+	if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+		err = srv.Release(ctx, reservation)
+	} else {
+		record := *reservation
+		record.Status = status
+		record.ContentType = rec.Header().Get("Content-Type")
+		record.Body = rec.body.Bytes()
+		err = srv.Complete(ctx, &record)
+	}
+	if err != nil {
+		a.cnt.GetLogger().Err(err).Str("key", reservation.Key).Msg("cannot save response by idempotency key")
+	}
+}
+
+// requestHash hash of method, path and body, request with the same key must have the same hash.
+func requestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyErrorStatus status of response for error of idempotency key.
+func idempotencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, customerror.ErrIdempotencyKeyInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, customerror.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, customerror.ErrIdempotencyKeyInProgress):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// responseRecorder write response through and keep its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap return original writer for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,228 @@
+package application
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/importer"
+	"github.com/vagafonov/shortener/internal/request"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// limits of import.
+const (
+	importBatchSize = 100
+	maxImportSize   = 32 << 20
+)
+
+// errors of import request.
+var (
+	errImportNoFile = customerror.New("import_file_missing", "multipart form must contain file")
+	errImportFormat = customerror.New(
+		"import_format_unknown",
+		"format must be csv or ndjson, set it with format parameter or extension of file",
+	)
+)
+
+// importUserURLs create URLs of user from uploaded CSV or NDJSON file. File is read and created
+// in batches, so large files are not kept in memory. Report has result of every row of file.
+//
+//nolint:funlen
+func (a *Application) importUserURLs(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	domain := strings.ToLower(req.URL.Query().Get("domain"))
+	if domain != "" && !a.cnt.GetConfig().HasDomain(domain) {
+		a.writeError(res, req, http.StatusBadRequest, validate.ErrValidateDomain)
+
+		return
+	}
+
+	req.Body = http.MaxBytesReader(res, req.Body, maxImportSize)
+	file, fileName, err := importFile(req)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = importer.FormatOf(fileName)
+	}
+	rows, err := importer.NewReader(format, file)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, errImportFormat)
+
+		return
+	}
+
+	report := response.ImportResponse{Rows: make([]response.ImportRowResponse, 0)}
+	validator := validate.NewValidator(a.cnt.GetLogger())
+	batch := make([]*entity.URL, 0, importBatchSize)
+	lines := make([]int, 0, importBatchSize)
+	fail := func(line int, original string, reason string) {
+		report.Failed++
+		report.Rows = append(report.Rows, response.ImportRowResponse{
+			Line:        line,
+			OriginalURL: original,
+			Status:      entity.ImportFailed,
+			Error:       reason,
+		})
+	}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := a.cnt.GetServiceURL().ImportURLs(
+			req.Context(),
+			batch,
+			a.cnt.GetConfig().ShortURLLength,
+			userID,
+			domain,
+		)
+		if err != nil {
+			reason := importErrorText(err, batchErrorStatus(err))
+			for i, v := range batch {
+				fail(lines[i], v.Original, reason)
+			}
+
+			return err
+		}
+		for i, r := range results {
+			row := response.ImportRowResponse{
+				Line:        lines[i],
+				OriginalURL: batch[i].Original,
+				ShortURL:    a.shortURL(r.URL),
+				Status:      r.Status,
+			}
+			if r.Status == entity.ImportExists {
+				report.Exists++
+			} else {
+				report.Created++
+				a.enqueuePreview(r.URL)
+			}
+			report.Rows = append(report.Rows, row)
+		}
+		batch = batch[:0]
+		lines = lines[:0]
+
+		return nil
+	}
+
+	for {
+		var row *request.ImportRow
+		row, err = rows.Next()
+		var rowErr *importer.RowError
+		if errors.As(err, &rowErr) {
+			fail(rowErr.Line, "", rowErr.Err.Error())
+
+			continue
+		}
+		if err != nil {
+			break
+		}
+		if err = validator.ImportRow(row); err != nil {
+			fail(row.Line, row.OriginalURL, err.Error())
+
+			continue
+		}
+
+		batch = append(batch, &entity.URL{
+			Original: row.OriginalURL,
+			Short:    row.Code,
+			Title:    row.Title,
+			Tags:     row.Tags,
+		})
+		lines = append(lines, row.Line)
+		if len(batch) == importBatchSize {
+			if err = flush(); err != nil {
+				a.writeImportReport(res, req, report, batchErrorStatus(err), err)
+
+				return
+			}
+		}
+	}
+
+	// rows read before broken part of file are still created
+	readErr := err
+	if err = flush(); err != nil {
+		a.writeImportReport(res, req, report, batchErrorStatus(err), err)
+
+		return
+	}
+	if !errors.Is(readErr, io.EOF) {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(readErr, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		a.writeImportReport(res, req, report, status, readErr)
+
+		return
+	}
+
+	a.writeImportReport(res, req, report, http.StatusOK, nil)
+}
+
+// writeImportReport write report with rows in order of file, err is error which stopped import.
+// Failed rows are reported at once and created rows after their batch, so rows are sorted here.
+func (a *Application) writeImportReport(
+	res http.ResponseWriter,
+	req *http.Request,
+	report response.ImportResponse,
+	status int,
+	err error,
+) {
+	slices.SortStableFunc(report.Rows, func(a, b response.ImportRowResponse) int {
+		return a.Line - b.Line
+	})
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Int("created", report.Created).Msg("import stopped")
+		report.Error = importErrorText(err, status)
+	}
+	a.writeJSON(res, req, status, report)
+}
+
+// importFile find part of multipart form with file, fields before it are skipped.
+func importFile(req *http.Request) (io.Reader, string, error) {
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return nil, "", errImportNoFile
+	}
+	for {
+		var part *multipart.Part
+		part, err = mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", errImportNoFile
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), nil
+		}
+	}
+}
+
+// importErrorText describe error in import report. Errors of server are described by status only,
+// because they may contain internals, the error itself is logged.
+func importErrorText(err error, status int) string {
+	if e := customerror.As(err); e != nil {
+		return e.Message
+	}
+	if status >= http.StatusInternalServerError {
+		return http.StatusText(status)
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// userBrokenURLs list URLs of user whose destinations failed checks several times in a row.
+func (a *Application) userBrokenURLs(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	userURLs, err := a.cnt.GetServiceURL().GetUserURLs(req.Context(), userID, a.cnt.GetConfig().ResultURL, "")
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot get user URLs")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	userURLsResp := make([]response.UserURLResponse, 0)
+	for _, v := range userURLs {
+		if v.IsBroken() && v.DeletedAt == nil {
+			userURLsResp = append(userURLsResp, newUserURLResponse(a.shortURL(v), v))
+		}
+	}
+
+	a.writeJSON(res, req, http.StatusOK, userURLsResp)
+}
@@ -0,0 +1,132 @@
+package application
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vagafonov/shortener/internal/cookie"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// maxLinkPasswordFormSize limit of body with password form.
+const maxLinkPasswordFormSize = 4 << 10
+
+var linkPasswordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Password required</title></head>
+<body>
+<form method="post">
+<p>This link is protected with password.</p>
+{{if .}}<p>{{.}}</p>{{end}}
+<input type="password" name="password" autofocus required>
+<button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
+// unlockShortURL check password of protected link, grant access to it with cookie and redirect.
+func (a *Application) unlockShortURL(res http.ResponseWriter, req *http.Request) {
+	shortURL, err := a.cnt.GetServiceURL().GetShortURL(
+		req.Context(),
+		a.requestShortKey(req),
+		a.currentUserID(req),
+	)
+	if err != nil {
+		if errors.Is(err, customerror.ErrURLDeleted) {
+			a.writeError(res, req, http.StatusGone, err)
+
+			return
+		}
+
+		a.cnt.GetLogger().Error().Err(err).Msg("cannot get short url")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+	if shortURL == nil {
+		a.writeError(res, req, http.StatusNotFound, customerror.ErrURLNotFound)
+
+		return
+	}
+	if !shortURL.HasPassword() {
+		if a.consumeClick(res, req, shortURL) {
+			a.redirectAfterUnlock(res, req, shortURL)
+		}
+
+		return
+	}
+
+	key := shortURL.Short + "|" + a.clientIP(req)
+	if ok, retryAfter := a.linkPasswordLimiter.Attempt(key, time.Now()); !ok {
+		a.cnt.GetLogger().Info().Str("short", shortURL.Short).Msg("too many attempts to enter link password")
+		res.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		a.writeLinkPasswordForm(res, http.StatusTooManyRequests, "Too many attempts, try again later.")
+
+		return
+	}
+
+	req.Body = http.MaxBytesReader(res, req.Body, maxLinkPasswordFormSize)
+	if !a.cnt.GetServiceURL().VerifyURLPassword(shortURL, req.PostFormValue("password")) {
+		a.writeLinkPasswordForm(res, http.StatusUnauthorized, "Wrong password.")
+
+		return
+	}
+	a.linkPasswordLimiter.Reset(key)
+	if !a.consumeClick(res, req, shortURL) {
+		return
+	}
+
+	c, err := cookie.NewLinkAccessCookie(
+		a.cnt.GetConfig().Keyring,
+		shortURL.Code(),
+		shortURL.PasswordHash,
+		a.cnt.GetConfig().LinkAccessTTL,
+	)
+	if err != nil {
+		a.cnt.GetLogger().Error().Err(err).Msg("cannot create link access cookie")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+	http.SetCookie(res, c)
+	a.redirectAfterUnlock(res, req, shortURL)
+}
+
+// redirectAfterUnlock redirect to destination of URL after form is submitted.
+// Form is submitted to address of page, so query of original request is kept.
+func (a *Application) redirectAfterUnlock(res http.ResponseWriter, req *http.Request, url *entity.URL) {
+	destination, ok := a.destination(res, req, url)
+	if !ok {
+		return
+	}
+	setNoStore(res)
+	// 303, чтобы браузер перешёл по ссылке методом GET
+	http.Redirect(res, req, destination, http.StatusSeeOther)
+}
+
+// hasLinkAccess check that link is not protected with password or it was entered recently.
+func (a *Application) hasLinkAccess(req *http.Request, url *entity.URL) bool {
+	if !url.HasPassword() {
+		return true
+	}
+	c, err := req.Cookie(cookie.LinkAccessName)
+	if err != nil {
+		return false
+	}
+
+	return cookie.CheckLinkAccess(a.cnt.GetConfig().Keyring, c.Value, url.Code(), url.PasswordHash, time.Now())
+}
+
+func (a *Application) writeLinkPasswordForm(res http.ResponseWriter, statusCode int, message string) {
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	setNoStore(res)
+	res.WriteHeader(statusCode)
+	if err := linkPasswordForm.Execute(res, message); err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot write link password form")
+	}
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/vagafonov/shortener/internal/cookie"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/pkg/oidc"
+)
+
+// oidcLoginTTL time given to user to login in identity provider.
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcLogin redirect user to identity provider. State, nonce and PKCE verifier are kept in encrypted cookie.
+func (a *Application) oidcLogin(res http.ResponseWriter, req *http.Request) {
+	st := &cookie.OIDCState{ExpiresAt: time.Now().Add(oidcLoginTTL).Unix()}
+	for _, v := range []*string{&st.State, &st.Nonce, &st.Verifier} {
+		random, err := oidc.RandomString()
+		if err != nil {
+			a.cnt.GetLogger().Error().Err(err).Msg("cannot generate oidc state")
+			a.writeError(res, req, http.StatusInternalServerError, nil)
+
+			return
+		}
+		*v = random
+	}
+
+	authURL, err := a.cnt.GetServiceOIDC().AuthCodeURL(req.Context(), st.State, st.Nonce, st.Verifier)
+	if err != nil {
+		a.cnt.GetLogger().Error().Err(err).Msg("cannot get oidc authorization url")
+		a.writeError(res, req, http.StatusBadGateway, nil)
+
+		return
+	}
+
+	c, err := cookie.NewOIDCStateCookie(a.cnt.GetConfig().Keyring, st)
+	if err != nil {
+		a.cnt.GetLogger().Error().Err(err).Msg("cannot create oidc state cookie")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+	http.SetCookie(res, c)
+	http.Redirect(res, req, authURL, http.StatusFound)
+}
+
+// oidcCallback exchange authorization code for identity and open session of user.
+func (a *Application) oidcCallback(res http.ResponseWriter, req *http.Request) {
+	http.SetCookie(res, cookie.ExpiredOIDCStateCookie())
+
+	q := req.URL.Query()
+	if q.Get("error") != "" {
+		a.cnt.GetLogger().Info().Str("error", q.Get("error")).Msg("oidc login canceled by provider")
+		a.writeError(res, req, http.StatusUnauthorized, customerror.ErrOIDCLogin)
+
+		return
+	}
+
+	stateCookie, err := req.Cookie(cookie.OIDCStateName)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, customerror.ErrOIDCState)
+
+		return
+	}
+	st, err := cookie.ParseOIDCState(a.cnt.GetConfig().Keyring, stateCookie.Value, time.Now())
+	if err != nil || st.State != q.Get("state") || q.Get("code") == "" {
+		a.writeError(res, req, http.StatusBadRequest, customerror.ErrOIDCState)
+
+		return
+	}
+
+	identity, err := a.cnt.GetServiceOIDC().Exchange(req.Context(), q.Get("code"), st.Verifier, st.Nonce)
+	if err != nil {
+		if errors.Is(err, customerror.ErrOIDCLogin) {
+			a.writeError(res, req, http.StatusUnauthorized, customerror.ErrOIDCLogin)
+
+			return
+		}
+		a.cnt.GetLogger().Error().Err(err).Msg("cannot exchange oidc code")
+		a.writeError(res, req, http.StatusBadGateway, nil)
+
+		return
+	}
+
+	anonymousUserID, err := a.getUserIDFromCookie(req)
+	if err != nil {
+		a.cnt.GetLogger().Info().Err(err).Msg("cannot get anonymous user from cookie")
+	}
+
+	token, err := a.cnt.GetServiceAccount().OpenSession(req.Context(), identity.UserID, anonymousUserID)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot open session")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	http.SetCookie(res, cookie.NewSessionCookie(token, a.cnt.GetConfig().SessionTTL))
+	a.writeJSON(res, req, http.StatusOK, response.NewIdentityResponse(identity))
+}
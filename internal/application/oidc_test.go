@@ -0,0 +1,179 @@
+package application
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/config"
+	"github.com/vagafonov/shortener/internal/container"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/service"
+	"github.com/vagafonov/shortener/internal/storage"
+	"github.com/vagafonov/shortener/pkg/hasher"
+	"github.com/vagafonov/shortener/pkg/oidc/oidctest"
+)
+
+type OIDCTestSuite struct {
+	suite.Suite
+	idp *oidctest.Provider
+	srv *httptest.Server
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
+
+func (s *OIDCTestSuite) SetupTest() {
+	s.idp = oidctest.NewProvider("shortener", "secret")
+	s.srv = httptest.NewServer(nil)
+
+	cfg := config.NewConfig(
+		"test",
+		s.srv.URL,
+		"",
+		"",
+		false,
+		[]byte("0123456789abcdef"),
+		10,
+		3,
+		config.ModeTest,
+	)
+	cfg.OIDCIssuer = s.idp.Issuer()
+	cfg.OIDCClientID = "shortener"
+	cfg.OIDCClientSecret = "secret"
+	cfg.OIDCRedirectURL = s.srv.URL + "/api/user/oidc/callback"
+
+	cnt := container.NewContainer(
+		cfg,
+		storage.NewMemoryStorage(),
+		storage.NewMemoryStorage(),
+		hasher.NewRandHasher(hasher.Alphabet),
+		logger.CreateLogger(cfg.LogLevel),
+		nil,
+	)
+	servURL, err := service.ServiceURLFactory(cnt, "real")
+	s.Require().NoError(err)
+	cnt.SetServiceURL(servURL)
+	servAPIKey, err := service.ServiceAPIKeyFactory(cnt, "real")
+	s.Require().NoError(err)
+	cnt.SetServiceAPIKey(servAPIKey)
+	servAccount, err := service.ServiceAccountFactory(cnt, "real")
+	s.Require().NoError(err)
+	cnt.SetServiceAccount(servAccount)
+	servOIDC, err := service.ServiceOIDCFactory(cnt, "real")
+	s.Require().NoError(err)
+	cnt.SetServiceOIDC(servOIDC)
+
+	s.srv.Config.Handler = NewApplication(cnt).Routes()
+}
+
+func (s *OIDCTestSuite) TearDownTest() {
+	s.srv.Close()
+	s.idp.Close()
+}
+
+// browser return client with own cookies which does not follow redirects.
+func (s *OIDCTestSuite) browser() *http.Client {
+	jar, err := cookiejar.New(nil)
+	s.Require().NoError(err)
+
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (s *OIDCTestSuite) get(client *http.Client, u string) *http.Response {
+	resp, err := client.Get(u) //nolint:noctx
+	s.Require().NoError(err)
+	s.T().Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+// login pass browser through provider and return identity from callback.
+func (s *OIDCTestSuite) login(client *http.Client) response.IdentityResponse {
+	resp := s.get(client, s.srv.URL+"/api/user/oidc/login")
+	s.Require().Equal(http.StatusFound, resp.StatusCode)
+	s.Require().True(strings.HasPrefix(resp.Header.Get("Location"), s.idp.Issuer()))
+
+	resp = s.get(client, resp.Header.Get("Location"))
+	s.Require().Equal(http.StatusFound, resp.StatusCode)
+
+	resp = s.get(client, resp.Header.Get("Location"))
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var identity response.IdentityResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&identity))
+
+	return identity
+}
+
+func (s *OIDCTestSuite) TestLogin() {
+	s.idp.SetSubject("alice", "alice@example.com")
+	laptop := s.browser()
+
+	body := strings.NewReader(`{"url":"https://go.dev"}`)
+	resp, err := laptop.Post(s.srv.URL+"/api/shorten", "application/json", body) //nolint:noctx
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	identity := s.login(laptop)
+	s.Require().Equal(service.OIDCUserID(s.idp.Issuer(), "alice"), identity.ID)
+	s.Require().Equal("alice@example.com", identity.Email)
+
+	resp = s.get(laptop, s.srv.URL+"/api/user/urls")
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	s.Run("same subject gets same user after key rotation", func() {
+		s.idp.RotateKey(false)
+		phone := s.browser()
+		s.Require().Equal(identity.ID, s.login(phone).ID)
+
+		resp := s.get(phone, s.srv.URL+"/api/user/urls")
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		b, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().Contains(string(b), "https://go.dev")
+	})
+
+	s.Run("another subject gets another user", func() {
+		s.idp.SetSubject("bob", "")
+		s.Require().NotEqual(identity.ID, s.login(s.browser()).ID)
+	})
+}
+
+func (s *OIDCTestSuite) TestInvalidCallback() {
+	s.Run("callback without state cookie", func() {
+		resp := s.get(s.browser(), s.srv.URL+"/api/user/oidc/callback?code=code&state=state")
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("callback with another state", func() {
+		client := s.browser()
+		resp := s.get(client, s.srv.URL+"/api/user/oidc/login")
+		s.Require().Equal(http.StatusFound, resp.StatusCode)
+		resp = s.get(client, s.srv.URL+"/api/user/oidc/callback?code=code&state=forged")
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("callback with unknown code", func() {
+		client := s.browser()
+		resp := s.get(client, s.srv.URL+"/api/user/oidc/login")
+		s.Require().Equal(http.StatusFound, resp.StatusCode)
+		resp = s.get(client, resp.Header.Get("Location"))
+		s.Require().Equal(http.StatusFound, resp.StatusCode)
+		location := strings.Replace(resp.Header.Get("Location"), "code=", "code=x", 1)
+		resp = s.get(client, location)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// searchUserURLs find URLs of user by original URL, code, title, description or notes, most relevant first.
+func (a *Application) searchUserURLs(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).SearchURLsRequest(req.URL.Query())
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	urls, total, err := a.cnt.GetServiceURL().SearchUserURLs(
+		req.Context(),
+		userID,
+		validatedRequest.Query,
+		validatedRequest.Limit,
+		validatedRequest.Offset,
+	)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot search user URLs")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	searchResp := response.SearchURLsResponse{
+		Total: total,
+		URLs:  make([]response.UserURLResponse, len(urls)),
+	}
+	for k, v := range urls {
+		searchResp.URLs[k] = newUserURLResponse(a.shortURL(v), v)
+	}
+
+	a.writeJSON(res, req, http.StatusOK, searchResp)
+}
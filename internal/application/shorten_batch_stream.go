@@ -0,0 +1,190 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/problem"
+	"github.com/vagafonov/shortener/internal/request"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ndjsonContentType media type of streamed batch and its results.
+const ndjsonContentType = "application/x-ndjson"
+
+// errors of streamed batch.
+var (
+	errBatchItem = customerror.New("invalid_batch_item", "item must be object with correlation_id and original_url")
+	// errStreamWrite client cannot receive results, it has disconnected.
+	errStreamWrite = errors.New("cannot write results")
+)
+
+// isNDJSON check that body of request is NDJSON.
+func isNDJSON(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	return err == nil && mediaType == ndjsonContentType
+}
+
+// shortenBatchStream shorten batch sent as NDJSON. Items are decoded one by one and created in chunks
+// of contract.BatchInsertSize, results of every chunk are written and flushed at once, so memory does not grow
+// with size of batch. Status is sent with the first results, later errors are reported by the last line.
+//
+//nolint:funlen
+func (a *Application) shortenBatchStream(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(res)
+	// HTTP/1.x server stops reading body after response is flushed unless full duplex is enabled
+	if err := rc.EnableFullDuplex(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot enable full duplex")
+	}
+
+	out := &ndjsonStream{res: res, rc: rc, enc: json.NewEncoder(res)}
+	chunk := make([]*entity.URL, 0, contract.BatchInsertSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		resp, err := a.cnt.GetServiceURL().MakeShortURLBatch(req.Context(), chunk, a.cnt.GetConfig().ResultURL, userID)
+		if err != nil {
+			return err
+		}
+		for _, v := range chunk {
+			a.enqueuePreview(v)
+		}
+		for _, v := range resp {
+			if err = out.write(v); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+
+		return out.flush()
+	}
+
+	dec := json.NewDecoder(req.Body)
+	var err error
+	for {
+		var item request.ShortenBatchRequest
+		err = dec.Decode(&item)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) || (err == nil && (item.CorrelationID == "" || item.OriginalURL == "")) {
+			itemErr := response.ShortenBatchErrorResponse{CorrelationID: item.CorrelationID, Error: errBatchItem.Error()}
+			if err = out.write(itemErr); err != nil {
+				break
+			}
+
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		chunk = append(chunk, &entity.URL{
+			ID:       item.CorrelationID,
+			Short:    a.cnt.GetHasher().Hash(a.cnt.GetConfig().ShortURLLength),
+			Original: item.OriginalURL,
+		})
+		if len(chunk) == contract.BatchInsertSize {
+			if err = flush(); err != nil {
+				a.writeStreamError(res, req, out, batchErrorStatus(err), err)
+
+				return
+			}
+		}
+	}
+
+	decodeErr := err
+	if errors.Is(decodeErr, errStreamWrite) {
+		a.cnt.GetLogger().Warn().Err(err).Msg("streamed batch stopped")
+
+		return
+	}
+	// items decoded before broken part of body are still created
+	if err = flush(); err != nil {
+		a.writeStreamError(res, req, out, batchErrorStatus(err), err)
+
+		return
+	}
+	if !errors.Is(decodeErr, io.EOF) {
+		a.writeStreamError(res, req, out, http.StatusBadRequest, fmt.Errorf("%w: %w", validate.ErrValidateJSON, decodeErr))
+
+		return
+	}
+	if !out.started {
+		a.writeError(res, req, http.StatusBadRequest, validate.ErrValidateEmpty)
+
+		return
+	}
+	if err = out.flush(); err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot flush batch results")
+	}
+}
+
+// writeStreamError report error which stopped streamed batch. Before the first results it is usual
+// response with status, after them it is the last line of response.
+func (a *Application) writeStreamError(
+	res http.ResponseWriter,
+	req *http.Request,
+	out *ndjsonStream,
+	status int,
+	err error,
+) {
+	a.cnt.GetLogger().Warn().Err(err).Msg("streamed batch stopped")
+	if !out.started {
+		a.writeError(res, req, status, err)
+
+		return
+	}
+	if errors.Is(err, errStreamWrite) {
+		return
+	}
+	if err = out.write(problem.New(req, status, err)); err == nil {
+		err = out.flush()
+	}
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot write error of batch")
+	}
+}
+
+// ndjsonStream write values as lines of NDJSON response, status 201 is sent before the first line.
+type ndjsonStream struct {
+	res     http.ResponseWriter
+	rc      *http.ResponseController
+	enc     *json.Encoder
+	started bool
+}
+
+func (s *ndjsonStream) write(v any) error {
+	if !s.started {
+		s.started = true
+		s.res.Header().Set("Content-Type", ndjsonContentType)
+		s.res.WriteHeader(http.StatusCreated)
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return errors.Join(errStreamWrite, err)
+	}
+
+	return nil
+}
+
+// flush send written lines to client, writer without buffer is not flushed.
+func (s *ndjsonStream) flush() error {
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return errors.Join(errStreamWrite, err)
+	}
+
+	return nil
+}
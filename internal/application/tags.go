@@ -0,0 +1,99 @@
+package application
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+func (a *Application) userTags(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	tags, err := a.cnt.GetServiceURL().GetUserTags(req.Context(), userID)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Msg("cannot get user tags")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	tagsResp := make([]response.TagStatsResponse, len(tags))
+	for k, v := range tags {
+		tagsResp[k] = response.NewTagStatsResponse(v)
+	}
+
+	a.writeJSON(res, req, http.StatusOK, tagsResp)
+}
+
+// renameTag rename tag on all URLs which user can edit.
+func (a *Application) renameTag(res http.ResponseWriter, req *http.Request) {
+	tag, err := url.PathUnescape(chi.URLParam(req, "tag"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).RenameTagRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	a.renameTags(res, req, []string{strings.ToLower(strings.TrimSpace(tag))}, validatedRequest.Name)
+}
+
+// mergeTags replace several tags with one tag on all URLs which user can edit.
+func (a *Application) mergeTags(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).MergeTagsRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	a.renameTags(res, req, validatedRequest.Tags, validatedRequest.Into)
+}
+
+func (a *Application) renameTags(res http.ResponseWriter, req *http.Request, from []string, to string) {
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	updated, err := a.cnt.GetServiceURL().RenameUserTags(req.Context(), userID, from, to)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Int("updated", updated).Msg("cannot rename tags")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusOK, response.RenameTagsResponse{Updated: updated})
+}
@@ -0,0 +1,263 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+func (a *Application) createOrganization(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).TeamRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	org, err := a.cnt.GetServiceTeam().CreateOrganization(req.Context(), userID, validatedRequest.Name)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot create organization")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusCreated, response.NewOrganizationResponse(org))
+}
+
+func (a *Application) createTeam(res http.ResponseWriter, req *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(req, "id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).TeamRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	team, err := a.cnt.GetServiceTeam().CreateTeam(req.Context(), userID, orgID, validatedRequest.Name)
+	if err != nil {
+		a.writeTeamError(res, req, err, "cannot create team")
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusCreated, response.NewTeamResponse(team))
+}
+
+func (a *Application) userTeams(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	members, err := a.cnt.GetServiceTeam().GetUserTeams(req.Context(), userID)
+	if err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot get user teams")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusOK, newTeamMembersResponse(members))
+}
+
+func (a *Application) teamMembers(res http.ResponseWriter, req *http.Request) {
+	teamID, err := uuid.Parse(chi.URLParam(req, "id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	members, err := a.cnt.GetServiceTeam().GetTeamMembers(req.Context(), userID, teamID)
+	if err != nil {
+		a.writeTeamError(res, req, err, "cannot get team members")
+
+		return
+	}
+
+	a.writeJSON(res, req, http.StatusOK, newTeamMembersResponse(members))
+}
+
+func (a *Application) setTeamMember(res http.ResponseWriter, req *http.Request) {
+	teamID, err := uuid.Parse(chi.URLParam(req, "id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(req, "user_id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).TeamMemberRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	err = a.cnt.GetServiceTeam().SetTeamMember(req.Context(), userID, teamID, memberID, validatedRequest.Role)
+	if err != nil {
+		a.writeTeamError(res, req, err, "cannot set team member")
+
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (a *Application) removeTeamMember(res http.ResponseWriter, req *http.Request) {
+	teamID, err := uuid.Parse(chi.URLParam(req, "id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(req, "user_id"))
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, nil)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeDelete)
+	if !ok {
+		return
+	}
+
+	if err = a.cnt.GetServiceTeam().RemoveTeamMember(req.Context(), userID, teamID, memberID); err != nil {
+		a.writeTeamError(res, req, err, "cannot remove team member")
+
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (a *Application) transferURL(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg("cannot read body")
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+
+		return
+	}
+
+	validatedRequest, err := validate.NewValidator(a.cnt.GetLogger()).TransferURLRequest(buf)
+	if err != nil {
+		a.writeError(res, req, http.StatusBadRequest, err)
+
+		return
+	}
+
+	userID, ok := a.authorize(res, req, entity.ScopeCreate)
+	if !ok {
+		return
+	}
+
+	err = a.cnt.GetServiceTeam().TransferURL(
+		req.Context(),
+		userID,
+		userURLKey(req),
+		validatedRequest.UserID,
+		validatedRequest.TeamID,
+	)
+	if err != nil {
+		a.writeTeamError(res, req, err, "cannot transfer url")
+
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// writeTeamError write response for errors of teams service.
+func (a *Application) writeTeamError(res http.ResponseWriter, req *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, customerror.ErrOrganizationNotFound),
+		errors.Is(err, customerror.ErrTeamNotFound),
+		errors.Is(err, customerror.ErrURLNotFound):
+		a.writeError(res, req, http.StatusNotFound, err)
+	case errors.Is(err, customerror.ErrTeamForbidden):
+		a.writeError(res, req, http.StatusForbidden, err)
+	case errors.Is(err, customerror.ErrTeamUnknownRole):
+		a.writeError(res, req, http.StatusBadRequest, err)
+	case errors.Is(err, customerror.ErrTeamLastAdmin):
+		a.writeError(res, req, http.StatusConflict, err)
+	default:
+		a.cnt.GetLogger().Warn().Str("error", err.Error()).Msg(msg)
+		a.writeError(res, req, http.StatusInternalServerError, nil)
+	}
+}
+
+func newTeamMembersResponse(members []*entity.TeamMember) []response.TeamMemberResponse {
+	resp := make([]response.TeamMemberResponse, len(members))
+	for k, v := range members {
+		resp[k] = response.NewTeamMemberResponse(v)
+	}
+
+	return resp
+}
@@ -0,0 +1,77 @@
+package application
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/cookie"
+	"github.com/vagafonov/shortener/internal/middleware"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// chooseVariant choose variant of URL for visitor and count click on it.
+// Visitor keeps variant from cookie, without cookie variant is chosen by hash of visitor identity.
+func (a *Application) chooseVariant(res http.ResponseWriter, req *http.Request, url *entity.URL) *entity.Variant {
+	if len(url.Variants) == 0 {
+		return nil
+	}
+
+	var variant *entity.Variant
+	if c, err := req.Cookie(cookie.VariantName); err == nil {
+		variant = url.Variant(c.Value)
+	}
+	if variant == nil {
+		variant = url.ChooseVariant(a.visitorKey(req))
+		if variant == nil {
+			return nil
+		}
+		http.SetCookie(res, cookie.NewVariantCookie(url.Code(), variant.ID))
+	}
+
+	if err := a.cnt.GetServiceURL().CountVariantClick(req.Context(), url, variant.ID); err != nil {
+		a.cnt.GetLogger().Warn().Err(err).Str("short", url.Short).Msg("cannot count variant click")
+	}
+
+	return variant
+}
+
+// visitorKey identity of visitor for choosing variant. It is user ID of authenticated user or of user cookie
+// sent by visitor. Cookie issued to visitor by this request is not used: clients which do not keep cookies
+// get new user ID every time, so they are identified by IP and User-Agent.
+func (a *Application) visitorKey(req *http.Request) string {
+	if middleware.APIKeyFromContext(req.Context()) != nil || middleware.SessionFromContext(req.Context()) != nil {
+		if userID := a.currentUserID(req); userID != uuid.Nil {
+			return userID.String()
+		}
+	}
+	if c, err := req.Cookie(cookie.Name); err == nil {
+		if token, err := cookie.ParseToken(a.cnt.GetConfig().Keyring, c.Value, time.Now()); err == nil {
+			return token.UserID.String()
+		}
+	}
+
+	return a.clientIP(req) + "|" + req.UserAgent()
+}
+
+func (a *Application) userURLVariants(res http.ResponseWriter, req *http.Request) {
+	userID, ok := a.authorize(res, req, entity.ScopeRead)
+	if !ok {
+		return
+	}
+
+	stats, err := a.cnt.GetServiceURL().GetURLVariantStats(req.Context(), userID, userURLKey(req))
+	if err != nil {
+		a.writeTeamError(res, req, err, "cannot get variants of url")
+
+		return
+	}
+
+	statsResp := make([]response.VariantStatsResponse, len(stats))
+	for k, v := range stats {
+		statsResp[k] = response.NewVariantStatsResponse(v)
+	}
+
+	a.writeJSON(res, req, http.StatusOK, statsResp)
+}
@@ -1,9 +1,63 @@
 package config
 
-import "github.com/rs/zerolog"
+import (
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/pkg/encrypting"
+)
 
 const shortURLLength = 8
 
+// defaults for user token.
+const (
+	DefaultCryptoKeyID            = "default"
+	defaultUserTokenTTL           = time.Hour
+	defaultUserTokenRefreshBefore = 15 * time.Minute
+	defaultSessionTTL             = 30 * 24 * time.Hour
+)
+
+// defaults for password protected links.
+const (
+	defaultLinkAccessTTL          = 10 * time.Minute
+	defaultLinkPasswordAttempts   = 5
+	defaultLinkPasswordAttemptsIn = 15 * time.Minute
+)
+
+// defaults for redirects.
+const (
+	defaultRedirectStatus   = http.StatusTemporaryRedirect
+	defaultRedirectCacheTTL = 24 * time.Hour
+)
+
+// defaults for fetching of previews of destinations.
+const (
+	defaultPreviewWorkers   = 4
+	defaultPreviewQueueSize = 1000
+	defaultPreviewTimeout   = 5 * time.Second
+	defaultPreviewMaxBytes  = 1 << 20
+	defaultPreviewRetries   = 2
+)
+
+// defaults for checking of destinations.
+const (
+	defaultLinkCheckInterval    = 24 * time.Hour
+	defaultLinkCheckWorkers     = 8
+	defaultLinkCheckPerHost     = 2
+	defaultLinkCheckTimeout     = 10 * time.Second
+	defaultLinkCheckBrokenAfter = 3
+)
+
+// defaultIdempotencyTTL how long responses of requests with idempotency key are kept.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// defaultGeoIPReloadInterval how often geo database file is checked for changes.
+const defaultGeoIPReloadInterval = time.Minute
+
 // application modes.
 const (
 	ModeProd Mode = "prod"
@@ -27,6 +81,78 @@ type Config struct {
 	DeleteURLsBatchSize int
 	DeleteURLsJobsCount int
 	Mode                Mode
+	// DailyURLsQuota max number of URLs created by user per day, 0 means unlimited.
+	DailyURLsQuota int
+	// ActiveURLsQuota max number of not deleted URLs of user, 0 means unlimited.
+	ActiveURLsQuota int
+	// Keyring keys for user token, active key is CryptoKey unless loaded from key file.
+	Keyring *encrypting.Keyring
+	// UserTokenTTL lifetime of user token.
+	UserTokenTTL time.Duration
+	// UserTokenRefreshBefore user token is reissued when it expires sooner than this.
+	UserTokenRefreshBefore time.Duration
+	// SessionTTL lifetime of session of named account.
+	SessionTTL time.Duration
+	// OIDCIssuer issuer of OpenID Connect provider, login with provider is disabled when empty.
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	// OIDCRedirectURL absolute URL of /api/user/oidc/callback registered in provider.
+	OIDCRedirectURL string
+	// LinkAccessTTL lifetime of cookie which allows to follow password protected link without password.
+	LinkAccessTTL time.Duration
+	// LinkPasswordAttempts max attempts to enter password of link from one IP per LinkPasswordAttemptsIn.
+	LinkPasswordAttempts   int
+	LinkPasswordAttemptsIn time.Duration
+	// RedirectStatus status of redirect for URLs without own status.
+	RedirectStatus int
+	// RedirectCacheTTL how long browsers may cache permanent redirects.
+	RedirectCacheTTL time.Duration
+	// GeoIPFile CSV file of networks and countries for country rules of URLs, rules are ignored when empty.
+	GeoIPFile           string
+	GeoIPReloadInterval time.Duration
+	// TrustedProxies networks of proxies whose X-Forwarded-For and X-Real-IP headers are trusted.
+	TrustedProxies []netip.Prefix
+	// Domains hosts of branded domains served besides ResultURL, every domain has own namespace of codes.
+	Domains []string
+	// PreviewWorkers number of workers fetching previews of destinations, 0 disables fetching.
+	PreviewWorkers   int
+	PreviewQueueSize int
+	// PreviewTimeout timeout of one attempt to fetch destination.
+	PreviewTimeout time.Duration
+	// PreviewMaxBytes only beginning of destination page up to this size is read.
+	PreviewMaxBytes int64
+	PreviewRetries  int
+	// LinkCheckInterval how often every live URL is checked.
+	LinkCheckInterval time.Duration
+	// LinkCheckWorkers number of simultaneous checks of destinations, 0 disables checking.
+	LinkCheckWorkers int
+	// LinkCheckPerHost number of simultaneous checks of one host.
+	LinkCheckPerHost int
+	LinkCheckTimeout time.Duration
+	// LinkCheckBrokenAfter number of failed checks in a row after which URL is marked as broken.
+	LinkCheckBrokenAfter int
+	// IdempotencyTTL how long responses of requests with idempotency key are kept, 0 disables idempotency keys.
+	IdempotencyTTL time.Duration
+}
+
+// BaseURL return base of short URLs of domain, ResultURL for default domain.
+// Branded domains are served with the same scheme as ResultURL.
+func (c *Config) BaseURL(domain string) string {
+	if domain == "" {
+		return c.ResultURL
+	}
+	scheme := "http"
+	if u, err := url.Parse(c.ResultURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+
+	return scheme + "://" + domain
+}
+
+// HasDomain check that branded domain is registered.
+func (c *Config) HasDomain(domain string) bool {
+	return slices.Contains(c.Domains, domain)
 }
 
 // Constructor for Config.
@@ -42,16 +168,37 @@ func NewConfig(
 	mode Mode,
 ) *Config {
 	return &Config{
-		ServerURL:           serverURL,
-		ResultURL:           resultURL,
-		ShortURLLength:      shortURLLength,
-		LogLevel:            zerolog.DebugLevel,
-		FileStoragePath:     fileStoragePath,
-		DatabaseDSN:         databaseDSN,
-		EnableHTTPS:         enableHTTPS,
-		CryptoKey:           cryptoKey,
-		DeleteURLsBatchSize: deleteURLsBatchSize,
-		DeleteURLsJobsCount: deleteURLsJobsCount,
-		Mode:                mode,
+		ServerURL:              serverURL,
+		ResultURL:              resultURL,
+		ShortURLLength:         shortURLLength,
+		LogLevel:               zerolog.DebugLevel,
+		FileStoragePath:        fileStoragePath,
+		DatabaseDSN:            databaseDSN,
+		EnableHTTPS:            enableHTTPS,
+		CryptoKey:              cryptoKey,
+		DeleteURLsBatchSize:    deleteURLsBatchSize,
+		DeleteURLsJobsCount:    deleteURLsJobsCount,
+		Mode:                   mode,
+		Keyring:                encrypting.NewKeyring(DefaultCryptoKeyID, cryptoKey),
+		UserTokenTTL:           defaultUserTokenTTL,
+		UserTokenRefreshBefore: defaultUserTokenRefreshBefore,
+		SessionTTL:             defaultSessionTTL,
+		LinkAccessTTL:          defaultLinkAccessTTL,
+		LinkPasswordAttempts:   defaultLinkPasswordAttempts,
+		LinkPasswordAttemptsIn: defaultLinkPasswordAttemptsIn,
+		RedirectStatus:         defaultRedirectStatus,
+		RedirectCacheTTL:       defaultRedirectCacheTTL,
+		GeoIPReloadInterval:    defaultGeoIPReloadInterval,
+		PreviewWorkers:         defaultPreviewWorkers,
+		PreviewQueueSize:       defaultPreviewQueueSize,
+		PreviewTimeout:         defaultPreviewTimeout,
+		PreviewMaxBytes:        defaultPreviewMaxBytes,
+		PreviewRetries:         defaultPreviewRetries,
+		LinkCheckInterval:      defaultLinkCheckInterval,
+		LinkCheckWorkers:       defaultLinkCheckWorkers,
+		LinkCheckPerHost:       defaultLinkCheckPerHost,
+		LinkCheckTimeout:       defaultLinkCheckTimeout,
+		LinkCheckBrokenAfter:   defaultLinkCheckBrokenAfter,
+		IdempotencyTTL:         defaultIdempotencyTTL,
 	}
 }
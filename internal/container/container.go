@@ -20,6 +20,13 @@ type Container struct {
 	db                 *sql.DB
 	serviceURL         contract.Service
 	serviceHealthCheck contract.ServiceHealthCheck
+	serviceAPIKey      contract.ServiceAPIKey
+	serviceAccount     contract.ServiceAccount
+	serviceOIDC        contract.ServiceOIDC
+	serviceTeam        contract.ServiceTeam
+	geoLocator         contract.GeoLocator
+	servicePreview     contract.ServicePreview
+	serviceIdempotency contract.ServiceIdempotency
 }
 
 // NewContainer Constructor for Container.
@@ -104,3 +111,73 @@ func (c *Container) GetServiceHealthCheck() contract.ServiceHealthCheck {
 func (c *Container) SetServiceHealthCheck(s contract.ServiceHealthCheck) {
 	c.serviceHealthCheck = s
 }
+
+// GetServiceAPIKey return service API key from container.
+func (c *Container) GetServiceAPIKey() contract.ServiceAPIKey {
+	return c.serviceAPIKey
+}
+
+// SetServiceAPIKey set ServiceAPIKey to container.
+func (c *Container) SetServiceAPIKey(s contract.ServiceAPIKey) {
+	c.serviceAPIKey = s
+}
+
+// GetServiceAccount return service account from container.
+func (c *Container) GetServiceAccount() contract.ServiceAccount {
+	return c.serviceAccount
+}
+
+// SetServiceAccount set ServiceAccount to container.
+func (c *Container) SetServiceAccount(s contract.ServiceAccount) {
+	c.serviceAccount = s
+}
+
+// GetServiceOIDC return service OIDC from container, nil when login with provider is disabled.
+func (c *Container) GetServiceOIDC() contract.ServiceOIDC {
+	return c.serviceOIDC
+}
+
+// SetServiceOIDC set ServiceOIDC to container.
+func (c *Container) SetServiceOIDC(s contract.ServiceOIDC) {
+	c.serviceOIDC = s
+}
+
+// GetServiceTeam return service of organizations and teams from container.
+func (c *Container) GetServiceTeam() contract.ServiceTeam {
+	return c.serviceTeam
+}
+
+// SetServiceTeam set ServiceTeam to container.
+func (c *Container) SetServiceTeam(s contract.ServiceTeam) {
+	c.serviceTeam = s
+}
+
+// GetGeoLocator return locator of countries from container, nil when geo database is not configured.
+func (c *Container) GetGeoLocator() contract.GeoLocator {
+	return c.geoLocator
+}
+
+// SetGeoLocator set GeoLocator to container.
+func (c *Container) SetGeoLocator(l contract.GeoLocator) {
+	c.geoLocator = l
+}
+
+// GetServicePreview return service of previews from container, nil when fetching of previews is disabled.
+func (c *Container) GetServicePreview() contract.ServicePreview {
+	return c.servicePreview
+}
+
+// SetServicePreview set ServicePreview to container.
+func (c *Container) SetServicePreview(s contract.ServicePreview) {
+	c.servicePreview = s
+}
+
+// GetServiceIdempotency return service of idempotency keys from container, nil when keys are disabled.
+func (c *Container) GetServiceIdempotency() contract.ServiceIdempotency {
+	return c.serviceIdempotency
+}
+
+// SetServiceIdempotency set ServiceIdempotency to container.
+func (c *Container) SetServiceIdempotency(s contract.ServiceIdempotency) {
+	c.serviceIdempotency = s
+}
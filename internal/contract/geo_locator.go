@@ -0,0 +1,9 @@
+package contract
+
+import "net/netip"
+
+// GeoLocator abstract interface for resolving country of IP address.
+type GeoLocator interface {
+	// Country return ISO 3166-1 alpha-2 code of country, empty when it is unknown.
+	Country(addr netip.Addr) string
+}
@@ -0,0 +1,19 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ServiceAccount abstract interface for accounts service.
+type ServiceAccount interface {
+	Register(ctx context.Context, login string, password string) (*entity.Account, error)
+	Login(ctx context.Context, login string, password string, anonymousUserID uuid.UUID) (string, *entity.Account, error)
+	OpenSession(ctx context.Context, userID uuid.UUID, anonymousUserID uuid.UUID) (string, error)
+	Logout(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, token string) (*entity.Session, error)
+	// RestoreAccounts restore accounts and sessions from backup storage, returns number of restored accounts.
+	RestoreAccounts(ctx context.Context) (int, error)
+}
@@ -0,0 +1,18 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ServiceAPIKey abstract interface for API keys service.
+type ServiceAPIKey interface {
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, scopes []string) (string, *entity.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	Authenticate(ctx context.Context, key string) (*entity.APIKey, error)
+	// RestoreAPIKeys restore API keys from backup storage, returns number of restored keys.
+	RestoreAPIKeys(ctx context.Context) (int, error)
+}
@@ -0,0 +1,22 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ServiceIdempotency abstract interface for service of idempotency keys, retried request with the same key
+// gets response of the first request instead of being processed again.
+type ServiceIdempotency interface {
+	// Begin reserve key for request and return reservation, saved record is returned when request with key
+	// is already completed.
+	Begin(ctx context.Context, userID uuid.UUID, key string, requestHash string) (*entity.IdempotencyRecord, error)
+	// Hold keep reservation while request is processed, returned function stops it.
+	Hold(ctx context.Context, reservation *entity.IdempotencyRecord) func()
+	// Complete save response of request into its reservation, it is kept for configured time.
+	Complete(ctx context.Context, record *entity.IdempotencyRecord) error
+	// Release free key reserved by request without saving response, so request can be retried.
+	Release(ctx context.Context, reservation *entity.IdempotencyRecord) error
+}
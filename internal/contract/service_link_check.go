@@ -0,0 +1,13 @@
+package contract
+
+import (
+	"context"
+)
+
+// ServiceLinkCheck abstract interface for checking of destinations of URLs in background.
+type ServiceLinkCheck interface {
+	// CheckAll check every live URL which was not checked during last interval, number of checked URLs is returned.
+	CheckAll(ctx context.Context) (int, error)
+	// Run check URLs every interval until context is done.
+	Run(ctx context.Context)
+}
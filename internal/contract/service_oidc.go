@@ -0,0 +1,13 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ServiceOIDC abstract interface for login with OpenID Connect provider.
+type ServiceOIDC interface {
+	AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (*entity.Identity, error)
+}
@@ -0,0 +1,15 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ServicePreview abstract interface for fetching previews of destinations of URLs in background.
+type ServicePreview interface {
+	// Enqueue schedule fetching of preview of URL, false is returned when queue is full.
+	Enqueue(url *entity.URL) bool
+	// Run fetch previews with pool of workers until context is done.
+	Run(ctx context.Context)
+}
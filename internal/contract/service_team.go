@@ -0,0 +1,21 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ServiceTeam abstract interface for organizations and teams service.
+type ServiceTeam interface {
+	CreateOrganization(ctx context.Context, userID uuid.UUID, name string) (*entity.Organization, error)
+	CreateTeam(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, name string) (*entity.Team, error)
+	GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error)
+	GetTeamMembers(ctx context.Context, userID uuid.UUID, teamID uuid.UUID) ([]*entity.TeamMember, error)
+	SetTeamMember(ctx context.Context, userID uuid.UUID, teamID uuid.UUID, memberID uuid.UUID, role string) error
+	RemoveTeamMember(ctx context.Context, userID uuid.UUID, teamID uuid.UUID, memberID uuid.UUID) error
+	TransferURL(ctx context.Context, userID uuid.UUID, short string, toUserID uuid.UUID, toTeamID uuid.UUID) error
+	// RestoreTeams restore organizations, teams and members from backup storage, returns number of restored teams.
+	RestoreTeams(ctx context.Context) (int, error)
+}
@@ -15,10 +15,23 @@ var ErrURLAlreadyExists = errors.New("url already exists")
 // Storage abstract interface for Service.
 // TODO Rename.
 type Service interface {
-	MakeShortURL(ctx context.Context, url string, length int, userID uuid.UUID) (*entity.URL, error)
-	MakeShortURLBatch(ctx context.Context, URLs []*entity.URL, baseURL string) ([]response.ShortenBatchResponse, error) //nolint:lll
-	GetShortURL(ctx context.Context, url string) (*entity.URL, error)
+	MakeShortURL(
+		ctx context.Context, url string, length int, userID uuid.UUID, domain string, settings *entity.URLPatch,
+	) (*entity.URL, error)
+	MakeShortURLBatch(ctx context.Context, URLs []*entity.URL, baseURL string, userID uuid.UUID) ([]response.ShortenBatchResponse, error) //nolint:lll
+	GetShortURL(ctx context.Context, url string, userID uuid.UUID) (*entity.URL, error)
+	UpdateUserURL(ctx context.Context, userID uuid.UUID, short string, patch *entity.URLPatch) (*entity.URL, error)
+	VerifyURLPassword(url *entity.URL, password string) bool
+	ConsumeURLClick(ctx context.Context, url *entity.URL) error
+	CountVariantClick(ctx context.Context, url *entity.URL, variant string) error
+	GetURLVariantStats(ctx context.Context, userID uuid.UUID, short string) ([]entity.VariantStats, error)
 	RestoreURLs(ctx context.Context, fileName string) (int, error)
-	GetUserURLs(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error)
+	GetUserURLs(ctx context.Context, userID uuid.UUID, baseURL string, tag string) ([]*entity.URL, error)
+	ImportURLs(ctx context.Context, urls []*entity.URL, length int, userID uuid.UUID, domain string) ([]entity.ImportResult, error) //nolint:lll
+	ExportUserURLs(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error
+	SearchUserURLs(ctx context.Context, userID uuid.UUID, query string, limit int, offset int) ([]*entity.URL, int, error)
+	GetUserTags(ctx context.Context, userID uuid.UUID) ([]entity.TagStats, error)
+	RenameUserTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error)
 	DeleteUserURLs(ctx context.Context, userID uuid.UUID, shortURLs []string, batchSize int, jobsCount int) error
+	GetUserQuotaUsage(ctx context.Context, userID uuid.UUID) (*entity.QuotaUsage, error)
 }
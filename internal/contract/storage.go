@@ -2,20 +2,49 @@ package contract
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/pkg/entity"
 )
 
+// BatchInsertSize number of URLs which storage inserts in one transaction, large batches are written in chunks of it.
+const BatchInsertSize = 100
+
 // Storage abstract interface for storage.
 type Storage interface {
+	APIKeyStorage
+	AccountStorage
+	TeamStorage
+	VariantStorage
+	IdempotencyStorage
+	// GetByHash get URL by short key regardless of visibility. Unknown key gives nil URL without error,
+	// deleted URL gives nil URL with customerror.ErrURLDeleted, so its key is still known as taken.
 	GetByHash(ctx context.Context, hash string) (*entity.URL, error)
-	GetByURL(ctx context.Context, url string) (*entity.URL, error)
+	// GetVisibleByHash get URL like GetByHash, but private URL which user cannot view is reported as unknown.
+	GetVisibleByHash(ctx context.Context, hash string, userID uuid.UUID) (*entity.URL, error)
+	UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error
+	ConsumeClick(ctx context.Context, short string) (bool, error)
+	// GetByURL get not deleted personal URL of user with given destination in namespace of domain.
+	// URLs of other users and domains are not found, so their codes are not disclosed.
+	GetByURL(ctx context.Context, url string, userID uuid.UUID, domain string) (*entity.URL, error)
 	Add(ctx context.Context, hash string, url string, userID uuid.UUID) (*entity.URL, error)
 	AddBatch(ctx context.Context, URLs []*entity.URL) (int, error)
 	GetAll(ctx context.Context) ([]*entity.URL, error)
-	GetAllURLsByUser(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error)
+	GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error)
+	// GetAllURLsByUser get URLs which user can view, only URLs marked with tag when tag is not empty.
+	GetAllURLsByUser(ctx context.Context, userID uuid.UUID, baseURL string, tag string) ([]*entity.URL, error)
+	// RenameTags replace tags from with tag to on not deleted URLs which user can edit in one write.
+	// Number of changed URLs is returned.
+	RenameTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error)
+	EachURLByUser(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error
+	SearchURLsByUser(ctx context.Context, userID uuid.UUID, query string, limit, offset int) ([]*entity.URL, int, error)
 	DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error
+	IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error
+	// ReserveDailyUsage increase counter of URLs created by user per day by n only if it does not exceed limit,
+	// limit 0 means no limit. Check and increase are atomic. Result reports whether counter was increased.
+	ReserveDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int, limit int) (bool, error)
+	GetQuotaUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*entity.QuotaUsage, error)
 	Ping(ctx context.Context) error
 	Truncate()
 	Close() error
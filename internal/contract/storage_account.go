@@ -0,0 +1,23 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AccountStorage abstract interface for storage of accounts and sessions.
+type AccountStorage interface {
+	AddAccount(ctx context.Context, account *entity.Account) error
+	GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error)
+	GetAllAccounts(ctx context.Context) ([]*entity.Account, error)
+	AddSession(ctx context.Context, session *entity.Session) error
+	GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error)
+	DeleteSession(ctx context.Context, hash string) error
+	// GetAllSessions get sessions of all users including deleted and expired ones.
+	GetAllSessions(ctx context.Context) ([]*entity.Session, error)
+	// HasSessions report whether user ever had session, including deleted and expired ones.
+	HasSessions(ctx context.Context, userID uuid.UUID) (bool, error)
+	ReassignURLs(ctx context.Context, fromUserID uuid.UUID, toUserID uuid.UUID) (int, error)
+}
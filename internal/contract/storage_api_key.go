@@ -0,0 +1,17 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// APIKeyStorage abstract interface for storage of API keys.
+type APIKeyStorage interface {
+	AddAPIKey(ctx context.Context, key *entity.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error)
+	GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+}
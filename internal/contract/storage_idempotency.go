@@ -0,0 +1,22 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// IdempotencyStorage abstract interface for storage of responses by idempotency keys.
+type IdempotencyStorage interface {
+	// ReserveIdempotencyKey save record of started request when user has no live record with its key,
+	// otherwise live record is returned and nothing is saved.
+	ReserveIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error)
+	// ExtendIdempotencyKey prolong reservation of request in progress until ExpiresAt of record.
+	// False is returned when key is not reserved by record anymore.
+	ExtendIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error)
+	// CompleteIdempotencyKey save response of request which still holds reservation of key.
+	// False is returned when key is not reserved by record anymore, e.g. it expired and was reserved again.
+	CompleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error)
+	// DeleteIdempotencyKey delete reservation of key made by record, reservation of other request is kept.
+	DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error
+}
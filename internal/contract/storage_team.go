@@ -0,0 +1,25 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// TeamStorage abstract interface for storage of organizations, teams and team members.
+type TeamStorage interface {
+	AddOrganization(ctx context.Context, org *entity.Organization) error
+	GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error)
+	AddTeam(ctx context.Context, team *entity.Team) error
+	GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error)
+	SetTeamMember(ctx context.Context, member *entity.TeamMember) error
+	RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error
+	GetTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) (*entity.TeamMember, error)
+	GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error)
+	GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error)
+	SetURLOwner(ctx context.Context, short string, userID uuid.UUID, teamID uuid.UUID) error
+	GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error)
+	GetAllTeams(ctx context.Context) ([]*entity.Team, error)
+	GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error)
+}
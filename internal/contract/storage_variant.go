@@ -0,0 +1,9 @@
+package contract
+
+import "context"
+
+// VariantStorage abstract interface for storage of clicks on variants of URLs in A/B experiments.
+type VariantStorage interface {
+	AddVariantClick(ctx context.Context, short string, variant string) error
+	GetVariantClicks(ctx context.Context, short string) (map[string]int64, error)
+}
@@ -1,56 +1,48 @@
 package cookie
 
 import (
-	"encoding/hex"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/vagafonov/shortener/pkg/encrypting"
 )
 
-const maxAgeCookie = 3600
+// Name name of cookie with user token.
+const Name = "userID"
 
 // CreateCookieWithUserID create cookie.
-func CreateCookieWithUserID(l *zerolog.Logger, cryptoKey []byte) *http.Cookie {
+func CreateCookieWithUserID(l *zerolog.Logger, kr *encrypting.Keyring, ttl time.Duration) *http.Cookie {
 	// Генерация UUID
 	newUUID, err := uuid.NewRandom()
 	if err != nil {
 		panic(err)
 	}
+	l.Debug().Msg("Сгенерированный UUID:" + newUUID.String())
 
-	// Преобразование UUID в строку
-	uuidString := newUUID.String()
-	l.Debug().Msg("Сгенерированный UUID:" + uuidString)
-
-	// Шифрование UUID
-	encrypted, err := encrypting.Encrypt(uuidString, cryptoKey)
+	c, err := NewCookieWithUserID(kr, newUUID, ttl)
 	if err != nil {
 		panic(err)
 	}
-	l.Debug().Msg("Зашифрованный UUID:" + hex.EncodeToString(encrypted))
 
-	return &http.Cookie{
-		Name:     "userID",
-		Value:    hex.EncodeToString(encrypted),
-		Path:     "/",
-		MaxAge:   maxAgeCookie,
-		HttpOnly: false,
-		Secure:   false,
-		SameSite: http.SameSiteNoneMode,
-	}
+	return c
 }
 
-// Decrypt.
-func Decrypt(enc string, cryptoKey []byte) (*string, error) {
-	binid, err := hex.DecodeString(enc)
-	if err != nil {
-		return nil, err
-	}
-	decrypted, err := encrypting.Decrypt(binid, cryptoKey)
+// NewCookieWithUserID create cookie with token for existing user.
+func NewCookieWithUserID(kr *encrypting.Keyring, userID uuid.UUID, ttl time.Duration) (*http.Cookie, error) {
+	token, err := IssueToken(kr, userID, time.Now(), ttl)
 	if err != nil {
 		return nil, err
 	}
 
-	return &decrypted, nil
+	return &http.Cookie{
+		Name:     Name,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: false,
+		Secure:   false,
+		SameSite: http.SameSiteNoneMode,
+	}, nil
 }
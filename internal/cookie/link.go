@@ -0,0 +1,75 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/encrypting"
+)
+
+// LinkAccessName name of cookie which grants access to password protected link.
+const LinkAccessName = "link_access"
+
+// maxLinkAccessLength limit of cookie value, it is not decrypted when longer.
+const maxLinkAccessLength = 256
+
+type linkAccess struct {
+	Code      string `json:"code"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewLinkAccessCookie create encrypted cookie which allows to follow link without password.
+// Cookie is sent only with requests of that link and is bound to password hash,
+// so it stops working when password is changed.
+func NewLinkAccessCookie(
+	kr *encrypting.Keyring,
+	code string,
+	passwordHash string,
+	ttl time.Duration,
+) (*http.Cookie, error) {
+	expiresAt := time.Now().Add(ttl)
+	payload, err := json.Marshal(linkAccess{Code: code, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return nil, err
+	}
+	kid, data, err := kr.Seal(payload, []byte(passwordHash))
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Cookie{
+		Name:     LinkAccessName,
+		Value:    kid + "." + base64.RawURLEncoding.EncodeToString(data),
+		Path:     "/" + code,
+		Expires:  expiresAt,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}, nil
+}
+
+// CheckLinkAccess check that cookie value grants access to link.
+func CheckLinkAccess(kr *encrypting.Keyring, value string, code string, passwordHash string, now time.Time) bool {
+	kid, encoded, ok := strings.Cut(value, ".")
+	if !ok || len(value) > maxLinkAccessLength {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	payload, err := kr.Open(kid, data, []byte(passwordHash))
+	if err != nil {
+		return false
+	}
+
+	var access linkAccess
+	if err = json.Unmarshal(payload, &access); err != nil {
+		return false
+	}
+
+	return access.Code == code && now.Before(time.Unix(access.ExpiresAt, 0))
+}
@@ -0,0 +1,92 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/encrypting"
+)
+
+// OIDCStateName name of cookie which keeps state of login with OpenID Connect provider.
+const OIDCStateName = "oidc_state"
+
+const (
+	oidcStatePath = "/api/user/oidc"
+	oidcStateAAD  = "oidc_state"
+	// maxOIDCStateLength limit of cookie value, it is not decrypted when longer.
+	maxOIDCStateLength = 1024
+)
+
+// ErrOIDCStateMalformed state cookie cannot be decrypted or expired.
+var ErrOIDCStateMalformed = errors.New("malformed oidc state")
+
+// OIDCState secrets of one login attempt, kept encrypted in cookie until callback.
+type OIDCState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewOIDCStateCookie create encrypted cookie with login state.
+func NewOIDCStateCookie(kr *encrypting.Keyring, st *OIDCState) (*http.Cookie, error) {
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	kid, data, err := kr.Seal(payload, []byte(oidcStateAAD))
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Cookie{
+		Name:     OIDCStateName,
+		Value:    kid + "." + base64.RawURLEncoding.EncodeToString(data),
+		Path:     oidcStatePath,
+		MaxAge:   int(time.Until(time.Unix(st.ExpiresAt, 0)).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}, nil
+}
+
+// ParseOIDCState decrypt login state from cookie value.
+func ParseOIDCState(kr *encrypting.Keyring, value string, now time.Time) (*OIDCState, error) {
+	kid, encoded, ok := strings.Cut(value, ".")
+	if !ok || len(value) > maxOIDCStateLength {
+		return nil, ErrOIDCStateMalformed
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrOIDCStateMalformed
+	}
+	payload, err := kr.Open(kid, data, []byte(oidcStateAAD))
+	if err != nil {
+		return nil, ErrOIDCStateMalformed
+	}
+
+	var st OIDCState
+	if err = json.Unmarshal(payload, &st); err != nil {
+		return nil, ErrOIDCStateMalformed
+	}
+	if !now.Before(time.Unix(st.ExpiresAt, 0)) {
+		return nil, ErrOIDCStateMalformed
+	}
+
+	return &st, nil
+}
+
+// ExpiredOIDCStateCookie create cookie which removes login state in browser.
+func ExpiredOIDCStateCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     OIDCStateName,
+		Value:    "",
+		Path:     oidcStatePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
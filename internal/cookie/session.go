@@ -0,0 +1,33 @@
+package cookie
+
+import (
+	"net/http"
+	"time"
+)
+
+// SessionName name of cookie with session token of named account.
+const SessionName = "session"
+
+// NewSessionCookie create cookie with session token.
+func NewSessionCookie(token string, ttl time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     SessionName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// ExpiredSessionCookie create cookie which removes session cookie in browser.
+func ExpiredSessionCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     SessionName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,121 @@
+package cookie
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/encrypting"
+)
+
+const (
+	tokenVersion     = "v1"
+	tokenSeparator   = "."
+	tokenParts       = 3
+	tokenPayloadSize = 16 + 8 + 8
+	maxTokenLength   = 256
+)
+
+// errors for user token.
+var (
+	ErrTokenMalformed = errors.New("malformed token")
+	ErrTokenExpired   = errors.New("token expired")
+)
+
+// UserToken payload of user token.
+type UserToken struct {
+	UserID    uuid.UUID
+	KeyID     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Legacy token in format before versioning: hex encoded encrypted UUID without expiry.
+	Legacy bool
+}
+
+// NeedsRefresh check that token must be reissued: it expires soon,
+// is encrypted with retired key or has legacy format.
+func (t *UserToken) NeedsRefresh(kr *encrypting.Keyring, now time.Time, refreshBefore time.Duration) bool {
+	return t.Legacy || t.KeyID != kr.ActiveID() || t.ExpiresAt.Sub(now) < refreshBefore
+}
+
+// IssueToken create token "v1.<key id>.<base64url(nonce|encrypted payload)>" with user ID,
+// issue and expiry time. Version and key ID are authenticated as additional data.
+func IssueToken(kr *encrypting.Keyring, userID uuid.UUID, now time.Time, ttl time.Duration) (string, error) {
+	payload := make([]byte, tokenPayloadSize)
+	copy(payload, userID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(now.Unix()))          //nolint:gosec
+	binary.BigEndian.PutUint64(payload[24:], uint64(now.Add(ttl).Unix())) //nolint:gosec
+
+	header := tokenVersion + tokenSeparator + kr.ActiveID()
+	keyID, data, err := kr.Seal(payload, []byte(header))
+	if err != nil {
+		return "", err
+	}
+
+	return tokenVersion + tokenSeparator + keyID + tokenSeparator + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// ParseToken decrypt and check token. Tokens in legacy format are accepted too.
+func ParseToken(kr *encrypting.Keyring, value string, now time.Time) (*UserToken, error) {
+	if value == "" || len(value) > maxTokenLength {
+		return nil, ErrTokenMalformed
+	}
+
+	if !strings.HasPrefix(value, tokenVersion+tokenSeparator) {
+		return parseLegacyToken(kr, value)
+	}
+
+	parts := strings.Split(value, tokenSeparator)
+	if len(parts) != tokenParts {
+		return nil, ErrTokenMalformed
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	header := parts[0] + tokenSeparator + parts[1]
+	payload, err := kr.Open(parts[1], data, []byte(header))
+	if err != nil || len(payload) != tokenPayloadSize {
+		return nil, ErrTokenMalformed
+	}
+
+	t := &UserToken{
+		KeyID:     parts[1],
+		IssuedAt:  time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0), //nolint:gosec
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(payload[24:])), 0), //nolint:gosec
+	}
+	copy(t.UserID[:], payload[:16])
+
+	if !now.Before(t.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	return t, nil
+}
+
+func parseLegacyToken(kr *encrypting.Keyring, value string) (*UserToken, error) {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	for _, id := range kr.IDs() {
+		plain, err := kr.Open(id, data, nil)
+		if err != nil {
+			continue
+		}
+		userID, err := uuid.Parse(string(plain))
+		if err != nil {
+			return nil, ErrTokenMalformed
+		}
+
+		return &UserToken{UserID: userID, KeyID: id, Legacy: true}, nil
+	}
+
+	return nil, ErrTokenMalformed
+}
@@ -0,0 +1,118 @@
+package cookie
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/pkg/encrypting"
+)
+
+type TokenTestSuite struct {
+	suite.Suite
+	kr *encrypting.Keyring
+}
+
+func TestTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenTestSuite))
+}
+
+func (s *TokenTestSuite) SetupTest() {
+	s.kr = encrypting.NewKeyring("k2", []byte("0123456789abcdef"))
+	s.kr.AddRetired("k1", []byte("fedcba9876543210"))
+}
+
+func (s *TokenTestSuite) TestIssueAndParse() {
+	now := time.Now()
+	userID := uuid.New()
+	value, err := IssueToken(s.kr, userID, now, time.Hour)
+	s.Require().NoError(err)
+
+	token, err := ParseToken(s.kr, value, now)
+	s.Require().NoError(err)
+	s.Require().Equal(userID, token.UserID)
+	s.Require().Equal("k2", token.KeyID)
+	s.Require().Equal(now.Unix(), token.IssuedAt.Unix())
+	s.Require().Equal(now.Add(time.Hour).Unix(), token.ExpiresAt.Unix())
+	s.Require().False(token.NeedsRefresh(s.kr, now, 15*time.Minute))
+	s.Require().True(token.NeedsRefresh(s.kr, now.Add(50*time.Minute), 15*time.Minute))
+}
+
+func (s *TokenTestSuite) TestExpired() {
+	now := time.Now()
+	value, err := IssueToken(s.kr, uuid.New(), now, time.Hour)
+	s.Require().NoError(err)
+
+	_, err = ParseToken(s.kr, value, now.Add(time.Hour))
+	s.Require().ErrorIs(err, ErrTokenExpired)
+}
+
+func (s *TokenTestSuite) TestRotation() {
+	now := time.Now()
+	old := encrypting.NewKeyring("k1", []byte("fedcba9876543210"))
+	value, err := IssueToken(old, uuid.New(), now, time.Hour)
+	s.Require().NoError(err)
+
+	token, err := ParseToken(s.kr, value, now)
+	s.Require().NoError(err)
+	s.Require().Equal("k1", token.KeyID)
+	s.Require().True(token.NeedsRefresh(s.kr, now, 15*time.Minute))
+
+	unknown := encrypting.NewKeyring("k0", []byte("fedcba9876543210"))
+	value, err = IssueToken(unknown, uuid.New(), now, time.Hour)
+	s.Require().NoError(err)
+	_, err = ParseToken(s.kr, value, now)
+	s.Require().ErrorIs(err, ErrTokenMalformed)
+}
+
+func (s *TokenTestSuite) TestLegacy() {
+	userID := uuid.New()
+	encrypted, err := encrypting.Encrypt(userID.String(), []byte("fedcba9876543210"))
+	s.Require().NoError(err)
+
+	token, err := ParseToken(s.kr, hex.EncodeToString(encrypted), time.Now())
+	s.Require().NoError(err)
+	s.Require().Equal(userID, token.UserID)
+	s.Require().True(token.Legacy)
+	s.Require().True(token.NeedsRefresh(s.kr, time.Now(), 15*time.Minute))
+}
+
+func (s *TokenTestSuite) TestMalformed() {
+	now := time.Now()
+	value, err := IssueToken(s.kr, uuid.New(), now, time.Hour)
+	s.Require().NoError(err)
+
+	tests := []string{
+		"",
+		"00",
+		"v1",
+		"v1.k2.",
+		"v1.k2.AAAA",
+		"v1.k1" + value[5:],
+		"v2" + value[2:],
+		value + "A",
+		value[:len(value)-2],
+		"not hex at all",
+	}
+	for _, v := range tests {
+		s.Run(v, func() {
+			_, err := ParseToken(s.kr, v, now)
+			s.Require().Error(err)
+		})
+	}
+}
+
+func (s *TokenTestSuite) TestLinkAccess() {
+	now := time.Now()
+	c, err := NewLinkAccessCookie(s.kr, "code", "hash", time.Minute)
+	s.Require().NoError(err)
+	s.Require().Equal("/code", c.Path)
+
+	s.Require().True(CheckLinkAccess(s.kr, c.Value, "code", "hash", now))
+	s.Require().False(CheckLinkAccess(s.kr, c.Value, "other", "hash", now), "another link")
+	s.Require().False(CheckLinkAccess(s.kr, c.Value, "code", "new hash", now), "password changed")
+	s.Require().False(CheckLinkAccess(s.kr, c.Value, "code", "hash", now.Add(2*time.Minute)), "expired")
+	s.Require().False(CheckLinkAccess(s.kr, "k2.bad", "code", "hash", now))
+}
@@ -0,0 +1,26 @@
+package cookie
+
+import (
+	"net/http"
+	"time"
+)
+
+// VariantName name of cookie with variant of link chosen for visitor in A/B experiment.
+const VariantName = "variant"
+
+// variantTTL how long visitor stays with chosen variant.
+const variantTTL = 30 * 24 * time.Hour
+
+// NewVariantCookie create cookie which keeps visitor with chosen variant of link.
+// Cookie is not encrypted, visitor who changes it only chooses another variant for self.
+func NewVariantCookie(code string, variantID string) *http.Cookie {
+	return &http.Cookie{
+		Name:     VariantName,
+		Value:    variantID,
+		Path:     "/" + code,
+		Expires:  time.Now().Add(variantTTL),
+		MaxAge:   int(variantTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
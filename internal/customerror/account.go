@@ -0,0 +1,14 @@
+package customerror
+
+// custom errors for accounts.
+var (
+	ErrAccountAlreadyExists = New("account_already_exists", "account already exists")
+	ErrInvalidCredentials   = New("invalid_credentials", "invalid login or password")
+	ErrSessionInvalid       = New("session_invalid", "session invalid")
+)
+
+// custom errors for login with OpenID Connect provider.
+var (
+	ErrOIDCState = New("oidc_state_invalid", "invalid oidc state")
+	ErrOIDCLogin = New("oidc_login_failed", "oidc login failed")
+)
@@ -0,0 +1,9 @@
+package customerror
+
+// custom errors for API keys.
+var (
+	ErrAPIKeyNotFound     = New("api_key_not_found", "api key not found")
+	ErrAPIKeyInvalid      = New("api_key_invalid", "api key invalid")
+	ErrAPIKeyScope        = New("api_key_scope_missing", "api key has no required scope")
+	ErrAPIKeyUnknownScope = New("api_key_scope_unknown", "unknown api key scope")
+)
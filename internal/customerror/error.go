@@ -0,0 +1,28 @@
+package customerror
+
+import "errors"
+
+// Error domain error with stable code. Clients rely on code, message is for humans and may change.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// New Constructor for Error.
+func New(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// As find domain error in chain of err, nil when err is not domain error.
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package customerror
+
+// custom errors for idempotency keys.
+var (
+	ErrIdempotencyKeyInvalid = New(
+		"idempotency_key_invalid",
+		"idempotency key must be 1-255 printable ASCII characters",
+	)
+	ErrIdempotencyKeyReused     = New("idempotency_key_reused", "idempotency key is already used for other request")
+	ErrIdempotencyKeyInProgress = New("idempotency_key_in_progress", "request with idempotency key is in progress")
+)
@@ -1,6 +1,10 @@
 package customerror
 
-import "errors"
-
 // ErrURLAlreadyExists error for already exists url.
-var ErrURLAlreadyExists = errors.New("url already exists")
+var ErrURLAlreadyExists = New("url_already_exists", "url already exists")
+
+// custom errors for quotas.
+var (
+	ErrDailyQuotaExceeded  = New("daily_quota_exceeded", "daily links quota exceeded")
+	ErrActiveQuotaExceeded = New("active_quota_exceeded", "active links quota exceeded")
+)
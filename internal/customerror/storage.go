@@ -1,10 +1,9 @@
 package customerror
 
-import "errors"
-
 // custom errors for storage.
 var (
-	ErrAlreadyExistsInStorage = errors.New("already exists")
-	ErrURLNotAdded            = errors.New("url not added")
-	ErrURLDeleted             = errors.New("url deleted")
+	ErrAlreadyExistsInStorage = New("already_exists", "already exists")
+	ErrURLNotAdded            = New("url_not_added", "url not added")
+	ErrURLDeleted             = New("url_deleted", "url deleted")
+	ErrURLExhausted           = New("url_exhausted", "url clicks limit exhausted")
 )
@@ -0,0 +1,11 @@
+package customerror
+
+// custom errors for organizations and teams.
+var (
+	ErrOrganizationNotFound = New("organization_not_found", "organization not found")
+	ErrTeamNotFound         = New("team_not_found", "team not found")
+	ErrTeamForbidden        = New("team_forbidden", "not enough privileges in team")
+	ErrTeamUnknownRole      = New("team_role_unknown", "unknown team role")
+	ErrTeamLastAdmin        = New("team_last_admin", "team must have at least one admin")
+	ErrURLNotFound          = New("url_not_found", "url not found")
+)
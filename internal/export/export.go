@@ -0,0 +1,203 @@
+// Package export writes links of user in formats for download.
+// Writers write nothing until first link or Close, so response status can still be changed when reading fails.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// formats of export.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatHTML   = "html"
+)
+
+// ErrUnknownFormat format of export is not supported.
+var ErrUnknownFormat = errors.New("format must be csv, ndjson or html")
+
+// csvHeader columns of CSV export.
+var csvHeader = []string{"code", "short_url", "original_url", "title", "created_at", "deleted_at"}
+
+// csvFormulaPrefixes first characters which make spreadsheet treat cell as formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// Link exported link.
+type Link struct {
+	Code      string     `json:"code"`
+	ShortURL  string     `json:"short_url"`    //nolint:tagliatelle
+	Original  string     `json:"original_url"` //nolint:tagliatelle
+	Title     string     `json:"title,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	CreatedAt time.Time  `json:"created_at"` //nolint:tagliatelle
+	DeletedAt *time.Time `json:"deleted_at"` //nolint:tagliatelle
+}
+
+// Writer write links one by one, Close must be called after last link.
+type Writer interface {
+	Write(link *Link) error
+	Close() error
+}
+
+// NewWriter Constructor for Writer of format.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatHTML:
+		return &bookmarksWriter{w: w}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ContentType media type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "text/html; charset=utf-8"
+	}
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (c *csvWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+
+	return c.w.Write(csvHeader)
+}
+
+// Write write row of link, timestamps are in RFC 3339 format and deleted_at is empty for live link.
+func (c *csvWriter) Write(link *Link) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	deletedAt := ""
+	if link.DeletedAt != nil {
+		deletedAt = link.DeletedAt.UTC().Format(time.RFC3339)
+	}
+
+	return c.w.Write([]string{
+		csvCell(link.Code),
+		link.ShortURL,
+		csvCell(link.Original),
+		csvCell(link.Title),
+		link.CreatedAt.UTC().Format(time.RFC3339),
+		deletedAt,
+	})
+}
+
+// csvCell escape user value with quote, so spreadsheet shows it as text instead of running it as formula.
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune(csvFormulaPrefixes, rune(v[0])) {
+		return "'" + v
+	}
+
+	return v
+}
+
+// Close write header if there were no links and flush buffered rows.
+func (c *csvWriter) Close() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+// Write write link as JSON object on its own line.
+func (n *ndjsonWriter) Write(link *Link) error {
+	return n.enc.Encode(link)
+}
+
+// Close nothing to finish.
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// bookmarksWriter write links in Netscape bookmarks format which browsers import.
+// Deleted links are skipped, because bookmark cannot be marked as deleted.
+type bookmarksWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (b *bookmarksWriter) start() error {
+	if b.started {
+		return nil
+	}
+	b.started = true
+	_, err := io.WriteString(b.w, `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`)
+
+	return err
+}
+
+// Write write bookmark of short URL named by title of link or its destination.
+func (b *bookmarksWriter) Write(link *Link) error {
+	if link.DeletedAt != nil {
+		return nil
+	}
+	if err := b.start(); err != nil {
+		return err
+	}
+
+	name := link.Title
+	if name == "" {
+		name = link.Original
+	}
+	tags := ""
+	if len(link.Tags) > 0 {
+		tags = fmt.Sprintf(` TAGS="%s"`, html.EscapeString(strings.Join(link.Tags, ",")))
+	}
+	_, err := fmt.Fprintf(b.w, "    <DT><A HREF=\"%s\" ADD_DATE=\"%d\"%s>%s</A>\n    <DD>%s\n",
+		html.EscapeString(link.ShortURL),
+		link.CreatedAt.Unix(),
+		tags,
+		html.EscapeString(name),
+		html.EscapeString(link.Original),
+	)
+
+	return err
+}
+
+// Close write end of list of bookmarks.
+func (b *bookmarksWriter) Close() error {
+	if err := b.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(b.w, "</DL><p>\n")
+
+	return err
+}
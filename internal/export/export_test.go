@@ -0,0 +1,109 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ExportTestSuite struct {
+	suite.Suite
+	links []*Link
+}
+
+func TestExportTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportTestSuite))
+}
+
+func (s *ExportTestSuite) SetupTest() {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	deletedAt := createdAt.Add(time.Hour)
+	s.links = []*Link{
+		{
+			Code:      "abc",
+			ShortURL:  "http://short.test/abc",
+			Original:  "https://example.com/?a=1&b=2",
+			Title:     `Sale, "spring"`,
+			Tags:      []string{"promo", "q1"},
+			CreatedAt: createdAt,
+		},
+		{
+			Code:      "old",
+			ShortURL:  "http://short.test/old",
+			Original:  "https://example.com/old",
+			CreatedAt: createdAt,
+			DeletedAt: &deletedAt,
+		},
+	}
+}
+
+func (s *ExportTestSuite) export(format string, links []*Link) string {
+	var out strings.Builder
+	w, err := NewWriter(format, &out)
+	s.Require().NoError(err)
+	for _, v := range links {
+		s.Require().NoError(w.Write(v))
+	}
+	s.Require().NoError(w.Close())
+
+	return out.String()
+}
+
+func (s *ExportTestSuite) TestCSV() {
+	s.Require().Equal(`code,short_url,original_url,title,created_at,deleted_at
+abc,http://short.test/abc,https://example.com/?a=1&b=2,"Sale, ""spring""",2024-01-02T03:04:05Z,
+old,http://short.test/old,https://example.com/old,,2024-01-02T03:04:05Z,2024-01-02T04:04:05Z
+`, s.export(FormatCSV, s.links))
+
+	s.Require().Equal("code,short_url,original_url,title,created_at,deleted_at\n", s.export(FormatCSV, nil))
+}
+
+func (s *ExportTestSuite) TestCSVFormula() {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.Require().Equal(`code,short_url,original_url,title,created_at,deleted_at
+abc,http://short.test/abc,"'=HYPERLINK(""http://evil.test"")",'@SUM(A1),2024-01-02T03:04:05Z,
+`, s.export(FormatCSV, []*Link{{
+		Code:      "abc",
+		ShortURL:  "http://short.test/abc",
+		Original:  `=HYPERLINK("http://evil.test")`,
+		Title:     "@SUM(A1)",
+		CreatedAt: createdAt,
+	}}))
+}
+
+func (s *ExportTestSuite) TestNDJSON() {
+	lines := strings.Split(strings.TrimSuffix(s.export(FormatNDJSON, s.links), "\n"), "\n")
+	s.Require().Len(lines, 2)
+	s.Require().JSONEq(`{
+		"code":"abc",
+		"short_url":"http://short.test/abc",
+		"original_url":"https://example.com/?a=1&b=2",
+		"title":"Sale, \"spring\"",
+		"tags":["promo","q1"],
+		"created_at":"2024-01-02T03:04:05Z",
+		"deleted_at":null
+	}`, lines[0])
+	s.Require().JSONEq(`{
+		"code":"old",
+		"short_url":"http://short.test/old",
+		"original_url":"https://example.com/old",
+		"created_at":"2024-01-02T03:04:05Z",
+		"deleted_at":"2024-01-02T04:04:05Z"
+	}`, lines[1])
+}
+
+func (s *ExportTestSuite) TestBookmarks() {
+	out := s.export(FormatHTML, s.links)
+	s.Require().True(strings.HasPrefix(out, "<!DOCTYPE NETSCAPE-Bookmark-file-1>\n"))
+	s.Require().Contains(out, `<DT><A HREF="http://short.test/abc" ADD_DATE="1704164645" TAGS="promo,q1">`+
+		`Sale, &#34;spring&#34;</A>`+"\n    <DD>https://example.com/?a=1&amp;b=2\n")
+	s.Require().NotContains(out, "http://short.test/old")
+	s.Require().True(strings.HasSuffix(out, "</DL><p>\n"))
+}
+
+func (s *ExportTestSuite) TestUnknownFormat() {
+	_, err := NewWriter("xml", &strings.Builder{})
+	s.Require().ErrorIs(err, ErrUnknownFormat)
+}
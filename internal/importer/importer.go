@@ -0,0 +1,286 @@
+// Package importer reads links from uploaded files: CSV and NDJSON files of this service
+// and exports of other URL shorteners, whose columns are recognized by their names.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/vagafonov/shortener/internal/request"
+)
+
+// formats of import.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// errors of import file.
+var (
+	ErrUnknownFormat = errors.New("format must be csv or ndjson")
+	ErrNoURLColumn   = errors.New("file has no column with destination url")
+	errLineNotObject = errors.New("line must be json object")
+)
+
+// maxLineSize limit of line of NDJSON file.
+const maxLineSize = 1 << 20
+
+// fields of row and names of columns with them in exports of different shorteners, names are compared without
+// case and punctuation. The first name found in file wins, so more specific names go first.
+var (
+	originalNames = []string{"originalurl", "longurl", "destination", "destinationurl", "target", "url", "original"}
+	codeNames     = []string{"code", "customcode", "shortcode", "keyword", "slashtag", "slug", "alias", "backhalf"}
+	shortURLNames = []string{"shorturl", "shortlink", "link"}
+	titleNames    = []string{"title", "name", "description"}
+	tagsNames     = []string{"tags", "tag", "labels"}
+)
+
+// RowError row cannot be read, other rows can still be read.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reader read rows of file one by one, io.EOF is returned after the last row.
+type Reader interface {
+	Next() (*request.ImportRow, error)
+}
+
+// NewReader Constructor for Reader of format.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		cr.ReuseRecord = true
+
+		return &csvReader{r: cr}, nil
+	case FormatNDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+		return &ndjsonReader{sc: sc}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// FormatOf format of file by extension of its name, empty for unknown extension.
+func FormatOf(fileName string) string {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	default:
+		return ""
+	}
+}
+
+// columns indexes of fields in CSV record, -1 for missing column.
+type columns struct {
+	original, code, shortURL, title, tags int
+}
+
+type csvReader struct {
+	r    *csv.Reader
+	cols *columns
+}
+
+// Next read row from CSV record, the first record is header with names of columns.
+func (c *csvReader) Next() (*request.ImportRow, error) {
+	if c.cols == nil {
+		header, err := c.r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, ErrNoURLColumn
+			}
+
+			return nil, err
+		}
+		names := make(map[string]int, len(header))
+		for i, v := range header {
+			if _, ok := names[normalizeName(v)]; !ok {
+				names[normalizeName(v)] = i
+			}
+		}
+		c.cols = &columns{
+			original: column(names, originalNames),
+			code:     column(names, codeNames),
+			shortURL: column(names, shortURLNames),
+			title:    column(names, titleNames),
+			tags:     column(names, tagsNames),
+		}
+		if c.cols.original < 0 {
+			return nil, ErrNoURLColumn
+		}
+	}
+
+	for {
+		record, err := c.r.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		line, _ := c.r.FieldPos(0)
+
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[i])
+		}
+		row := &request.ImportRow{
+			Line:        line,
+			OriginalURL: field(c.cols.original),
+			Code:        field(c.cols.code),
+			Title:       field(c.cols.title),
+			Tags:        splitTags(field(c.cols.tags)),
+		}
+		if row.Code == "" {
+			row.Code = codeOf(field(c.cols.shortURL))
+		}
+
+		return row, nil
+	}
+}
+
+type ndjsonReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// Next read row from JSON object on its own line, tags may be array or string with separated tags.
+func (n *ndjsonReader) Next() (*request.ImportRow, error) {
+	var obj map[string]any
+	for obj == nil {
+		if !n.sc.Scan() {
+			if err := n.sc.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, io.EOF
+		}
+		n.line++
+		line := bytes.TrimSpace(n.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(line, &obj); err != nil || obj == nil {
+			return nil, &RowError{Line: n.line, Err: errLineNotObject}
+		}
+	}
+
+	fields := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if _, ok := fields[normalizeName(k)]; !ok {
+			fields[normalizeName(k)] = v
+		}
+	}
+	str := func(names []string) string {
+		for _, name := range names {
+			if s, ok := fields[name].(string); ok {
+				return strings.TrimSpace(s)
+			}
+		}
+
+		return ""
+	}
+
+	row := &request.ImportRow{
+		Line:        n.line,
+		OriginalURL: str(originalNames),
+		Code:        str(codeNames),
+		Title:       str(titleNames),
+		Tags:        splitTags(str(tagsNames)),
+	}
+	for _, name := range tagsNames {
+		if tags, ok := fields[name].([]any); ok {
+			row.Tags = make([]string, 0, len(tags))
+			for _, t := range tags {
+				if s, ok := t.(string); ok {
+					row.Tags = append(row.Tags, s)
+				}
+			}
+
+			break
+		}
+	}
+	if row.Code == "" {
+		row.Code = codeOf(str(shortURLNames))
+	}
+
+	return row, nil
+}
+
+// normalizeName lowercase name of column and drop everything except letters and digits,
+// so "Long URL", "long_url" and "longUrl" are the same name.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// column index of the first of names found in header.
+func column(header map[string]int, names []string) int {
+	for _, name := range names {
+		if i, ok := header[name]; ok {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// splitTags split tags separated by commas, semicolons or pipes.
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == '|'
+	})
+}
+
+// codeOf code of short link, it is the last segment of path. Short link may be written without scheme.
+func codeOf(shortURL string) string {
+	if shortURL == "" {
+		return ""
+	}
+	if u, err := url.Parse(shortURL); err == nil {
+		shortURL = u.Path
+	}
+	shortURL = strings.TrimRight(shortURL, "/")
+
+	return shortURL[strings.LastIndexByte(shortURL, '/')+1:]
+}
@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/request"
+)
+
+type ImporterTestSuite struct {
+	suite.Suite
+}
+
+func TestImporterTestSuite(t *testing.T) {
+	suite.Run(t, new(ImporterTestSuite))
+}
+
+// readAll read rows of file, line numbers of broken rows are returned separately.
+func (s *ImporterTestSuite) readAll(format string, file string) ([]*request.ImportRow, []int) {
+	r, err := NewReader(format, strings.NewReader(file))
+	s.Require().NoError(err)
+
+	rows := make([]*request.ImportRow, 0)
+	broken := make([]int, 0)
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, broken
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			broken = append(broken, rowErr.Line)
+
+			continue
+		}
+		s.Require().NoError(err)
+		rows = append(rows, row)
+	}
+}
+
+func (s *ImporterTestSuite) TestCSV() {
+	s.Run("export of this service", func() {
+		rows, broken := s.readAll(FormatCSV, `code,short_url,original_url,title,created_at,deleted_at
+abc,http://short.test/abc,https://example.com/a,"Sale, spring",2024-01-02T03:04:05Z,
+
+old,http://short.test/go.brand.test/old,https://example.com/old,,2024-01-02T03:04:05Z,2024-01-03T03:04:05Z
+`)
+		s.Require().Empty(broken)
+		s.Require().Equal([]*request.ImportRow{
+			{Line: 2, OriginalURL: "https://example.com/a", Code: "abc", Title: "Sale, spring"},
+			{Line: 4, OriginalURL: "https://example.com/old", Code: "old"},
+		}, rows)
+	})
+
+	s.Run("export of other shortener", func() {
+		rows, broken := s.readAll(FormatCSV, `Title,Link,Long URL,Tags
+Docs,https://bit.ly/3xYz/,https://example.com/docs,help|docs
+Home, bit.ly/home ,https://example.com,
+`)
+		s.Require().Empty(broken)
+		s.Require().Equal([]*request.ImportRow{
+			{Line: 2, OriginalURL: "https://example.com/docs", Code: "3xYz", Title: "Docs", Tags: []string{"help", "docs"}},
+			{Line: 3, OriginalURL: "https://example.com", Code: "home", Title: "Home"},
+		}, rows)
+	})
+
+	s.Run("file without destination", func() {
+		for _, file := range []string{"", "code,title\nabc,Docs\n"} {
+			r, err := NewReader(FormatCSV, strings.NewReader(file))
+			s.Require().NoError(err)
+			_, err = r.Next()
+			s.Require().ErrorIs(err, ErrNoURLColumn)
+		}
+	})
+}
+
+func (s *ImporterTestSuite) TestNDJSON() {
+	rows, broken := s.readAll(FormatNDJSON, `{"code":"abc","original_url":"https://example.com/a","tags":["promo","q1"]}
+
+{"keyword":"docs","url":"https://example.com/docs","title":"Docs","tags":"help;docs"}
+not json
+["https://example.com/array"]
+{"shortUrl":"https://rebrand.ly/sale","destination":"https://example.com/sale"}
+`)
+	s.Require().Equal([]int{4, 5}, broken)
+	s.Require().Equal([]*request.ImportRow{
+		{Line: 1, OriginalURL: "https://example.com/a", Code: "abc", Tags: []string{"promo", "q1"}},
+		{Line: 3, OriginalURL: "https://example.com/docs", Code: "docs", Title: "Docs", Tags: []string{"help", "docs"}},
+		{Line: 6, OriginalURL: "https://example.com/sale", Code: "sale"},
+	}, rows)
+}
+
+func (s *ImporterTestSuite) TestFormat() {
+	s.Require().Equal(FormatCSV, FormatOf("links.CSV"))
+	s.Require().Equal(FormatNDJSON, FormatOf("links.jsonl"))
+	s.Require().Empty(FormatOf("links.xlsx"))
+
+	_, err := NewReader("xlsx", strings.NewReader(""))
+	s.Require().ErrorIs(err, ErrUnknownFormat)
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/problem"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+type ctxKey string
+
+const apiKeyCtxKey ctxKey = "apiKey"
+
+const bearerPrefix = "Bearer "
+
+// WithAPIKey Проверяет API ключ из заголовка Authorization и сохраняет его в контексте запроса.
+func (mw *middleware) WithAPIKey(next http.Handler, s contract.ServiceAPIKey) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := BearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		apiKey, err := s.Authenticate(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, customerror.ErrAPIKeyInvalid) {
+				mw.logger.Info().Msg("invalid api key")
+				problem.Write(w, r, http.StatusUnauthorized, err)
+
+				return
+			}
+			mw.logger.Error().Err(err).Msg("cannot authenticate api key")
+			problem.Write(w, r, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyCtxKey, apiKey)))
+	})
+}
+
+// APIKeyFromContext return API key of authenticated request or nil.
+func APIKeyFromContext(ctx context.Context) *entity.APIKey {
+	apiKey, _ := ctx.Value(apiKeyCtxKey).(*entity.APIKey)
+
+	return apiKey
+}
+
+// BearerToken return token from Authorization header.
+func BearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+
+	return token, token != ""
+}
@@ -4,9 +4,14 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/problem"
 	"github.com/vagafonov/shortener/pkg/compress"
 )
 
+// errGzipBody request has Content-Encoding gzip, but its body is not gzip.
+var errGzipBody = customerror.New("invalid_gzip_body", "request body is not valid gzip")
+
 // middleware для сжатия.
 func (mw *middleware) WithCompress(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -40,7 +45,8 @@ func (mw *middleware) WithCompress(next http.Handler) http.Handler {
 		if sendsGzip {
 			compressReader, err := compress.NewCompressGzipReader(r.Body)
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
+				mw.logger.Info().Err(err).Msg("cannot read gzip body")
+				problem.Write(originalWriter, r, http.StatusBadRequest, errGzipBody)
 
 				return
 			}
@@ -1,17 +1,28 @@
 package middleware
 
 import (
-	"encoding/hex"
+	"context"
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/internal/cookie"
+	"github.com/vagafonov/shortener/internal/problem"
 	"github.com/vagafonov/shortener/pkg/encrypting"
 )
 
-// Проверяет наличие cookie c идентификатором пользователя и выдает ее в случае ее отсутствия.
-func (mw *middleware) WithUserIDCookie(next http.Handler, cryptoKey []byte) http.Handler {
+const userIDCtxKey ctxKey = "userID"
+
+// Проверяет наличие cookie c токеном пользователя и выдает ее в случае ее отсутствия.
+// Токен перевыпускается, если скоро истекает или зашифрован выведенным из оборота ключом.
+func (mw *middleware) WithUserIDCookie(
+	next http.Handler,
+	kr *encrypting.Keyring,
+	ttl time.Duration,
+	refreshBefore time.Duration,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.RequestURI, "/debug/") {
 			next.ServeHTTP(w, r)
@@ -19,7 +30,14 @@ func (mw *middleware) WithUserIDCookie(next http.Handler, cryptoKey []byte) http
 			return
 		}
 
-		userIDCoockie, err := r.Cookie("userID")
+		// запросы с API ключом не нуждаются в cookie
+		if _, ok := BearerToken(r); ok {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		userIDCoockie, err := r.Cookie(cookie.Name)
 		if err != nil {
 			if !errors.Is(err, http.ErrNoCookie) {
 				mw.logger.Error().Msgf("error: %v\n", err)
@@ -29,34 +47,61 @@ func (mw *middleware) WithUserIDCookie(next http.Handler, cryptoKey []byte) http
 		}
 
 		if userIDCoockie != nil && userIDCoockie.Value == "" {
-			w.WriteHeader(http.StatusUnauthorized)
+			problem.Write(w, r, http.StatusUnauthorized, nil)
 
 			return
 		}
 
+		var userID uuid.UUID
+		now := time.Now()
 		if userIDCoockie == nil {
 			mw.logger.Debug().Msg("cookie doesn't exist. setting")
-			ck := mw.setCookie(w, cryptoKey)
-			r.AddCookie(ck)
+			userID = mw.setCookie(w, kr, uuid.New(), ttl)
 		} else {
-			mw.logger.Debug().Msg("cookie exist")
-			// Расшифровка UUID
-			binUserID, _ := hex.DecodeString(userIDCoockie.Value)
-			decrypted, err := encrypting.Decrypt(binUserID, cryptoKey)
-			mw.logger.Info().Str("decrypted", decrypted).Send()
-			if err != nil {
-				mw.setCookie(w, cryptoKey)
+			token, err := cookie.ParseToken(kr, userIDCoockie.Value, now)
+			switch {
+			case err != nil:
+				mw.logger.Info().Err(err).Msg("cookie with invalid token. setting new")
+				userID = mw.setCookie(w, kr, uuid.New(), ttl)
+			case token.NeedsRefresh(kr, now, refreshBefore):
+				mw.logger.Debug().Msg("cookie refreshed")
+				userID = mw.setCookie(w, kr, token.UserID, ttl)
+			default:
+				userID = token.UserID
 			}
 		}
 
-		next.ServeHTTP(w, r)
+		if userID == uuid.Nil {
+			problem.Write(w, r, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userIDCtxKey, userID)))
 	})
 }
 
-func (mw *middleware) setCookie(w http.ResponseWriter, cryptoKey []byte) *http.Cookie {
-	c := cookie.CreateCookieWithUserID(mw.logger, cryptoKey)
+// UserIDFromContext return userID resolved from cookie.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userIDCtxKey).(uuid.UUID)
+
+	return userID, ok
+}
+
+func (mw *middleware) setCookie(
+	w http.ResponseWriter,
+	kr *encrypting.Keyring,
+	userID uuid.UUID,
+	ttl time.Duration,
+) uuid.UUID {
+	c, err := cookie.NewCookieWithUserID(kr, userID, ttl)
+	if err != nil {
+		mw.logger.Error().Err(err).Msg("cannot create cookie")
+
+		return uuid.Nil
+	}
 	mw.logger.Debug().Str("name", c.Name).Str("value", c.Value).Msg("created cookie")
 	http.SetCookie(w, c)
 
-	return c
+	return userID
 }
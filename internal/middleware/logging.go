@@ -3,6 +3,8 @@ package middleware
 import (
 	"net/http"
 	"time"
+
+	"github.com/vagafonov/shortener/internal/requestid"
 )
 
 // WithLogging middleware для логирования.
@@ -16,7 +18,7 @@ func (mw *middleware) WithLogging(next http.Handler) http.Handler {
 				size:   0,
 			},
 		}
-		l := mw.logger.Info().Str("URI", r.RequestURI)
+		l := mw.logger.Info().Str("URI", r.RequestURI).Str("request_id", requestid.FromContext(r.Context()))
 		next.ServeHTTP(&lw, r)
 		l.Dur("duration", time.Since(start))
 		l.Int("status", lw.responseData.status)
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/requestid"
+)
+
+// maxRequestIDLength ID of request sent by proxy is accepted up to this length.
+const maxRequestIDLength = 128
+
+// WithRequestID Сохраняет ID запроса в контексте и возвращает его в заголовке ответа.
+// ID, переданный прокси, используется как есть, иначе создаётся новый.
+func (mw *middleware) WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !isValidRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// isValidRequestID check that ID is not empty and has only printable ASCII characters, so it is safe to log.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/cookie"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/problem"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+const sessionCtxKey ctxKey = "session"
+
+// WithSession Проверяет cookie с сессией именованного аккаунта и сохраняет сессию в контексте запроса.
+// Недействительная сессия удаляется, запрос обрабатывается как анонимный.
+func (mw *middleware) WithSession(next http.Handler, s contract.ServiceAccount) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionCookie, err := r.Cookie(cookie.SessionName)
+		if err != nil || sessionCookie.Value == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		session, err := s.Authenticate(r.Context(), sessionCookie.Value)
+		if err != nil {
+			if errors.Is(err, customerror.ErrSessionInvalid) {
+				mw.logger.Info().Msg("invalid session")
+				http.SetCookie(w, cookie.ExpiredSessionCookie())
+				next.ServeHTTP(w, r)
+
+				return
+			}
+			mw.logger.Error().Err(err).Msg("cannot authenticate session")
+			problem.Write(w, r, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionCtxKey, session)))
+	})
+}
+
+// SessionFromContext return session of authenticated account or nil.
+func SessionFromContext(ctx context.Context) *entity.Session {
+	session, _ := ctx.Value(sessionCtxKey).(*entity.Session)
+
+	return session
+}
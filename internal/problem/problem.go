@@ -0,0 +1,68 @@
+// Package problem writes error responses in format of RFC 7807.
+// Only messages of domain errors are sent to client, other errors are described by status.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/requestid"
+	"github.com/vagafonov/shortener/internal/response"
+	"github.com/vagafonov/shortener/internal/validate"
+)
+
+// typeBlank type of problem which is described by status.
+const typeBlank = "about:blank"
+
+// New create problem for error. Errors of server are never described, because they may contain internals.
+func New(req *http.Request, status int, err error) *response.Problem {
+	p := &response.Problem{
+		Type:      typeBlank,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  req.URL.Path,
+		Code:      StatusCode(status),
+		RequestID: requestid.FromContext(req.Context()),
+	}
+	if status >= http.StatusInternalServerError {
+		return p
+	}
+
+	if e := customerror.As(err); e != nil {
+		p.Code = e.Code
+		p.Detail = e.Message
+	}
+	var fieldErr *validate.FieldError
+	if errors.As(err, &fieldErr) {
+		p.Errors = []response.FieldProblem{{Field: fieldErr.Field, Code: p.Code, Detail: p.Detail}}
+	}
+
+	return p
+}
+
+// Write write problem for error as response with status.
+func Write(res http.ResponseWriter, req *http.Request, status int, err error) {
+	body, err := json.Marshal(New(req, status, err))
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	res.Header().Set("Content-Type", response.ProblemContentType)
+	res.WriteHeader(status)
+	_, _ = res.Write(body)
+}
+
+// StatusCode code of error which has no own code, it is name of status, e.g. "not_found".
+func StatusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
@@ -0,0 +1,7 @@
+package request
+
+// AccountRequest.
+type AccountRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// CreateAPIKeyRequest.
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
@@ -0,0 +1,12 @@
+package request
+
+// ImportRow link read from row of import file.
+type ImportRow struct {
+	// Line number of line in file where row starts.
+	Line        int
+	OriginalURL string
+	// Code requested code of short URL, empty if new code must be generated.
+	Code  string
+	Title string
+	Tags  []string
+}
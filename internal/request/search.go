@@ -0,0 +1,8 @@
+package request
+
+// SearchURLsRequest query and page of search over URLs of user.
+type SearchURLsRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
@@ -1,6 +1,23 @@
 package request
 
+import "github.com/vagafonov/shortener/pkg/entity"
+
 // ShortenRequest.
 type ShortenRequest struct {
 	URL string `json:"url"`
+	// Domain host of branded domain, empty for default domain.
+	Domain       string               `json:"domain,omitempty"`
+	Title        string               `json:"title,omitempty"`
+	Description  string               `json:"description,omitempty"`
+	Notes        string               `json:"notes,omitempty"`
+	Tags         []string             `json:"tags,omitempty"`
+	Visibility   string               `json:"visibility,omitempty"`
+	Password     string               `json:"password,omitempty"`
+	MaxClicks    int64                `json:"max_clicks,omitempty"`      //nolint:tagliatelle
+	Redirect     int                  `json:"redirect_status,omitempty"` //nolint:tagliatelle
+	QueryMode    string               `json:"query_mode,omitempty"`      //nolint:tagliatelle
+	UTM          map[string]string    `json:"utm,omitempty"`
+	DeviceRules  []entity.DeviceRule  `json:"device_rules,omitempty"`  //nolint:tagliatelle
+	CountryRules []entity.CountryRule `json:"country_rules,omitempty"` //nolint:tagliatelle
+	Variants     []entity.Variant     `json:"variants,omitempty"`
 }
@@ -0,0 +1,12 @@
+package request
+
+// RenameTagRequest new name of tag. Tag is merged into existing tag with the same name.
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// MergeTagsRequest tags which are replaced with tag Into.
+type MergeTagsRequest struct {
+	Tags []string `json:"tags"`
+	Into string   `json:"into"`
+}
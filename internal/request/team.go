@@ -0,0 +1,19 @@
+package request
+
+import "github.com/google/uuid"
+
+// TeamRequest request to create organization or team.
+type TeamRequest struct {
+	Name string `json:"name"`
+}
+
+// TeamMemberRequest.
+type TeamMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// TransferURLRequest exactly one of user or team must be set.
+type TransferURLRequest struct {
+	UserID uuid.UUID `json:"user_id"` //nolint:tagliatelle
+	TeamID uuid.UUID `json:"team_id"` //nolint:tagliatelle
+}
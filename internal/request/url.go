@@ -0,0 +1,28 @@
+package request
+
+import "github.com/vagafonov/shortener/pkg/entity"
+
+// UpdateURLRequest settings of URL, omitted fields are not changed.
+type UpdateURLRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Notes       *string `json:"notes"`
+	// Tags replace all tags of URL, empty list removes them.
+	Tags       []string `json:"tags"`
+	Visibility *string  `json:"visibility"`
+	// Password empty value removes password protection.
+	Password *string `json:"password"`
+	// MaxClicks zero value removes clicks limit.
+	MaxClicks *int64 `json:"max_clicks"` //nolint:tagliatelle
+	// Redirect zero value resets status of redirect to default of server.
+	Redirect  *int    `json:"redirect_status"` //nolint:tagliatelle
+	QueryMode *string `json:"query_mode"`      //nolint:tagliatelle
+	// UTM empty object removes UTM template.
+	UTM map[string]string `json:"utm"`
+	// DeviceRules ordered rules, empty list removes them.
+	DeviceRules []entity.DeviceRule `json:"device_rules"` //nolint:tagliatelle
+	// CountryRules ordered rules, empty list removes them.
+	CountryRules []entity.CountryRule `json:"country_rules"` //nolint:tagliatelle
+	// Variants empty list stops A/B experiment.
+	Variants []entity.Variant `json:"variants"`
+}
@@ -0,0 +1,21 @@
+// Package requestid keeps ID of request, it is sent back to client and logged, so errors can be traced.
+package requestid
+
+import "context"
+
+// Header header of request and response with ID of request.
+const Header = "X-Request-Id"
+
+type ctxKey struct{}
+
+// NewContext return context with ID of request.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext return ID of request, empty when it is not set.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+
+	return id
+}
@@ -0,0 +1,24 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AccountResponse.
+type AccountResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"created_at"` //nolint:tagliatelle
+}
+
+// NewAccountResponse Constructor for AccountResponse.
+func NewAccountResponse(account *entity.Account) AccountResponse {
+	return AccountResponse{
+		ID:        account.ID,
+		Login:     account.Login,
+		CreatedAt: account.CreatedAt,
+	}
+}
@@ -0,0 +1,31 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// APIKeyResponse.
+type APIKeyResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`           //nolint:tagliatelle
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` //nolint:tagliatelle
+	Key       string     `json:"key,omitempty"`
+}
+
+// NewAPIKeyResponse Constructor for APIKeyResponse.
+func NewAPIKeyResponse(key *entity.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
@@ -0,0 +1,11 @@
+package response
+
+// ExpandResponse destination and status of short URL. Destination is set only for active URL.
+type ExpandResponse struct {
+	ShortURL    string           `json:"short_url"`              //nolint:tagliatelle
+	OriginalURL string           `json:"original_url,omitempty"` //nolint:tagliatelle
+	Status      string           `json:"status"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Preview     *PreviewResponse `json:"preview,omitempty"`
+}
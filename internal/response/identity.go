@@ -0,0 +1,24 @@
+package response
+
+import (
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// IdentityResponse.
+type IdentityResponse struct {
+	ID      uuid.UUID `json:"id"`
+	Issuer  string    `json:"issuer"`
+	Subject string    `json:"subject"`
+	Email   string    `json:"email,omitempty"`
+}
+
+// NewIdentityResponse Constructor for IdentityResponse.
+func NewIdentityResponse(identity *entity.Identity) IdentityResponse {
+	return IdentityResponse{
+		ID:      identity.UserID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	}
+}
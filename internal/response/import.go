@@ -0,0 +1,20 @@
+package response
+
+// ImportRowResponse result of import of row of file.
+type ImportRowResponse struct {
+	Line        int    `json:"line"`
+	OriginalURL string `json:"original_url,omitempty"` //nolint:tagliatelle
+	ShortURL    string `json:"short_url,omitempty"`    //nolint:tagliatelle
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ImportResponse report of import. Renamed URLs are counted as created,
+// Error is set when import stopped before end of file.
+type ImportResponse struct {
+	Created int                 `json:"created"`
+	Exists  int                 `json:"exists"`
+	Failed  int                 `json:"failed"`
+	Rows    []ImportRowResponse `json:"rows"`
+	Error   string              `json:"error,omitempty"`
+}
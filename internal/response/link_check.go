@@ -0,0 +1,27 @@
+package response
+
+import (
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// LinkCheckResponse.
+type LinkCheckResponse struct {
+	Status    int       `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"` //nolint:tagliatelle
+	Failures  int       `json:"failures"`
+	Broken    bool      `json:"broken"`
+}
+
+// NewLinkCheckResponse Constructor for LinkCheckResponse.
+func NewLinkCheckResponse(c *entity.LinkCheck) LinkCheckResponse {
+	return LinkCheckResponse{
+		Status:    c.Status,
+		Error:     c.Error,
+		CheckedAt: c.CheckedAt,
+		Failures:  c.Failures,
+		Broken:    c.Broken,
+	}
+}
@@ -0,0 +1,29 @@
+package response
+
+import (
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// PreviewResponse.
+type PreviewResponse struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"` //nolint:tagliatelle
+	Favicon     string    `json:"favicon,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"` //nolint:tagliatelle
+}
+
+// NewPreviewResponse Constructor for PreviewResponse.
+func NewPreviewResponse(p *entity.LinkPreview) PreviewResponse {
+	return PreviewResponse{
+		Title:       p.Title,
+		Description: p.Description,
+		Image:       p.Image,
+		SiteName:    p.SiteName,
+		Favicon:     p.Favicon,
+		FetchedAt:   p.FetchedAt,
+	}
+}
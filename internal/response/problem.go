@@ -0,0 +1,23 @@
+package response
+
+// ProblemContentType media type of error response.
+const ProblemContentType = "application/problem+json"
+
+// Problem error response in format of RFC 7807. Code is stable code of error which clients can rely on.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	Code      string         `json:"code"`
+	RequestID string         `json:"request_id,omitempty"` //nolint:tagliatelle
+	Errors    []FieldProblem `json:"errors,omitempty"`
+}
+
+// FieldProblem invalid field of request.
+type FieldProblem struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
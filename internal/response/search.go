@@ -0,0 +1,7 @@
+package response
+
+// SearchURLsResponse page of found URLs and total number of found URLs.
+type SearchURLsResponse struct {
+	Total int               `json:"total"`
+	URLs  []UserURLResponse `json:"urls"`
+}
@@ -5,3 +5,9 @@ type ShortenBatchResponse struct {
 	CorrelationID string `json:"correlation_id"` //nolint:tagliatelle
 	ShortURL      string `json:"short_url"`      //nolint:tagliatelle
 }
+
+// ShortenBatchErrorResponse item of streamed batch which is not shortened.
+type ShortenBatchErrorResponse struct {
+	CorrelationID string `json:"correlation_id"` //nolint:tagliatelle
+	Error         string `json:"error"`
+}
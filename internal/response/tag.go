@@ -0,0 +1,22 @@
+package response
+
+import "github.com/vagafonov/shortener/pkg/entity"
+
+// TagStatsResponse.
+type TagStatsResponse struct {
+	Tag  string `json:"tag"`
+	URLs int    `json:"urls"`
+}
+
+// NewTagStatsResponse Constructor for TagStatsResponse.
+func NewTagStatsResponse(stats entity.TagStats) TagStatsResponse {
+	return TagStatsResponse{
+		Tag:  stats.Tag,
+		URLs: stats.URLs,
+	}
+}
+
+// RenameTagsResponse number of URLs whose tags were changed.
+type RenameTagsResponse struct {
+	Updated int `json:"updated"`
+}
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// OrganizationResponse.
+type OrganizationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uuid.UUID `json:"owner_id"`   //nolint:tagliatelle
+	CreatedAt time.Time `json:"created_at"` //nolint:tagliatelle
+}
+
+// NewOrganizationResponse Constructor for OrganizationResponse.
+func NewOrganizationResponse(org *entity.Organization) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		OwnerID:   org.OwnerID,
+		CreatedAt: org.CreatedAt,
+	}
+}
+
+// TeamResponse.
+type TeamResponse struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"` //nolint:tagliatelle
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"` //nolint:tagliatelle
+}
+
+// NewTeamResponse Constructor for TeamResponse.
+func NewTeamResponse(team *entity.Team) TeamResponse {
+	return TeamResponse{
+		ID:             team.ID,
+		OrganizationID: team.OrganizationID,
+		Name:           team.Name,
+		CreatedAt:      team.CreatedAt,
+	}
+}
+
+// TeamMemberResponse.
+type TeamMemberResponse struct {
+	TeamID    uuid.UUID `json:"team_id"` //nolint:tagliatelle
+	UserID    uuid.UUID `json:"user_id"` //nolint:tagliatelle
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"` //nolint:tagliatelle
+}
+
+// NewTeamMemberResponse Constructor for TeamMemberResponse.
+func NewTeamMemberResponse(member *entity.TeamMember) TeamMemberResponse {
+	return TeamMemberResponse{
+		TeamID:    member.TeamID,
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}
+}
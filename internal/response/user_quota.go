@@ -0,0 +1,9 @@
+package response
+
+// UserQuotaResponse.
+type UserQuotaResponse struct {
+	DailyLimit  int `json:"daily_limit"`  //nolint:tagliatelle
+	DailyUsed   int `json:"daily_used"`   //nolint:tagliatelle
+	ActiveLimit int `json:"active_limit"` //nolint:tagliatelle
+	ActiveUsed  int `json:"active_used"`  //nolint:tagliatelle
+}
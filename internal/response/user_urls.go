@@ -1,9 +1,34 @@
 package response
 
+import (
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
 // UserURLResponse.
 type UserURLResponse struct {
-	ShortURL    string `json:"short_url"`    //nolint:tagliatelle
-	OriginalURL string `json:"original_url"` //nolint:tagliatelle
+	ShortURL    string   `json:"short_url"`    //nolint:tagliatelle
+	OriginalURL string   `json:"original_url"` //nolint:tagliatelle
+	Domain      string   `json:"domain,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Preview metadata of destination, it is set when server has fetched destination.
+	Preview *PreviewResponse `json:"preview,omitempty"`
+	// LinkCheck result of last check of destination, it is set when server has checked destination.
+	LinkCheck  *LinkCheckResponse `json:"link_check,omitempty"` //nolint:tagliatelle
+	TeamID     *uuid.UUID         `json:"team_id,omitempty"`    //nolint:tagliatelle
+	Visibility string             `json:"visibility,omitempty"`
+	Protected  bool               `json:"password_protected,omitempty"` //nolint:tagliatelle
+	// RemainingClicks set only for URLs with clicks limit.
+	RemainingClicks *int64               `json:"remaining_clicks,omitempty"` //nolint:tagliatelle
+	RedirectStatus  int                  `json:"redirect_status,omitempty"`  //nolint:tagliatelle
+	QueryMode       string               `json:"query_mode,omitempty"`       //nolint:tagliatelle
+	UTM             map[string]string    `json:"utm,omitempty"`
+	DeviceRules     []entity.DeviceRule  `json:"device_rules,omitempty"`  //nolint:tagliatelle
+	CountryRules    []entity.CountryRule `json:"country_rules,omitempty"` //nolint:tagliatelle
+	Variants        []entity.Variant     `json:"variants,omitempty"`
 }
 
 // NewUserURLResponse Constructor for UserURLResponse.
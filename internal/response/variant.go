@@ -0,0 +1,21 @@
+package response
+
+import "github.com/vagafonov/shortener/pkg/entity"
+
+// VariantStatsResponse.
+type VariantStatsResponse struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+}
+
+// NewVariantStatsResponse Constructor for VariantStatsResponse.
+func NewVariantStatsResponse(stats entity.VariantStats) VariantStatsResponse {
+	return VariantStatsResponse{
+		ID:     stats.ID,
+		URL:    stats.URL,
+		Weight: stats.Weight,
+		Clicks: stats.Clicks,
+	}
+}
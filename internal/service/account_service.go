@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/config"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/encrypting"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sessionTokenBytes = 32
+
+type accountService struct {
+	logger        *zerolog.Logger
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+	cfg           *config.Config
+	// dummyHash compared with password of unknown login, so response time does not reveal existing logins.
+	dummyHash func() []byte
+}
+
+// NewAccountService Constructor for AccountService.
+func NewAccountService(
+	logger *zerolog.Logger,
+	mainStorage contract.Storage,
+	backupStorage contract.Storage,
+	cfg *config.Config,
+) contract.ServiceAccount {
+	return &accountService{
+		logger:        logger,
+		mainStorage:   mainStorage,
+		backupStorage: backupStorage,
+		cfg:           cfg,
+		dummyHash: sync.OnceValue(func() []byte {
+			hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+			return hash
+		}),
+	}
+}
+
+// Register create account with bcrypt hashed password.
+func (s *accountService) Register(ctx context.Context, login string, password string) (*entity.Account, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	account := &entity.Account{
+		ID:           uuid.New(),
+		Login:        login,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err = s.mainStorage.AddAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	if err = s.backupStorage.AddAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("cannot save account in backup storage: %w", err)
+	}
+
+	return account, nil
+}
+
+// Login check password and open new session of account.
+func (s *accountService) Login(
+	ctx context.Context,
+	login string,
+	password string,
+	anonymousUserID uuid.UUID,
+) (string, *entity.Account, error) {
+	account, err := s.mainStorage.GetAccountByLogin(ctx, login)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot get account: %w", err)
+	}
+
+	if account == nil {
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash(), []byte(password))
+
+		return "", nil, customerror.ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return "", nil, customerror.ErrInvalidCredentials
+		}
+
+		return "", nil, fmt.Errorf("cannot compare password: %w", err)
+	}
+
+	token, err := s.OpenSession(ctx, account.ID, anonymousUserID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, account, nil
+}
+
+// OpenSession open new session of user. URLs of anonymous user are moved to user on first session only,
+// later logins from shared devices must not take links of other visitors.
+// Returns session token which is shown only once.
+func (s *accountService) OpenSession(ctx context.Context, userID uuid.UUID, anonymousUserID uuid.UUID) (string, error) {
+	hasSessions, err := s.mainStorage.HasSessions(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("cannot check sessions: %w", err)
+	}
+
+	random, err := encrypting.GenerateRandom(sessionTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate session token: %w", err)
+	}
+	token := hex.EncodeToString(random)
+
+	now := time.Now().UTC()
+	session := &entity.Session{
+		Hash:      hashToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.SessionTTL),
+	}
+	if err = s.mainStorage.AddSession(ctx, session); err != nil {
+		return "", fmt.Errorf("cannot save session: %w", err)
+	}
+	if err = s.backupStorage.AddSession(ctx, session); err != nil {
+		return "", fmt.Errorf("cannot save session in backup storage: %w", err)
+	}
+
+	if !hasSessions && anonymousUserID != uuid.Nil && anonymousUserID != userID {
+		if err = s.claimURLs(ctx, anonymousUserID, userID); err != nil {
+			return "", err
+		}
+	}
+
+	return token, nil
+}
+
+// claimURLs move URLs of anonymous user to account in main and backup storages.
+func (s *accountService) claimURLs(ctx context.Context, anonymousUserID uuid.UUID, accountID uuid.UUID) error {
+	claimed, err := s.mainStorage.ReassignURLs(ctx, anonymousUserID, accountID)
+	if err != nil {
+		return fmt.Errorf("cannot reassign urls in main storage: %w", err)
+	}
+
+	if claimed == 0 {
+		return nil
+	}
+
+	if _, err = s.backupStorage.ReassignURLs(ctx, anonymousUserID, accountID); err != nil {
+		return fmt.Errorf("cannot reassign urls in backup storage: %w", err)
+	}
+	s.logger.Info().Int("count", claimed).Str("account", accountID.String()).Msg("anonymous urls claimed")
+
+	return nil
+}
+
+// Logout close session in main and backup storages.
+func (s *accountService) Logout(ctx context.Context, token string) error {
+	hash := hashToken(token)
+	if err := s.mainStorage.DeleteSession(ctx, hash); err != nil {
+		return err
+	}
+	if err := s.backupStorage.DeleteSession(ctx, hash); err != nil {
+		return fmt.Errorf("cannot delete session in backup storage: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreAccounts restore accounts with their sessions from backup storage.
+func (s *accountService) RestoreAccounts(ctx context.Context) (int, error) {
+	accounts, err := s.backupStorage.GetAllAccounts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get all accounts: %w", err)
+	}
+	for i, v := range accounts {
+		if err = s.mainStorage.AddAccount(ctx, v); err != nil {
+			return i, fmt.Errorf("failed to add account: %w", err)
+		}
+	}
+
+	sessions, err := s.backupStorage.GetAllSessions(ctx)
+	if err != nil {
+		return len(accounts), fmt.Errorf("failed to get all sessions: %w", err)
+	}
+	for _, v := range sessions {
+		if err = s.mainStorage.AddSession(ctx, v); err != nil {
+			return len(accounts), fmt.Errorf("failed to add session: %w", err)
+		}
+	}
+
+	return len(accounts), nil
+}
+
+// Authenticate find active session by its token.
+func (s *accountService) Authenticate(ctx context.Context, token string) (*entity.Session, error) {
+	session, err := s.mainStorage.GetSessionByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get session: %w", err)
+	}
+
+	if session == nil || session.DeletedAt != nil || !time.Now().Before(session.ExpiresAt) {
+		return nil, customerror.ErrSessionInvalid
+	}
+
+	return session, nil
+}
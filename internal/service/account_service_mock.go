@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AccountServiceMock mock.
+type AccountServiceMock struct {
+	registerEntity     *entity.Account
+	registerError      error
+	loginToken         string
+	loginEntity        *entity.Account
+	loginError         error
+	openSessionToken   string
+	openSessionError   error
+	logoutError        error
+	authenticateEntity *entity.Session
+	authenticateError  error
+}
+
+// NewAccountServiceMock Constructor for AccountServiceMock.
+func NewAccountServiceMock() contract.ServiceAccount {
+	return &AccountServiceMock{}
+}
+
+// Register mock.
+func (s *AccountServiceMock) Register(ctx context.Context, login string, password string) (*entity.Account, error) {
+	return s.registerEntity, s.registerError
+}
+
+// SetRegisterResult mock.
+func (s *AccountServiceMock) SetRegisterResult(e *entity.Account, err error) {
+	s.registerEntity = e
+	s.registerError = err
+}
+
+// Login mock.
+func (s *AccountServiceMock) Login(
+	ctx context.Context,
+	login string,
+	password string,
+	anonymousUserID uuid.UUID,
+) (string, *entity.Account, error) {
+	return s.loginToken, s.loginEntity, s.loginError
+}
+
+// SetLoginResult mock.
+func (s *AccountServiceMock) SetLoginResult(token string, e *entity.Account, err error) {
+	s.loginToken = token
+	s.loginEntity = e
+	s.loginError = err
+}
+
+// OpenSession mock.
+func (s *AccountServiceMock) OpenSession(
+	ctx context.Context,
+	userID uuid.UUID,
+	anonymousUserID uuid.UUID,
+) (string, error) {
+	return s.openSessionToken, s.openSessionError
+}
+
+// SetOpenSessionResult mock.
+func (s *AccountServiceMock) SetOpenSessionResult(token string, err error) {
+	s.openSessionToken = token
+	s.openSessionError = err
+}
+
+// Logout mock.
+func (s *AccountServiceMock) Logout(ctx context.Context, token string) error {
+	return s.logoutError
+}
+
+// SetLogoutResult mock.
+func (s *AccountServiceMock) SetLogoutResult(err error) {
+	s.logoutError = err
+}
+
+// Authenticate mock.
+func (s *AccountServiceMock) Authenticate(ctx context.Context, token string) (*entity.Session, error) {
+	return s.authenticateEntity, s.authenticateError
+}
+
+// SetAuthenticateResult mock.
+func (s *AccountServiceMock) SetAuthenticateResult(e *entity.Session, err error) {
+	s.authenticateEntity = e
+	s.authenticateError = err
+}
+
+// RestoreAccounts mock.
+func (s *AccountServiceMock) RestoreAccounts(ctx context.Context) (int, error) {
+	return 0, nil
+}
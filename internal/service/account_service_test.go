@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/config"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/storage"
+)
+
+type ServiceAccountSuite struct {
+	suite.Suite
+	cfg           *config.Config
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+	service       contract.ServiceAccount
+}
+
+func TestServiceAccountSuite(t *testing.T) {
+	suite.Run(t, new(ServiceAccountSuite))
+}
+
+func (s *ServiceAccountSuite) SetupTest() {
+	s.cfg = config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	s.mainStorage = storage.NewMemoryStorage()
+	s.backupStorage = storage.NewMemoryStorage()
+	s.service = NewAccountService(logger.CreateLogger(s.cfg.LogLevel), s.mainStorage, s.backupStorage, s.cfg)
+}
+
+func (s *ServiceAccountSuite) TestRegisterAndLogin() {
+	ctx := context.Background()
+	account, err := s.service.Register(ctx, "alice", "password")
+	s.Require().NoError(err)
+	s.Require().NotEqual("password", account.PasswordHash)
+
+	_, err = s.service.Register(ctx, "alice", "password")
+	s.Require().ErrorIs(err, customerror.ErrAccountAlreadyExists)
+
+	s.Run("wrong password", func() {
+		_, _, err := s.service.Login(ctx, "alice", "wrong password", uuid.Nil)
+		s.Require().ErrorIs(err, customerror.ErrInvalidCredentials)
+	})
+
+	s.Run("unknown login", func() {
+		_, _, err := s.service.Login(ctx, "bob", "password", uuid.Nil)
+		s.Require().ErrorIs(err, customerror.ErrInvalidCredentials)
+	})
+
+	s.Run("login, authenticate and logout", func() {
+		token, logged, err := s.service.Login(ctx, "alice", "password", uuid.Nil)
+		s.Require().NoError(err)
+		s.Require().Equal(account.ID, logged.ID)
+
+		session, err := s.service.Authenticate(ctx, token)
+		s.Require().NoError(err)
+		s.Require().Equal(account.ID, session.UserID)
+
+		s.Require().NoError(s.service.Logout(ctx, token))
+		_, err = s.service.Authenticate(ctx, token)
+		s.Require().ErrorIs(err, customerror.ErrSessionInvalid)
+	})
+
+	s.Run("expired session", func() {
+		s.cfg.SessionTTL = -time.Second
+		token, _, err := s.service.Login(ctx, "alice", "password", uuid.Nil)
+		s.Require().NoError(err)
+		_, err = s.service.Authenticate(ctx, token)
+		s.Require().ErrorIs(err, customerror.ErrSessionInvalid)
+	})
+}
+
+func (s *ServiceAccountSuite) TestClaimAnonymousURLs() {
+	ctx := context.Background()
+	anonymousUserID := uuid.New()
+	for _, storage := range []contract.Storage{s.mainStorage, s.backupStorage} {
+		_, err := storage.Add(ctx, "short1", "https://ya.ru", anonymousUserID)
+		s.Require().NoError(err)
+		_, err = storage.Add(ctx, "short2", "https://go.dev", uuid.New())
+		s.Require().NoError(err)
+	}
+
+	account, err := s.service.Register(ctx, "alice", "password")
+	s.Require().NoError(err)
+	_, _, err = s.service.Login(ctx, "alice", "password", anonymousUserID)
+	s.Require().NoError(err)
+
+	for _, storage := range []contract.Storage{s.mainStorage, s.backupStorage} {
+		urls, err := storage.GetAllURLsByUser(ctx, account.ID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+		s.Require().Equal("short1", urls[0].Short)
+
+		urls, err = storage.GetAllURLsByUser(ctx, anonymousUserID, "", "")
+		s.Require().NoError(err)
+		s.Require().Empty(urls)
+	}
+
+	s.Run("urls are claimed on first login only", func() {
+		otherUserID := uuid.New()
+		_, err := s.mainStorage.Add(ctx, "short3", "https://go.dev/doc", otherUserID)
+		s.Require().NoError(err)
+		_, _, err = s.service.Login(ctx, "alice", "password", otherUserID)
+		s.Require().NoError(err)
+
+		urls, err := s.mainStorage.GetAllURLsByUser(ctx, otherUserID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+	})
+}
+
+func (s *ServiceAccountSuite) TestRestoreAccounts() {
+	ctx := context.Background()
+	const backupFile = "test-accounts-db"
+	defer func() {
+		os.Remove(backupFile)
+		os.Remove(backupFile + ".accounts")
+		os.Remove(backupFile + ".sessions")
+	}()
+	newService := func(mainStorage contract.Storage) contract.ServiceAccount {
+		backupStorage, err := storage.NewFileSystemStorage(backupFile)
+		s.Require().NoError(err)
+
+		return NewAccountService(logger.CreateLogger(s.cfg.LogLevel), mainStorage, backupStorage, s.cfg)
+	}
+	srv := newService(storage.NewMemoryStorage())
+	account, err := srv.Register(ctx, "alice", "password")
+	s.Require().NoError(err)
+	token, _, err := srv.Login(ctx, "alice", "password", uuid.Nil)
+	s.Require().NoError(err)
+	closed, _, err := srv.Login(ctx, "alice", "password", uuid.Nil)
+	s.Require().NoError(err)
+	s.Require().NoError(srv.Logout(ctx, closed))
+
+	// после перезапуска пользователь остаётся авторизованным
+	srv = newService(storage.NewMemoryStorage())
+	restored, err := srv.RestoreAccounts(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(1, restored)
+
+	session, err := srv.Authenticate(ctx, token)
+	s.Require().NoError(err)
+	s.Require().Equal(account.ID, session.UserID)
+	_, err = srv.Authenticate(ctx, closed)
+	s.Require().ErrorIs(err, customerror.ErrSessionInvalid, "closed session stays closed")
+	_, _, err = srv.Login(ctx, "alice", "password", uuid.Nil)
+	s.Require().NoError(err)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/encrypting"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+const (
+	apiKeyPrefix        = "shk_"
+	apiKeyRandomBytes   = 32
+	apiKeyVisibleLength = 12
+)
+
+type apiKeyService struct {
+	logger        *zerolog.Logger
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+}
+
+// NewAPIKeyService Constructor for APIKeyService.
+func NewAPIKeyService(
+	logger *zerolog.Logger,
+	mainStorage contract.Storage,
+	backupStorage contract.Storage,
+) contract.ServiceAPIKey {
+	return &apiKeyService{
+		logger:        logger,
+		mainStorage:   mainStorage,
+		backupStorage: backupStorage,
+	}
+}
+
+// CreateAPIKey create new API key for user. Returns plain key which is shown only once.
+func (s *apiKeyService) CreateAPIKey(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+	scopes []string,
+) (string, *entity.APIKey, error) {
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	random, err := encrypting.GenerateRandom(apiKeyRandomBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot generate api key: %w", err)
+	}
+	plain := apiKeyPrefix + hex.EncodeToString(random)
+
+	key := &entity.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:apiKeyVisibleLength],
+		Hash:      hashToken(plain),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err = s.mainStorage.AddAPIKey(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("cannot save api key: %w", err)
+	}
+	if err = s.backupStorage.AddAPIKey(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("cannot save api key in backup storage: %w", err)
+	}
+
+	return plain, key, nil
+}
+
+// GetUserAPIKeys get all API keys of user.
+func (s *apiKeyService) GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	return s.mainStorage.GetAPIKeysByUser(ctx, userID)
+}
+
+// RevokeAPIKey revoke API key of user in main and backup storages.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	if err := s.mainStorage.RevokeAPIKey(ctx, userID, id); err != nil {
+		return err
+	}
+	// ключ, созданный до появления резервной копии ключей, в резервном хранилище отсутствует
+	err := s.backupStorage.RevokeAPIKey(ctx, userID, id)
+	if err != nil && !errors.Is(err, customerror.ErrAPIKeyNotFound) {
+		return fmt.Errorf("cannot revoke api key in backup storage: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreAPIKeys restore API keys with their revocations from backup storage.
+func (s *apiKeyService) RestoreAPIKeys(ctx context.Context) (int, error) {
+	keys, err := s.backupStorage.GetAllAPIKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get all api keys: %w", err)
+	}
+	for i, v := range keys {
+		if err = s.mainStorage.AddAPIKey(ctx, v); err != nil {
+			return i, fmt.Errorf("failed to add api key: %w", err)
+		}
+	}
+
+	return len(keys), nil
+}
+
+// Authenticate find active API key by its plain value.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, customerror.ErrAPIKeyInvalid
+	}
+
+	apiKey, err := s.mainStorage.GetAPIKeyByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get api key: %w", err)
+	}
+
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		return nil, customerror.ErrAPIKeyInvalid
+	}
+
+	return apiKey, nil
+}
+
+// hashToken return hex encoded SHA-256 of secret token, only hashes of tokens are stored.
+func hashToken(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes check scopes and remove duplicates, empty scopes means all scopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return slices.Clone(entity.AllScopes), nil
+	}
+
+	res := make([]string, 0, len(scopes))
+	for _, v := range entity.AllScopes {
+		if slices.Contains(scopes, v) {
+			res = append(res, v)
+		}
+	}
+	for _, v := range scopes {
+		if !slices.Contains(entity.AllScopes, v) {
+			return nil, fmt.Errorf("%w: %s", customerror.ErrAPIKeyUnknownScope, v)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// APIKeyServiceMock mock.
+type APIKeyServiceMock struct {
+	createAPIKeyPlain    string
+	createAPIKeyEntity   *entity.APIKey
+	createAPIKeyError    error
+	getUserAPIKeysEntity []*entity.APIKey
+	getUserAPIKeysError  error
+	revokeAPIKeyError    error
+	authenticateEntity   *entity.APIKey
+	authenticateError    error
+}
+
+// NewAPIKeyServiceMock Constructor for APIKeyServiceMock.
+func NewAPIKeyServiceMock() contract.ServiceAPIKey {
+	return &APIKeyServiceMock{}
+}
+
+// CreateAPIKey mock.
+func (s *APIKeyServiceMock) CreateAPIKey(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+	scopes []string,
+) (string, *entity.APIKey, error) {
+	return s.createAPIKeyPlain, s.createAPIKeyEntity, s.createAPIKeyError
+}
+
+// SetCreateAPIKeyResult mock.
+func (s *APIKeyServiceMock) SetCreateAPIKeyResult(plain string, e *entity.APIKey, err error) {
+	s.createAPIKeyPlain = plain
+	s.createAPIKeyEntity = e
+	s.createAPIKeyError = err
+}
+
+// GetUserAPIKeys mock.
+func (s *APIKeyServiceMock) GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	return s.getUserAPIKeysEntity, s.getUserAPIKeysError
+}
+
+// SetGetUserAPIKeysResult mock.
+func (s *APIKeyServiceMock) SetGetUserAPIKeysResult(e []*entity.APIKey, err error) {
+	s.getUserAPIKeysEntity = e
+	s.getUserAPIKeysError = err
+}
+
+// RevokeAPIKey mock.
+func (s *APIKeyServiceMock) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return s.revokeAPIKeyError
+}
+
+// SetRevokeAPIKeyResult mock.
+func (s *APIKeyServiceMock) SetRevokeAPIKeyResult(err error) {
+	s.revokeAPIKeyError = err
+}
+
+// Authenticate mock.
+func (s *APIKeyServiceMock) Authenticate(ctx context.Context, key string) (*entity.APIKey, error) {
+	return s.authenticateEntity, s.authenticateError
+}
+
+// SetAuthenticateResult mock.
+func (s *APIKeyServiceMock) SetAuthenticateResult(e *entity.APIKey, err error) {
+	s.authenticateEntity = e
+	s.authenticateError = err
+}
+
+// RestoreAPIKeys mock.
+func (s *APIKeyServiceMock) RestoreAPIKeys(ctx context.Context) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/storage"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+type ServiceAPIKeySuite struct {
+	suite.Suite
+}
+
+func TestServiceAPIKeySuite(t *testing.T) {
+	suite.Run(t, new(ServiceAPIKeySuite))
+}
+
+func (s *ServiceAPIKeySuite) TestLifecycle() {
+	ctx := context.Background()
+	srv := NewAPIKeyService(
+		logger.CreateLogger(zerolog.DebugLevel),
+		storage.NewMemoryStorage(),
+		storage.NewMemoryStorage(),
+	)
+	userID := uuid.New()
+
+	plain, key, err := srv.CreateAPIKey(ctx, userID, "backend", []string{entity.ScopeRead, entity.ScopeCreate})
+	s.Require().NoError(err)
+	s.Require().NotEqual(key.Hash, plain)
+	s.Require().Equal([]string{entity.ScopeCreate, entity.ScopeRead}, key.Scopes)
+
+	s.Run("authenticate with valid key", func() {
+		authenticated, err := srv.Authenticate(ctx, plain)
+		s.Require().NoError(err)
+		s.Require().Equal(userID, authenticated.UserID)
+		s.Require().True(authenticated.HasScope(entity.ScopeRead))
+		s.Require().False(authenticated.HasScope(entity.ScopeDelete))
+	})
+
+	s.Run("authenticate with unknown key", func() {
+		_, err := srv.Authenticate(ctx, "shk_unknown")
+		s.Require().ErrorIs(err, customerror.ErrAPIKeyInvalid)
+	})
+
+	s.Run("list user keys", func() {
+		keys, err := srv.GetUserAPIKeys(ctx, userID)
+		s.Require().NoError(err)
+		s.Require().Len(keys, 1)
+	})
+
+	s.Run("revoke key of another user", func() {
+		err := srv.RevokeAPIKey(ctx, uuid.New(), key.ID)
+		s.Require().ErrorIs(err, customerror.ErrAPIKeyNotFound)
+	})
+
+	s.Run("revoked key is not accepted", func() {
+		s.Require().NoError(srv.RevokeAPIKey(ctx, userID, key.ID))
+		_, err := srv.Authenticate(ctx, plain)
+		s.Require().ErrorIs(err, customerror.ErrAPIKeyInvalid)
+	})
+
+	s.Run("create key with unknown scope", func() {
+		_, _, err := srv.CreateAPIKey(ctx, userID, "bad", []string{"admin"})
+		s.Require().ErrorIs(err, customerror.ErrAPIKeyUnknownScope)
+	})
+
+	s.Run("create key with all scopes by default", func() {
+		_, key, err := srv.CreateAPIKey(ctx, userID, "all", nil)
+		s.Require().NoError(err)
+		s.Require().Equal(entity.AllScopes, key.Scopes)
+	})
+}
+
+func (s *ServiceAPIKeySuite) TestRestoreAPIKeys() {
+	ctx := context.Background()
+	const backupFile = "test-api-keys-db"
+	defer func() {
+		os.Remove(backupFile)
+		os.Remove(backupFile + ".api_keys")
+	}()
+	newService := func(mainStorage contract.Storage) contract.ServiceAPIKey {
+		backupStorage, err := storage.NewFileSystemStorage(backupFile)
+		s.Require().NoError(err)
+
+		return NewAPIKeyService(logger.CreateLogger(zerolog.DebugLevel), mainStorage, backupStorage)
+	}
+	srv := newService(storage.NewMemoryStorage())
+	userID := uuid.New()
+	plain, _, err := srv.CreateAPIKey(ctx, userID, "backend", nil)
+	s.Require().NoError(err)
+	revokedPlain, revoked, err := srv.CreateAPIKey(ctx, userID, "revoked", nil)
+	s.Require().NoError(err)
+	s.Require().NoError(srv.RevokeAPIKey(ctx, userID, revoked.ID))
+
+	// после перезапуска ключи восстанавливаются из резервного хранилища
+	srv = newService(storage.NewMemoryStorage())
+	restored, err := srv.RestoreAPIKeys(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(2, restored)
+
+	authenticated, err := srv.Authenticate(ctx, plain)
+	s.Require().NoError(err)
+	s.Require().Equal(userID, authenticated.UserID)
+	_, err = srv.Authenticate(ctx, revokedPlain)
+	s.Require().ErrorIs(err, customerror.ErrAPIKeyInvalid, "revoked key stays revoked")
+}
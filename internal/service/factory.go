@@ -5,6 +5,8 @@ import (
 
 	"github.com/vagafonov/shortener/internal/container"
 	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/linkcheck"
+	"github.com/vagafonov/shortener/pkg/preview"
 )
 
 // ErrUndefinedServiceType error for undefined service type.
@@ -20,6 +22,7 @@ func ServiceURLFactory(cnt *container.Container, t string) (contract.Service, er
 			cnt.GetMainStorage(),
 			cnt.GetBackupStorage(),
 			cnt.GetHasher(),
+			cnt.GetConfig(),
 		), nil
 	case "mock":
 		return NewURLServiceMock(), nil
@@ -43,3 +46,140 @@ func ServiceHealthCheckFactory(cnt *container.Container, t string) (contract.Ser
 		return nil, ErrUndefinedServiceType
 	}
 }
+
+// ServiceAPIKeyFactory return concrete service API key.
+func ServiceAPIKeyFactory(cnt *container.Container, t string) (contract.ServiceAPIKey, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		return NewAPIKeyService(
+			cnt.GetLogger(),
+			cnt.GetMainStorage(),
+			cnt.GetBackupStorage(),
+		), nil
+	case "mock":
+		return NewAPIKeyServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
+
+// ServiceAccountFactory return concrete service account.
+func ServiceAccountFactory(cnt *container.Container, t string) (contract.ServiceAccount, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		return NewAccountService(
+			cnt.GetLogger(),
+			cnt.GetMainStorage(),
+			cnt.GetBackupStorage(),
+			cnt.GetConfig(),
+		), nil
+	case "mock":
+		return NewAccountServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
+
+// ServiceOIDCFactory return concrete service OIDC.
+func ServiceOIDCFactory(cnt *container.Container, t string) (contract.ServiceOIDC, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		return NewOIDCService(
+			cnt.GetLogger(),
+			cnt.GetConfig(),
+		), nil
+	case "mock":
+		return NewOIDCServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
+
+// ServiceTeamFactory return concrete service of organizations and teams.
+func ServiceTeamFactory(cnt *container.Container, t string) (contract.ServiceTeam, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		return NewTeamService(
+			cnt.GetLogger(),
+			cnt.GetMainStorage(),
+			cnt.GetBackupStorage(),
+		), nil
+	case "mock":
+		return NewTeamServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
+
+// ServicePreviewFactory return concrete service of previews of destinations.
+func ServicePreviewFactory(cnt *container.Container, t string) (contract.ServicePreview, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		cfg := cnt.GetConfig()
+
+		return NewPreviewService(
+			cnt.GetLogger(),
+			cnt.GetMainStorage(),
+			cnt.GetBackupStorage(),
+			preview.NewFetcher(preview.Options{
+				Timeout:  cfg.PreviewTimeout,
+				MaxBytes: cfg.PreviewMaxBytes,
+				Retries:  cfg.PreviewRetries,
+			}),
+			cfg.PreviewWorkers,
+			cfg.PreviewQueueSize,
+		), nil
+	case "mock":
+		return NewPreviewServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
+
+// ServiceLinkCheckFactory return concrete service of checks of destinations.
+func ServiceLinkCheckFactory(cnt *container.Container, t string) (contract.ServiceLinkCheck, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		cfg := cnt.GetConfig()
+
+		return NewLinkCheckService(
+			cnt.GetLogger(),
+			cnt.GetMainStorage(),
+			cnt.GetBackupStorage(),
+			linkcheck.NewChecker(linkcheck.Options{
+				Timeout: cfg.LinkCheckTimeout,
+				PerHost: cfg.LinkCheckPerHost,
+			}),
+			cfg.LinkCheckInterval,
+			cfg.LinkCheckWorkers,
+			cfg.LinkCheckBrokenAfter,
+		), nil
+	case "mock":
+		return NewLinkCheckServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
+
+// ServiceIdempotencyFactory return concrete service of idempotency keys.
+func ServiceIdempotencyFactory(cnt *container.Container, t string) (contract.ServiceIdempotency, error) {
+	// TODO use enum
+	switch t {
+	case "real":
+		return NewIdempotencyService(
+			cnt.GetLogger(),
+			cnt.GetMainStorage(),
+			cnt.GetConfig().IdempotencyTTL,
+		), nil
+	case "mock":
+		return NewIdempotencyServiceMock(), nil
+	default:
+		return nil, ErrUndefinedServiceType
+	}
+}
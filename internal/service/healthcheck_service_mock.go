@@ -7,7 +7,9 @@ import (
 )
 
 // mock.
-type HealthCheckServiceMock struct{}
+type HealthCheckServiceMock struct {
+	pingError error
+}
 
 // NewHealthCheckServiceMock Constructor for HealthCheckServiceMock.
 func NewHealthCheckServiceMock() contract.ServiceHealthCheck {
@@ -16,5 +18,10 @@ func NewHealthCheckServiceMock() contract.ServiceHealthCheck {
 
 // mock.
 func (s *HealthCheckServiceMock) Ping(ctx context.Context) error {
-	return nil
+	return s.pingError
+}
+
+// SetPingResult set result of Ping.
+func (s *HealthCheckServiceMock) SetPingResult(err error) {
+	s.pingError = err
 }
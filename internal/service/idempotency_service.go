@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockTimeout key of request which has not completed in this time, e.g. because server stopped,
+	// can be used again. Reservation of running request is extended while it is held.
+	idempotencyLockTimeout = time.Minute
+)
+
+// errIdempotencyReservationLost key was reserved by other request after reservation of this request expired.
+var errIdempotencyReservationLost = errors.New("reservation of idempotency key is lost")
+
+type idempotencyService struct {
+	logger      *zerolog.Logger
+	mainStorage contract.Storage
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+// NewIdempotencyService Constructor for IdempotencyService.
+func NewIdempotencyService(
+	logger *zerolog.Logger,
+	mainStorage contract.Storage,
+	ttl time.Duration,
+) contract.ServiceIdempotency {
+	return &idempotencyService{
+		logger:      logger,
+		mainStorage: mainStorage,
+		ttl:         ttl,
+		lockTimeout: idempotencyLockTimeout,
+	}
+}
+
+// Begin reserve key for request and return reservation, saved record is returned when request is completed.
+// Key used for other request or by request in progress is rejected.
+func (s *idempotencyService) Begin(
+	ctx context.Context,
+	userID uuid.UUID,
+	key string,
+	requestHash string,
+) (*entity.IdempotencyRecord, error) {
+	if !isValidIdempotencyKey(key) {
+		return nil, customerror.ErrIdempotencyKeyInvalid
+	}
+
+	// время обрезано до точности базы данных, по нему резервирование находится при завершении
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	reservation := &entity.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.lockDuration()),
+	}
+	saved, err := s.mainStorage.ReserveIdempotencyKey(ctx, reservation)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reserve idempotency key: %w", err)
+	}
+
+	switch {
+	case saved == nil:
+		return reservation, nil
+	case saved.RequestHash != requestHash:
+		return nil, customerror.ErrIdempotencyKeyReused
+	case !saved.IsCompleted():
+		return nil, customerror.ErrIdempotencyKeyInProgress
+	default:
+		return saved, nil
+	}
+}
+
+// Hold extend reservation until returned function is called, so key of long request is not reserved again.
+func (s *idempotencyService) Hold(ctx context.Context, reservation *entity.IdempotencyRecord) func() {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.lockDuration() / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			record := *reservation
+			record.ExpiresAt = time.Now().UTC().Add(s.lockDuration())
+			ok, err := s.mainStorage.ExtendIdempotencyKey(ctx, &record)
+			if err == nil && !ok {
+				err = errIdempotencyReservationLost
+			}
+			if err != nil {
+				s.logger.Error().Err(err).Str("key", reservation.Key).Msg("cannot extend reservation of idempotency key")
+
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Complete save response of request by its reservation for configured time.
+func (s *idempotencyService) Complete(ctx context.Context, record *entity.IdempotencyRecord) error {
+	record.ExpiresAt = time.Now().UTC().Add(s.ttl)
+	ok, err := s.mainStorage.CompleteIdempotencyKey(ctx, record)
+	if err == nil && !ok {
+		err = errIdempotencyReservationLost
+	}
+	if err != nil {
+		return fmt.Errorf("cannot save response by idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release free key reserved by request without saving response.
+func (s *idempotencyService) Release(ctx context.Context, reservation *entity.IdempotencyRecord) error {
+	if err := s.mainStorage.DeleteIdempotencyKey(ctx, reservation); err != nil {
+		return fmt.Errorf("cannot release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// lockDuration time for which key of request in progress is reserved.
+func (s *idempotencyService) lockDuration() time.Duration {
+	return min(s.lockTimeout, s.ttl)
+}
+
+// isValidIdempotencyKey check that key is not empty and has only printable ASCII characters.
+func isValidIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// IdempotencyServiceMock mock.
+type IdempotencyServiceMock struct {
+	beginRecord   *entity.IdempotencyRecord
+	beginError    error
+	completeError error
+	releaseError  error
+}
+
+// NewIdempotencyServiceMock Constructor for IdempotencyServiceMock.
+func NewIdempotencyServiceMock() contract.ServiceIdempotency {
+	return &IdempotencyServiceMock{}
+}
+
+// Begin mock.
+func (s *IdempotencyServiceMock) Begin(
+	ctx context.Context,
+	userID uuid.UUID,
+	key string,
+	requestHash string,
+) (*entity.IdempotencyRecord, error) {
+	if s.beginRecord == nil && s.beginError == nil {
+		return &entity.IdempotencyRecord{UserID: userID, Key: key, RequestHash: requestHash}, nil
+	}
+
+	return s.beginRecord, s.beginError
+}
+
+// SetBeginResult mock.
+func (s *IdempotencyServiceMock) SetBeginResult(record *entity.IdempotencyRecord, err error) {
+	s.beginRecord = record
+	s.beginError = err
+}
+
+// Hold mock.
+func (s *IdempotencyServiceMock) Hold(ctx context.Context, reservation *entity.IdempotencyRecord) func() {
+	return func() {}
+}
+
+// Complete mock.
+func (s *IdempotencyServiceMock) Complete(ctx context.Context, record *entity.IdempotencyRecord) error {
+	return s.completeError
+}
+
+// SetCompleteResult mock.
+func (s *IdempotencyServiceMock) SetCompleteResult(err error) {
+	s.completeError = err
+}
+
+// Release mock.
+func (s *IdempotencyServiceMock) Release(ctx context.Context, reservation *entity.IdempotencyRecord) error {
+	return s.releaseError
+}
+
+// SetReleaseResult mock.
+func (s *IdempotencyServiceMock) SetReleaseResult(err error) {
+	s.releaseError = err
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/storage"
+)
+
+type ServiceIdempotencySuite struct {
+	suite.Suite
+}
+
+func TestServiceIdempotencySuite(t *testing.T) {
+	suite.Run(t, new(ServiceIdempotencySuite))
+}
+
+func (s *ServiceIdempotencySuite) TestLifecycle() {
+	ctx := context.Background()
+	srv := NewIdempotencyService(logger.CreateLogger(zerolog.DebugLevel), storage.NewMemoryStorage(), time.Hour)
+	userID := uuid.New()
+
+	reservation, err := srv.Begin(ctx, userID, "key", "hash")
+	s.Require().NoError(err)
+	s.Require().False(reservation.IsCompleted())
+
+	s.Run("request in progress", func() {
+		_, err := srv.Begin(ctx, userID, "key", "hash")
+		s.Require().ErrorIs(err, customerror.ErrIdempotencyKeyInProgress)
+	})
+
+	s.Run("key of other request", func() {
+		_, err := srv.Begin(ctx, userID, "key", "other")
+		s.Require().ErrorIs(err, customerror.ErrIdempotencyKeyReused)
+	})
+
+	s.Run("invalid key", func() {
+		_, err := srv.Begin(ctx, userID, "key\n", "hash")
+		s.Require().ErrorIs(err, customerror.ErrIdempotencyKeyInvalid)
+		_, err = srv.Begin(ctx, userID, strings.Repeat("k", 256), "hash")
+		s.Require().ErrorIs(err, customerror.ErrIdempotencyKeyInvalid)
+	})
+
+	s.Run("completed request is replayed", func() {
+		record := *reservation
+		record.Status = http.StatusCreated
+		record.Body = []byte("http://test/abc")
+		s.Require().NoError(srv.Complete(ctx, &record))
+		saved, err := srv.Begin(ctx, userID, "key", "hash")
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusCreated, saved.Status)
+		s.Require().Equal([]byte("http://test/abc"), saved.Body)
+		s.Require().WithinDuration(time.Now().Add(time.Hour), saved.ExpiresAt, time.Minute)
+	})
+
+	s.Run("released key can be used again", func() {
+		reservation, err := srv.Begin(ctx, userID, "released", "hash")
+		s.Require().NoError(err)
+		s.Require().NoError(srv.Release(ctx, reservation))
+		saved, err := srv.Begin(ctx, userID, "released", "other")
+		s.Require().NoError(err)
+		s.Require().False(saved.IsCompleted())
+	})
+}
+
+func (s *ServiceIdempotencySuite) TestExpiredReservation() {
+	ctx := context.Background()
+	srv := &idempotencyService{
+		logger:      logger.CreateLogger(zerolog.DebugLevel),
+		mainStorage: storage.NewMemoryStorage(),
+		ttl:         time.Hour,
+		lockTimeout: time.Millisecond,
+	}
+	userID := uuid.New()
+
+	stale, err := srv.Begin(ctx, userID, "key", "hash")
+	s.Require().NoError(err)
+	time.Sleep(2 * time.Millisecond)
+	reservation, err := srv.Begin(ctx, userID, "key", "hash")
+	s.Require().NoError(err, "expired reservation is replaced")
+
+	s.Run("stale request cannot complete or release key", func() {
+		record := *stale
+		record.Status = http.StatusCreated
+		s.Require().Error(srv.Complete(ctx, &record))
+		s.Require().NoError(srv.Release(ctx, stale))
+
+		record = *reservation
+		record.Status = http.StatusAccepted
+		s.Require().NoError(srv.Complete(ctx, &record))
+		saved, err := srv.Begin(ctx, userID, "key", "hash")
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusAccepted, saved.Status)
+	})
+}
+
+func (s *ServiceIdempotencySuite) TestHold() {
+	ctx := context.Background()
+	srv := &idempotencyService{
+		logger:      logger.CreateLogger(zerolog.DebugLevel),
+		mainStorage: storage.NewMemoryStorage(),
+		ttl:         time.Hour,
+		lockTimeout: 20 * time.Millisecond,
+	}
+	userID := uuid.New()
+
+	reservation, err := srv.Begin(ctx, userID, "key", "hash")
+	s.Require().NoError(err)
+	stop := srv.Hold(ctx, reservation)
+	time.Sleep(50 * time.Millisecond)
+	_, err = srv.Begin(ctx, userID, "key", "hash")
+	s.Require().ErrorIs(err, customerror.ErrIdempotencyKeyInProgress, "reservation is extended while it is held")
+	stop()
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = srv.Begin(ctx, userID, "key", "hash")
+	s.Require().NoError(err, "reservation expires after it is not held")
+}
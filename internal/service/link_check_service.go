@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/linkcheck"
+)
+
+// linkCheckPageSize number of URLs read from storage at once.
+const linkCheckPageSize = 500
+
+type linkCheckService struct {
+	logger        *zerolog.Logger
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+	checker       *linkcheck.Checker
+	interval      time.Duration
+	workers       int
+	brokenAfter   int
+}
+
+// NewLinkCheckService Constructor for LinkCheckService.
+func NewLinkCheckService(
+	logger *zerolog.Logger,
+	mainStorage contract.Storage,
+	backupStorage contract.Storage,
+	checker *linkcheck.Checker,
+	interval time.Duration,
+	workers int,
+	brokenAfter int,
+) contract.ServiceLinkCheck {
+	return &linkCheckService{
+		logger:        logger,
+		mainStorage:   mainStorage,
+		backupStorage: backupStorage,
+		checker:       checker,
+		interval:      interval,
+		workers:       workers,
+		brokenAfter:   brokenAfter,
+	}
+}
+
+// Run check URLs at start and then every interval until context is done.
+// URLs checked during last interval are skipped, so restarts of server do not repeat checks.
+func (s *linkCheckService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		checked, err := s.CheckAll(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("cannot check urls")
+		}
+		s.logger.Info().Int("checked", checked).Msg("urls are checked")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll walk live URLs page by page and check due ones with pool of workers.
+func (s *linkCheckService) CheckAll(ctx context.Context) (int, error) {
+	jobs := make(chan *entity.URL)
+	wg := sync.WaitGroup{}
+	wg.Add(s.workers)
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				s.check(ctx, url)
+			}
+		}()
+	}
+
+	checked, err := s.walk(ctx, jobs)
+	close(jobs)
+	wg.Wait()
+
+	return checked, err
+}
+
+// walk send due URLs to jobs.
+func (s *linkCheckService) walk(ctx context.Context, jobs chan<- *entity.URL) (int, error) {
+	dueBefore := time.Now().Add(-s.interval)
+	checked := 0
+	after := ""
+	for {
+		urls, err := s.mainStorage.GetLiveURLs(ctx, after, linkCheckPageSize)
+		if err != nil {
+			return checked, err
+		}
+		for _, v := range urls {
+			if v.LinkCheck != nil && v.LinkCheck.CheckedAt.After(dueBefore) {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return checked, ctx.Err()
+			case jobs <- v:
+				checked++
+			}
+		}
+		if len(urls) < linkCheckPageSize {
+			return checked, nil
+		}
+		after = urls[len(urls)-1].Short
+	}
+}
+
+func (s *linkCheckService) check(ctx context.Context, url *entity.URL) {
+	status, err := s.checker.Check(ctx, url.Original)
+	if ctx.Err() != nil {
+		return
+	}
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	result := entity.NextLinkCheck(url.LinkCheck, status, errMsg, time.Now().UTC(), s.brokenAfter)
+	if result.Broken && !url.IsBroken() {
+		s.logger.Info().Str("short", url.Short).Int("status", status).Str("error", errMsg).Msg("destination is broken")
+	}
+
+	patch := &entity.URLPatch{LinkCheck: result}
+	if err = s.mainStorage.UpdateURL(ctx, url.Short, patch); err != nil {
+		s.logger.Error().Err(err).Str("short", url.Short).Msg("cannot save link check in main storage")
+
+		return
+	}
+	if err = s.backupStorage.UpdateURL(ctx, url.Short, patch); err != nil {
+		s.logger.Error().Err(err).Str("short", url.Short).Msg("cannot save link check in backup storage")
+	}
+}
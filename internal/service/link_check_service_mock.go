@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/internal/contract"
+)
+
+// LinkCheckServiceMock mock.
+type LinkCheckServiceMock struct {
+	checkAllResultChecked int
+	checkAllResultError   error
+}
+
+// NewLinkCheckServiceMock Constructor for LinkCheckServiceMock.
+func NewLinkCheckServiceMock() contract.ServiceLinkCheck {
+	return &LinkCheckServiceMock{}
+}
+
+// CheckAll mock.
+func (s *LinkCheckServiceMock) CheckAll(ctx context.Context) (int, error) {
+	return s.checkAllResultChecked, s.checkAllResultError
+}
+
+// SetCheckAllResult mock.
+func (s *LinkCheckServiceMock) SetCheckAllResult(checked int, err error) {
+	s.checkAllResultChecked = checked
+	s.checkAllResultError = err
+}
+
+// Run mock.
+func (s *LinkCheckServiceMock) Run(ctx context.Context) {
+}
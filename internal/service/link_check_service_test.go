@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/storage"
+	"github.com/vagafonov/shortener/pkg/linkcheck"
+)
+
+type ServiceLinkCheckSuite struct {
+	suite.Suite
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+	destination   *httptest.Server
+}
+
+func TestServiceLinkCheckSuite(t *testing.T) {
+	suite.Run(t, new(ServiceLinkCheckSuite))
+}
+
+func (s *ServiceLinkCheckSuite) SetupTest() {
+	s.mainStorage = storage.NewMemoryStorage()
+	s.backupStorage = storage.NewMemoryStorage()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	s.destination = httptest.NewServer(mux)
+}
+
+func (s *ServiceLinkCheckSuite) TearDownTest() {
+	s.destination.Close()
+}
+
+func (s *ServiceLinkCheckSuite) newService(interval time.Duration) contract.ServiceLinkCheck {
+	return NewLinkCheckService(
+		logger.CreateLogger(zerolog.DebugLevel),
+		s.mainStorage,
+		s.backupStorage,
+		linkcheck.NewChecker(linkcheck.Options{AllowPrivate: true}),
+		interval,
+		2,
+		2,
+	)
+}
+
+func (s *ServiceLinkCheckSuite) TestCheckAll() {
+	ctx := context.Background()
+	userID := uuid.New()
+	for short, path := range map[string]string{"ok": "/ok", "gone": "/gone", "deleted": "/ok?deleted"} {
+		for _, st := range []contract.Storage{s.mainStorage, s.backupStorage} {
+			_, err := st.Add(ctx, short, s.destination.URL+path, userID)
+			s.Require().NoError(err)
+		}
+	}
+	s.Require().NoError(s.mainStorage.DeleteURLsByUser(ctx, userID, []string{"deleted"}))
+
+	s.Run("first check", func() {
+		checked, err := s.newService(time.Hour).CheckAll(ctx)
+		s.Require().NoError(err)
+		s.Require().Equal(2, checked)
+
+		url, err := s.mainStorage.GetByHash(ctx, "gone")
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusNotFound, url.LinkCheck.Status)
+		s.Require().Equal(1, url.LinkCheck.Failures)
+		s.Require().False(url.IsBroken())
+
+		url, err = s.backupStorage.GetByHash(ctx, "ok")
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, url.LinkCheck.Status)
+		s.Require().Zero(url.LinkCheck.Failures)
+	})
+
+	s.Run("recently checked urls are skipped", func() {
+		checked, err := s.newService(time.Hour).CheckAll(ctx)
+		s.Require().NoError(err)
+		s.Require().Zero(checked)
+	})
+
+	s.Run("broken after failures in a row", func() {
+		checked, err := s.newService(time.Nanosecond).CheckAll(ctx)
+		s.Require().NoError(err)
+		s.Require().Equal(2, checked)
+
+		url, err := s.mainStorage.GetByHash(ctx, "gone")
+		s.Require().NoError(err)
+		s.Require().True(url.IsBroken())
+		s.Require().Equal(2, url.LinkCheck.Failures)
+
+		url, err = s.mainStorage.GetByHash(ctx, "ok")
+		s.Require().NoError(err)
+		s.Require().False(url.IsBroken())
+	})
+
+	s.Run("unreachable destination", func() {
+		s.destination.Close()
+		_, err := s.newService(time.Nanosecond).CheckAll(ctx)
+		s.Require().NoError(err)
+
+		url, err := s.mainStorage.GetByHash(ctx, "ok")
+		s.Require().NoError(err)
+		s.Require().Zero(url.LinkCheck.Status)
+		s.Require().NotEmpty(url.LinkCheck.Error)
+		s.Require().Equal(1, url.LinkCheck.Failures)
+	})
+}
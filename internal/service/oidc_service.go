@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/config"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/oidc"
+)
+
+// oidcUserNamespace namespace of userID derived from issuer and subject of identity provider.
+var oidcUserNamespace = uuid.MustParse("9b0b7c1e-52c3-4a53-8f0e-7d3c0d1f6a21")
+
+type oidcService struct {
+	logger *zerolog.Logger
+	client *oidc.Client
+	issuer string
+}
+
+// NewOIDCService Constructor for OIDCService.
+func NewOIDCService(logger *zerolog.Logger, cfg *config.Config) contract.ServiceOIDC {
+	return &oidcService{
+		logger: logger,
+		client: oidc.NewClient(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       []string{"openid", "email"},
+		}, nil),
+		issuer: cfg.OIDCIssuer,
+	}
+}
+
+// AuthCodeURL return URL of identity provider where user is redirected to login.
+func (s *oidcService) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	return s.client.AuthCodeURL(ctx, state, nonce, verifier)
+}
+
+// Exchange exchange authorization code for verified identity of user.
+// Subject of provider is mapped to the same userID on every login.
+func (s *oidcService) Exchange(
+	ctx context.Context,
+	code string,
+	verifier string,
+	nonce string,
+) (*entity.Identity, error) {
+	claims, err := s.client.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrProvider) || isTokenError(err) {
+			s.logger.Info().Err(err).Msg("oidc login rejected")
+
+			return nil, fmt.Errorf("%w: %w", customerror.ErrOIDCLogin, err)
+		}
+
+		return nil, err
+	}
+
+	return &entity.Identity{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		UserID:  OIDCUserID(s.issuer, claims.Subject),
+	}, nil
+}
+
+// OIDCUserID return stable userID of subject of identity provider.
+func OIDCUserID(issuer string, subject string) uuid.UUID {
+	return uuid.NewSHA1(oidcUserNamespace, []byte(issuer+"\n"+subject))
+}
+
+func isTokenError(err error) bool {
+	for _, v := range []error{
+		oidc.ErrTokenMalformed,
+		oidc.ErrTokenAlg,
+		oidc.ErrTokenSignature,
+		oidc.ErrTokenClaims,
+		oidc.ErrTokenExpired,
+		oidc.ErrUnknownKey,
+	} {
+		if errors.Is(err, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// OIDCServiceMock mock.
+type OIDCServiceMock struct {
+	authCodeURL      string
+	authCodeURLError error
+	exchangeIdentity *entity.Identity
+	exchangeError    error
+}
+
+// NewOIDCServiceMock Constructor for OIDCServiceMock.
+func NewOIDCServiceMock() contract.ServiceOIDC {
+	return &OIDCServiceMock{}
+}
+
+// AuthCodeURL mock.
+func (s *OIDCServiceMock) AuthCodeURL(
+	ctx context.Context,
+	state string,
+	nonce string,
+	verifier string,
+) (string, error) {
+	return s.authCodeURL, s.authCodeURLError
+}
+
+// SetAuthCodeURLResult mock.
+func (s *OIDCServiceMock) SetAuthCodeURLResult(u string, err error) {
+	s.authCodeURL = u
+	s.authCodeURLError = err
+}
+
+// Exchange mock.
+func (s *OIDCServiceMock) Exchange(
+	ctx context.Context,
+	code string,
+	verifier string,
+	nonce string,
+) (*entity.Identity, error) {
+	return s.exchangeIdentity, s.exchangeError
+}
+
+// SetExchangeResult mock.
+func (s *OIDCServiceMock) SetExchangeResult(e *entity.Identity, err error) {
+	s.exchangeIdentity = e
+	s.exchangeError = err
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/preview"
+)
+
+// previewJob URL whose destination must be fetched.
+type previewJob struct {
+	short    string
+	original string
+}
+
+type previewService struct {
+	logger        *zerolog.Logger
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+	fetcher       *preview.Fetcher
+	workers       int
+	jobs          chan previewJob
+}
+
+// NewPreviewService Constructor for PreviewService.
+// Jobs which do not fit into queue are dropped, so creation of URLs never waits for fetching.
+func NewPreviewService(
+	logger *zerolog.Logger,
+	mainStorage contract.Storage,
+	backupStorage contract.Storage,
+	fetcher *preview.Fetcher,
+	workers int,
+	queueSize int,
+) contract.ServicePreview {
+	return &previewService{
+		logger:        logger,
+		mainStorage:   mainStorage,
+		backupStorage: backupStorage,
+		fetcher:       fetcher,
+		workers:       workers,
+		jobs:          make(chan previewJob, queueSize),
+	}
+}
+
+// Enqueue schedule fetching of preview of URL.
+func (s *previewService) Enqueue(url *entity.URL) bool {
+	select {
+	case s.jobs <- previewJob{short: url.Short, original: url.Original}:
+		return true
+	default:
+		s.logger.Warn().Str("short", url.Short).Msg("preview queue is full, preview is not fetched")
+
+		return false
+	}
+}
+
+// Run fetch previews with pool of workers until context is done.
+func (s *previewService) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	wg.Add(s.workers)
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.jobs:
+					s.fetch(ctx, job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *previewService) fetch(ctx context.Context, job previewJob) {
+	m, err := s.fetcher.Fetch(ctx, job.original)
+	if err != nil {
+		s.logger.Info().Err(err).Str("short", job.short).Msg("cannot fetch preview of url")
+
+		return
+	}
+
+	patch := &entity.URLPatch{Preview: &entity.LinkPreview{
+		Title:       m.Title,
+		Description: m.Description,
+		Image:       m.Image,
+		SiteName:    m.SiteName,
+		Favicon:     m.Favicon,
+		FetchedAt:   time.Now().UTC(),
+	}}
+	if err = s.mainStorage.UpdateURL(ctx, job.short, patch); err != nil {
+		s.logger.Error().Err(err).Str("short", job.short).Msg("cannot save preview in main storage")
+
+		return
+	}
+	if err = s.backupStorage.UpdateURL(ctx, job.short, patch); err != nil {
+		s.logger.Error().Err(err).Str("short", job.short).Msg("cannot save preview in backup storage")
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// PreviewServiceMock mock.
+type PreviewServiceMock struct {
+	enqueued []string
+}
+
+// NewPreviewServiceMock Constructor for PreviewServiceMock.
+func NewPreviewServiceMock() contract.ServicePreview {
+	return &PreviewServiceMock{}
+}
+
+// Enqueue mock.
+func (s *PreviewServiceMock) Enqueue(url *entity.URL) bool {
+	s.enqueued = append(s.enqueued, url.Short)
+
+	return true
+}
+
+// Run mock.
+func (s *PreviewServiceMock) Run(ctx context.Context) {
+}
+
+// Enqueued return short URLs passed to Enqueue and forget them.
+func (s *PreviewServiceMock) Enqueued() []string {
+	res := s.enqueued
+	s.enqueued = nil
+
+	return res
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/storage"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/preview"
+)
+
+type ServicePreviewSuite struct {
+	suite.Suite
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+}
+
+func TestServicePreviewSuite(t *testing.T) {
+	suite.Run(t, new(ServicePreviewSuite))
+}
+
+// SetupTest file storages are used, because workers update URLs concurrently with reads of test.
+func (s *ServicePreviewSuite) SetupTest() {
+	var err error
+	s.mainStorage, err = storage.NewFileSystemStorage(filepath.Join(s.T().TempDir(), "main.json"))
+	s.Require().NoError(err)
+	s.backupStorage, err = storage.NewFileSystemStorage(filepath.Join(s.T().TempDir(), "backup.json"))
+	s.Require().NoError(err)
+}
+
+func (s *ServicePreviewSuite) TearDownTest() {
+	s.mainStorage.Close()
+	s.backupStorage.Close()
+}
+
+func (s *ServicePreviewSuite) TestFetchPreview() {
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<title>Docs</title><meta property="og:image" content="/cover.png">`)) //nolint:errcheck
+	}))
+	defer destination.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := NewPreviewService(
+		logger.CreateLogger(zerolog.DebugLevel),
+		s.mainStorage,
+		s.backupStorage,
+		preview.NewFetcher(preview.Options{AllowPrivate: true}),
+		2,
+		10,
+	)
+	go srv.Run(ctx)
+
+	for _, st := range []contract.Storage{s.mainStorage, s.backupStorage} {
+		_, err := st.Add(ctx, "docs", destination.URL+"/docs", uuid.New())
+		s.Require().NoError(err)
+	}
+	s.Require().True(srv.Enqueue(&entity.URL{Short: "docs", Original: destination.URL + "/docs"}))
+
+	for _, st := range []contract.Storage{s.mainStorage, s.backupStorage} {
+		s.Require().Eventually(func() bool {
+			url, err := st.GetVisibleByHash(ctx, "docs", uuid.Nil)
+
+			return err == nil && url.Preview != nil
+		}, time.Second, 10*time.Millisecond)
+		url, err := st.GetVisibleByHash(ctx, "docs", uuid.Nil)
+		s.Require().NoError(err)
+		s.Require().Equal("Docs", url.Preview.Title)
+		s.Require().Equal(destination.URL+"/cover.png", url.Preview.Image)
+		s.Require().Equal(destination.URL+"/favicon.ico", url.Preview.Favicon)
+	}
+}
+
+func (s *ServicePreviewSuite) TestQueueIsFull() {
+	srv := NewPreviewService(
+		logger.CreateLogger(zerolog.DebugLevel),
+		s.mainStorage,
+		s.backupStorage,
+		preview.NewFetcher(preview.Options{}),
+		1,
+		1,
+	)
+	s.Require().True(srv.Enqueue(&entity.URL{Short: "a", Original: "https://example.com/a"}))
+	s.Require().False(srv.Enqueue(&entity.URL{Short: "b", Original: "https://example.com/b"}))
+}
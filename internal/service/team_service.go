@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+type teamService struct {
+	logger        *zerolog.Logger
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+}
+
+// NewTeamService Constructor for TeamService.
+func NewTeamService(
+	logger *zerolog.Logger,
+	mainStorage contract.Storage,
+	backupStorage contract.Storage,
+) contract.ServiceTeam {
+	return &teamService{
+		logger:        logger,
+		mainStorage:   mainStorage,
+		backupStorage: backupStorage,
+	}
+}
+
+// CreateOrganization create organization owned by user.
+func (s *teamService) CreateOrganization(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+) (*entity.Organization, error) {
+	org := &entity.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		OwnerID:   userID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.mainStorage.AddOrganization(ctx, org); err != nil {
+		return nil, fmt.Errorf("cannot save organization: %w", err)
+	}
+	if err := s.backupStorage.AddOrganization(ctx, org); err != nil {
+		return nil, fmt.Errorf("cannot save organization in backup storage: %w", err)
+	}
+
+	return org, nil
+}
+
+// CreateTeam create team in organization. Only owner of organization can create teams, creator becomes team admin.
+func (s *teamService) CreateTeam(
+	ctx context.Context,
+	userID uuid.UUID,
+	orgID uuid.UUID,
+	name string,
+) (*entity.Team, error) {
+	org, err := s.mainStorage.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get organization: %w", err)
+	}
+	if org == nil {
+		return nil, customerror.ErrOrganizationNotFound
+	}
+	if org.OwnerID != userID {
+		return nil, customerror.ErrTeamForbidden
+	}
+
+	now := time.Now().UTC()
+	team := &entity.Team{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Name:           name,
+		CreatedAt:      now,
+	}
+	if err = s.mainStorage.AddTeam(ctx, team); err != nil {
+		return nil, fmt.Errorf("cannot save team: %w", err)
+	}
+	if err = s.backupStorage.AddTeam(ctx, team); err != nil {
+		return nil, fmt.Errorf("cannot save team in backup storage: %w", err)
+	}
+
+	err = s.setTeamMember(ctx, &entity.TeamMember{
+		TeamID:    team.ID,
+		UserID:    userID,
+		Role:      entity.RoleAdmin,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot add team admin: %w", err)
+	}
+
+	return team, nil
+}
+
+// GetUserTeams get all memberships of user.
+func (s *teamService) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	return s.mainStorage.GetUserTeams(ctx, userID)
+}
+
+// GetTeamMembers get members of team. Only members can see each other.
+func (s *teamService) GetTeamMembers(
+	ctx context.Context,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+) ([]*entity.TeamMember, error) {
+	if _, err := s.requireRole(ctx, teamID, userID, entity.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.mainStorage.GetTeamMembers(ctx, teamID)
+}
+
+// SetTeamMember add member to team or change role of member. Only team admin can manage members.
+func (s *teamService) SetTeamMember(
+	ctx context.Context,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+	memberID uuid.UUID,
+	role string,
+) error {
+	if !entity.IsRole(role) {
+		return customerror.ErrTeamUnknownRole
+	}
+	if _, err := s.requireRole(ctx, teamID, userID, entity.RoleAdmin); err != nil {
+		return err
+	}
+	if role != entity.RoleAdmin {
+		if err := s.checkLastAdmin(ctx, teamID, memberID); err != nil {
+			return err
+		}
+	}
+
+	err := s.setTeamMember(ctx, &entity.TeamMember{
+		TeamID:    teamID,
+		UserID:    memberID,
+		Role:      role,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set team member: %w", err)
+	}
+
+	return nil
+}
+
+// setTeamMember save member of team in main and backup storages.
+func (s *teamService) setTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	if err := s.mainStorage.SetTeamMember(ctx, member); err != nil {
+		return err
+	}
+	if err := s.backupStorage.SetTeamMember(ctx, member); err != nil {
+		return fmt.Errorf("in backup storage: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTeamMember remove member from team. Admin can remove anybody, other members can only leave team.
+func (s *teamService) RemoveTeamMember(
+	ctx context.Context,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+	memberID uuid.UUID,
+) error {
+	role := entity.RoleAdmin
+	if userID == memberID {
+		role = entity.RoleViewer
+	}
+	if _, err := s.requireRole(ctx, teamID, userID, role); err != nil {
+		return err
+	}
+	if err := s.checkLastAdmin(ctx, teamID, memberID); err != nil {
+		return err
+	}
+
+	if err := s.mainStorage.RemoveTeamMember(ctx, teamID, memberID); err != nil {
+		return fmt.Errorf("cannot remove team member: %w", err)
+	}
+	if err := s.backupStorage.RemoveTeamMember(ctx, teamID, memberID); err != nil {
+		return fmt.Errorf("cannot remove team member in backup storage: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreTeams restore organizations, teams and their members from backup storage.
+func (s *teamService) RestoreTeams(ctx context.Context) (int, error) {
+	orgs, err := s.backupStorage.GetAllOrganizations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get all organizations: %w", err)
+	}
+	for _, v := range orgs {
+		if err = s.mainStorage.AddOrganization(ctx, v); err != nil {
+			return 0, fmt.Errorf("failed to add organization: %w", err)
+		}
+	}
+
+	teams, err := s.backupStorage.GetAllTeams(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get all teams: %w", err)
+	}
+	for i, v := range teams {
+		if err = s.mainStorage.AddTeam(ctx, v); err != nil {
+			return i, fmt.Errorf("failed to add team: %w", err)
+		}
+	}
+
+	members, err := s.backupStorage.GetAllTeamMembers(ctx)
+	if err != nil {
+		return len(teams), fmt.Errorf("failed to get all team members: %w", err)
+	}
+	for _, v := range members {
+		if err = s.mainStorage.SetTeamMember(ctx, v); err != nil {
+			return len(teams), fmt.Errorf("failed to add team member: %w", err)
+		}
+	}
+
+	return len(teams), nil
+}
+
+// TransferURL transfer URL to another user or to team.
+// User must be able to edit URL, team links can be moved out of team only by team admin.
+// When URL is transferred to team, user must be at least editor of that team.
+func (s *teamService) TransferURL(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+	toUserID uuid.UUID,
+	toTeamID uuid.UUID,
+) error {
+	url, err := s.mainStorage.GetByHash(ctx, short)
+	if err != nil && !errors.Is(err, customerror.ErrURLDeleted) {
+		return fmt.Errorf("cannot get url: %w", err)
+	}
+	if url == nil {
+		return customerror.ErrURLNotFound
+	}
+
+	if url.OwnedByTeam() {
+		role := entity.RoleAdmin
+		if url.TeamID == toTeamID {
+			role = entity.RoleEditor
+		}
+		if _, err = s.requireRole(ctx, url.TeamID, userID, role); err != nil {
+			if errors.Is(err, customerror.ErrTeamNotFound) {
+				return customerror.ErrURLNotFound
+			}
+
+			return err
+		}
+	} else if url.UserID != userID {
+		return customerror.ErrURLNotFound
+	}
+
+	// создатель ссылки сохраняется, если ссылка передаётся команде
+	ownerID := toUserID
+	if toTeamID != uuid.Nil {
+		if _, err = s.requireRole(ctx, toTeamID, userID, entity.RoleEditor); err != nil {
+			return err
+		}
+		ownerID = url.UserID
+	}
+
+	if err = s.mainStorage.SetURLOwner(ctx, short, ownerID, toTeamID); err != nil {
+		return fmt.Errorf("cannot transfer url in main storage: %w", err)
+	}
+	if err = s.backupStorage.SetURLOwner(ctx, short, ownerID, toTeamID); err != nil {
+		return fmt.Errorf("cannot transfer url in backup storage: %w", err)
+	}
+
+	return nil
+}
+
+// requireRole check that team exists and user has at least given role in it.
+func (s *teamService) requireRole(
+	ctx context.Context,
+	teamID uuid.UUID,
+	userID uuid.UUID,
+	role string,
+) (*entity.TeamMember, error) {
+	team, err := s.mainStorage.GetTeam(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get team: %w", err)
+	}
+	if team == nil {
+		return nil, customerror.ErrTeamNotFound
+	}
+
+	member, err := s.mainStorage.GetTeamMember(ctx, teamID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get team member: %w", err)
+	}
+	if member == nil {
+		// не участникам команды не раскрываем её существование
+		return nil, customerror.ErrTeamNotFound
+	}
+	if !member.HasRole(role) {
+		return nil, customerror.ErrTeamForbidden
+	}
+
+	return member, nil
+}
+
+// checkLastAdmin return error if member is the only admin of team.
+func (s *teamService) checkLastAdmin(ctx context.Context, teamID uuid.UUID, memberID uuid.UUID) error {
+	members, err := s.mainStorage.GetTeamMembers(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("cannot get team members: %w", err)
+	}
+
+	admins := 0
+	isAdmin := false
+	for _, v := range members {
+		if v.Role != entity.RoleAdmin {
+			continue
+		}
+		admins++
+		if v.UserID == memberID {
+			isAdmin = true
+		}
+	}
+	if isAdmin && admins == 1 {
+		return customerror.ErrTeamLastAdmin
+	}
+
+	return nil
+}
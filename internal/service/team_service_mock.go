@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// TeamServiceMock mock.
+type TeamServiceMock struct {
+	createOrganizationEntity *entity.Organization
+	createOrganizationError  error
+	createTeamEntity         *entity.Team
+	createTeamError          error
+	userTeamsEntity          []*entity.TeamMember
+	userTeamsError           error
+	teamMembersEntity        []*entity.TeamMember
+	teamMembersError         error
+	setTeamMemberError       error
+	removeTeamMemberError    error
+	transferURLError         error
+}
+
+// NewTeamServiceMock Constructor for TeamServiceMock.
+func NewTeamServiceMock() contract.ServiceTeam {
+	return &TeamServiceMock{}
+}
+
+// CreateOrganization mock.
+func (s *TeamServiceMock) CreateOrganization(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+) (*entity.Organization, error) {
+	return s.createOrganizationEntity, s.createOrganizationError
+}
+
+// SetCreateOrganizationResult mock.
+func (s *TeamServiceMock) SetCreateOrganizationResult(e *entity.Organization, err error) {
+	s.createOrganizationEntity = e
+	s.createOrganizationError = err
+}
+
+// CreateTeam mock.
+func (s *TeamServiceMock) CreateTeam(
+	ctx context.Context,
+	userID uuid.UUID,
+	orgID uuid.UUID,
+	name string,
+) (*entity.Team, error) {
+	return s.createTeamEntity, s.createTeamError
+}
+
+// SetCreateTeamResult mock.
+func (s *TeamServiceMock) SetCreateTeamResult(e *entity.Team, err error) {
+	s.createTeamEntity = e
+	s.createTeamError = err
+}
+
+// GetUserTeams mock.
+func (s *TeamServiceMock) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	return s.userTeamsEntity, s.userTeamsError
+}
+
+// SetGetUserTeamsResult mock.
+func (s *TeamServiceMock) SetGetUserTeamsResult(e []*entity.TeamMember, err error) {
+	s.userTeamsEntity = e
+	s.userTeamsError = err
+}
+
+// GetTeamMembers mock.
+func (s *TeamServiceMock) GetTeamMembers(
+	ctx context.Context,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+) ([]*entity.TeamMember, error) {
+	return s.teamMembersEntity, s.teamMembersError
+}
+
+// SetGetTeamMembersResult mock.
+func (s *TeamServiceMock) SetGetTeamMembersResult(e []*entity.TeamMember, err error) {
+	s.teamMembersEntity = e
+	s.teamMembersError = err
+}
+
+// SetTeamMember mock.
+func (s *TeamServiceMock) SetTeamMember(
+	ctx context.Context,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+	memberID uuid.UUID,
+	role string,
+) error {
+	return s.setTeamMemberError
+}
+
+// SetSetTeamMemberResult mock.
+func (s *TeamServiceMock) SetSetTeamMemberResult(err error) {
+	s.setTeamMemberError = err
+}
+
+// RemoveTeamMember mock.
+func (s *TeamServiceMock) RemoveTeamMember(
+	ctx context.Context,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+	memberID uuid.UUID,
+) error {
+	return s.removeTeamMemberError
+}
+
+// SetRemoveTeamMemberResult mock.
+func (s *TeamServiceMock) SetRemoveTeamMemberResult(err error) {
+	s.removeTeamMemberError = err
+}
+
+// TransferURL mock.
+func (s *TeamServiceMock) TransferURL(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+	toUserID uuid.UUID,
+	toTeamID uuid.UUID,
+) error {
+	return s.transferURLError
+}
+
+// SetTransferURLResult mock.
+func (s *TeamServiceMock) SetTransferURLResult(err error) {
+	s.transferURLError = err
+}
+
+// RestoreTeams mock.
+func (s *TeamServiceMock) RestoreTeams(ctx context.Context) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/config"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/internal/logger"
+	"github.com/vagafonov/shortener/internal/storage"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/hasher"
+)
+
+type ServiceTeamSuite struct {
+	suite.Suite
+	mainStorage   contract.Storage
+	backupStorage contract.Storage
+	service       contract.ServiceTeam
+	adminID       uuid.UUID
+	team          *entity.Team
+}
+
+func TestServiceTeamSuite(t *testing.T) {
+	suite.Run(t, new(ServiceTeamSuite))
+}
+
+func (s *ServiceTeamSuite) SetupTest() {
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	s.mainStorage = storage.NewMemoryStorage()
+	s.backupStorage = storage.NewMemoryStorage()
+	s.service = NewTeamService(logger.CreateLogger(cfg.LogLevel), s.mainStorage, s.backupStorage)
+
+	ctx := context.Background()
+	s.adminID = uuid.New()
+	org, err := s.service.CreateOrganization(ctx, s.adminID, "acme")
+	s.Require().NoError(err)
+	s.team, err = s.service.CreateTeam(ctx, s.adminID, org.ID, "marketing")
+	s.Require().NoError(err)
+}
+
+func (s *ServiceTeamSuite) TestCreateTeam() {
+	ctx := context.Background()
+
+	_, err := s.service.CreateTeam(ctx, uuid.New(), s.team.OrganizationID, "sales")
+	s.Require().ErrorIs(err, customerror.ErrTeamForbidden)
+
+	_, err = s.service.CreateTeam(ctx, s.adminID, uuid.New(), "sales")
+	s.Require().ErrorIs(err, customerror.ErrOrganizationNotFound)
+
+	teams, err := s.service.GetUserTeams(ctx, s.adminID)
+	s.Require().NoError(err)
+	s.Require().Len(teams, 1)
+	s.Require().Equal(entity.RoleAdmin, teams[0].Role)
+}
+
+func (s *ServiceTeamSuite) TestMembers() {
+	ctx := context.Background()
+	editorID := uuid.New()
+
+	s.Require().ErrorIs(
+		s.service.SetTeamMember(ctx, s.adminID, s.team.ID, editorID, "owner"),
+		customerror.ErrTeamUnknownRole,
+	)
+	s.Require().NoError(s.service.SetTeamMember(ctx, s.adminID, s.team.ID, editorID, entity.RoleEditor))
+
+	s.Run("only admin manages members", func() {
+		err := s.service.SetTeamMember(ctx, editorID, s.team.ID, uuid.New(), entity.RoleViewer)
+		s.Require().ErrorIs(err, customerror.ErrTeamForbidden)
+
+		err = s.service.RemoveTeamMember(ctx, editorID, s.team.ID, s.adminID)
+		s.Require().ErrorIs(err, customerror.ErrTeamForbidden)
+	})
+
+	s.Run("strangers do not see team", func() {
+		_, err := s.service.GetTeamMembers(ctx, uuid.New(), s.team.ID)
+		s.Require().ErrorIs(err, customerror.ErrTeamNotFound)
+
+		members, err := s.service.GetTeamMembers(ctx, editorID, s.team.ID)
+		s.Require().NoError(err)
+		s.Require().Len(members, 2)
+	})
+
+	s.Run("last admin stays", func() {
+		err := s.service.SetTeamMember(ctx, s.adminID, s.team.ID, s.adminID, entity.RoleViewer)
+		s.Require().ErrorIs(err, customerror.ErrTeamLastAdmin)
+
+		err = s.service.RemoveTeamMember(ctx, s.adminID, s.team.ID, s.adminID)
+		s.Require().ErrorIs(err, customerror.ErrTeamLastAdmin)
+	})
+
+	s.Run("member leaves team", func() {
+		s.Require().NoError(s.service.RemoveTeamMember(ctx, editorID, s.team.ID, editorID))
+
+		teams, err := s.service.GetUserTeams(ctx, editorID)
+		s.Require().NoError(err)
+		s.Require().Empty(teams)
+	})
+}
+
+func (s *ServiceTeamSuite) TestTransferURL() {
+	ctx := context.Background()
+	ownerID, viewerID, strangerID := uuid.New(), uuid.New(), uuid.New()
+	s.Require().NoError(s.service.SetTeamMember(ctx, s.adminID, s.team.ID, ownerID, entity.RoleEditor))
+	s.Require().NoError(s.service.SetTeamMember(ctx, s.adminID, s.team.ID, viewerID, entity.RoleViewer))
+	for _, st := range []contract.Storage{s.mainStorage, s.backupStorage} {
+		_, err := st.Add(ctx, "short", "https://example.com", ownerID)
+		s.Require().NoError(err)
+	}
+
+	s.Run("stranger cannot transfer url", func() {
+		err := s.service.TransferURL(ctx, strangerID, "short", strangerID, uuid.Nil)
+		s.Require().ErrorIs(err, customerror.ErrURLNotFound)
+
+		err = s.service.TransferURL(ctx, ownerID, "unknown", strangerID, uuid.Nil)
+		s.Require().ErrorIs(err, customerror.ErrURLNotFound)
+	})
+
+	s.Run("transfer to team", func() {
+		s.Require().NoError(s.service.TransferURL(ctx, ownerID, "short", uuid.Nil, s.team.ID))
+
+		for _, st := range []contract.Storage{s.mainStorage, s.backupStorage} {
+			url, err := st.GetByHash(ctx, "short")
+			s.Require().NoError(err)
+			s.Require().Equal(s.team.ID, url.TeamID)
+			s.Require().Equal(ownerID, url.UserID)
+		}
+
+		urls, err := s.mainStorage.GetAllURLsByUser(ctx, viewerID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+	})
+
+	s.Run("viewer cannot delete team url", func() {
+		s.Require().NoError(s.mainStorage.DeleteURLsByUser(ctx, viewerID, []string{"short"}))
+		_, err := s.mainStorage.GetByHash(ctx, "short")
+		s.Require().NoError(err)
+	})
+
+	s.Run("only team admin moves url out of team", func() {
+		err := s.service.TransferURL(ctx, ownerID, "short", ownerID, uuid.Nil)
+		s.Require().ErrorIs(err, customerror.ErrTeamForbidden)
+
+		s.Require().NoError(s.service.TransferURL(ctx, s.adminID, "short", strangerID, uuid.Nil))
+
+		urls, err := s.mainStorage.GetAllURLsByUser(ctx, viewerID, "", "")
+		s.Require().NoError(err)
+		s.Require().Empty(urls)
+
+		urls, err = s.mainStorage.GetAllURLsByUser(ctx, strangerID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+	})
+
+	s.Run("transfer to team requires editor role", func() {
+		s.Require().NoError(s.service.SetTeamMember(ctx, s.adminID, s.team.ID, strangerID, entity.RoleViewer))
+
+		err := s.service.TransferURL(ctx, strangerID, "short", uuid.Nil, s.team.ID)
+		s.Require().ErrorIs(err, customerror.ErrTeamForbidden)
+	})
+}
+
+func (s *ServiceTeamSuite) TestUpdateTeamURL() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	urlService := NewURLService(
+		logger.CreateLogger(cfg.LogLevel),
+		s.mainStorage,
+		s.backupStorage,
+		hasher.NewMockHasher(),
+		cfg,
+	)
+	editorID, viewerID := uuid.New(), uuid.New()
+	s.Require().NoError(s.service.SetTeamMember(ctx, s.adminID, s.team.ID, editorID, entity.RoleEditor))
+	s.Require().NoError(s.service.SetTeamMember(ctx, s.adminID, s.team.ID, viewerID, entity.RoleViewer))
+	for _, st := range []contract.Storage{s.mainStorage, s.backupStorage} {
+		_, err := st.Add(ctx, "short", "https://example.com", editorID)
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(s.service.TransferURL(ctx, editorID, "short", uuid.Nil, s.team.ID))
+
+	private := entity.VisibilityPrivate
+	patch := &entity.URLPatch{Visibility: &private}
+
+	_, err := urlService.UpdateUserURL(ctx, uuid.New(), "short", patch)
+	s.Require().ErrorIs(err, customerror.ErrURLNotFound)
+
+	_, err = urlService.UpdateUserURL(ctx, viewerID, "short", patch)
+	s.Require().ErrorIs(err, customerror.ErrTeamForbidden)
+
+	url, err := urlService.UpdateUserURL(ctx, editorID, "short", patch)
+	s.Require().NoError(err)
+	s.Require().True(url.IsPrivate())
+
+	url, err = urlService.GetShortURL(ctx, "short", uuid.New())
+	s.Require().NoError(err)
+	s.Require().Nil(url)
+
+	url, err = urlService.GetShortURL(ctx, "short", viewerID)
+	s.Require().NoError(err)
+	s.Require().NotNil(url)
+
+	backup, err := s.backupStorage.GetByHash(ctx, "short")
+	s.Require().NoError(err)
+	s.Require().True(backup.IsPrivate())
+}
+
+func (s *ServiceTeamSuite) TestRestoreTeams() {
+	ctx := context.Background()
+	const backupFile = "test-teams-db"
+	defer func() {
+		for _, suffix := range []string{"", ".organizations", ".teams", ".team_members"} {
+			os.Remove(backupFile + suffix)
+		}
+	}()
+	newService := func(mainStorage contract.Storage) contract.ServiceTeam {
+		backupStorage, err := storage.NewFileSystemStorage(backupFile)
+		s.Require().NoError(err)
+
+		return NewTeamService(logger.CreateLogger(zerolog.DebugLevel), mainStorage, backupStorage)
+	}
+	srv := newService(storage.NewMemoryStorage())
+	org, err := srv.CreateOrganization(ctx, s.adminID, "acme")
+	s.Require().NoError(err)
+	team, err := srv.CreateTeam(ctx, s.adminID, org.ID, "marketing")
+	s.Require().NoError(err)
+	editorID, removedID := uuid.New(), uuid.New()
+	s.Require().NoError(srv.SetTeamMember(ctx, s.adminID, team.ID, editorID, entity.RoleEditor))
+	s.Require().NoError(srv.SetTeamMember(ctx, s.adminID, team.ID, removedID, entity.RoleViewer))
+	s.Require().NoError(srv.RemoveTeamMember(ctx, s.adminID, team.ID, removedID))
+
+	// после перезапуска команды и участники восстанавливаются из резервного хранилища
+	srv = newService(storage.NewMemoryStorage())
+	restored, err := srv.RestoreTeams(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(1, restored)
+
+	members, err := srv.GetTeamMembers(ctx, editorID, team.ID)
+	s.Require().NoError(err)
+	s.Require().Len(members, 2)
+	s.Require().Equal(entity.RoleAdmin, members[0].Role)
+	s.Require().Equal(entity.RoleEditor, members[1].Role)
+	_, err = srv.CreateTeam(ctx, s.adminID, org.ID, "sales")
+	s.Require().NoError(err, "owner of restored organization can create teams")
+}
@@ -2,16 +2,23 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/config"
 	"github.com/vagafonov/shortener/internal/contract"
 	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/internal/response"
 	"github.com/vagafonov/shortener/pkg/entity"
 	hash "github.com/vagafonov/shortener/pkg/hasher"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // TODO rename.
@@ -20,53 +27,348 @@ type urlService struct {
 	mainStorage   contract.Storage
 	backupStorage contract.Storage
 	hasher        hash.Hasher
+	cfg           *config.Config
+	quotaLocks    [quotaLockStripes]sync.Mutex
 }
 
+// quotaLockStripes number of locks which serialize creation of URLs by users when quotas are set.
+const quotaLockStripes = 64
+
 // NewURLService Constructor for URLService.
 func NewURLService(
 	logger *zerolog.Logger,
 	mainStorage contract.Storage,
 	backupStorage contract.Storage,
 	hasher hash.Hasher,
+	cfg *config.Config,
 ) contract.Service {
 	return &urlService{
 		logger:        logger,
 		mainStorage:   mainStorage,
 		backupStorage: backupStorage,
 		hasher:        hasher,
+		cfg:           cfg,
 	}
 }
 
-// MakeShortURL make short url.
-func (s *urlService) MakeShortURL(ctx context.Context, url string, length int, userID uuid.UUID) (*entity.URL, error) {
-	shortURL, err := s.mainStorage.GetByURL(ctx, url)
+// MakeShortURL make short url. Code is generated in namespace of domain, empty domain is default one.
+// Existing URL is returned with customerror.ErrURLAlreadyExists only when user already shortened url
+// in the same domain, URLs of other users are never disclosed. URL with settings is always created anew
+// and its settings are saved together with it, so it is never available without them and
+// existing URL with other settings is not returned instead of it.
+func (s *urlService) MakeShortURL(
+	ctx context.Context,
+	url string,
+	length int,
+	userID uuid.UUID,
+	domain string,
+	settings *entity.URLPatch,
+) (*entity.URL, error) {
+	if settings == nil {
+		shortURL, err := s.mainStorage.GetByURL(ctx, url, userID, domain)
+		if err != nil {
+			return nil, err
+		}
+		if shortURL != nil {
+			return shortURL, customerror.ErrURLAlreadyExists
+		}
+	}
+	created := &entity.URL{Short: entity.ShortKey(domain, s.hasher.Hash(length)), Original: url, UserID: userID}
+	if settings != nil {
+		if err := applyURLSettings(created, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	var shortURL *entity.URL
+	err := s.withQuota(ctx, userID, 1, func() (int, error) {
+		var err error
+		if shortURL, err = addURL(ctx, s.mainStorage, created, settings != nil); err != nil {
+			return 0, err
+		}
+
+		return 1, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if shortURL != nil {
-		return shortURL, customerror.ErrURLAlreadyExists
+	if _, err = addURL(ctx, s.backupStorage, created, settings != nil); err != nil {
+		return nil, err
 	}
-	hashShortURL := s.hasher.Hash(length)
-	shortURL, err = s.mainStorage.Add(ctx, hashShortURL, url, userID)
+
+	return shortURL, nil
+}
+
+// applyURLSettings apply settings to new URL, password is saved as hash.
+func applyURLSettings(url *entity.URL, settings *entity.URLPatch) error {
+	settings, err := hashURLPassword(settings)
+	if err != nil {
+		return err
+	}
+	settings.Apply(url)
+	url.CreatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// addURL add new URL to storage. URL with settings is added as batch, which saves all settings in one write.
+func addURL(ctx context.Context, st contract.Storage, url *entity.URL, withSettings bool) (*entity.URL, error) {
+	if !withSettings {
+		return st.Add(ctx, url.Short, url.Original, url.UserID)
+	}
+	stored := *url
+	if _, err := st.AddBatch(ctx, []*entity.URL{&stored}); err != nil {
+		return nil, fmt.Errorf("cannot add url: %w", err)
+	}
+
+	return &stored, nil
+}
+
+// GetUserQuotaUsage get current usage of quotas by user.
+func (s *urlService) GetUserQuotaUsage(ctx context.Context, userID uuid.UUID) (*entity.QuotaUsage, error) {
+	return s.mainStorage.GetQuotaUsage(ctx, userID, today())
+}
+
+// checkQuota check that user can create n more URLs.
+func (s *urlService) checkQuota(ctx context.Context, userID uuid.UUID, n int) error {
+	if s.cfg.DailyURLsQuota <= 0 && s.cfg.ActiveURLsQuota <= 0 {
+		return nil
+	}
+
+	usage, err := s.mainStorage.GetQuotaUsage(ctx, userID, today())
+	if err != nil {
+		return fmt.Errorf("cannot get quota usage: %w", err)
+	}
+
+	if s.cfg.DailyURLsQuota > 0 && usage.CreatedToday+n > s.cfg.DailyURLsQuota {
+		return customerror.ErrDailyQuotaExceeded
+	}
+
+	if s.cfg.ActiveURLsQuota > 0 && usage.Active+n > s.cfg.ActiveURLsQuota {
+		return customerror.ErrActiveQuotaExceeded
+	}
+
+	return nil
+}
+
+// withQuota create n URLs of user with create, which returns number of created URLs.
+// Daily usage is reserved before URLs are created, so concurrent requests cannot exceed daily quota,
+// and reservation of URLs which were not created is released. Active URLs are counted from stored ones,
+// so when quotas are set creation of URLs by one user is serialized.
+func (s *urlService) withQuota(ctx context.Context, userID uuid.UUID, n int, create func() (int, error)) error {
+	if s.cfg.DailyURLsQuota > 0 || s.cfg.ActiveURLsQuota > 0 {
+		lock := s.quotaLock(userID)
+		lock.Lock()
+		defer lock.Unlock()
+
+		if err := s.checkQuota(ctx, userID, n); err != nil {
+			return err
+		}
+	}
+
+	day := today()
+	reserved, err := s.mainStorage.ReserveDailyUsage(ctx, userID, day, n, s.cfg.DailyURLsQuota)
+	if err != nil {
+		return fmt.Errorf("cannot reserve daily usage: %w", err)
+	}
+	if !reserved {
+		return customerror.ErrDailyQuotaExceeded
+	}
+
+	created, err := create()
+	if created < n {
+		if releaseErr := s.mainStorage.IncrementDailyUsage(ctx, userID, day, created-n); releaseErr != nil {
+			s.logger.Error().Err(releaseErr).Msg("cannot release reserved daily usage")
+		}
+	}
+
+	return err
+}
+
+// quotaLock lock which serializes creation of URLs by user.
+func (s *urlService) quotaLock(userID uuid.UUID) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write(userID[:])
+
+	return &s.quotaLocks[h.Sum32()%quotaLockStripes]
+}
+
+// today return beginning of current day in UTC.
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour) //nolint:mnd,gomnd
+}
+
+// GetShortURL get short url. Private URL is returned only if user can view it.
+func (s *urlService) GetShortURL(ctx context.Context, url string, userID uuid.UUID) (*entity.URL, error) {
+	s.logger.Info().Str("url", url).Msg("GetShortURL")
+
+	return s.mainStorage.GetVisibleByHash(ctx, url, userID)
+}
+
+// UpdateUserURL change settings of URL. User must be owner of URL or editor in team of URL.
+func (s *urlService) UpdateUserURL(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+	patch *entity.URLPatch,
+) (*entity.URL, error) {
+	url, member, err := s.getViewableURL(ctx, userID, short)
 	if err != nil {
 		return nil, err
 	}
-	_, err = s.backupStorage.Add(ctx, hashShortURL, url, userID)
+	if !member.CanEdit() {
+		return nil, customerror.ErrTeamForbidden
+	}
+
+	patch, err = hashURLPassword(patch)
 	if err != nil {
 		return nil, err
 	}
+	if err = s.mainStorage.UpdateURL(ctx, short, patch); err != nil {
+		return nil, fmt.Errorf("cannot update url in main storage: %w", err)
+	}
+	if err = s.backupStorage.UpdateURL(ctx, short, patch); err != nil {
+		return nil, fmt.Errorf("cannot update url in backup storage: %w", err)
+	}
+	patch.Apply(url)
 
-	return shortURL, nil
+	return url, nil
 }
 
-// GetShortURL get short url.
-func (s *urlService) GetShortURL(ctx context.Context, url string) (*entity.URL, error) {
-	s.logger.Info().Str("url", url).Msg("GetShortURL")
+// getViewableURL get URL with role of user for it. URL which user cannot view is not found.
+func (s *urlService) getViewableURL(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+) (*entity.URL, *entity.TeamMember, error) {
+	url, err := s.mainStorage.GetByHash(ctx, short)
+	if err != nil && !errors.Is(err, customerror.ErrURLDeleted) {
+		return nil, nil, fmt.Errorf("cannot get url: %w", err)
+	}
+	if url == nil {
+		return nil, nil, customerror.ErrURLNotFound
+	}
+
+	member, err := urlMember(ctx, s.mainStorage, url, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !member.CanView() {
+		return nil, nil, customerror.ErrURLNotFound
+	}
+
+	return url, member, nil
+}
+
+// VerifyURLPassword check password of protected URL.
+func (s *urlService) VerifyURLPassword(url *entity.URL, password string) bool {
+	if !url.HasPassword() {
+		return true
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(url.PasswordHash), []byte(password)) == nil
+}
 
-	return s.mainStorage.GetByHash(ctx, url)
+// ConsumeURLClick count click on URL with clicks limit before redirect.
+// ErrURLExhausted is returned when URL cannot be followed anymore.
+func (s *urlService) ConsumeURLClick(ctx context.Context, url *entity.URL) error {
+	if !url.HasClicksLimit() {
+		return nil
+	}
+
+	ok, err := s.mainStorage.ConsumeClick(ctx, url.Short)
+	if err != nil {
+		return fmt.Errorf("cannot consume click in main storage: %w", err)
+	}
+	if !ok {
+		return customerror.ErrURLExhausted
+	}
+	// счётчик в резервном хранилище нужен только для восстановления, ошибка не мешает переходу
+	if _, err = s.backupStorage.ConsumeClick(ctx, url.Short); err != nil {
+		s.logger.Warn().Str("error", err.Error()).Msg("cannot consume click in backup storage")
+	}
+
+	return nil
 }
 
-// RestoreURLs restore short URLs.
+// CountVariantClick count click on variant of URL in A/B experiment.
+// Clicks are statistics, so they are kept only in main storage.
+func (s *urlService) CountVariantClick(ctx context.Context, url *entity.URL, variant string) error {
+	if err := s.mainStorage.AddVariantClick(ctx, url.Short, variant); err != nil {
+		return fmt.Errorf("cannot count variant click: %w", err)
+	}
+
+	return nil
+}
+
+// GetURLVariantStats get clicks on variants of URL. User must be able to view URL.
+func (s *urlService) GetURLVariantStats(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+) ([]entity.VariantStats, error) {
+	url, _, err := s.getViewableURL(ctx, userID, short)
+	if err != nil {
+		return nil, err
+	}
+
+	clicks, err := s.mainStorage.GetVariantClicks(ctx, short)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get variant clicks: %w", err)
+	}
+	stats := make([]entity.VariantStats, len(url.Variants))
+	for i, v := range url.Variants {
+		stats[i] = entity.VariantStats{Variant: v, Clicks: clicks[v.ID]}
+	}
+
+	return stats, nil
+}
+
+// hashURLPassword return copy of patch where plain password is replaced with hash.
+func hashURLPassword(patch *entity.URLPatch) (*entity.URLPatch, error) {
+	if patch.Password == nil {
+		return patch, nil
+	}
+
+	hashed := *patch
+	hashed.Password = nil
+	passwordHash := ""
+	if *patch.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*patch.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("cannot hash url password: %w", err)
+		}
+		passwordHash = string(hash)
+	}
+	hashed.PasswordHash = &passwordHash
+
+	return &hashed, nil
+}
+
+// urlMember return role of user for URL. Owner of personal URL is treated as admin.
+func urlMember(
+	ctx context.Context,
+	st contract.Storage,
+	url *entity.URL,
+	userID uuid.UUID,
+) (*entity.TeamMember, error) {
+	if !url.OwnedByTeam() {
+		if url.UserID != userID {
+			return nil, nil //nolint:nilnil
+		}
+
+		return &entity.TeamMember{UserID: userID, Role: entity.RoleAdmin}, nil
+	}
+
+	member, err := st.GetTeamMember(ctx, url.TeamID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get team member: %w", err)
+	}
+
+	return member, nil
+}
+
+// RestoreURLs restore short URLs from backup storage with all their settings, counters and deletion marks.
 func (s *urlService) RestoreURLs(ctx context.Context, fileName string) (int, error) {
 	// TODO need pagination
 
@@ -75,14 +377,21 @@ func (s *urlService) RestoreURLs(ctx context.Context, fileName string) (int, err
 		return 0, fmt.Errorf("failed to get all URLs: %w", err)
 	}
 
+	restored, err := s.mainStorage.AddBatch(ctx, URLs)
+	if err != nil {
+		return restored, fmt.Errorf("failed to add URLs: %w", err)
+	}
+
+	day := today()
 	for _, v := range URLs {
-		// TODO handle id
-		if _, err = s.mainStorage.Add(ctx, v.Short, v.Original, v.UserID); err != nil {
-			return 0, fmt.Errorf("failed to add URL: %w", err)
+		if v.CreatedAt.UTC().Truncate(24 * time.Hour).Equal(day) { //nolint:mnd,gomnd
+			if err = s.mainStorage.IncrementDailyUsage(ctx, v.UserID, day, 1); err != nil {
+				return restored, fmt.Errorf("failed to restore daily usage: %w", err)
+			}
 		}
 	}
 
-	return len(URLs), err
+	return restored, nil
 }
 
 // MakeShortURLBatch make short URL batch.
@@ -90,10 +399,23 @@ func (s *urlService) MakeShortURLBatch(
 	ctx context.Context,
 	urls []*entity.URL,
 	baseURL string,
+	userID uuid.UUID,
 ) ([]response.ShortenBatchResponse, error) {
-	totalCreated, err := s.mainStorage.AddBatch(ctx, urls)
+	for _, v := range urls {
+		v.UserID = userID
+	}
+
+	var totalCreated int
+	err := s.withQuota(ctx, userID, len(urls), func() (int, error) {
+		var err error
+		if totalCreated, err = s.mainStorage.AddBatch(ctx, urls); err != nil {
+			return totalCreated, fmt.Errorf("cannot add batch to main storage: %w", err)
+		}
+
+		return totalCreated, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("cannot add batch to main storage: %w", err)
+		return nil, err
 	}
 
 	resp := make([]response.ShortenBatchResponse, totalCreated)
@@ -113,9 +435,150 @@ func (s *urlService) MakeShortURLBatch(
 	return resp, nil
 }
 
-// GetUserURLs get user URLS.
-func (s *urlService) GetUserURLs(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error) {
-	return s.mainStorage.GetAllURLsByUser(ctx, userID, baseURL)
+// ImportURLs create imported URLs of user with one batch through MakeShortURLBatch. URL whose destination
+// user already shortened in namespace of domain is not created again. Code of URL is kept when it is free
+// in namespace of domain, otherwise and for URL without code new code is generated. Results are in order of urls.
+func (s *urlService) ImportURLs(
+	ctx context.Context,
+	urls []*entity.URL,
+	length int,
+	userID uuid.UUID,
+	domain string,
+) ([]entity.ImportResult, error) {
+	res := make([]entity.ImportResult, len(urls))
+	batch := make([]*entity.URL, 0, len(urls))
+	originals := make(map[string]*entity.URL, len(urls))
+	keys := make(map[string]struct{}, len(urls))
+	for i, v := range urls {
+		existing := originals[v.Original]
+		if existing == nil {
+			var err error
+			if existing, err = s.mainStorage.GetByURL(ctx, v.Original, userID, domain); err != nil {
+				return nil, fmt.Errorf("cannot get url by destination: %w", err)
+			}
+		}
+		if existing != nil {
+			res[i] = entity.ImportResult{URL: existing, Status: entity.ImportExists}
+
+			continue
+		}
+
+		status := entity.ImportCreated
+		if v.Short == "" {
+			v.Short = entity.ShortKey(domain, s.hasher.Hash(length))
+		} else {
+			v.Short = entity.ShortKey(domain, v.Short)
+			taken, err := s.isKeyTaken(ctx, v.Short, keys)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				v.Short = entity.ShortKey(domain, s.hasher.Hash(length))
+				status = entity.ImportRenamed
+			}
+		}
+		keys[v.Short] = struct{}{}
+		originals[v.Original] = v
+		batch = append(batch, v)
+		res[i] = entity.ImportResult{URL: v, Status: status}
+	}
+
+	if len(batch) > 0 {
+		if _, err := s.MakeShortURLBatch(ctx, batch, s.cfg.ResultURL, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// isKeyTaken check that short key is used by URL in storage, including deleted one, or by URL of batch.
+func (s *urlService) isKeyTaken(ctx context.Context, key string, batch map[string]struct{}) (bool, error) {
+	if _, ok := batch[key]; ok {
+		return true, nil
+	}
+	url, err := s.mainStorage.GetByHash(ctx, key)
+	if errors.Is(err, customerror.ErrURLDeleted) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot get url by code: %w", err)
+	}
+
+	return url != nil, nil
+}
+
+// GetUserURLs get user URLS, only URLs marked with tag when tag is not empty.
+func (s *urlService) GetUserURLs(
+	ctx context.Context,
+	userID uuid.UUID,
+	baseURL string,
+	tag string,
+) ([]*entity.URL, error) {
+	return s.mainStorage.GetAllURLsByUser(ctx, userID, baseURL, tag)
+}
+
+// ExportUserURLs call fn for every personal URL of user including deleted ones.
+// URLs are read from storage one by one, so export of many URLs does not load them in memory.
+func (s *urlService) ExportUserURLs(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error {
+	return s.mainStorage.EachURLByUser(ctx, userID, fn)
+}
+
+// SearchUserURLs find URLs which user can view by original URL, code, title, description or notes.
+// Page of most relevant URLs and total number of found URLs are returned.
+func (s *urlService) SearchUserURLs(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	urls, total, err := s.mainStorage.SearchURLsByUser(ctx, userID, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot search user urls: %w", err)
+	}
+
+	return urls, total, nil
+}
+
+// GetUserTags get tags of URLs which user can view with number of URLs marked with each tag.
+func (s *urlService) GetUserTags(ctx context.Context, userID uuid.UUID) ([]entity.TagStats, error) {
+	urls, err := s.mainStorage.GetAllURLsByUser(ctx, userID, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get user urls: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, url := range urls {
+		if url.DeletedAt != nil {
+			continue
+		}
+		for _, t := range url.Tags {
+			counts[t]++
+		}
+	}
+
+	res := make([]entity.TagStats, 0, len(counts))
+	for t, n := range counts {
+		res = append(res, entity.TagStats{Tag: t, URLs: n})
+	}
+	slices.SortFunc(res, func(a, b entity.TagStats) int { return strings.Compare(a.Tag, b.Tag) })
+
+	return res, nil
+}
+
+// RenameUserTags replace tags from with tag to on URLs which user can edit, several tags are merged into one.
+// URLs of teams where user cannot edit are left as is. Number of changed URLs is returned.
+func (s *urlService) RenameUserTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	changed, err := s.mainStorage.RenameTags(ctx, userID, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("cannot rename tags in main storage: %w", err)
+	}
+	if _, err = s.backupStorage.RenameTags(ctx, userID, from, to); err != nil {
+		return changed, fmt.Errorf("cannot rename tags in backup storage: %w", err)
+	}
+
+	return changed, nil
 }
 
 // DeleteUserURLs delete user URLS.
@@ -165,6 +628,9 @@ func (s *urlService) batchDeleteConsumer(ctx context.Context, ch chan []string,
 				if err := s.mainStorage.DeleteURLsByUser(ctx, userID, batch); err != nil {
 					s.logger.Error().Err(err).Strs("batch", batch).Msg("failed delete batch urls in consumer")
 				}
+				if err := s.backupStorage.DeleteURLsByUser(ctx, userID, batch); err != nil {
+					s.logger.Error().Err(err).Strs("batch", batch).Msg("failed delete batch urls in backup storage")
+				}
 				s.logger.Debug().Strs("batch", batch).Msgf("gorutine №%v successfully handled batch in comsumer", n)
 			}
 			wg.Done()
@@ -76,14 +76,16 @@ func (s *ServiceDBSuite) TestGetShortURL() {
 			Short:    "****",
 			Original: "some_url",
 		}
-		m.EXPECT().GetByHash(ctx, "some_url").Return(expEntity, nil)
+		userID := uuid.New()
+		m.EXPECT().GetVisibleByHash(ctx, "some_url", userID).Return(expEntity, nil)
 		s.service = NewURLService(
 			s.cnt.GetLogger(),
 			m,
 			s.cnt.GetBackupStorage(),
 			s.cnt.GetHasher(),
+			s.cnt.GetConfig(),
 		)
-		e, err := s.service.GetShortURL(ctx, "some_url")
+		e, err := s.service.GetShortURL(ctx, "some_url", userID)
 		s.Require().NoError(err)
 		s.Require().Equal(expEntity, e)
 	})
@@ -100,17 +102,19 @@ func (s *ServiceDBSuite) TestMakeShortURL() {
 			Short:    "*****",
 			Original: "some_url",
 		}
-		m.EXPECT().GetByURL(ctx, "some_url").Return(nil, nil)
 		userID := uuid.Must(uuid.NewUUID())
+		m.EXPECT().GetByURL(ctx, "some_url", userID, "").Return(nil, nil)
 		m.EXPECT().Add(ctx, "*****", "some_url", userID).Return(expEntity, nil)
+		m.EXPECT().ReserveDailyUsage(ctx, userID, gomock.Any(), 1, 0).Return(true, nil)
 		s.service = NewURLService(
 			s.cnt.GetLogger(),
 			m,
 			s.cnt.GetBackupStorage(),
 			s.cnt.GetHasher(),
+			s.cnt.GetConfig(),
 		)
 
-		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID)
+		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID, "", nil)
 		s.Require().NoError(err)
 		s.Require().Equal(expEntity, e)
 	})
@@ -125,16 +129,17 @@ func (s *ServiceDBSuite) TestMakeShortURL() {
 			Short:    "*****",
 			Original: "some_url",
 		}
-		m.EXPECT().GetByURL(ctx, "some_url").Return(expEntity, nil)
+		userID := uuid.Must(uuid.NewUUID())
+		m.EXPECT().GetByURL(ctx, "some_url", userID, "").Return(expEntity, nil)
 		s.service = NewURLService(
 			s.cnt.GetLogger(),
 			m,
 			s.cnt.GetBackupStorage(),
 			s.cnt.GetHasher(),
+			s.cnt.GetConfig(),
 		)
 
-		userID := uuid.Must(uuid.NewUUID())
-		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID)
+		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID, "", nil)
 		s.Require().Error(err)
 		s.Require().Equal(expEntity, e)
 	})
@@ -155,12 +160,13 @@ func (s *ServiceDBSuite) TestRestoreURLs() {
 		defer ctrl.Finish()
 		m := storage.NewMockStorage(ctrl)
 
-		m.EXPECT().Add(ctx, "*****", "some_url", userID).Return(expEntity, nil)
+		m.EXPECT().AddBatch(ctx, []*entity.URL{expEntity}).Return(1, nil)
 		s.service = NewURLService(
 			s.cnt.GetLogger(),
 			m,
 			s.cnt.GetBackupStorage(),
 			s.cnt.GetHasher(),
+			s.cnt.GetConfig(),
 		)
 
 		totalRestored, err := s.service.RestoreURLs(ctx, fileName)
@@ -176,25 +182,30 @@ func (s *ServiceDBSuite) TestAddBatch() {
 		defer ctrl.Finish()
 		m := storage.NewMockStorage(ctrl)
 
+		userID := uuid.New()
 		newEntities := []*entity.URL{
 			{
 				ID:       "1",
 				Short:    "*****",
 				Original: "aaa",
+				UserID:   userID,
 			},
 			{
 				ID:       "2",
 				Short:    "*****",
 				Original: "bbb",
+				UserID:   userID,
 			},
 		}
 
 		m.EXPECT().AddBatch(ctx, newEntities).Return(2, nil)
+		m.EXPECT().ReserveDailyUsage(ctx, userID, gomock.Any(), 2, 0).Return(true, nil)
 		s.service = NewURLService(
 			s.cnt.GetLogger(),
 			m,
 			s.cnt.GetBackupStorage(),
 			s.cnt.GetHasher(),
+			s.cnt.GetConfig(),
 		)
 
 		req := []request.ShortenBatchRequest{
@@ -216,7 +227,7 @@ func (s *ServiceDBSuite) TestAddBatch() {
 				Original: v.OriginalURL,
 			}
 		}
-		resp, err := s.service.MakeShortURLBatch(ctx, URLs, "url")
+		resp, err := s.service.MakeShortURLBatch(ctx, URLs, "url", userID)
 		s.Require().NoError(err)
 		respExp := []response.ShortenBatchResponse{
 			{
@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/uuid"
@@ -12,6 +15,7 @@ import (
 	"github.com/vagafonov/shortener/internal/config"
 	"github.com/vagafonov/shortener/internal/container"
 	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/internal/logger"
 	"github.com/vagafonov/shortener/internal/request"
 	"github.com/vagafonov/shortener/internal/response"
@@ -84,6 +88,7 @@ func (s *ServiceURLMemorySuite) SetupSuite() {
 		s.cnt.GetMainStorage(),
 		s.cnt.GetBackupStorage(),
 		s.cnt.GetHasher(),
+		s.cnt.GetConfig(),
 	)
 }
 
@@ -97,7 +102,7 @@ func (s *ServiceURLMemorySuite) TestGetShortURL() {
 			Original: "some_url",
 		}
 		s.mainStorage.SetGetByHashResponse(expEntity, nil)
-		e, err := s.service.GetShortURL(ctx, "some_url")
+		e, err := s.service.GetShortURL(ctx, "some_url", uuid.Nil)
 		s.Require().NoError(err)
 		s.Require().Equal(expEntity, e)
 	})
@@ -114,7 +119,7 @@ func (s *ServiceURLMemorySuite) TestMakeShortURL() {
 		}
 		s.mainStorage.SetAddResponse(expEntity, nil)
 		userID := uuid.Must(uuid.NewUUID())
-		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID)
+		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID, "", nil)
 		s.Require().NoError(err)
 		s.Require().Equal(expEntity, e)
 	})
@@ -128,7 +133,7 @@ func (s *ServiceURLMemorySuite) TestMakeShortURL() {
 		}
 		s.mainStorage.SetGetByURLResponse(expEntity, nil)
 		userID := uuid.Must(uuid.NewUUID())
-		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID)
+		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID, "", nil)
 		s.Require().Error(err)
 		s.Require().Equal(expEntity, e)
 	})
@@ -145,11 +150,7 @@ func (s *ServiceURLMemorySuite) TestRestoreURLs() {
 				Original: "",
 			},
 		}, nil)
-		s.mainStorage.SetAddResponse(&entity.URL{
-			UUID:     uuid.UUID{},
-			Short:    "",
-			Original: "",
-		}, nil)
+		s.mainStorage.SetAddBatchResponse(1, nil)
 
 		totalRestored, err := s.service.RestoreURLs(ctx, fileName)
 		s.Require().NoError(err)
@@ -172,7 +173,7 @@ func (s *ServiceURLMemorySuite) TestRestoreURLs() {
 				Original: "",
 			},
 		}, nil)
-		s.mainStorage.SetAddResponse(nil, ErrEmpty)
+		s.mainStorage.SetAddBatchResponse(0, ErrEmpty)
 		_, err := s.service.RestoreURLs(ctx, fileName)
 		s.Require().Error(err)
 	})
@@ -200,12 +201,69 @@ func (s *ServiceURLMemorySuite) TestRestoreURLs() {
 				Original: v.OriginalURL,
 			}
 		}
-		resp, err := s.service.MakeShortURLBatch(ctx, URLs, "url")
+		resp, err := s.service.MakeShortURLBatch(ctx, URLs, "url", uuid.New())
 		s.Require().Equal(expResp, resp)
 		s.Require().NoError(err)
 	})
 }
 
+func (s *ServiceURLMemorySuite) TestRestoreURLsAfterRestart() {
+	ctx := context.Background()
+	const backupFile = "test-restart-db"
+	defer os.Remove(backupFile)
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	newService := func(mainStorage contract.Storage) contract.Service {
+		backupStorage, err := storage.NewFileSystemStorage(backupFile)
+		s.Require().NoError(err)
+
+		return NewURLService(
+			logger.CreateLogger(cfg.LogLevel),
+			mainStorage,
+			backupStorage,
+			hasher.NewRandHasher(hasher.Alphabet),
+			cfg,
+		)
+	}
+	srv := newService(storage.NewMemoryStorage())
+	userID := uuid.New()
+
+	visibility, password, maxClicks := entity.VisibilityPrivate, "secret", int64(2)
+	tags, utm := []string{"promo"}, map[string]string{"utm_source": "mail"}
+	url, err := srv.MakeShortURL(ctx, "https://example.com/private", 8, userID, "", &entity.URLPatch{
+		Visibility: &visibility,
+		Password:   &password,
+		MaxClicks:  &maxClicks,
+		Tags:       tags,
+		UTM:        utm,
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(srv.ConsumeURLClick(ctx, url))
+	deleted, err := srv.MakeShortURL(ctx, "https://example.com/deleted", 8, userID, "", nil)
+	s.Require().NoError(err)
+	s.Require().NoError(srv.DeleteUserURLs(ctx, userID, []string{deleted.Short}, 1, 1))
+
+	// после перезапуска основное хранилище пустое и восстанавливается из резервного
+	mainStorage := storage.NewMemoryStorage()
+	restored, err := newService(mainStorage).RestoreURLs(ctx, backupFile)
+	s.Require().NoError(err)
+	s.Require().Equal(2, restored)
+
+	visible, err := mainStorage.GetVisibleByHash(ctx, url.Short, uuid.New())
+	s.Require().NoError(err)
+	s.Require().Nil(visible, "private URL is not visible to other users")
+	stored, err := mainStorage.GetByHash(ctx, url.Short)
+	s.Require().NoError(err)
+	s.Require().True(stored.IsPrivate())
+	s.Require().True(srv.VerifyURLPassword(stored, password))
+	s.Require().Equal(int64(1), stored.Clicks)
+	s.Require().Equal(maxClicks, stored.MaxClicks)
+	s.Require().Equal(tags, stored.Tags)
+	s.Require().Equal(utm, stored.UTM)
+
+	_, err = mainStorage.GetByHash(ctx, deleted.Short)
+	s.Require().ErrorIs(err, customerror.ErrURLDeleted)
+}
+
 func (s *ServiceURLMemorySuite) TestGetUserURLs() {
 	s.Run("get user urls", func() {
 		ctx := context.Background()
@@ -220,7 +278,7 @@ func (s *ServiceURLMemorySuite) TestGetUserURLs() {
 		}
 		s.mainStorage.SetGetAllURLsByUserResponse(exp, nil)
 
-		userURLs, err := s.service.GetUserURLs(ctx, userID, "")
+		userURLs, err := s.service.GetUserURLs(ctx, userID, "", "")
 		s.Require().Len(userURLs, 1)
 		s.Require().NoError(err)
 		s.Require().Equal(exp, userURLs)
@@ -238,3 +296,440 @@ func (s *ServiceURLMemorySuite) TestDeleteUserURLs() {
 		s.Require().NoError(err)
 	})
 }
+
+func (s *ServiceURLMemorySuite) TestQuota() {
+	ctx := context.Background()
+	userID := uuid.Must(uuid.NewUUID())
+	defer func() {
+		s.cnt.GetConfig().DailyURLsQuota = 0
+		s.cnt.GetConfig().ActiveURLsQuota = 0
+	}()
+
+	s.Run("daily quota exceeded", func() {
+		s.cnt.GetConfig().DailyURLsQuota = 2
+		s.cnt.GetConfig().ActiveURLsQuota = 0
+		s.mainStorage.SetGetByURLResponse(nil, nil)
+		s.mainStorage.SetGetQuotaUsageResponse(&entity.QuotaUsage{CreatedToday: 2, Active: 2}, nil)
+		_, err := s.service.MakeShortURL(ctx, "some_url", 5, userID, "", nil)
+		s.Require().ErrorIs(err, customerror.ErrDailyQuotaExceeded)
+	})
+
+	s.Run("active quota exceeded by batch", func() {
+		s.cnt.GetConfig().DailyURLsQuota = 0
+		s.cnt.GetConfig().ActiveURLsQuota = 3
+		s.mainStorage.SetGetQuotaUsageResponse(&entity.QuotaUsage{CreatedToday: 0, Active: 2}, nil)
+		_, err := s.service.MakeShortURLBatch(ctx, []*entity.URL{{ID: "1"}, {ID: "2"}}, "url", userID)
+		s.Require().ErrorIs(err, customerror.ErrActiveQuotaExceeded)
+	})
+
+	s.Run("quota not exceeded", func() {
+		s.cnt.GetConfig().DailyURLsQuota = 2
+		s.cnt.GetConfig().ActiveURLsQuota = 3
+		s.mainStorage.SetGetQuotaUsageResponse(&entity.QuotaUsage{CreatedToday: 1, Active: 2}, nil)
+		expEntity := &entity.URL{Short: "*****", Original: "some_url"}
+		s.mainStorage.SetAddResponse(expEntity, nil)
+		e, err := s.service.MakeShortURL(ctx, "some_url", 5, userID, "", nil)
+		s.Require().NoError(err)
+		s.Require().Equal(expEntity, e)
+	})
+}
+
+func (s *ServiceURLMemorySuite) TestConcurrentQuota() {
+	ctx := context.Background()
+	secret := []byte("0123456789abcdef")
+	for _, tc := range []struct {
+		name   string
+		daily  int
+		active int
+	}{
+		{name: "daily quota", daily: 5},
+		{name: "active quota", active: 5},
+	} {
+		s.Run(tc.name, func() {
+			cfg := config.NewConfig("test", "http://test:8080", "", "", false, secret, 10, 3, config.ModeTest)
+			cfg.DailyURLsQuota = tc.daily
+			cfg.ActiveURLsQuota = tc.active
+			mainStorage := storage.NewMemoryStorage()
+			srv := NewURLService(
+				logger.CreateLogger(cfg.LogLevel),
+				mainStorage,
+				storage.NewMemoryStorage(),
+				hasher.NewRandHasher(hasher.Alphabet),
+				cfg,
+			)
+			userID := uuid.New()
+
+			var wg sync.WaitGroup
+			var created atomic.Int32
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, err := srv.MakeShortURL(ctx, fmt.Sprintf("https://example.com/%d", i), 8, userID, "", nil)
+					if err == nil {
+						created.Add(1)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			s.Require().Equal(int32(5), created.Load())
+			usage, err := mainStorage.GetQuotaUsage(ctx, userID, today())
+			s.Require().NoError(err)
+			s.Require().Equal(&entity.QuotaUsage{CreatedToday: 5, Active: 5}, usage)
+		})
+	}
+}
+
+func (s *ServiceURLMemorySuite) TestBrandedDomains() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	cfg.Domains = []string{"go.brand-a.com"}
+	srv := NewURLService(
+		logger.CreateLogger(cfg.LogLevel),
+		storage.NewMemoryStorage(),
+		storage.NewMemoryStorage(),
+		hasher.NewMockHasher(),
+		cfg,
+	)
+	userID := uuid.New()
+
+	def, err := srv.MakeShortURL(ctx, "https://example.com/default", 4, userID, "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal("****", def.Short)
+	branded, err := srv.MakeShortURL(ctx, "https://example.com/branded", 4, userID, "go.brand-a.com", nil)
+	s.Require().NoError(err)
+	s.Require().Equal("go.brand-a.com/****", branded.Short)
+	s.Require().Equal("go.brand-a.com", branded.Domain())
+	s.Require().Equal("****", branded.Code())
+
+	// одинаковые коды на разных доменах не пересекаются
+	url, err := srv.GetShortURL(ctx, "****", userID)
+	s.Require().NoError(err)
+	s.Require().Equal("https://example.com/default", url.Original)
+	url, err = srv.GetShortURL(ctx, entity.ShortKey("go.brand-a.com", "****"), userID)
+	s.Require().NoError(err)
+	s.Require().Equal("https://example.com/branded", url.Original)
+	url, err = srv.GetShortURL(ctx, entity.ShortKey("brand-b.link", "****"), userID)
+	s.Require().NoError(err)
+	s.Require().Nil(url)
+}
+
+func (s *ServiceURLMemorySuite) TestMakeShortURLDedupe() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	cfg.Domains = []string{"go.brand-a.com"}
+	srv := NewURLService(
+		logger.CreateLogger(cfg.LogLevel),
+		storage.NewMemoryStorage(),
+		storage.NewMemoryStorage(),
+		hasher.NewRandHasher(hasher.Alphabet),
+		cfg,
+	)
+	userID, otherID := uuid.New(), uuid.New()
+	const original = "https://example.com/same"
+
+	def, err := srv.MakeShortURL(ctx, original, 8, userID, "", nil)
+	s.Require().NoError(err)
+	branded, err := srv.MakeShortURL(ctx, original, 8, userID, "go.brand-a.com", nil)
+	s.Require().NoError(err)
+	s.Require().Equal("go.brand-a.com", branded.Domain())
+	s.Require().NotEqual(def.Short, branded.Short)
+
+	url, err := srv.MakeShortURL(ctx, original, 8, userID, "go.brand-a.com", nil)
+	s.Require().ErrorIs(err, customerror.ErrURLAlreadyExists)
+	s.Require().Equal(branded.Short, url.Short)
+	url, err = srv.MakeShortURL(ctx, original, 8, userID, "", nil)
+	s.Require().ErrorIs(err, customerror.ErrURLAlreadyExists)
+	s.Require().Equal(def.Short, url.Short)
+
+	// код другого пользователя не раскрывается
+	other, err := srv.MakeShortURL(ctx, original, 8, otherID, "go.brand-a.com", nil)
+	s.Require().NoError(err)
+	s.Require().NotEqual(branded.Short, other.Short)
+	s.Require().Equal(otherID, other.UserID)
+}
+
+func (s *ServiceURLMemorySuite) TestMakeShortURLWithSettings() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	mainStorage, backupStorage := storage.NewMemoryStorage(), storage.NewMemoryStorage()
+	srv := NewURLService(
+		logger.CreateLogger(cfg.LogLevel),
+		mainStorage,
+		backupStorage,
+		hasher.NewRandHasher(hasher.Alphabet),
+		cfg,
+	)
+	userID := uuid.New()
+	const original = "https://example.com/secret"
+
+	public, err := srv.MakeShortURL(ctx, original, 8, userID, "", nil)
+	s.Require().NoError(err)
+
+	visibility, password := entity.VisibilityPrivate, "secret"
+	settings := &entity.URLPatch{Visibility: &visibility, Password: &password}
+	url, err := srv.MakeShortURL(ctx, original, 8, userID, "", settings)
+	s.Require().NoError(err)
+	s.Require().NotEqual(public.Short, url.Short)
+
+	// ссылка сохраняется сразу с настройками в обоих хранилищах
+	for _, st := range []contract.Storage{mainStorage, backupStorage} {
+		stored, err := st.GetByHash(ctx, url.Short)
+		s.Require().NoError(err)
+		s.Require().True(stored.IsPrivate())
+		s.Require().True(srv.VerifyURLPassword(stored, password))
+		s.Require().Equal(userID, stored.UserID)
+	}
+}
+
+func (s *ServiceURLMemorySuite) TestUserTags() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	mainStorage, backupStorage := storage.NewMemoryStorage(), storage.NewMemoryStorage()
+	srv := NewURLService(logger.CreateLogger(cfg.LogLevel), mainStorage, backupStorage, hasher.NewMockHasher(), cfg)
+	userID, otherID := uuid.New(), uuid.New()
+
+	urls := []struct {
+		short  string
+		userID uuid.UUID
+		tags   []string
+	}{
+		{short: "a", userID: userID, tags: []string{"promo", "q1"}},
+		{short: "b", userID: userID, tags: []string{"promo-2024"}},
+		{short: "c", userID: userID, tags: []string{"docs"}},
+		{short: "d", userID: otherID, tags: []string{"promo"}},
+	}
+	for _, u := range urls {
+		for _, st := range []contract.Storage{mainStorage, backupStorage} {
+			_, err := st.Add(ctx, u.short, "https://example.com/"+u.short, u.userID)
+			s.Require().NoError(err)
+			s.Require().NoError(st.UpdateURL(ctx, u.short, &entity.URLPatch{Tags: u.tags}))
+		}
+	}
+
+	tags, err := srv.GetUserTags(ctx, userID)
+	s.Require().NoError(err)
+	s.Require().Equal([]entity.TagStats{
+		{Tag: "docs", URLs: 1},
+		{Tag: "promo", URLs: 1},
+		{Tag: "promo-2024", URLs: 1},
+		{Tag: "q1", URLs: 1},
+	}, tags)
+
+	updated, err := srv.RenameUserTags(ctx, userID, []string{"promo", "promo-2024"}, "marketing")
+	s.Require().NoError(err)
+	s.Require().Equal(2, updated)
+
+	tags, err = srv.GetUserTags(ctx, userID)
+	s.Require().NoError(err)
+	s.Require().Equal([]entity.TagStats{
+		{Tag: "docs", URLs: 1},
+		{Tag: "marketing", URLs: 2},
+		{Tag: "q1", URLs: 1},
+	}, tags)
+
+	// чужие ссылки не меняются
+	url, err := backupStorage.GetByHash(ctx, "d")
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"promo"}, url.Tags)
+	url, err = backupStorage.GetByHash(ctx, "a")
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"marketing", "q1"}, url.Tags)
+}
+
+func (s *ServiceURLMemorySuite) TestSearchUserURLs() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	mainStorage, backupStorage := storage.NewMemoryStorage(), storage.NewMemoryStorage()
+	srv := NewURLService(logger.CreateLogger(cfg.LogLevel), mainStorage, backupStorage, hasher.NewMockHasher(), cfg)
+	userID, otherID := uuid.New(), uuid.New()
+
+	urls := []struct {
+		short    string
+		original string
+		userID   uuid.UUID
+		title    string
+		notes    string
+	}{
+		{short: "spring", original: "https://shop.test/sale", userID: userID, title: "Spring sale"},
+		{short: "onboard", original: "https://docs.test/start", userID: userID, notes: "linked from sale email"},
+		{short: "pricing", original: "https://shop.test/pricing", userID: userID, title: "Pricing"},
+		{short: "foreign", original: "https://shop.test/sale-2", userID: otherID, title: "Sale"},
+	}
+	for _, u := range urls {
+		_, err := mainStorage.Add(ctx, u.short, u.original, u.userID)
+		s.Require().NoError(err)
+		title, notes := u.title, u.notes
+		s.Require().NoError(mainStorage.UpdateURL(ctx, u.short, &entity.URLPatch{Title: &title, Notes: &notes}))
+	}
+	shorts := func(urls []*entity.URL) []string {
+		res := make([]string, len(urls))
+		for i, v := range urls {
+			res[i] = v.Short
+		}
+
+		return res
+	}
+
+	found, total, err := srv.SearchUserURLs(ctx, userID, "sale", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(2, total)
+	s.Require().Equal([]string{"onboard", "spring"}, shorts(found))
+
+	found, total, err = srv.SearchUserURLs(ctx, userID, "pricng", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(1, total)
+	s.Require().Equal([]string{"pricing"}, shorts(found))
+
+	found, total, err = srv.SearchUserURLs(ctx, userID, "shop", 1, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(2, total)
+	s.Require().Len(found, 1)
+}
+
+func (s *ServiceURLMemorySuite) TestImportURLs() {
+	ctx := context.Background()
+	cfg := config.NewConfig("test", "http://test:8080", "", "", false, []byte("0123456789abcdef"), 10, 3, config.ModeTest)
+	mainStorage, backupStorage := storage.NewMemoryStorage(), storage.NewMemoryStorage()
+	srv := NewURLService(
+		logger.CreateLogger(cfg.LogLevel),
+		mainStorage,
+		backupStorage,
+		hasher.NewRandHasher(hasher.Alphabet),
+		cfg,
+	)
+	userID := uuid.New()
+	_, err := mainStorage.Add(ctx, "promo", "https://example.com/existing", userID)
+	s.Require().NoError(err)
+
+	res, err := srv.ImportURLs(ctx, []*entity.URL{
+		{Original: "https://example.com/existing", Short: "old"},
+		{Original: "https://example.com/a", Short: "promo"},
+		{Original: "https://example.com/b", Short: "docs", Title: "Docs", Tags: []string{"help"}},
+		{Original: "https://example.com/b", Short: "docs-2"},
+		{Original: "https://example.com/c", Short: "docs"},
+		{Original: "https://example.com/d"},
+	}, 6, userID, "")
+	s.Require().NoError(err)
+	s.Require().Len(res, 6)
+
+	s.Require().Equal(entity.ImportExists, res[0].Status)
+	s.Require().Equal("promo", res[0].URL.Short)
+	s.Require().Equal(entity.ImportRenamed, res[1].Status)
+	s.Require().Len(res[1].URL.Short, 6)
+	s.Require().Equal(entity.ImportCreated, res[2].Status)
+	s.Require().Equal("docs", res[2].URL.Short)
+	s.Require().Equal(entity.ImportExists, res[3].Status)
+	s.Require().Equal("docs", res[3].URL.Short)
+	s.Require().Equal(entity.ImportRenamed, res[4].Status)
+	s.Require().Equal(entity.ImportCreated, res[5].Status)
+	s.Require().Len(res[5].URL.Short, 6)
+
+	url, err := backupStorage.GetByHash(ctx, "docs")
+	s.Require().NoError(err)
+	s.Require().Equal(userID, url.UserID)
+	s.Require().Equal("Docs", url.Title)
+	s.Require().Equal([]string{"help"}, url.Tags)
+
+	s.Run("url of another user is not reported", func() {
+		_, err = mainStorage.Add(ctx, "foreign", "https://example.com/foreign", uuid.New())
+		s.Require().NoError(err)
+		urls := []*entity.URL{{Original: "https://example.com/foreign"}}
+		res, err = srv.ImportURLs(ctx, urls, 6, userID, "")
+		s.Require().NoError(err)
+		s.Require().Equal(entity.ImportCreated, res[0].Status)
+		s.Require().NotEqual("foreign", res[0].URL.Short)
+		s.Require().Equal(userID, res[0].URL.UserID)
+	})
+
+	s.Run("codes of branded domain", func() {
+		urls := []*entity.URL{{Original: "https://example.com/e", Short: "docs"}}
+		res, err = srv.ImportURLs(ctx, urls, 6, userID, "go.test")
+		s.Require().NoError(err)
+		s.Require().Equal(entity.ImportCreated, res[0].Status)
+		s.Require().Equal("go.test/docs", res[0].URL.Short)
+	})
+}
+
+func (s *ServiceURLMemorySuite) TestURLPassword() {
+	ctx := context.Background()
+	userID := uuid.New()
+	s.mainStorage.SetGetByHashResponse(&entity.URL{Short: "short", Original: "some_url", UserID: userID}, nil)
+
+	password := "secret"
+	url, err := s.service.UpdateUserURL(ctx, userID, "short", &entity.URLPatch{Password: &password})
+	s.Require().NoError(err)
+	s.Require().True(url.HasPassword())
+	s.Require().NotEqual(password, url.PasswordHash)
+	s.Require().True(s.service.VerifyURLPassword(url, password))
+	s.Require().False(s.service.VerifyURLPassword(url, "wrong"))
+
+	s.Run("remove password", func() {
+		empty := ""
+		url, err := s.service.UpdateUserURL(ctx, userID, "short", &entity.URLPatch{Password: &empty})
+		s.Require().NoError(err)
+		s.Require().False(url.HasPassword())
+		s.Require().True(s.service.VerifyURLPassword(url, ""))
+	})
+
+	s.Run("url of another user", func() {
+		_, err := s.service.UpdateUserURL(ctx, uuid.New(), "short", &entity.URLPatch{Password: &password})
+		s.Require().ErrorIs(err, customerror.ErrURLNotFound)
+	})
+}
+
+func (s *ServiceURLMemorySuite) TestConsumeURLClick() {
+	ctx := context.Background()
+
+	s.Run("url without limit is not counted", func() {
+		s.mainStorage.SetConsumeClickResponse(false, nil)
+		s.Require().NoError(s.service.ConsumeURLClick(ctx, &entity.URL{Short: "short"}))
+	})
+
+	s.Run("limit is not exhausted", func() {
+		s.mainStorage.SetConsumeClickResponse(true, nil)
+		s.Require().NoError(s.service.ConsumeURLClick(ctx, &entity.URL{Short: "short", MaxClicks: 1}))
+	})
+
+	s.Run("limit is exhausted", func() {
+		s.mainStorage.SetConsumeClickResponse(false, nil)
+		err := s.service.ConsumeURLClick(ctx, &entity.URL{Short: "short", MaxClicks: 1, Clicks: 1})
+		s.Require().ErrorIs(err, customerror.ErrURLExhausted)
+	})
+
+	s.Run("storage error", func() {
+		s.mainStorage.SetConsumeClickResponse(false, ErrEmpty)
+		err := s.service.ConsumeURLClick(ctx, &entity.URL{Short: "short", MaxClicks: 1})
+		s.Require().ErrorIs(err, ErrEmpty)
+	})
+}
+
+func (s *ServiceURLMemorySuite) TestGetURLVariantStats() {
+	ctx := context.Background()
+	userID := uuid.New()
+	s.mainStorage.SetGetByHashResponse(&entity.URL{
+		Short:    "short",
+		Original: "some_url",
+		UserID:   userID,
+		Variants: []entity.Variant{
+			{ID: "a", URL: "https://a.test", Weight: 70},
+			{ID: "b", URL: "https://b.test", Weight: 30},
+		},
+	}, nil)
+	s.mainStorage.SetGetVariantClicksResponse(map[string]int64{"a": 7}, nil)
+
+	s.Run("owner gets clicks per variant", func() {
+		stats, err := s.service.GetURLVariantStats(ctx, userID, "short")
+		s.Require().NoError(err)
+		s.Require().Equal([]entity.VariantStats{
+			{Variant: entity.Variant{ID: "a", URL: "https://a.test", Weight: 70}, Clicks: 7},
+			{Variant: entity.Variant{ID: "b", URL: "https://b.test", Weight: 30}, Clicks: 0},
+		}, stats)
+	})
+
+	s.Run("url of another user", func() {
+		_, err := s.service.GetURLVariantStats(ctx, uuid.New(), "short")
+		s.Require().ErrorIs(err, customerror.ErrURLNotFound)
+	})
+}
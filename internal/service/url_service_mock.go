@@ -13,13 +13,36 @@ import (
 type URLServiceMock struct {
 	makeShortURLEntity        *entity.URL
 	makeShortURLError         error
+	makeShortURLSettings      *entity.URLPatch
 	getShortURLEntity         *entity.URL
 	getShortURLError          error
 	makeShortURLBatchResponse []response.ShortenBatchResponse
 	makeShortURLBatchError    error
+	makeShortURLBatchErrors   []error
 	getUserURLsEntities       []*entity.URL
 	getUserURLsError          error
+	getUserURLsTag            string
 	deleteUserURLsError       error
+	getUserQuotaUsageEntity   *entity.QuotaUsage
+	getUserQuotaUsageError    error
+	updateUserURLEntity       *entity.URL
+	updateUserURLError        error
+	verifyURLPasswordResult   bool
+	consumeURLClickError      error
+	countVariantClickError    error
+	getURLVariantStatsEntity  []entity.VariantStats
+	getURLVariantStatsError   error
+	getUserTagsEntity         []entity.TagStats
+	getUserTagsError          error
+	renameUserTagsCount       int
+	renameUserTagsError       error
+	searchUserURLsEntities    []*entity.URL
+	searchUserURLsTotal       int
+	searchUserURLsError       error
+	exportUserURLsEntities    []*entity.URL
+	exportUserURLsError       error
+	importURLsResults         []entity.ImportResult
+	importURLsError           error
 }
 
 // NewURLServiceMock Constructor for URLServiceMock.
@@ -33,7 +56,11 @@ func (s *URLServiceMock) MakeShortURL(
 	url string,
 	length int,
 	userID uuid.UUID,
+	domain string,
+	settings *entity.URLPatch,
 ) (*entity.URL, error) {
+	s.makeShortURLSettings = settings
+
 	return s.makeShortURLEntity, s.makeShortURLError
 }
 
@@ -43,8 +70,13 @@ func (s *URLServiceMock) SetMakeShortURLResult(e *entity.URL, err error) {
 	s.makeShortURLError = err
 }
 
+// MakeShortURLSettings mock return settings passed to last MakeShortURL call.
+func (s *URLServiceMock) MakeShortURLSettings() *entity.URLPatch {
+	return s.makeShortURLSettings
+}
+
 // GetShortURL mock.
-func (s *URLServiceMock) GetShortURL(ctx context.Context, url string) (*entity.URL, error) {
+func (s *URLServiceMock) GetShortURL(ctx context.Context, url string, userID uuid.UUID) (*entity.URL, error) {
 	return s.getShortURLEntity, s.getShortURLError
 }
 
@@ -64,9 +96,18 @@ func (s *URLServiceMock) MakeShortURLBatch(
 	ctx context.Context,
 	urls []*entity.URL,
 	baseURL string,
+	userID uuid.UUID,
 ) (
 	[]response.ShortenBatchResponse, error,
 ) {
+	if len(s.makeShortURLBatchErrors) > 0 {
+		err := s.makeShortURLBatchErrors[0]
+		s.makeShortURLBatchErrors = s.makeShortURLBatchErrors[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return s.makeShortURLBatchResponse, s.makeShortURLBatchError
 }
 
@@ -76,8 +117,37 @@ func (s *URLServiceMock) SetMakeShortURLBatchResult(resp []response.ShortenBatch
 	s.makeShortURLBatchError = err
 }
 
+// SetMakeShortURLBatchErrors set errors of next calls of MakeShortURLBatch one by one, nil is usual result.
+func (s *URLServiceMock) SetMakeShortURLBatchErrors(errs ...error) {
+	s.makeShortURLBatchErrors = errs
+}
+
+// ImportURLs mock.
+func (s *URLServiceMock) ImportURLs(
+	ctx context.Context,
+	urls []*entity.URL,
+	length int,
+	userID uuid.UUID,
+	domain string,
+) ([]entity.ImportResult, error) {
+	return s.importURLsResults, s.importURLsError
+}
+
+// SetImportURLsResult mock, results must be in order of imported urls.
+func (s *URLServiceMock) SetImportURLsResult(res []entity.ImportResult, err error) {
+	s.importURLsResults = res
+	s.importURLsError = err
+}
+
 // GetUserURLs mock.
-func (s *URLServiceMock) GetUserURLs(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error) {
+func (s *URLServiceMock) GetUserURLs(
+	ctx context.Context,
+	userID uuid.UUID,
+	baseURL string,
+	tag string,
+) ([]*entity.URL, error) {
+	s.getUserURLsTag = tag
+
 	return s.getUserURLsEntities, s.getUserURLsError
 }
 
@@ -87,6 +157,46 @@ func (s *URLServiceMock) SetGetUserURLsResult(e []*entity.URL, err error) {
 	s.getUserURLsError = err
 }
 
+// GetUserURLsTag mock return tag passed to last GetUserURLs call.
+func (s *URLServiceMock) GetUserURLsTag() string {
+	return s.getUserURLsTag
+}
+
+// ExportUserURLs mock.
+func (s *URLServiceMock) ExportUserURLs(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error {
+	for _, v := range s.exportUserURLsEntities {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return s.exportUserURLsError
+}
+
+// SetExportUserURLsResult mock, fn is called for every URL and then err is returned.
+func (s *URLServiceMock) SetExportUserURLsResult(e []*entity.URL, err error) {
+	s.exportUserURLsEntities = e
+	s.exportUserURLsError = err
+}
+
+// SearchUserURLs mock.
+func (s *URLServiceMock) SearchUserURLs(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	return s.searchUserURLsEntities, s.searchUserURLsTotal, s.searchUserURLsError
+}
+
+// SetSearchUserURLsResult mock.
+func (s *URLServiceMock) SetSearchUserURLsResult(e []*entity.URL, total int, err error) {
+	s.searchUserURLsEntities = e
+	s.searchUserURLsTotal = total
+	s.searchUserURLsError = err
+}
+
 // DeleteUserURLs mock.
 func (s *URLServiceMock) DeleteUserURLs(
 	ctx context.Context,
@@ -102,3 +212,97 @@ func (s *URLServiceMock) DeleteUserURLs(
 func (s *URLServiceMock) SetDeleteUserURLsResult(err error) {
 	s.deleteUserURLsError = err
 }
+
+// GetUserQuotaUsage mock.
+func (s *URLServiceMock) GetUserQuotaUsage(ctx context.Context, userID uuid.UUID) (*entity.QuotaUsage, error) {
+	return s.getUserQuotaUsageEntity, s.getUserQuotaUsageError
+}
+
+// SetGetUserQuotaUsageResult mock.
+func (s *URLServiceMock) SetGetUserQuotaUsageResult(u *entity.QuotaUsage, err error) {
+	s.getUserQuotaUsageEntity = u
+	s.getUserQuotaUsageError = err
+}
+
+// UpdateUserURL mock.
+func (s *URLServiceMock) UpdateUserURL(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+	patch *entity.URLPatch,
+) (*entity.URL, error) {
+	return s.updateUserURLEntity, s.updateUserURLError
+}
+
+// SetUpdateUserURLResult mock.
+func (s *URLServiceMock) SetUpdateUserURLResult(e *entity.URL, err error) {
+	s.updateUserURLEntity = e
+	s.updateUserURLError = err
+}
+
+// VerifyURLPassword mock.
+func (s *URLServiceMock) VerifyURLPassword(url *entity.URL, password string) bool {
+	return s.verifyURLPasswordResult
+}
+
+// SetVerifyURLPasswordResult mock.
+func (s *URLServiceMock) SetVerifyURLPasswordResult(ok bool) {
+	s.verifyURLPasswordResult = ok
+}
+
+// ConsumeURLClick mock.
+func (s *URLServiceMock) ConsumeURLClick(ctx context.Context, url *entity.URL) error {
+	return s.consumeURLClickError
+}
+
+// SetConsumeURLClickResult mock.
+func (s *URLServiceMock) SetConsumeURLClickResult(err error) {
+	s.consumeURLClickError = err
+}
+
+// CountVariantClick mock.
+func (s *URLServiceMock) CountVariantClick(ctx context.Context, url *entity.URL, variant string) error {
+	return s.countVariantClickError
+}
+
+// SetCountVariantClickResult mock.
+func (s *URLServiceMock) SetCountVariantClickResult(err error) {
+	s.countVariantClickError = err
+}
+
+// GetURLVariantStats mock.
+func (s *URLServiceMock) GetURLVariantStats(
+	ctx context.Context,
+	userID uuid.UUID,
+	short string,
+) ([]entity.VariantStats, error) {
+	return s.getURLVariantStatsEntity, s.getURLVariantStatsError
+}
+
+// SetGetURLVariantStatsResult mock.
+func (s *URLServiceMock) SetGetURLVariantStatsResult(stats []entity.VariantStats, err error) {
+	s.getURLVariantStatsEntity = stats
+	s.getURLVariantStatsError = err
+}
+
+// GetUserTags mock.
+func (s *URLServiceMock) GetUserTags(ctx context.Context, userID uuid.UUID) ([]entity.TagStats, error) {
+	return s.getUserTagsEntity, s.getUserTagsError
+}
+
+// SetGetUserTagsResult mock.
+func (s *URLServiceMock) SetGetUserTagsResult(tags []entity.TagStats, err error) {
+	s.getUserTagsEntity = tags
+	s.getUserTagsError = err
+}
+
+// RenameUserTags mock.
+func (s *URLServiceMock) RenameUserTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	return s.renameUserTagsCount, s.renameUserTagsError
+}
+
+// SetRenameUserTagsResult mock.
+func (s *URLServiceMock) SetRenameUserTagsResult(n int, err error) {
+	s.renameUserTagsCount = n
+	s.renameUserTagsError = err
+}
@@ -3,8 +3,11 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/internal/contract"
@@ -12,7 +15,16 @@ import (
 	"github.com/vagafonov/shortener/pkg/entity"
 )
 
-const batchInsertSize = 100
+// urlColumns columns of urls table in order of scanURL.
+const urlColumns = `id, short, original, title, description, notes, tags, user_id, team_id, visibility,
+	password_hash, max_clicks, clicks, redirect_status, query_mode, utm, device_rules, country_rules, variants,
+	preview, link_check, created_at, deleted_at`
+
+// urlSearchText expression of text matched by search, it is the same as expression of trigram index urls_search_idx.
+const urlSearchText = `lower(short || ' ' || original || ' ' || title || ' ' || description || ' ' || notes)`
+
+// likeEscaper escape wildcards of LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
 type dbStorage struct {
 	connection *sql.DB
@@ -27,11 +39,30 @@ func NewDBStorage(db *sql.DB) contract.Storage {
 
 // GetByHash get short urls by hash from database.
 func (s *dbStorage) GetByHash(ctx context.Context, key string) (*entity.URL, error) {
-	q := `SELECT id, short, original, user_id, deleted_at FROM urls WHERE short = $1`
-	row := s.connection.QueryRowContext(ctx, q, key)
-	var url entity.URL
-	err := row.Scan(&url.UUID, &url.Short, &url.Original, &url.UserID, &url.DeletedAt)
+	q := `SELECT ` + urlColumns + ` FROM urls WHERE short = $1`
+
+	return s.getByHash(ctx, q, key)
+}
+
+// GetVisibleByHash get short url by hash if user can follow it.
+// Private URLs are returned only to owner or members of team, for others they do not exist.
+func (s *dbStorage) GetVisibleByHash(ctx context.Context, key string, userID uuid.UUID) (*entity.URL, error) {
+	q := `SELECT ` + urlColumns + ` FROM urls WHERE short = $1 AND (
+			visibility <> 'private'
+			OR (team_id IS NULL AND user_id = $2)
+			OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $2)
+		)`
+
+	return s.getByHash(ctx, q, key, userID)
+}
+
+func (s *dbStorage) getByHash(ctx context.Context, q string, args ...any) (*entity.URL, error) {
+	url, err := scanURL(s.connection.QueryRowContext(ctx, q, args...))
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
 		return nil, fmt.Errorf("cannot get url by hash: %w", err)
 	}
 
@@ -39,18 +70,191 @@ func (s *dbStorage) GetByHash(ctx context.Context, key string) (*entity.URL, err
 		return nil, customerror.ErrURLDeleted
 	}
 
+	return url, nil
+}
+
+// scanURL scan row selected with urlColumns.
+func scanURL(row rowScanner) (*entity.URL, error) {
+	var url entity.URL
+	var teamID uuid.NullUUID
+	var tags, utm, deviceRules, countryRules, variants, preview, linkCheck []byte
+	err := row.Scan(
+		&url.UUID,
+		&url.Short,
+		&url.Original,
+		&url.Title,
+		&url.Description,
+		&url.Notes,
+		&tags,
+		&url.UserID,
+		&teamID,
+		&url.Visibility,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.Clicks,
+		&url.RedirectStatus,
+		&url.QueryMode,
+		&utm,
+		&deviceRules,
+		&countryRules,
+		&variants,
+		&preview,
+		&linkCheck,
+		&url.CreatedAt,
+		&url.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	url.TeamID = teamID.UUID
+	if err = json.Unmarshal(tags, &url.Tags); err != nil {
+		return nil, fmt.Errorf("cannot decode tags: %w", err)
+	}
+	if url.UTM, err = unmarshalUTM(utm); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(deviceRules, &url.DeviceRules); err != nil {
+		return nil, fmt.Errorf("cannot decode device rules: %w", err)
+	}
+	if err = json.Unmarshal(countryRules, &url.CountryRules); err != nil {
+		return nil, fmt.Errorf("cannot decode country rules: %w", err)
+	}
+	if err = json.Unmarshal(variants, &url.Variants); err != nil {
+		return nil, fmt.Errorf("cannot decode variants: %w", err)
+	}
+	if preview != nil {
+		if err = json.Unmarshal(preview, &url.Preview); err != nil {
+			return nil, fmt.Errorf("cannot decode preview: %w", err)
+		}
+	}
+	if linkCheck != nil {
+		if err = json.Unmarshal(linkCheck, &url.LinkCheck); err != nil {
+			return nil, fmt.Errorf("cannot decode link check: %w", err)
+		}
+	}
+
 	return &url, nil
 }
 
-// GetByURL get short urls by url from database.
-func (s *dbStorage) GetByURL(ctx context.Context, val string) (*entity.URL, error) {
-	q := `SELECT id, short, original FROM urls WHERE original = $1`
-	row := s.connection.QueryRowContext(ctx, q, val)
-	if row.Err() != nil {
-		return nil, fmt.Errorf("sql query error: %w", row.Err())
+// UpdateURL change URL settings. Only not nil fields of patch are updated.
+func (s *dbStorage) UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error {
+	args := []any{short}
+	sets := make([]string, 0)
+	set := func(column string, v any) {
+		args = append(args, v)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
 	}
-	var url entity.URL
-	err := row.Scan(&url.UUID, &url.Short, &url.Original)
+	if patch.Title != nil {
+		set("title", *patch.Title)
+	}
+	if patch.Description != nil {
+		set("description", *patch.Description)
+	}
+	if patch.Notes != nil {
+		set("notes", *patch.Notes)
+	}
+	if patch.Tags != nil {
+		tags, err := json.Marshal(patch.Tags)
+		if err != nil {
+			return fmt.Errorf("cannot encode tags: %w", err)
+		}
+		set("tags", string(tags))
+	}
+	if patch.Preview != nil {
+		preview, err := json.Marshal(patch.Preview)
+		if err != nil {
+			return fmt.Errorf("cannot encode preview: %w", err)
+		}
+		set("preview", string(preview))
+	}
+	if patch.LinkCheck != nil {
+		linkCheck, err := json.Marshal(patch.LinkCheck)
+		if err != nil {
+			return fmt.Errorf("cannot encode link check: %w", err)
+		}
+		set("link_check", string(linkCheck))
+	}
+	if patch.Visibility != nil {
+		set("visibility", *patch.Visibility)
+	}
+	if patch.PasswordHash != nil {
+		set("password_hash", *patch.PasswordHash)
+	}
+	if patch.MaxClicks != nil {
+		set("max_clicks", *patch.MaxClicks)
+	}
+	if patch.RedirectStatus != nil {
+		set("redirect_status", *patch.RedirectStatus)
+	}
+	if patch.QueryMode != nil {
+		set("query_mode", *patch.QueryMode)
+	}
+	if patch.UTM != nil {
+		utm, err := json.Marshal(patch.UTM)
+		if err != nil {
+			return fmt.Errorf("cannot encode utm: %w", err)
+		}
+		set("utm", string(utm))
+	}
+	if patch.DeviceRules != nil {
+		rules, err := json.Marshal(patch.DeviceRules)
+		if err != nil {
+			return fmt.Errorf("cannot encode device rules: %w", err)
+		}
+		set("device_rules", string(rules))
+	}
+	if patch.CountryRules != nil {
+		rules, err := json.Marshal(patch.CountryRules)
+		if err != nil {
+			return fmt.Errorf("cannot encode country rules: %w", err)
+		}
+		set("country_rules", string(rules))
+	}
+	if patch.Variants != nil {
+		variants, err := json.Marshal(patch.Variants)
+		if err != nil {
+			return fmt.Errorf("cannot encode variants: %w", err)
+		}
+		set("variants", string(variants))
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	q := `UPDATE urls SET ` + strings.Join(sets, ", ") + ` WHERE short = $1`
+	if _, err := s.connection.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("cannot update url: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeClick count click on URL if its clicks limit is not exhausted.
+// Conditional update makes concurrent clicks unable to exceed the limit.
+func (s *dbStorage) ConsumeClick(ctx context.Context, short string) (bool, error) {
+	q := `UPDATE urls SET clicks = clicks + 1
+		WHERE short = $1 AND deleted_at IS NULL AND (max_clicks = 0 OR clicks < max_clicks)`
+	res, err := s.connection.ExecContext(ctx, q, short)
+	if err != nil {
+		return false, fmt.Errorf("cannot consume url click: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("cannot get affected rows when consume url click: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetByURL get not deleted personal URL of user by url in namespace of domain from database.
+func (s *dbStorage) GetByURL(ctx context.Context, val string, userID uuid.UUID, domain string) (*entity.URL, error) {
+	q := `SELECT ` + urlColumns + ` FROM urls
+		WHERE original = $1 AND team_id IS NULL AND user_id = $2 AND deleted_at IS NULL
+			AND (CASE WHEN strpos(short, '/') = 0 THEN '' ELSE left(short, strpos(short, '/') - 1) END) = $3
+		ORDER BY created_at
+		LIMIT 1`
+	url, err := scanURL(s.connection.QueryRowContext(ctx, q, val, userID, domain))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil //nolint:nilnil
@@ -59,14 +263,15 @@ func (s *dbStorage) GetByURL(ctx context.Context, val string) (*entity.URL, erro
 		return nil, fmt.Errorf("cannot get url by original url: %w", err)
 	}
 
-	return &url, nil
+	return url, nil
 }
 
 // Add create new short url in database.
 func (s *dbStorage) Add(ctx context.Context, hash string, url string, userID uuid.UUID) (*entity.URL, error) {
-	q := `INSERT INTO urls (id, short, original, user_id) VALUES ($1, $2, $3, $4)`
+	q := `INSERT INTO urls (id, short, original, user_id, created_at) VALUES ($1, $2, $3, $4, $5)`
 	id := uuid.New()
-	res, err := s.connection.ExecContext(ctx, q, id, hash, url, userID)
+	createdAt := time.Now().UTC()
+	res, err := s.connection.ExecContext(ctx, q, id, hash, url, userID, createdAt)
 	if err != nil {
 		return nil, fmt.Errorf("cannot add url: %w", err)
 	}
@@ -81,10 +286,11 @@ func (s *dbStorage) Add(ctx context.Context, hash string, url string, userID uui
 	}
 
 	return &entity.URL{
-		UUID:     id,
-		Short:    hash,
-		Original: url,
-		UserID:   userID,
+		UUID:      id,
+		Short:     hash,
+		Original:  url,
+		UserID:    userID,
+		CreatedAt: createdAt,
 	}, nil
 }
 
@@ -122,19 +328,19 @@ func (s *dbStorage) AddBatch(ctx context.Context, b []*entity.URL) (int, error)
 	for _, v := range b {
 		v.UUID = uuid.New()
 		bufIns = append(bufIns, v)
-		if len(bufIns) == batchInsertSize {
+		if len(bufIns) == contract.BatchInsertSize {
 			if err := s.batchInsert(ctx, bufIns); err != nil {
 				return inserted, err
 			}
-			inserted = +len(bufIns)
+			inserted += len(bufIns)
 			bufIns = nil
 		}
 	}
 
 	if err := s.batchInsert(ctx, bufIns); err != nil {
-		return 0, err
+		return inserted, err
 	}
-	inserted = +len(bufIns)
+	inserted += len(bufIns)
 
 	return inserted, nil
 }
@@ -146,14 +352,35 @@ func (s *dbStorage) batchInsert(ctx context.Context, urls []*entity.URL) error {
 	}
 	defer tx.Rollback() //nolint:errcheck
 
-	stmt, err := tx.PrepareContext(ctx, "INSERT INTO urls (id, short, original, user_id) VALUES($1, $2, $3, $4)")
+	stmt, err := tx.PrepareContext(
+		ctx,
+		`INSERT INTO urls (id, short, original, user_id, created_at, visibility, title, description, notes, tags,
+			password_hash, max_clicks, redirect_status, query_mode, utm, device_rules, country_rules, variants)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+	)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	for _, u := range urls {
-		_, err := stmt.ExecContext(ctx, u.UUID, u.Short, u.Original, u.UserID)
+		if u.CreatedAt.IsZero() {
+			u.CreatedAt = time.Now().UTC()
+		}
+		if u.Visibility == "" {
+			u.Visibility = entity.VisibilityPublic
+		}
+		columns, err := encodeURLColumns(u)
+		if err != nil {
+			return err
+		}
+		_, err = stmt.ExecContext(
+			ctx,
+			u.UUID, u.Short, u.Original, u.UserID, u.CreatedAt, u.Visibility,
+			u.Title, u.Description, u.Notes, columns.tags,
+			u.PasswordHash, u.MaxClicks, u.RedirectStatus, u.QueryMode,
+			columns.utm, columns.deviceRules, columns.countryRules, columns.variants,
+		)
 		if err != nil {
 			return err
 		}
@@ -162,11 +389,90 @@ func (s *dbStorage) batchInsert(ctx context.Context, urls []*entity.URL) error {
 	return tx.Commit()
 }
 
-// GetAllURLsByUser Get all urls by user from database.
-func (s *dbStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error) {
-	q := `SELECT id, short, original FROM urls WHERE user_id = $1 LIMIT 1000`
+// urlJSONColumns encoded values of jsonb columns of URL.
+type urlJSONColumns struct {
+	tags, utm, deviceRules, countryRules, variants string
+}
 
-	rows, err := s.connection.QueryContext(ctx, q, userID)
+// encodeURLColumns encode settings of URL stored in jsonb columns, empty settings are stored as column defaults.
+func encodeURLColumns(u *entity.URL) (*urlJSONColumns, error) {
+	var c urlJSONColumns
+	var err error
+	if c.tags, err = encodeJSONColumn(u.Tags, "[]"); err != nil {
+		return nil, fmt.Errorf("cannot encode tags: %w", err)
+	}
+	if c.utm, err = encodeJSONColumn(u.UTM, "{}"); err != nil {
+		return nil, fmt.Errorf("cannot encode utm: %w", err)
+	}
+	if c.deviceRules, err = encodeJSONColumn(u.DeviceRules, "[]"); err != nil {
+		return nil, fmt.Errorf("cannot encode device rules: %w", err)
+	}
+	if c.countryRules, err = encodeJSONColumn(u.CountryRules, "[]"); err != nil {
+		return nil, fmt.Errorf("cannot encode country rules: %w", err)
+	}
+	if c.variants, err = encodeJSONColumn(u.Variants, "[]"); err != nil {
+		return nil, fmt.Errorf("cannot encode variants: %w", err)
+	}
+
+	return &c, nil
+}
+
+// encodeJSONColumn encode value of jsonb column, nil value is replaced with empty one.
+func encodeJSONColumn(v any, empty string) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if string(b) == "null" {
+		return empty, nil
+	}
+
+	return string(b), nil
+}
+
+// GetLiveURLs get not deleted URLs in order of short after given one, so all URLs can be walked page by page.
+func (s *dbStorage) GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error) {
+	q := `SELECT ` + urlColumns + ` FROM urls WHERE deleted_at IS NULL AND short > $1 ORDER BY short LIMIT $2`
+
+	rows, err := s.connection.QueryContext(ctx, q, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	urls := make([]*entity.URL, 0, limit)
+	for rows.Next() {
+		var u *entity.URL
+		u, err = scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get live urls: %w", err)
+		}
+		urls = append(urls, u)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan live urls: %w", err)
+	}
+
+	return urls, nil
+}
+
+// GetAllURLsByUser Get all urls by user from database.
+// Personal URLs of user and URLs of teams where user is a member are returned.
+func (s *dbStorage) GetAllURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	baseURL string,
+	tag string,
+) ([]*entity.URL, error) {
+	q := `SELECT ` + urlColumns + ` FROM urls
+		WHERE ((team_id IS NULL AND user_id = $1)
+			OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $1))
+			AND ($2 = '' OR tags ? $2)
+		LIMIT 1000`
+
+	rows, err := s.connection.QueryContext(ctx, q, userID, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -174,13 +480,12 @@ func (s *dbStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID, base
 
 	urls := make([]*entity.URL, 0)
 	for rows.Next() {
-		var u entity.URL
-		err = rows.Scan(&u.UUID, &u.Short, &u.Original)
+		var u *entity.URL
+		u, err = scanURL(rows)
 		if err != nil {
 			return nil, fmt.Errorf("cannot get all urls by user: %w", err)
 		}
-		u.Short = fmt.Sprintf("%s/%s", baseURL, u.Short)
-		urls = append(urls, &u)
+		urls = append(urls, u)
 	}
 
 	err = rows.Err()
@@ -191,6 +496,120 @@ func (s *dbStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID, base
 	return urls, nil
 }
 
+// RenameTags replace tags from with tag to on not deleted URLs which user can edit with one update.
+// Tags are kept sorted in byte order and without duplicates like entity.NormalizeTags does.
+func (s *dbStorage) RenameTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	fromJSON, err := json.Marshal(from)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode tags: %w", err)
+	}
+	q := `UPDATE urls SET tags = (
+			SELECT jsonb_agg(t ORDER BY t COLLATE "C") FROM (
+				SELECT t FROM jsonb_array_elements_text(urls.tags) AS t WHERE NOT $2::jsonb ? t
+				UNION SELECT $3::text
+			) AS renamed(t)
+		)
+		WHERE deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM jsonb_array_elements_text(urls.tags) AS t WHERE $2::jsonb ? t)
+			AND (
+				(team_id IS NULL AND user_id = $1)
+				OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $1 AND role IN ('editor', 'admin'))
+			)`
+	res, err := s.connection.ExecContext(ctx, q, userID, string(fromJSON), to)
+	if err != nil {
+		return 0, fmt.Errorf("cannot rename tags: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get affected rows when rename tags: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+// EachURLByUser call fn for every personal URL of user including deleted ones in order of creation.
+// Rows are read one by one, so URLs are not loaded in memory at once.
+func (s *dbStorage) EachURLByUser(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error {
+	q := `SELECT ` + urlColumns + ` FROM urls WHERE team_id IS NULL AND user_id = $1 ORDER BY created_at, short`
+	rows, err := s.connection.QueryContext(ctx, q, userID)
+	if err != nil {
+		return fmt.Errorf("cannot get urls of user: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("cannot scan url of user: %w", err)
+		}
+		if err = fn(url); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// SearchURLsByUser find live URLs which user can view by substring or trigram word similarity.
+// URLs containing query go first, then URLs are ordered by similarity.
+func (s *dbStorage) SearchURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	where := ` WHERE deleted_at IS NULL
+		AND ((team_id IS NULL AND user_id = $1) OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $1))
+		AND (` + urlSearchText + ` LIKE $2 OR $3 <% ` + urlSearchText + `)`
+	args := []any{userID, "%" + likeEscaper.Replace(query) + "%", query}
+
+	var total int
+	if err := s.connection.QueryRowContext(ctx, `SELECT count(*) FROM urls`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("cannot count found urls: %w", err)
+	}
+
+	q := `SELECT ` + urlColumns + ` FROM urls` + where + `
+		ORDER BY strpos(` + urlSearchText + `, $3) > 0 DESC, word_similarity($3, ` + urlSearchText + `) DESC, short
+		LIMIT $4 OFFSET $5`
+	rows, err := s.connection.QueryContext(ctx, q, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot search urls: %w", err)
+	}
+	defer rows.Close()
+
+	urls := make([]*entity.URL, 0, limit)
+	for rows.Next() {
+		var u *entity.URL
+		u, err = scanURL(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot get found urls: %w", err)
+		}
+		urls = append(urls, u)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot scan found urls: %w", err)
+	}
+
+	return urls, total, nil
+}
+
+// unmarshalUTM decode UTM template stored as jsonb, empty template is nil.
+func unmarshalUTM(b []byte) (map[string]string, error) {
+	var utm map[string]string
+	if err := json.Unmarshal(b, &utm); err != nil {
+		return nil, fmt.Errorf("cannot decode utm: %w", err)
+	}
+	if len(utm) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return utm, nil
+}
+
 // DeleteURLsByUser delete URLS by user.
 func (s *dbStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error {
 	tx, err := s.connection.Begin()
@@ -205,7 +624,12 @@ func (s *dbStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batc
 		return fmt.Errorf("failed to rollback transaction for delete urls: %w", err)
 	}
 
-	stmt, err := s.connection.PrepareContext(ctx, "UPDATE urls SET deleted_at = NOW() WHERE short = $1")
+	// удалять можно свои ссылки и ссылки команд, где пользователь editor или admin
+	stmt, err := s.connection.PrepareContext(ctx, `UPDATE urls SET deleted_at = NOW()
+		WHERE short = $2 AND (
+			(team_id IS NULL AND user_id = $1)
+			OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $1 AND role IN ('editor', 'admin'))
+		)`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare context for delete urls: %w", err)
 	}
@@ -217,8 +641,7 @@ func (s *dbStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batc
 	}
 
 	for _, v := range batch {
-		// _, err := stmt.ExecContext(ctx, userID, v)
-		_, err := stmt.ExecContext(ctx, v)
+		_, err := stmt.ExecContext(ctx, userID, v)
 		if err != nil {
 			return fmt.Errorf("failed to exec context for delete urls: %w", err)
 		}
@@ -227,6 +650,57 @@ func (s *dbStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batc
 	return tx.Commit()
 }
 
+// IncrementDailyUsage increase counter of URLs created by user per day.
+func (s *dbStorage) IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error {
+	q := `INSERT INTO user_quotas (user_id, day, created) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, day) DO UPDATE SET created = user_quotas.created + EXCLUDED.created`
+	if _, err := s.connection.ExecContext(ctx, q, userID, day, n); err != nil {
+		return fmt.Errorf("cannot increment daily usage: %w", err)
+	}
+
+	return nil
+}
+
+// ReserveDailyUsage increase counter of URLs created by user per day if it does not exceed limit.
+// Row of counter is locked by upsert, so concurrent reservations see result of each other.
+func (s *dbStorage) ReserveDailyUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+	n int,
+	limit int,
+) (bool, error) {
+	q := `INSERT INTO user_quotas (user_id, day, created)
+		SELECT $1, $2, $3::integer WHERE $4::integer <= 0 OR $3::integer <= $4::integer
+		ON CONFLICT (user_id, day) DO UPDATE SET created = user_quotas.created + EXCLUDED.created
+			WHERE $4::integer <= 0 OR user_quotas.created + EXCLUDED.created <= $4::integer
+		RETURNING created`
+	var created int
+	err := s.connection.QueryRowContext(ctx, q, userID, day, n, limit).Scan(&created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot reserve daily usage: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetQuotaUsage get URLs created by user per day and total active URLs of user.
+func (s *dbStorage) GetQuotaUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*entity.QuotaUsage, error) {
+	q := `SELECT
+		COALESCE((SELECT created FROM user_quotas WHERE user_id = $1 AND day = $2), 0),
+		(SELECT count(*) FROM urls WHERE user_id = $1 AND deleted_at IS NULL)`
+	var usage entity.QuotaUsage
+	err := s.connection.QueryRowContext(ctx, q, userID, day).Scan(&usage.CreatedToday, &usage.Active)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get quota usage: %w", err)
+	}
+
+	return &usage, nil
+}
+
 // Ping not implemented.
 func (s *dbStorage) Ping(ctx context.Context) error {
 	return s.connection.PingContext(ctx)
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddAccount save account in database.
+func (s *dbStorage) AddAccount(ctx context.Context, account *entity.Account) error {
+	q := `INSERT INTO accounts (id, login, password_hash, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (login) DO NOTHING`
+	res, err := s.connection.ExecContext(ctx, q, account.ID, account.Login, account.PasswordHash, account.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot add account: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cannot get affected rows when add account: %w", err)
+	}
+
+	if rows == 0 {
+		return customerror.ErrAccountAlreadyExists
+	}
+
+	return nil
+}
+
+// GetAccountByLogin get account by login from database.
+func (s *dbStorage) GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error) {
+	q := `SELECT id, login, password_hash, created_at FROM accounts WHERE login = $1`
+	var account entity.Account
+	err := s.connection.QueryRowContext(ctx, q, login).Scan(
+		&account.ID,
+		&account.Login,
+		&account.PasswordHash,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, fmt.Errorf("cannot get account by login: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetAllAccounts get accounts of all users from database.
+func (s *dbStorage) GetAllAccounts(ctx context.Context) ([]*entity.Account, error) {
+	q := `SELECT id, login, password_hash, created_at FROM accounts ORDER BY created_at`
+	rows, err := s.connection.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]*entity.Account, 0)
+	for rows.Next() {
+		var account entity.Account
+		if err = rows.Scan(&account.ID, &account.Login, &account.PasswordHash, &account.CreatedAt); err != nil {
+			return nil, fmt.Errorf("cannot get accounts: %w", err)
+		}
+		accounts = append(accounts, &account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// AddSession save session in database.
+func (s *dbStorage) AddSession(ctx context.Context, session *entity.Session) error {
+	q := `INSERT INTO sessions (hash, user_id, created_at, expires_at, deleted_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := s.connection.ExecContext(
+		ctx,
+		q,
+		session.Hash,
+		session.UserID,
+		session.CreatedAt,
+		session.ExpiresAt,
+		session.DeletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot add session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSessionByHash get session by hash from database.
+func (s *dbStorage) GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error) {
+	q := `SELECT hash, user_id, created_at, expires_at, deleted_at FROM sessions WHERE hash = $1`
+	var session entity.Session
+	err := s.connection.QueryRowContext(ctx, q, hash).Scan(
+		&session.Hash,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+		&session.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, fmt.Errorf("cannot get session by hash: %w", err)
+	}
+
+	return &session, nil
+}
+
+// DeleteSession mark session as deleted.
+func (s *dbStorage) DeleteSession(ctx context.Context, hash string) error {
+	q := `UPDATE sessions SET deleted_at = NOW() WHERE hash = $1 AND deleted_at IS NULL`
+	if _, err := s.connection.ExecContext(ctx, q, hash); err != nil {
+		return fmt.Errorf("cannot delete session: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllSessions get sessions of all users from database.
+func (s *dbStorage) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	q := `SELECT hash, user_id, created_at, expires_at, deleted_at FROM sessions ORDER BY created_at`
+	rows, err := s.connection.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]*entity.Session, 0)
+	for rows.Next() {
+		var session entity.Session
+		err = rows.Scan(&session.Hash, &session.UserID, &session.CreatedAt, &session.ExpiresAt, &session.DeletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get sessions: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// HasSessions report whether user ever had session.
+func (s *dbStorage) HasSessions(ctx context.Context, userID uuid.UUID) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM sessions WHERE user_id = $1)`
+	var found bool
+	if err := s.connection.QueryRowContext(ctx, q, userID).Scan(&found); err != nil {
+		return false, fmt.Errorf("cannot check sessions of user: %w", err)
+	}
+
+	return found, nil
+}
+
+// ReassignURLs move all URLs of one user to another.
+func (s *dbStorage) ReassignURLs(ctx context.Context, fromUserID uuid.UUID, toUserID uuid.UUID) (int, error) {
+	q := `UPDATE urls SET user_id = $2 WHERE user_id = $1`
+	res, err := s.connection.ExecContext(ctx, q, fromUserID, toUserID)
+	if err != nil {
+		return 0, fmt.Errorf("cannot reassign urls: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get affected rows when reassign urls: %w", err)
+	}
+
+	return int(rows), nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+const scopesSeparator = ","
+
+// AddAPIKey save API key in database.
+func (s *dbStorage) AddAPIKey(ctx context.Context, key *entity.APIKey) error {
+	q := `INSERT INTO api_keys (id, user_id, name, prefix, hash, scopes, created_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := s.connection.ExecContext(
+		ctx,
+		q,
+		key.ID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		strings.Join(key.Scopes, scopesSeparator),
+		key.CreatedAt,
+		key.RevokedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot add api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash get API key by hash from database.
+func (s *dbStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	q := `SELECT id, user_id, name, prefix, hash, scopes, created_at, revoked_at FROM api_keys WHERE hash = $1`
+	key, err := scanAPIKey(s.connection.QueryRowContext(ctx, q, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, fmt.Errorf("cannot get api key by hash: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeysByUser get all API keys of user from database.
+func (s *dbStorage) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	q := `SELECT id, user_id, name, prefix, hash, scopes, created_at, revoked_at
+		FROM api_keys WHERE user_id = $1 ORDER BY created_at`
+
+	return s.queryAPIKeys(ctx, q, userID)
+}
+
+// GetAllAPIKeys get API keys of all users from database.
+func (s *dbStorage) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	q := `SELECT id, user_id, name, prefix, hash, scopes, created_at, revoked_at FROM api_keys ORDER BY created_at`
+
+	return s.queryAPIKeys(ctx, q)
+}
+
+func (s *dbStorage) queryAPIKeys(ctx context.Context, q string, args ...any) ([]*entity.APIKey, error) {
+	rows, err := s.connection.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*entity.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get api keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey mark API key of user as revoked.
+func (s *dbStorage) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	q := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	res, err := s.connection.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("cannot revoke api key: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cannot get affected rows when revoke api key: %w", err)
+	}
+
+	if rows == 0 {
+		return customerror.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	var scopes string
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&scopes,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, scopesSeparator)
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// reserveIdempotencyAttempts live record may be deleted between failed reservation and reading of it,
+// then reservation is tried again.
+const reserveIdempotencyAttempts = 3
+
+// ReserveIdempotencyKey save record of started request in database if key of user has no live record.
+// Expired records of all users are purged, so keys which are never retried do not stay in database.
+func (s *dbStorage) ReserveIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (*entity.IdempotencyRecord, error) {
+	now := time.Now().UTC()
+	q := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+	if _, err := s.connection.ExecContext(ctx, q, now); err != nil {
+		return nil, fmt.Errorf("cannot delete expired idempotency keys: %w", err)
+	}
+
+	for i := 0; i < reserveIdempotencyAttempts; i++ {
+		q = `INSERT INTO idempotency_keys (user_id, key, request_hash, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5) ON CONFLICT (user_id, key) DO NOTHING`
+		res, err := s.connection.ExecContext(
+			ctx,
+			q,
+			record.UserID,
+			record.Key,
+			record.RequestHash,
+			record.CreatedAt,
+			record.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("cannot reserve idempotency key: %w", err)
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get affected rows when reserve idempotency key: %w", err)
+		}
+		if rows > 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		q = `SELECT user_id, key, request_hash, status, content_type, body, created_at, expires_at
+			FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+		var v entity.IdempotencyRecord
+		err = s.connection.QueryRowContext(ctx, q, record.UserID, record.Key).Scan(
+			&v.UserID,
+			&v.Key,
+			&v.RequestHash,
+			&v.Status,
+			&v.ContentType,
+			&v.Body,
+			&v.CreatedAt,
+			&v.ExpiresAt,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot get idempotency key: %w", err)
+		}
+		if !v.IsExpired(now) {
+			return &v, nil
+		}
+		if err = s.DeleteIdempotencyKey(ctx, &v); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("cannot reserve idempotency key %s", record.Key)
+}
+
+// ExtendIdempotencyKey prolong reservation of request in progress in database.
+func (s *dbStorage) ExtendIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	q := `UPDATE idempotency_keys SET expires_at = $1
+		WHERE user_id = $2 AND key = $3 AND request_hash = $4 AND created_at = $5 AND status = 0`
+
+	return s.updateIdempotencyKey(
+		ctx,
+		q,
+		record.ExpiresAt,
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+		record.CreatedAt,
+	)
+}
+
+// CompleteIdempotencyKey save response of request which holds reservation of key in database.
+func (s *dbStorage) CompleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	q := `UPDATE idempotency_keys SET status = $1, content_type = $2, body = $3, expires_at = $4
+		WHERE user_id = $5 AND key = $6 AND request_hash = $7 AND created_at = $8`
+
+	return s.updateIdempotencyKey(
+		ctx,
+		q,
+		record.Status,
+		record.ContentType,
+		record.Body,
+		record.ExpiresAt,
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+		record.CreatedAt,
+	)
+}
+
+// updateIdempotencyKey execute update of record and report whether record was found.
+func (s *dbStorage) updateIdempotencyKey(ctx context.Context, q string, args ...any) (bool, error) {
+	res, err := s.connection.ExecContext(ctx, q, args...)
+	if err != nil {
+		return false, fmt.Errorf("cannot update idempotency key: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("cannot get affected rows when update idempotency key: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// DeleteIdempotencyKey delete reservation made by record from database, so key can be used again.
+func (s *dbStorage) DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	q := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND request_hash = $3 AND created_at = $4`
+	_, err := s.connection.ExecContext(ctx, q, record.UserID, record.Key, record.RequestHash, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot delete idempotency key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddOrganization save organization in database.
+func (s *dbStorage) AddOrganization(ctx context.Context, org *entity.Organization) error {
+	q := `INSERT INTO organizations (id, name, owner_id, created_at) VALUES ($1, $2, $3, $4)`
+	if _, err := s.connection.ExecContext(ctx, q, org.ID, org.Name, org.OwnerID, org.CreatedAt); err != nil {
+		return fmt.Errorf("cannot add organization: %w", err)
+	}
+
+	return nil
+}
+
+// GetOrganization get organization by id from database.
+func (s *dbStorage) GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error) {
+	q := `SELECT id, name, owner_id, created_at FROM organizations WHERE id = $1`
+	var org entity.Organization
+	err := s.connection.QueryRowContext(ctx, q, id).Scan(&org.ID, &org.Name, &org.OwnerID, &org.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, fmt.Errorf("cannot get organization: %w", err)
+	}
+
+	return &org, nil
+}
+
+// AddTeam save team in database.
+func (s *dbStorage) AddTeam(ctx context.Context, team *entity.Team) error {
+	q := `INSERT INTO teams (id, organization_id, name, created_at) VALUES ($1, $2, $3, $4)`
+	if _, err := s.connection.ExecContext(ctx, q, team.ID, team.OrganizationID, team.Name, team.CreatedAt); err != nil {
+		return fmt.Errorf("cannot add team: %w", err)
+	}
+
+	return nil
+}
+
+// GetTeam get team by id from database.
+func (s *dbStorage) GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error) {
+	q := `SELECT id, organization_id, name, created_at FROM teams WHERE id = $1`
+	var team entity.Team
+	err := s.connection.QueryRowContext(ctx, q, id).Scan(&team.ID, &team.OrganizationID, &team.Name, &team.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, fmt.Errorf("cannot get team: %w", err)
+	}
+
+	return &team, nil
+}
+
+// SetTeamMember add member to team or change role of existing member.
+func (s *dbStorage) SetTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	q := `INSERT INTO team_members (team_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role`
+	_, err := s.connection.ExecContext(ctx, q, member.TeamID, member.UserID, member.Role, member.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot set team member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTeamMember remove member from team.
+func (s *dbStorage) RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	q := `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`
+	if _, err := s.connection.ExecContext(ctx, q, teamID, userID); err != nil {
+		return fmt.Errorf("cannot remove team member: %w", err)
+	}
+
+	return nil
+}
+
+// GetTeamMember get membership of user in team.
+func (s *dbStorage) GetTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) (*entity.TeamMember, error) {
+	q := `SELECT team_id, user_id, role, created_at FROM team_members WHERE team_id = $1 AND user_id = $2`
+	member, err := scanTeamMember(s.connection.QueryRowContext(ctx, q, teamID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil //nolint:nilnil
+		}
+
+		return nil, fmt.Errorf("cannot get team member: %w", err)
+	}
+
+	return member, nil
+}
+
+// GetTeamMembers get all members of team.
+func (s *dbStorage) GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	q := `SELECT team_id, user_id, role, created_at FROM team_members WHERE team_id = $1 ORDER BY created_at`
+
+	return s.queryTeamMembers(ctx, q, teamID)
+}
+
+// GetUserTeams get all memberships of user.
+func (s *dbStorage) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	q := `SELECT team_id, user_id, role, created_at FROM team_members WHERE user_id = $1 ORDER BY created_at`
+
+	return s.queryTeamMembers(ctx, q, userID)
+}
+
+// SetURLOwner transfer URL to user or team. URL belongs to user when teamID is empty.
+func (s *dbStorage) SetURLOwner(ctx context.Context, short string, userID uuid.UUID, teamID uuid.UUID) error {
+	q := `UPDATE urls SET user_id = $2, team_id = $3 WHERE short = $1`
+	_, err := s.connection.ExecContext(ctx, q, short, userID, uuid.NullUUID{UUID: teamID, Valid: teamID != uuid.Nil})
+	if err != nil {
+		return fmt.Errorf("cannot set url owner: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllOrganizations get all organizations from database.
+func (s *dbStorage) GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error) {
+	q := `SELECT id, name, owner_id, created_at FROM organizations ORDER BY created_at`
+	rows, err := s.connection.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get organizations: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]*entity.Organization, 0)
+	for rows.Next() {
+		var org entity.Organization
+		if err = rows.Scan(&org.ID, &org.Name, &org.OwnerID, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("cannot scan organization: %w", err)
+		}
+		res = append(res, &org)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan organizations: %w", err)
+	}
+
+	return res, nil
+}
+
+// GetAllTeams get all teams from database.
+func (s *dbStorage) GetAllTeams(ctx context.Context) ([]*entity.Team, error) {
+	q := `SELECT id, organization_id, name, created_at FROM teams ORDER BY created_at`
+	rows, err := s.connection.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get teams: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]*entity.Team, 0)
+	for rows.Next() {
+		var team entity.Team
+		if err = rows.Scan(&team.ID, &team.OrganizationID, &team.Name, &team.CreatedAt); err != nil {
+			return nil, fmt.Errorf("cannot scan team: %w", err)
+		}
+		res = append(res, &team)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan teams: %w", err)
+	}
+
+	return res, nil
+}
+
+// GetAllTeamMembers get members of all teams from database.
+func (s *dbStorage) GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error) {
+	q := `SELECT team_id, user_id, role, created_at FROM team_members ORDER BY created_at`
+
+	return s.queryTeamMembers(ctx, q)
+}
+
+func (s *dbStorage) queryTeamMembers(ctx context.Context, q string, args ...any) ([]*entity.TeamMember, error) {
+	rows, err := s.connection.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get team members: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]*entity.TeamMember, 0)
+	for rows.Next() {
+		member, err := scanTeamMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan team member: %w", err)
+		}
+		res = append(res, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan team members: %w", err)
+	}
+
+	return res, nil
+}
+
+func scanTeamMember(row rowScanner) (*entity.TeamMember, error) {
+	var member entity.TeamMember
+	if err := row.Scan(&member.TeamID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// AddVariantClick count click on variant of URL in database.
+func (s *dbStorage) AddVariantClick(ctx context.Context, short string, variant string) error {
+	q := `INSERT INTO url_variant_clicks (short, variant, clicks) VALUES ($1, $2, 1)
+		ON CONFLICT (short, variant) DO UPDATE SET clicks = url_variant_clicks.clicks + 1`
+	if _, err := s.connection.ExecContext(ctx, q, short, variant); err != nil {
+		return fmt.Errorf("cannot add variant click: %w", err)
+	}
+
+	return nil
+}
+
+// GetVariantClicks get clicks on variants of URL from database.
+func (s *dbStorage) GetVariantClicks(ctx context.Context, short string) (map[string]int64, error) {
+	q := `SELECT variant, clicks FROM url_variant_clicks WHERE short = $1`
+	rows, err := s.connection.QueryContext(ctx, q, short)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get variant clicks: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[string]int64)
+	for rows.Next() {
+		var variant string
+		var clicks int64
+		if err = rows.Scan(&variant, &clicks); err != nil {
+			return nil, fmt.Errorf("cannot scan variant clicks: %w", err)
+		}
+		res[variant] = clicks
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot scan variant clicks: %w", err)
+	}
+
+	return res, nil
+}
@@ -5,16 +5,37 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/trigram"
 )
 
 type fileSystemStorage struct {
-	file    *os.File
-	encoder *json.Encoder
-	scanner *bufio.Scanner
+	// mu serializes appends to file of URLs, so read-modify-append of URL cannot lose concurrent change.
+	mu       sync.Mutex
+	file     *os.File
+	encoder  *json.Encoder
+	scanner  *bufio.Scanner
+	apiKeys  *recordLog[*entity.APIKey]
+	accounts *recordLog[*entity.Account]
+	sessions *recordLog[*entity.Session]
+	orgs     *recordLog[*entity.Organization]
+	teams    *recordLog[*entity.Team]
+	members  *recordLog[*entity.TeamMember]
+	// variantClicks one record per click on variant of URL.
+	variantClicks *recordLog[*entity.VariantClick]
+	// idempotency records by idempotency keys, mutex makes check and reservation of key atomic.
+	idempotencyMu sync.Mutex
+	idempotency   *recordLog[*entity.IdempotencyRecord]
+	// search trigram index of texts of URLs by short, it is built on first search.
+	searchMu sync.Mutex
+	search   *trigram.Index
 }
 
 // Constructor for FileSystemStorage.
@@ -27,36 +48,106 @@ func NewFileSystemStorage(fileName string) (contract.Storage, error) {
 	}
 	fss.encoder = json.NewEncoder(fss.file)
 	fss.scanner = bufio.NewScanner(fss.file)
+	fss.apiKeys = newRecordLog[*entity.APIKey](fileName + ".api_keys")
+	fss.accounts = newRecordLog[*entity.Account](fileName + ".accounts")
+	fss.sessions = newRecordLog[*entity.Session](fileName + ".sessions")
+	fss.orgs = newRecordLog[*entity.Organization](fileName + ".organizations")
+	fss.teams = newRecordLog[*entity.Team](fileName + ".teams")
+	fss.members = newRecordLog[*entity.TeamMember](fileName + ".team_members")
+	fss.variantClicks = newRecordLog[*entity.VariantClick](fileName + ".variant_clicks")
+	fss.idempotency = newRecordLog[*entity.IdempotencyRecord](fileName + ".idempotency")
 
 	return &fss, nil
 }
 
-// GetByHash get short ny hash.
+// GetByHash get current state of URL by hash.
 func (fss *fileSystemStorage) GetByHash(ctx context.Context, hash string) (*entity.URL, error) {
-	var e *entity.URL
-	for fss.scanner.Scan() {
-		err := json.Unmarshal(fss.scanner.Bytes(), &e)
-		if err != nil {
-			return nil, err
-		}
-		if e.Short == hash {
-			return e, nil
-		}
+	url, err := fss.findURL(hash)
+	if err != nil || url == nil {
+		return nil, err
+	}
+	if url.DeletedAt != nil {
+		return nil, customerror.ErrURLDeleted
 	}
 
-	return nil, nil //nolint:nilnil
+	return url, nil
 }
 
-// GetByURL get by URL.
-func (fss *fileSystemStorage) GetByURL(ctx context.Context, url string) (*entity.URL, error) {
-	var e *entity.URL
-	for fss.scanner.Scan() {
-		err := json.Unmarshal(fss.scanner.Bytes(), &e)
-		if err != nil {
-			return nil, err
-		}
-		if e.Original == url {
-			return e, nil
+// GetVisibleByHash get short URL by hash if user can follow it.
+// Private URLs are returned only to owner or members of team, for others they do not exist.
+func (fss *fileSystemStorage) GetVisibleByHash(ctx context.Context, hash string, userID uuid.UUID) (*entity.URL, error) {
+	url, err := fss.findURL(hash)
+	if err != nil || url == nil {
+		return nil, err
+	}
+
+	member, err := fss.GetTeamMember(ctx, url.TeamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isVisible(url, urlMember(url, userID, member)) {
+		return nil, nil //nolint:nilnil
+	}
+	if url.DeletedAt != nil {
+		return nil, customerror.ErrURLDeleted
+	}
+
+	return url, nil
+}
+
+// UpdateURL change URL settings. Updated URL is appended to the end of file.
+func (fss *fileSystemStorage) UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	url, err := fss.findURL(short)
+	if err != nil || url == nil {
+		return err
+	}
+	patch.Apply(url)
+	if err = fss.encoder.Encode(url); err != nil {
+		return err
+	}
+	if patch.Title != nil || patch.Description != nil || patch.Notes != nil {
+		fss.indexURLs(url)
+	}
+
+	return nil
+}
+
+// ConsumeClick count click on URL if its clicks limit is not exhausted.
+// URL with incremented counter is appended to the end of file under lock,
+// so concurrent clicks cannot exceed the limit.
+func (fss *fileSystemStorage) ConsumeClick(ctx context.Context, short string) (bool, error) {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	url, err := fss.findURL(short)
+	if err != nil || url == nil || url.DeletedAt != nil {
+		return false, err
+	}
+	if url.HasClicksLimit() && url.Clicks >= url.MaxClicks {
+		return false, nil
+	}
+	url.Clicks++
+
+	return true, fss.encoder.Encode(url)
+}
+
+// GetByURL get not deleted personal URL of user by URL in namespace of domain.
+func (fss *fileSystemStorage) GetByURL(
+	ctx context.Context,
+	url string,
+	userID uuid.UUID,
+	domain string,
+) (*entity.URL, error) {
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range urls {
+		if isUserURLOf(v, url, userID, domain) {
+			return v, nil
 		}
 	}
 
@@ -70,71 +161,366 @@ func (fss *fileSystemStorage) Add(
 	value string,
 	userID uuid.UUID,
 ) (*entity.URL, error) {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
 	url := &entity.URL{
-		UUID:     uuid.New(),
-		Short:    key,
-		Original: value,
-		UserID:   userID,
+		UUID:      uuid.New(),
+		Short:     key,
+		Original:  value,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
 	}
 
-	return url, fss.encoder.Encode(url)
+	if err := fss.encoder.Encode(url); err != nil {
+		return nil, err
+	}
+	fss.indexURLs(url)
+
+	return url, nil
 }
 
 // GetAll get all short URLs.
 func (fss *fileSystemStorage) GetAll(ctx context.Context) ([]*entity.URL, error) {
-	res := make([]*entity.URL, 0)
-	var e entity.URL
-	for fss.scanner.Scan() {
-		err := json.Unmarshal(fss.scanner.Bytes(), &e)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, &e)
+	return fss.loadURLs()
+}
+
+// GetLiveURLs get not deleted URLs in order of short after given one.
+func (fss *fileSystemStorage) GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error) {
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, err
 	}
 
-	return res, nil
+	return liveURLsPage(urls, after, limit), nil
 }
 
 // AddBatch add multiple short URLs.
 func (fss *fileSystemStorage) AddBatch(ctx context.Context, b []*entity.URL) (int, error) {
-	encoder := json.NewEncoder(fss.file)
-	for _, v := range b {
-		err := encoder.Encode(v)
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	for i, v := range b {
+		err := fss.encoder.Encode(v)
 		if err != nil {
-			return 0, err
+			fss.indexURLs(b[:i]...)
+
+			return i, err
 		}
 	}
+	fss.indexURLs(b...)
 
 	return len(b), nil
 }
 
-// GetAllURLsByUser get all URLs by user.
+// GetAllURLsByUser get all URLs by user including URLs of teams where user is a member.
 func (fss *fileSystemStorage) GetAllURLsByUser(
 	ctx context.Context,
 	userID uuid.UUID,
 	baseURL string,
+	tag string,
 ) ([]*entity.URL, error) {
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := fss.loadTeamMembers()
+	if err != nil {
+		return nil, err
+	}
+
 	res := make([]*entity.URL, 0)
-	var e entity.URL
-	for fss.scanner.Scan() {
-		err := json.Unmarshal(fss.scanner.Bytes(), &e)
-		if err != nil {
-			return nil, err
+	for _, v := range urls {
+		if tag != "" && !v.HasTag(tag) {
+			continue
 		}
-
-		if e.UserID == userID {
-			res = append(res, &e)
+		if urlMember(v, userID, members[teamMemberKey{teamID: v.TeamID, userID: userID}]).CanView() {
+			res = append(res, v)
 		}
 	}
 
 	return res, nil
 }
 
-// DeleteURLsByUser TODO need implement.
+// RenameTags replace tags from with tag to on not deleted URLs which user can edit.
+// Changed URLs are appended to the end of file under lock.
+func (fss *fileSystemStorage) RenameTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return 0, err
+	}
+	members, err := fss.loadTeamMembers()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, v := range urls {
+		if v.DeletedAt != nil || !urlMember(v, userID, members[teamMemberKey{teamID: v.TeamID, userID: userID}]).CanEdit() {
+			continue
+		}
+		tags, ok := v.RenameTags(from, to)
+		if !ok {
+			continue
+		}
+		v.Tags = tags
+		if err = fss.encoder.Encode(v); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+// EachURLByUser call fn for every personal URL of user including deleted ones in order of last change.
+// First pass over file finds the latest record of every URL, second pass passes only these records to fn,
+// so URLs are not loaded in memory at once.
+func (fss *fileSystemStorage) EachURLByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	fn func(url *entity.URL) error,
+) error {
+	latest := make(map[string]int)
+	line := 0
+	err := fss.scanAll(func(e *entity.URL) {
+		latest[e.Short] = line
+		line++
+	})
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+	line = 0
+	err = fss.scanAll(func(e *entity.URL) {
+		if fnErr == nil && latest[e.Short] == line && e.UserID == userID && !e.OwnedByTeam() {
+			fnErr = fn(e)
+		}
+		line++
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+
+	return err
+}
+
+// SearchURLsByUser find live URLs which user can view by substring or trigram similarity, most relevant first.
+func (fss *fileSystemStorage) SearchURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	index, err := fss.searchIndex()
+	if err != nil {
+		return nil, 0, err
+	}
+	matches := index.Search(query, trigram.DefaultThreshold)
+	if len(matches) == 0 {
+		return []*entity.URL{}, 0, nil
+	}
+
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, 0, err
+	}
+	members, err := fss.loadTeamMembers()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byShort := make(map[string]*entity.URL, len(urls))
+	for _, v := range urls {
+		byShort[v.Short] = v
+	}
+	found, total := searchURLs(matches, byShort, func(url *entity.URL) bool {
+		return urlMember(url, userID, members[teamMemberKey{teamID: url.TeamID, userID: userID}]).CanView()
+	}, limit, offset)
+
+	return found, total, nil
+}
+
+// searchIndex return trigram index of stored URLs, index is built from file on first call.
+func (fss *fileSystemStorage) searchIndex() (*trigram.Index, error) {
+	fss.searchMu.Lock()
+	defer fss.searchMu.Unlock()
+
+	if fss.search != nil {
+		return fss.search, nil
+	}
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, err
+	}
+	index := trigram.NewIndex()
+	for _, v := range urls {
+		index.Add(v.Short, v.SearchText())
+	}
+	fss.search = index
+
+	return index, nil
+}
+
+// indexURLs update texts of written URLs in search index if it is built.
+// URLs must be written to file before, so index built concurrently cannot miss them.
+func (fss *fileSystemStorage) indexURLs(urls ...*entity.URL) {
+	fss.searchMu.Lock()
+	defer fss.searchMu.Unlock()
+
+	if fss.search == nil {
+		return
+	}
+	for _, v := range urls {
+		fss.search.Add(v.Short, v.SearchText())
+	}
+}
+
+// DeleteURLsByUser mark URLs as deleted if user can edit them. Updated URLs are appended to the end of file.
 func (fss *fileSystemStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return err
+	}
+	members, err := fss.loadTeamMembers()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, v := range urls {
+		if v.DeletedAt != nil || !slices.Contains(batch, v.Short) {
+			continue
+		}
+		if !urlMember(v, userID, members[teamMemberKey{teamID: v.TeamID, userID: userID}]).CanEdit() {
+			continue
+		}
+		v.DeletedAt = &now
+		if err = fss.encoder.Encode(v); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// IncrementDailyUsage does nothing, usage is calculated from stored URLs.
+func (fss *fileSystemStorage) IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error {
+	return nil
+}
+
+// ReserveDailyUsage check that URLs created by user per day do not exceed limit with n more URLs.
+// Usage is calculated from stored URLs, so nothing is reserved, creation of URLs by user is serialized by service.
+func (fss *fileSystemStorage) ReserveDailyUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+	n int,
+	limit int,
+) (bool, error) {
+	if limit <= 0 {
+		return true, nil
+	}
+	usage, err := fss.GetQuotaUsage(ctx, userID, day)
+	if err != nil {
+		return false, err
+	}
+
+	return usage.CreatedToday+n <= limit, nil
+}
+
+// GetQuotaUsage get URLs created by user per day and total active URLs of user.
+func (fss *fileSystemStorage) GetQuotaUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+) (*entity.QuotaUsage, error) {
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &entity.QuotaUsage{}
+	for _, e := range urls {
+		if e.UserID != userID {
+			continue
+		}
+		if !e.CreatedAt.Before(day) && e.CreatedAt.Before(day.AddDate(0, 0, 1)) {
+			usage.CreatedToday++
+		}
+		if e.DeletedAt == nil {
+			usage.Active++
+		}
+	}
+
+	return usage, nil
+}
+
+// scanAll read whole file from the beginning and call fn for each stored URL.
+func (fss *fileSystemStorage) scanAll(fn func(e *entity.URL)) error {
+	f, err := os.Open(fss.file.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e entity.URL
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return err
+		}
+		fn(&e)
+	}
+
+	return scanner.Err()
+}
+
+// loadURLs return current state of stored URLs in order of creation.
+// The file is append-only, so later records of the same short URL override earlier ones.
+func (fss *fileSystemStorage) loadURLs() ([]*entity.URL, error) {
+	res := make([]*entity.URL, 0)
+	positions := make(map[string]int)
+	err := fss.scanAll(func(e *entity.URL) {
+		if i, ok := positions[e.Short]; ok {
+			res[i] = e
+
+			return
+		}
+		positions[e.Short] = len(res)
+		res = append(res, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// findURL return current state of URL by short.
+func (fss *fileSystemStorage) findURL(short string) (*entity.URL, error) {
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range urls {
+		if v.Short == short {
+			return v, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
 // Ping DeleteURLsByUser TODO need implement.
 func (fss *fileSystemStorage) Ping(ctx context.Context) error {
 	return nil
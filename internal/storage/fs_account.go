@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddAccount save account in file system.
+func (fss *fileSystemStorage) AddAccount(ctx context.Context, account *entity.Account) error {
+	existing, err := fss.GetAccountByLogin(ctx, account.Login)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return customerror.ErrAccountAlreadyExists
+	}
+
+	return fss.accounts.append(account)
+}
+
+// GetAccountByLogin get account by login.
+func (fss *fileSystemStorage) GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error) {
+	var res *entity.Account
+	err := fss.accounts.each(func(v *entity.Account) {
+		if v.Login == login {
+			res = v
+		}
+	})
+
+	return res, err
+}
+
+// GetAllAccounts get accounts of all users in order of creation.
+func (fss *fileSystemStorage) GetAllAccounts(ctx context.Context) ([]*entity.Account, error) {
+	res := make([]*entity.Account, 0)
+	positions := make(map[string]int)
+	err := fss.accounts.each(func(v *entity.Account) {
+		if i, ok := positions[v.Login]; ok {
+			res[i] = v
+
+			return
+		}
+		positions[v.Login] = len(res)
+		res = append(res, v)
+	})
+
+	return res, err
+}
+
+// AddSession save session in file system.
+func (fss *fileSystemStorage) AddSession(ctx context.Context, session *entity.Session) error {
+	return fss.sessions.append(session)
+}
+
+// GetSessionByHash get session by hash.
+func (fss *fileSystemStorage) GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error) {
+	var res *entity.Session
+	err := fss.sessions.each(func(v *entity.Session) {
+		if v.Hash == hash {
+			res = v
+		}
+	})
+
+	return res, err
+}
+
+// DeleteSession mark session as deleted.
+func (fss *fileSystemStorage) DeleteSession(ctx context.Context, hash string) error {
+	session, err := fss.GetSessionByHash(ctx, hash)
+	if err != nil || session == nil || session.DeletedAt != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	session.DeletedAt = &now
+
+	return fss.sessions.append(session)
+}
+
+// GetAllSessions get current state of sessions of all users in order of creation.
+func (fss *fileSystemStorage) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	res := make([]*entity.Session, 0)
+	positions := make(map[string]int)
+	err := fss.sessions.each(func(v *entity.Session) {
+		if i, ok := positions[v.Hash]; ok {
+			res[i] = v
+
+			return
+		}
+		positions[v.Hash] = len(res)
+		res = append(res, v)
+	})
+
+	return res, err
+}
+
+// HasSessions report whether user ever had session.
+func (fss *fileSystemStorage) HasSessions(ctx context.Context, userID uuid.UUID) (bool, error) {
+	found := false
+	err := fss.sessions.each(func(v *entity.Session) {
+		if v.UserID == userID {
+			found = true
+		}
+	})
+
+	return found, err
+}
+
+// ReassignURLs move all URLs of one user to another. Updated URLs are appended to the end of file.
+func (fss *fileSystemStorage) ReassignURLs(ctx context.Context, fromUserID uuid.UUID, toUserID uuid.UUID) (int, error) {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	urls, err := fss.loadURLs()
+	if err != nil {
+		return 0, err
+	}
+
+	reassigned := 0
+	for _, v := range urls {
+		if v.UserID != fromUserID {
+			continue
+		}
+		v.UserID = toUserID
+		if err = fss.encoder.Encode(v); err != nil {
+			return reassigned, err
+		}
+		reassigned++
+	}
+
+	return reassigned, nil
+}
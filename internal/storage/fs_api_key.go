@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddAPIKey save API key in file system.
+func (fss *fileSystemStorage) AddAPIKey(ctx context.Context, key *entity.APIKey) error {
+	return fss.apiKeys.append(key)
+}
+
+// GetAPIKeyByHash get API key by hash.
+func (fss *fileSystemStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	keys, err := fss.loadAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range keys {
+		if v.Hash == hash {
+			return v, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// GetAPIKeysByUser get all API keys of user.
+func (fss *fileSystemStorage) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	keys, err := fss.loadAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entity.APIKey, 0)
+	for _, v := range keys {
+		if v.UserID == userID {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res, nil
+}
+
+// GetAllAPIKeys get current state of API keys of all users.
+func (fss *fileSystemStorage) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	keys, err := fss.loadAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entity.APIKey, 0, len(keys))
+	for _, v := range keys {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res, nil
+}
+
+// RevokeAPIKey mark API key of user as revoked.
+func (fss *fileSystemStorage) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	keys, err := fss.loadAPIKeys()
+	if err != nil {
+		return err
+	}
+	key, ok := keys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return customerror.ErrAPIKeyNotFound
+	}
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+
+	return fss.apiKeys.append(key)
+}
+
+func (fss *fileSystemStorage) loadAPIKeys() (map[uuid.UUID]*entity.APIKey, error) {
+	keys := make(map[uuid.UUID]*entity.APIKey)
+	err := fss.apiKeys.each(func(v *entity.APIKey) {
+		keys[v.ID] = v
+	})
+
+	return keys, err
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// ReserveIdempotencyKey save record of started request if key of user has no live record.
+func (fss *fileSystemStorage) ReserveIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (*entity.IdempotencyRecord, error) {
+	fss.idempotencyMu.Lock()
+	defer fss.idempotencyMu.Unlock()
+
+	v, err := fss.findIdempotencyRecord(record.UserID, record.Key)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil && !v.IsExpired(time.Now()) {
+		return v, nil
+	}
+
+	return nil, fss.idempotency.append(record)
+}
+
+// ExtendIdempotencyKey prolong reservation of request in progress.
+func (fss *fileSystemStorage) ExtendIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (bool, error) {
+	return fss.replaceIdempotencyRecord(record, record, func(v *entity.IdempotencyRecord) bool {
+		return !v.IsCompleted()
+	})
+}
+
+// CompleteIdempotencyKey save response of request which holds reservation of key.
+func (fss *fileSystemStorage) CompleteIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (bool, error) {
+	return fss.replaceIdempotencyRecord(record, record, func(*entity.IdempotencyRecord) bool { return true })
+}
+
+// DeleteIdempotencyKey save expired record instead of reservation made by record, so key can be used again.
+func (fss *fileSystemStorage) DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	expired := &entity.IdempotencyRecord{UserID: record.UserID, Key: record.Key}
+	_, err := fss.replaceIdempotencyRecord(record, expired, func(*entity.IdempotencyRecord) bool { return true })
+
+	return err
+}
+
+// replaceIdempotencyRecord append record v when the last record of key belongs to reservation
+// of record and matches fn.
+func (fss *fileSystemStorage) replaceIdempotencyRecord(
+	record *entity.IdempotencyRecord,
+	v *entity.IdempotencyRecord,
+	fn func(v *entity.IdempotencyRecord) bool,
+) (bool, error) {
+	fss.idempotencyMu.Lock()
+	defer fss.idempotencyMu.Unlock()
+
+	last, err := fss.findIdempotencyRecord(record.UserID, record.Key)
+	if err != nil || last == nil || !last.SameReservation(record) || !fn(last) {
+		return false, err
+	}
+
+	return true, fss.idempotency.append(v)
+}
+
+// findIdempotencyRecord find the last record of key of user.
+func (fss *fileSystemStorage) findIdempotencyRecord(userID uuid.UUID, key string) (*entity.IdempotencyRecord, error) {
+	var res *entity.IdempotencyRecord
+	err := fss.idempotency.each(func(v *entity.IdempotencyRecord) {
+		if v.UserID == userID && v.Key == key {
+			res = v
+		}
+	})
+
+	return res, err
+}
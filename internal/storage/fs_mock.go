@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/internal/contract"
@@ -33,7 +34,12 @@ func (s *FileSystemStorageMock) GetByHash(ctx context.Context, hash string) (*en
 }
 
 // GetByURL mock.
-func (s *FileSystemStorageMock) GetByURL(ctx context.Context, url string) (*entity.URL, error) {
+func (s *FileSystemStorageMock) GetByURL(
+	ctx context.Context,
+	url string,
+	userID uuid.UUID,
+	domain string,
+) (*entity.URL, error) {
 	return nil, nil //nolint:nilnil
 }
 
@@ -64,6 +70,11 @@ func (s *FileSystemStorageMock) SetGetAllResponse(e []*entity.URL, err error) {
 	s.getAllResponseError = err
 }
 
+// GetLiveURLs mock.
+func (s *FileSystemStorageMock) GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error) {
+	return liveURLsPage(s.getAllResponseEntity, after, limit), s.getAllResponseError
+}
+
 // AddBatch mock.
 func (s *FileSystemStorageMock) AddBatch(ctx context.Context, b []*entity.URL) (int, error) {
 	return s.addBatchResponseTotalCreated, s.addBatchResponseError
@@ -80,10 +91,41 @@ func (s *FileSystemStorageMock) GetAllURLsByUser(
 	ctx context.Context,
 	userID uuid.UUID,
 	baseURL string,
+	tag string,
 ) ([]*entity.URL, error) {
 	return nil, nil
 }
 
+// RenameTags mock.
+func (s *FileSystemStorageMock) RenameTags(
+	ctx context.Context,
+	userID uuid.UUID,
+	from []string,
+	to string,
+) (int, error) {
+	return 0, nil
+}
+
+// EachURLByUser mock.
+func (s *FileSystemStorageMock) EachURLByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	fn func(url *entity.URL) error,
+) error {
+	return nil
+}
+
+// SearchURLsByUser mock.
+func (s *FileSystemStorageMock) SearchURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	return nil, 0, nil
+}
+
 // DeleteURLsByUser mock.
 func (s *FileSystemStorageMock) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error {
 	return nil
@@ -94,6 +136,237 @@ func (s *FileSystemStorageMock) SetDeleteURLsByUserResponse(err error) {
 	s.deleteURLsByUserError = err
 }
 
+// IncrementDailyUsage mock.
+func (s *FileSystemStorageMock) IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error {
+	return nil
+}
+
+// ReserveDailyUsage mock.
+func (s *FileSystemStorageMock) ReserveDailyUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+	n int,
+	limit int,
+) (bool, error) {
+	return true, nil
+}
+
+// GetQuotaUsage mock.
+func (s *FileSystemStorageMock) GetQuotaUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+) (*entity.QuotaUsage, error) {
+	return &entity.QuotaUsage{}, nil
+}
+
+// AddAPIKey mock.
+func (s *FileSystemStorageMock) AddAPIKey(ctx context.Context, key *entity.APIKey) error {
+	return nil
+}
+
+// GetAPIKeyByHash mock.
+func (s *FileSystemStorageMock) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// GetAPIKeysByUser mock.
+func (s *FileSystemStorageMock) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	return nil, nil
+}
+
+// GetAllAPIKeys mock.
+func (s *FileSystemStorageMock) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	return nil, nil
+}
+
+// RevokeAPIKey mock.
+func (s *FileSystemStorageMock) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return nil
+}
+
+// AddAccount mock.
+func (s *FileSystemStorageMock) AddAccount(ctx context.Context, account *entity.Account) error {
+	return nil
+}
+
+// GetAccountByLogin mock.
+func (s *FileSystemStorageMock) GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// GetAllAccounts mock.
+func (s *FileSystemStorageMock) GetAllAccounts(ctx context.Context) ([]*entity.Account, error) {
+	return nil, nil
+}
+
+// AddSession mock.
+func (s *FileSystemStorageMock) AddSession(ctx context.Context, session *entity.Session) error {
+	return nil
+}
+
+// GetSessionByHash mock.
+func (s *FileSystemStorageMock) GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// DeleteSession mock.
+func (s *FileSystemStorageMock) DeleteSession(ctx context.Context, hash string) error {
+	return nil
+}
+
+// GetAllSessions mock.
+func (s *FileSystemStorageMock) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	return nil, nil
+}
+
+// HasSessions mock.
+func (s *FileSystemStorageMock) HasSessions(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+// ReassignURLs mock.
+func (s *FileSystemStorageMock) ReassignURLs(
+	ctx context.Context,
+	fromUserID uuid.UUID,
+	toUserID uuid.UUID,
+) (int, error) {
+	return 0, nil
+}
+
+// AddOrganization mock.
+func (s *FileSystemStorageMock) AddOrganization(ctx context.Context, org *entity.Organization) error {
+	return nil
+}
+
+// GetOrganization mock.
+func (s *FileSystemStorageMock) GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// AddTeam mock.
+func (s *FileSystemStorageMock) AddTeam(ctx context.Context, team *entity.Team) error {
+	return nil
+}
+
+// GetTeam mock.
+func (s *FileSystemStorageMock) GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// SetTeamMember mock.
+func (s *FileSystemStorageMock) SetTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	return nil
+}
+
+// RemoveTeamMember mock.
+func (s *FileSystemStorageMock) RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	return nil
+}
+
+// GetTeamMember mock.
+func (s *FileSystemStorageMock) GetTeamMember(
+	ctx context.Context,
+	teamID uuid.UUID,
+	userID uuid.UUID,
+) (*entity.TeamMember, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// GetTeamMembers mock.
+func (s *FileSystemStorageMock) GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	return nil, nil
+}
+
+// GetUserTeams mock.
+func (s *FileSystemStorageMock) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	return nil, nil
+}
+
+// SetURLOwner mock.
+func (s *FileSystemStorageMock) SetURLOwner(
+	ctx context.Context,
+	short string,
+	userID uuid.UUID,
+	teamID uuid.UUID,
+) error {
+	return nil
+}
+
+// GetAllOrganizations mock.
+func (s *FileSystemStorageMock) GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error) {
+	return nil, nil
+}
+
+// GetAllTeams mock.
+func (s *FileSystemStorageMock) GetAllTeams(ctx context.Context) ([]*entity.Team, error) {
+	return nil, nil
+}
+
+// GetAllTeamMembers mock.
+func (s *FileSystemStorageMock) GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error) {
+	return nil, nil
+}
+
+// GetVisibleByHash mock.
+func (s *FileSystemStorageMock) GetVisibleByHash(
+	ctx context.Context,
+	hash string,
+	userID uuid.UUID,
+) (*entity.URL, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// UpdateURL mock.
+func (s *FileSystemStorageMock) UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error {
+	return nil
+}
+
+// ConsumeClick mock.
+func (s *FileSystemStorageMock) ConsumeClick(ctx context.Context, short string) (bool, error) {
+	return true, nil
+}
+
+// AddVariantClick mock.
+func (s *FileSystemStorageMock) AddVariantClick(ctx context.Context, short string, variant string) error {
+	return nil
+}
+
+// GetVariantClicks mock.
+func (s *FileSystemStorageMock) GetVariantClicks(ctx context.Context, short string) (map[string]int64, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// ReserveIdempotencyKey mock.
+func (s *FileSystemStorageMock) ReserveIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (*entity.IdempotencyRecord, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// ExtendIdempotencyKey mock.
+func (s *FileSystemStorageMock) ExtendIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (bool, error) {
+	return true, nil
+}
+
+// CompleteIdempotencyKey mock.
+func (s *FileSystemStorageMock) CompleteIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (bool, error) {
+	return true, nil
+}
+
+// DeleteIdempotencyKey mock.
+func (s *FileSystemStorageMock) DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	return nil
+}
+
 // Ping mock.
 func (s *FileSystemStorageMock) Ping(ctx context.Context) error { return nil }
 
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// recordLog append-only file with JSON lines records of one kind,
+// kept next to the file with URLs. Later records override earlier ones.
+type recordLog[T any] struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newRecordLog[T any](path string) *recordLog[T] {
+	return &recordLog[T]{path: path}
+}
+
+// append write record to the end of file.
+func (l *recordLog[T]) append(v T) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o666) //nolint:gosec,mnd,gomnd
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(v)
+}
+
+// each read whole file from the beginning and call fn for each record.
+func (l *recordLog[T]) each(fn func(v T)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return err
+		}
+		fn(v)
+	}
+
+	return scanner.Err()
+}
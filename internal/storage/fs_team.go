@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddOrganization save organization in file system.
+func (fss *fileSystemStorage) AddOrganization(ctx context.Context, org *entity.Organization) error {
+	return fss.orgs.append(org)
+}
+
+// GetOrganization get organization by id.
+func (fss *fileSystemStorage) GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error) {
+	var res *entity.Organization
+	err := fss.orgs.each(func(v *entity.Organization) {
+		if v.ID == id {
+			res = v
+		}
+	})
+
+	return res, err
+}
+
+// AddTeam save team in file system.
+func (fss *fileSystemStorage) AddTeam(ctx context.Context, team *entity.Team) error {
+	return fss.teams.append(team)
+}
+
+// GetTeam get team by id.
+func (fss *fileSystemStorage) GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error) {
+	var res *entity.Team
+	err := fss.teams.each(func(v *entity.Team) {
+		if v.ID == id {
+			res = v
+		}
+	})
+
+	return res, err
+}
+
+// SetTeamMember add member to team or change role of existing member.
+func (fss *fileSystemStorage) SetTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	existing, err := fss.GetTeamMember(ctx, member.TeamID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		existing.Role = member.Role
+		member = existing
+	}
+
+	return fss.members.append(member)
+}
+
+// RemoveTeamMember remove member from team. Record without role is appended.
+func (fss *fileSystemStorage) RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	return fss.members.append(&entity.TeamMember{TeamID: teamID, UserID: userID})
+}
+
+// GetTeamMember get membership of user in team.
+func (fss *fileSystemStorage) GetTeamMember(
+	ctx context.Context,
+	teamID uuid.UUID,
+	userID uuid.UUID,
+) (*entity.TeamMember, error) {
+	members, err := fss.loadTeamMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	return members[teamMemberKey{teamID: teamID, userID: userID}], nil
+}
+
+// GetTeamMembers get all members of team.
+func (fss *fileSystemStorage) GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	return fss.filterMembers(func(m *entity.TeamMember) bool { return m.TeamID == teamID })
+}
+
+// GetUserTeams get all memberships of user.
+func (fss *fileSystemStorage) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	return fss.filterMembers(func(m *entity.TeamMember) bool { return m.UserID == userID })
+}
+
+// SetURLOwner transfer URL to user or team. Updated URL is appended to the end of file.
+func (fss *fileSystemStorage) SetURLOwner(ctx context.Context, short string, userID uuid.UUID, teamID uuid.UUID) error {
+	fss.mu.Lock()
+	defer fss.mu.Unlock()
+
+	url, err := fss.findURL(short)
+	if err != nil || url == nil {
+		return err
+	}
+	url.UserID = userID
+	url.TeamID = teamID
+
+	return fss.encoder.Encode(url)
+}
+
+// GetAllOrganizations get all organizations in order of creation.
+func (fss *fileSystemStorage) GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error) {
+	res := make([]*entity.Organization, 0)
+	err := fss.orgs.each(func(v *entity.Organization) {
+		res = append(res, v)
+	})
+
+	return res, err
+}
+
+// GetAllTeams get all teams in order of creation.
+func (fss *fileSystemStorage) GetAllTeams(ctx context.Context) ([]*entity.Team, error) {
+	res := make([]*entity.Team, 0)
+	err := fss.teams.each(func(v *entity.Team) {
+		res = append(res, v)
+	})
+
+	return res, err
+}
+
+// GetAllTeamMembers get current members of all teams.
+func (fss *fileSystemStorage) GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error) {
+	return fss.filterMembers(func(*entity.TeamMember) bool { return true })
+}
+
+// loadTeamMembers return current memberships. Records without role mean removed members.
+func (fss *fileSystemStorage) loadTeamMembers() (map[teamMemberKey]*entity.TeamMember, error) {
+	res := make(map[teamMemberKey]*entity.TeamMember)
+	err := fss.members.each(func(v *entity.TeamMember) {
+		key := teamMemberKey{teamID: v.TeamID, userID: v.UserID}
+		if v.Role == "" {
+			delete(res, key)
+
+			return
+		}
+		res[key] = v
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (fss *fileSystemStorage) filterMembers(fn func(m *entity.TeamMember) bool) ([]*entity.TeamMember, error) {
+	members, err := fss.loadTeamMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterMembers(members, fn), nil
+}
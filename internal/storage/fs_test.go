@@ -3,11 +3,17 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/pkg/entity"
 )
 
@@ -40,10 +46,11 @@ func (s *FileSystemStorageTestSuite) TestAdd() {
 	s.Require().NoError(err)
 
 	s.Equal(entity.URL{
-		UUID:     resultURL.UUID,
-		Short:    "1",
-		Original: "2",
-		UserID:   userID,
+		UUID:      resultURL.UUID,
+		Short:     "1",
+		Original:  "2",
+		UserID:    userID,
+		CreatedAt: resultURL.CreatedAt,
 	}, *urlActual)
 	os.Remove(fileName)
 }
@@ -129,10 +136,16 @@ func (s *FileSystemStorageTestSuite) TestGetByURL() {
 	s.Require().NoError(err)
 	defer fss.Close()
 
-	url, err := fss.GetByURL(ctx, "full2")
+	url, err := fss.GetByURL(ctx, "full2", entityURL.UserID, "")
 	s.Require().NoError(err)
-
 	s.Require().Equal(entityURL, url)
+
+	url, err = fss.GetByURL(ctx, "full2", uuid.New(), "")
+	s.Require().NoError(err)
+	s.Require().Nil(url)
+	url, err = fss.GetByURL(ctx, "full2", entityURL.UserID, "go.brand-a.com")
+	s.Require().NoError(err)
+	s.Require().Nil(url)
 	os.Remove(fileName)
 }
 
@@ -194,10 +207,538 @@ func (s *FileSystemStorageTestSuite) TestGetUserURLs() {
 		s.Require().NoError(err)
 		defer fss.Close()
 
-		url, err := fss.GetAllURLsByUser(ctx, userID, "")
+		url, err := fss.GetAllURLsByUser(ctx, userID, "", "")
 		s.Require().NoError(err)
 
 		s.Require().Equal([]*entity.URL{entityURL}, url)
 		os.Remove(fileName)
 	})
 }
+
+func (s *FileSystemStorageTestSuite) TestAPIKeys() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+		os.Remove(fileName + ".api_keys")
+	}()
+
+	userID := uuid.New()
+	key := &entity.APIKey{
+		ID:     uuid.New(),
+		UserID: userID,
+		Name:   "backend",
+		Hash:   "hash",
+		Scopes: []string{entity.ScopeRead},
+	}
+	s.Require().NoError(fss.AddAPIKey(ctx, key))
+
+	found, err := fss.GetAPIKeyByHash(ctx, "hash")
+	s.Require().NoError(err)
+	s.Require().Equal(key, found)
+
+	s.Require().NoError(fss.RevokeAPIKey(ctx, userID, key.ID))
+	s.Require().Error(fss.RevokeAPIKey(ctx, userID, key.ID))
+
+	keys, err := fss.GetAPIKeysByUser(ctx, userID)
+	s.Require().NoError(err)
+	s.Require().Len(keys, 1)
+	s.Require().NotNil(keys[0].RevokedAt)
+}
+
+func (s *FileSystemStorageTestSuite) TestAccounts() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+		os.Remove(fileName + ".accounts")
+		os.Remove(fileName + ".sessions")
+	}()
+
+	account := &entity.Account{ID: uuid.New(), Login: "alice", PasswordHash: "hash"}
+	s.Require().NoError(fss.AddAccount(ctx, account))
+	s.Require().ErrorIs(fss.AddAccount(ctx, account), customerror.ErrAccountAlreadyExists)
+
+	found, err := fss.GetAccountByLogin(ctx, "alice")
+	s.Require().NoError(err)
+	s.Require().Equal(account, found)
+
+	hasSessions, err := fss.HasSessions(ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().False(hasSessions)
+	s.Require().NoError(fss.AddSession(ctx, &entity.Session{Hash: "hash", UserID: account.ID}))
+	s.Require().NoError(fss.DeleteSession(ctx, "hash"))
+	session, err := fss.GetSessionByHash(ctx, "hash")
+	s.Require().NoError(err)
+	s.Require().NotNil(session.DeletedAt)
+	hasSessions, err = fss.HasSessions(ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().True(hasSessions)
+
+	s.Run("reassign urls", func() {
+		anonymousUserID := uuid.New()
+		_, err := fss.Add(ctx, "short1", "full1", anonymousUserID)
+		s.Require().NoError(err)
+		_, err = fss.Add(ctx, "short2", "full2", uuid.New())
+		s.Require().NoError(err)
+
+		reassigned, err := fss.ReassignURLs(ctx, anonymousUserID, account.ID)
+		s.Require().NoError(err)
+		s.Require().Equal(1, reassigned)
+
+		urls, err := fss.GetAllURLsByUser(ctx, account.ID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+
+		all, err := fss.GetAll(ctx)
+		s.Require().NoError(err)
+		s.Require().Len(all, 2)
+	})
+}
+
+func (s *FileSystemStorageTestSuite) TestTeams() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+		os.Remove(fileName + ".teams")
+		os.Remove(fileName + ".team_members")
+	}()
+
+	team := &entity.Team{ID: uuid.New(), OrganizationID: uuid.New(), Name: "marketing"}
+	s.Require().NoError(fss.AddTeam(ctx, team))
+	found, err := fss.GetTeam(ctx, team.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(team, found)
+
+	adminID, viewerID, ownerID := uuid.New(), uuid.New(), uuid.New()
+	s.Require().NoError(fss.SetTeamMember(ctx, &entity.TeamMember{TeamID: team.ID, UserID: adminID, Role: entity.RoleAdmin}))
+	s.Require().NoError(fss.SetTeamMember(ctx, &entity.TeamMember{TeamID: team.ID, UserID: viewerID, Role: entity.RoleEditor}))
+	s.Require().NoError(fss.SetTeamMember(ctx, &entity.TeamMember{TeamID: team.ID, UserID: viewerID, Role: entity.RoleViewer}))
+
+	members, err := fss.GetTeamMembers(ctx, team.ID)
+	s.Require().NoError(err)
+	s.Require().Len(members, 2)
+
+	_, err = fss.Add(ctx, "short1", "full1", ownerID)
+	s.Require().NoError(err)
+	_, err = fss.Add(ctx, "short2", "full2", ownerID)
+	s.Require().NoError(err)
+	s.Require().NoError(fss.SetURLOwner(ctx, "short1", ownerID, team.ID))
+
+	s.Run("members see team urls", func() {
+		urls, err := fss.GetAllURLsByUser(ctx, viewerID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+		s.Require().Equal(team.ID, urls[0].TeamID)
+
+		urls, err = fss.GetAllURLsByUser(ctx, ownerID, "", "")
+		s.Require().NoError(err)
+		s.Require().Len(urls, 1)
+		s.Require().Equal("short2", urls[0].Short)
+	})
+
+	s.Run("viewer cannot delete team urls", func() {
+		s.Require().NoError(fss.DeleteURLsByUser(ctx, viewerID, []string{"short1", "short2"}))
+		urls, err := fss.GetAll(ctx)
+		s.Require().NoError(err)
+		s.Require().Nil(urls[0].DeletedAt)
+		s.Require().Nil(urls[1].DeletedAt)
+
+		s.Require().NoError(fss.DeleteURLsByUser(ctx, adminID, []string{"short1", "short2"}))
+		urls, err = fss.GetAll(ctx)
+		s.Require().NoError(err)
+		s.Require().NotNil(urls[0].DeletedAt)
+		s.Require().Nil(urls[1].DeletedAt)
+	})
+
+	s.Run("removed member", func() {
+		s.Require().NoError(fss.RemoveTeamMember(ctx, team.ID, viewerID))
+		member, err := fss.GetTeamMember(ctx, team.ID, viewerID)
+		s.Require().NoError(err)
+		s.Require().Nil(member)
+
+		teams, err := fss.GetUserTeams(ctx, adminID)
+		s.Require().NoError(err)
+		s.Require().Len(teams, 1)
+	})
+}
+
+func (s *FileSystemStorageTestSuite) TestPrivateURL() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	ownerID := uuid.New()
+	_, err = fss.Add(ctx, "private", "http://private.test", ownerID)
+	s.Require().NoError(err)
+	visibility := entity.VisibilityPrivate
+	s.Require().NoError(fss.UpdateURL(ctx, "private", &entity.URLPatch{Visibility: &visibility}))
+
+	url, err := fss.GetVisibleByHash(ctx, "private", uuid.New())
+	s.Require().NoError(err)
+	s.Require().Nil(url)
+
+	url, err = fss.GetVisibleByHash(ctx, "private", ownerID)
+	s.Require().NoError(err)
+	s.Require().Equal(entity.VisibilityPrivate, url.Visibility)
+
+	all, err := fss.GetAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(all, 1)
+}
+
+func (s *FileSystemStorageTestSuite) TestMaxClicks() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	_, err = fss.Add(ctx, "once", "http://once.test", uuid.New())
+	s.Require().NoError(err)
+	maxClicks := int64(2)
+	s.Require().NoError(fss.UpdateURL(ctx, "once", &entity.URLPatch{MaxClicks: &maxClicks}))
+
+	s.Require().Equal(int64(2), consumeConcurrently(ctx, fss, "once", 10))
+
+	url, err := fss.GetVisibleByHash(ctx, "once", uuid.Nil)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(2), url.Clicks)
+	s.Require().Equal(int64(0), url.RemainingClicks())
+}
+
+func (s *FileSystemStorageTestSuite) TestGetByHashContract() {
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	testGetByHash(&s.Suite, fss)
+}
+
+func (s *FileSystemStorageTestSuite) TestConcurrentUpdateAndDelete() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	userID := uuid.New()
+	for i := 0; i < 10; i++ {
+		short := fmt.Sprintf("u%d", i)
+		_, err = fss.Add(ctx, short, "http://"+short+".test", userID)
+		s.Require().NoError(err)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			title := "title"
+			s.NoError(fss.UpdateURL(ctx, short, &entity.URLPatch{Title: &title}))
+		}()
+		go func() {
+			defer wg.Done()
+			s.NoError(fss.DeleteURLsByUser(ctx, userID, []string{short}))
+		}()
+		wg.Wait()
+
+		_, err = fss.GetVisibleByHash(ctx, short, userID)
+		s.Require().ErrorIs(err, customerror.ErrURLDeleted, short)
+	}
+}
+
+func (s *FileSystemStorageTestSuite) TestDeviceRules() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	_, err = fss.Add(ctx, "app", "http://app.test", uuid.New())
+	s.Require().NoError(err)
+	rules := []entity.DeviceRule{
+		{OS: "ios", URL: "https://apps.apple.com/app/id1"},
+		{Device: "tablet", URL: "http://app.test/tablet"},
+	}
+	s.Require().NoError(fss.UpdateURL(ctx, "app", &entity.URLPatch{DeviceRules: rules}))
+
+	url, err := fss.GetVisibleByHash(ctx, "app", uuid.Nil)
+	s.Require().NoError(err)
+	s.Require().Equal(rules, url.DeviceRules)
+
+	s.Require().NoError(fss.UpdateURL(ctx, "app", &entity.URLPatch{DeviceRules: []entity.DeviceRule{}}))
+	url, err = fss.GetVisibleByHash(ctx, "app", uuid.Nil)
+	s.Require().NoError(err)
+	s.Require().Empty(url.DeviceRules)
+}
+
+func (s *FileSystemStorageTestSuite) TestURLMetadata() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	_, err = fss.Add(ctx, "doc", "http://docs.test", uuid.New())
+	s.Require().NoError(err)
+	title, notes := "Docs", "linked from onboarding email"
+	s.Require().NoError(fss.UpdateURL(ctx, "doc", &entity.URLPatch{
+		Title: &title,
+		Notes: &notes,
+		Tags:  []string{"docs", "onboarding"},
+	}))
+
+	url, err := fss.GetVisibleByHash(ctx, "doc", uuid.Nil)
+	s.Require().NoError(err)
+	s.Require().Equal(title, url.Title)
+	s.Require().Equal(notes, url.Notes)
+	s.Require().Equal([]string{"docs", "onboarding"}, url.Tags)
+
+	s.Require().NoError(fss.UpdateURL(ctx, "doc", &entity.URLPatch{Tags: []string{}}))
+	url, err = fss.GetVisibleByHash(ctx, "doc", uuid.Nil)
+	s.Require().NoError(err)
+	s.Require().Empty(url.Tags)
+	s.Require().Equal(title, url.Title)
+}
+
+func (s *FileSystemStorageTestSuite) TestTags() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	userID, otherID := uuid.New(), uuid.New()
+	for _, u := range []struct {
+		short  string
+		userID uuid.UUID
+		tags   []string
+	}{
+		{short: "a", userID: userID, tags: []string{"promo", "q1"}},
+		{short: "b", userID: userID, tags: []string{"sale"}},
+		{short: "c", userID: userID, tags: []string{"docs"}},
+		{short: "d", userID: otherID, tags: []string{"promo"}},
+	} {
+		_, err = fss.Add(ctx, u.short, "http://"+u.short+".test", u.userID)
+		s.Require().NoError(err)
+		s.Require().NoError(fss.UpdateURL(ctx, u.short, &entity.URLPatch{Tags: u.tags}))
+	}
+
+	urls, err := fss.GetAllURLsByUser(ctx, userID, "", "promo")
+	s.Require().NoError(err)
+	s.Require().Len(urls, 1)
+	s.Require().Equal("a", urls[0].Short)
+
+	changed, err := fss.RenameTags(ctx, userID, []string{"promo", "sale"}, "marketing")
+	s.Require().NoError(err)
+	s.Require().Equal(2, changed)
+
+	urls, err = fss.GetAllURLsByUser(ctx, userID, "", "marketing")
+	s.Require().NoError(err)
+	s.Require().Len(urls, 2)
+	url, err := fss.GetByHash(ctx, "a")
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"marketing", "q1"}, url.Tags)
+	url, err = fss.GetByHash(ctx, "d")
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"promo"}, url.Tags)
+}
+
+func (s *FileSystemStorageTestSuite) TestSearchURLs() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	userID := uuid.New()
+	shorts := func(urls []*entity.URL) []string {
+		res := make([]string, len(urls))
+		for i, v := range urls {
+			res[i] = v.Short
+		}
+
+		return res
+	}
+	_, err = fss.Add(ctx, "maps", "https://google.com/maps", userID)
+	s.Require().NoError(err)
+	_, err = fss.Add(ctx, "mail", "https://mail.google.com", userID)
+	s.Require().NoError(err)
+	_, err = fss.Add(ctx, "other", "https://google.com/other", uuid.New())
+	s.Require().NoError(err)
+
+	urls, total, err := fss.SearchURLsByUser(ctx, userID, "Google", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(2, total)
+	s.Require().Equal([]string{"mail", "maps"}, shorts(urls))
+
+	s.Run("index is updated after it is built", func() {
+		_, err = fss.Add(ctx, "docs", "https://example.com/docs", userID)
+		s.Require().NoError(err)
+		title := "Google documentation"
+		s.Require().NoError(fss.UpdateURL(ctx, "docs", &entity.URLPatch{Title: &title}))
+
+		urls, total, err = fss.SearchURLsByUser(ctx, userID, "documentaton", 10, 0)
+		s.Require().NoError(err)
+		s.Require().Equal(1, total)
+		s.Require().Equal([]string{"docs"}, shorts(urls))
+	})
+
+	s.Run("pagination and deleted urls", func() {
+		s.Require().NoError(fss.DeleteURLsByUser(ctx, userID, []string{"mail"}))
+
+		urls, total, err = fss.SearchURLsByUser(ctx, userID, "google", 1, 1)
+		s.Require().NoError(err)
+		s.Require().Equal(2, total)
+		s.Require().Equal([]string{"maps"}, shorts(urls))
+
+		urls, total, err = fss.SearchURLsByUser(ctx, userID, "google", 1, 5)
+		s.Require().NoError(err)
+		s.Require().Equal(2, total)
+		s.Require().Empty(urls)
+	})
+}
+
+func (s *FileSystemStorageTestSuite) TestEachURLByUser() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+	}()
+
+	userID := uuid.New()
+	_, err = fss.Add(ctx, "first", "https://ya.ru/first", userID)
+	s.Require().NoError(err)
+	_, err = fss.Add(ctx, "second", "https://ya.ru/second", userID)
+	s.Require().NoError(err)
+	_, err = fss.Add(ctx, "other", "https://ya.ru/other", uuid.New())
+	s.Require().NoError(err)
+	s.Require().NoError(fss.DeleteURLsByUser(ctx, userID, []string{"first"}))
+
+	var urls []*entity.URL
+	err = fss.EachURLByUser(ctx, userID, func(url *entity.URL) error {
+		urls = append(urls, url)
+
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Require().Len(urls, 2)
+	s.Require().Equal("second", urls[0].Short)
+	s.Require().Equal("first", urls[1].Short)
+	s.Require().NotNil(urls[1].DeletedAt)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = fss.EachURLByUser(ctx, userID, func(url *entity.URL) error {
+		calls++
+
+		return stop
+	})
+	s.Require().ErrorIs(err, stop)
+	s.Require().Equal(1, calls)
+}
+
+func (s *FileSystemStorageTestSuite) TestVariantClicks() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+		os.Remove(fileName + ".variant_clicks")
+	}()
+
+	s.Require().NoError(fss.AddVariantClick(ctx, "short", "a"))
+	s.Require().NoError(fss.AddVariantClick(ctx, "short", "a"))
+	s.Require().NoError(fss.AddVariantClick(ctx, "short", "b"))
+	s.Require().NoError(fss.AddVariantClick(ctx, "other", "a"))
+
+	clicks, err := fss.GetVariantClicks(ctx, "short")
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]int64{"a": 2, "b": 1}, clicks)
+}
+
+func (s *FileSystemStorageTestSuite) TestIdempotencyKeys() {
+	ctx := context.Background()
+	fss, err := NewFileSystemStorage(fileName)
+	s.Require().NoError(err)
+	defer func() {
+		fss.Close()
+		os.Remove(fileName)
+		os.Remove(fileName + ".idempotency")
+	}()
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+	record := &entity.IdempotencyRecord{
+		UserID:      userID,
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	saved, err := fss.ReserveIdempotencyKey(ctx, record)
+	s.Require().NoError(err)
+	s.Require().Nil(saved)
+
+	other := &entity.IdempotencyRecord{UserID: userID, Key: "key", RequestHash: "other"}
+	saved, err = fss.ReserveIdempotencyKey(ctx, other)
+	s.Require().NoError(err)
+	s.Require().Equal("hash", saved.RequestHash)
+	s.Require().False(saved.IsCompleted())
+
+	saved, err = fss.ReserveIdempotencyKey(ctx, &entity.IdempotencyRecord{
+		UserID:    uuid.New(),
+		Key:       "key",
+		ExpiresAt: now.Add(time.Minute),
+	})
+	s.Require().NoError(err)
+	s.Require().Nil(saved, "keys of other users are independent")
+
+	record.Status = http.StatusCreated
+	record.Body = []byte("body")
+	stale := *record
+	stale.CreatedAt = now.Add(-time.Minute)
+	ok, err := fss.CompleteIdempotencyKey(ctx, &stale)
+	s.Require().NoError(err)
+	s.Require().False(ok, "only reservation of request can be completed")
+	ok, err = fss.CompleteIdempotencyKey(ctx, record)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	saved, err = fss.ReserveIdempotencyKey(ctx, &entity.IdempotencyRecord{UserID: userID, Key: "key"})
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusCreated, saved.Status)
+	s.Require().Equal([]byte("body"), saved.Body)
+
+	s.Require().NoError(fss.DeleteIdempotencyKey(ctx, record))
+	saved, err = fss.ReserveIdempotencyKey(ctx, &entity.IdempotencyRecord{UserID: userID, Key: "key"})
+	s.Require().NoError(err)
+	s.Require().Nil(saved)
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddVariantClick append click on variant of URL to file.
+func (fss *fileSystemStorage) AddVariantClick(ctx context.Context, short string, variant string) error {
+	return fss.variantClicks.append(&entity.VariantClick{Short: short, Variant: variant})
+}
+
+// GetVariantClicks count clicks on variants of URL stored in file.
+func (fss *fileSystemStorage) GetVariantClicks(ctx context.Context, short string) (map[string]int64, error) {
+	res := make(map[string]int64)
+	err := fss.variantClicks.each(func(v *entity.VariantClick) {
+		if v.Short == short {
+			res[v.Variant]++
+		}
+	})
+
+	return res, err
+}
@@ -2,38 +2,150 @@ package storage
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/internal/contract"
 	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/trigram"
 )
 
-// memoryStorage store URLs in memory.
+// memoryStorage store URLs in memory. Maps are guarded by mu, stored entities are not changed in place,
+// changed copy replaces them, so entities returned to callers can be read without lock.
 type memoryStorage struct {
-	storage map[string]*entity.URL
+	mu         sync.RWMutex
+	storage    map[string]*entity.URL
+	dailyUsage map[dailyUsageKey]int
+	apiKeys    map[uuid.UUID]*entity.APIKey
+	accounts   map[string]*entity.Account
+	sessions   map[string]*entity.Session
+	orgs       map[uuid.UUID]*entity.Organization
+	teams      map[uuid.UUID]*entity.Team
+	members    map[teamMemberKey]*entity.TeamMember
+	// variantClicks *atomic.Int64 counters by variantClickKey.
+	variantClicks sync.Map
+	// idempotency records are reserved by concurrent requests, so they are guarded by own mutex.
+	idempotencyMu sync.Mutex
+	idempotency   map[idempotencyKey]*entity.IdempotencyRecord
+	// search trigram index of texts of URLs by short.
+	search *trigram.Index
+}
+
+type dailyUsageKey struct {
+	userID uuid.UUID
+	day    time.Time
 }
 
 // NewMemoryStorage Constructor for MemoryStorage.
 func NewMemoryStorage() contract.Storage {
 	return &memoryStorage{
-		storage: make(map[string]*entity.URL),
+		storage:     make(map[string]*entity.URL),
+		dailyUsage:  make(map[dailyUsageKey]int),
+		apiKeys:     make(map[uuid.UUID]*entity.APIKey),
+		accounts:    make(map[string]*entity.Account),
+		sessions:    make(map[string]*entity.Session),
+		orgs:        make(map[uuid.UUID]*entity.Organization),
+		teams:       make(map[uuid.UUID]*entity.Team),
+		members:     make(map[teamMemberKey]*entity.TeamMember),
+		idempotency: make(map[idempotencyKey]*entity.IdempotencyRecord),
+		search:      trigram.NewIndex(),
 	}
 }
 
 // GetByHash get short URLs by hash.
 func (s *memoryStorage) GetByHash(ctx context.Context, key string) (*entity.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getByHash(key)
+}
+
+func (s *memoryStorage) getByHash(key string) (*entity.URL, error) {
 	if v, ok := s.storage[key]; ok {
+		if v.DeletedAt != nil {
+			return nil, customerror.ErrURLDeleted
+		}
+
 		return v, nil
 	}
 
 	return nil, nil //nolint:nilnil
 }
 
-// GetByURL get short URLs by url.
-func (s *memoryStorage) GetByURL(ctx context.Context, val string) (*entity.URL, error) {
+// GetVisibleByHash get short URL by hash if user can follow it.
+// Private URLs are returned only to owner or members of team, for others they do not exist.
+func (s *memoryStorage) GetVisibleByHash(ctx context.Context, key string, userID uuid.UUID) (*entity.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.storage[key]
+	if !ok || !isVisible(v, s.member(v, userID)) {
+		return nil, nil //nolint:nilnil
+	}
+
+	return s.getByHash(key)
+}
+
+// UpdateURL change URL settings.
+func (s *memoryStorage) UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateURL(short, func(v *entity.URL) {
+		patch.Apply(v)
+		if patch.Title != nil || patch.Description != nil || patch.Notes != nil {
+			s.search.Add(short, v.SearchText())
+		}
+	})
+
+	return nil
+}
+
+// updateURL replace URL with its copy changed by fn. Caller must hold write lock.
+func (s *memoryStorage) updateURL(short string, fn func(v *entity.URL)) {
+	v, ok := s.storage[short]
+	if !ok {
+		return
+	}
+	changed := *v
+	fn(&changed)
+	s.storage[short] = &changed
+}
+
+// ConsumeClick count click on URL if its clicks limit is not exhausted.
+// Counter is checked and changed under write lock, so concurrent clicks cannot exceed the limit.
+func (s *memoryStorage) ConsumeClick(ctx context.Context, short string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.storage[short]
+	if !ok || v.DeletedAt != nil {
+		return false, nil
+	}
+	if v.HasClicksLimit() && v.Clicks >= v.MaxClicks {
+		return false, nil
+	}
+	s.updateURL(short, func(v *entity.URL) { v.Clicks++ })
+
+	return true, nil
+}
+
+// GetByURL get not deleted personal URL of user by url in namespace of domain.
+func (s *memoryStorage) GetByURL(
+	ctx context.Context,
+	val string,
+	userID uuid.UUID,
+	domain string,
+) (*entity.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	for _, v := range s.storage {
-		if val == v.Original {
+		if isUserURLOf(v, val, userID, domain) {
 			return v, nil
 		}
 	}
@@ -43,24 +155,36 @@ func (s *memoryStorage) GetByURL(ctx context.Context, val string) (*entity.URL,
 
 // Add create new short URL in memory.
 func (s *memoryStorage) Add(ctx context.Context, hash string, url string, userID uuid.UUID) (*entity.URL, error) {
-	for k, v := range s.storage {
-		if k == hash || v.Original == url {
-			return nil, customerror.ErrAlreadyExistsInStorage
-		}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.storage[hash]; ok {
+		return nil, customerror.ErrAlreadyExistsInStorage
 	}
 	s.storage[hash] = &entity.URL{
-		ID:       "",
-		UUID:     uuid.Must(uuid.NewUUID()),
-		Short:    hash,
-		Original: url,
-		UserID:   userID,
+		ID:        "",
+		UUID:      uuid.Must(uuid.NewUUID()),
+		Short:     hash,
+		Original:  url,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
 	}
+	s.search.Add(hash, s.storage[hash].SearchText())
 
 	return s.storage[hash], nil
 }
 
+// isUserURLOf check that URL is not deleted personal URL of user with destination val in namespace of domain.
+func isUserURLOf(url *entity.URL, val string, userID uuid.UUID, domain string) bool {
+	return url.Original == val && url.UserID == userID && !url.OwnedByTeam() &&
+		url.DeletedAt == nil && url.Domain() == domain
+}
+
 // GetAll get all short urls from memory.
 func (s *memoryStorage) GetAll(ctx context.Context) ([]*entity.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	res := make([]*entity.URL, len(s.storage))
 	i := 0
 	for _, v := range s.storage {
@@ -71,20 +195,72 @@ func (s *memoryStorage) GetAll(ctx context.Context) ([]*entity.URL, error) {
 	return res, nil
 }
 
-// AddBatch add multiple short URLs.
+// GetLiveURLs get not deleted URLs in order of short after given one.
+func (s *memoryStorage) GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error) {
+	urls, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return liveURLsPage(urls, after, limit), nil
+}
+
+// liveURLsPage select page of not deleted URLs ordered by short, which follows after given short.
+func liveURLsPage(urls []*entity.URL, after string, limit int) []*entity.URL {
+	res := make([]*entity.URL, 0)
+	for _, v := range urls {
+		if v.DeletedAt == nil && v.Short > after {
+			res = append(res, v)
+		}
+	}
+	slices.SortFunc(res, func(a, b *entity.URL) int {
+		return strings.Compare(a.Short, b.Short)
+	})
+
+	return res[:min(limit, len(res))]
+}
+
+// AddBatch add multiple short URLs. Copies of URLs are stored, so caller can keep using them.
+// Batch with key which already exists is not added, like in Add.
 func (s *memoryStorage) AddBatch(ctx context.Context, b []*entity.URL) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make(map[string]struct{}, len(b))
 	for _, v := range b {
-		s.storage[v.Short] = v
+		if _, ok := s.storage[v.Short]; ok {
+			return 0, customerror.ErrAlreadyExistsInStorage
+		}
+		if _, ok := keys[v.Short]; ok {
+			return 0, customerror.ErrAlreadyExistsInStorage
+		}
+		keys[v.Short] = struct{}{}
+	}
+	for _, v := range b {
+		if v.CreatedAt.IsZero() {
+			v.CreatedAt = time.Now().UTC()
+		}
+		stored := *v
+		s.storage[v.Short] = &stored
+		s.search.Add(v.Short, v.SearchText())
 	}
 
 	return len(b), nil
 }
 
-// GetAllURLsByUser get all URLs ny user.
-func (s *memoryStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error) {
+// GetAllURLsByUser get all URLs ny user including URLs of teams where user is a member.
+func (s *memoryStorage) GetAllURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	baseURL string,
+	tag string,
+) ([]*entity.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	res := make([]*entity.URL, 0)
 	for _, v := range s.storage {
-		if v.UserID != userID {
+		if !s.member(v, userID).CanView() || (tag != "" && !v.HasTag(tag)) {
 			continue
 		}
 		res = append(res, v)
@@ -93,11 +269,135 @@ func (s *memoryStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID,
 	return res, nil
 }
 
-// DeleteURLsByUser not implemented.
+// RenameTags replace tags from with tag to on not deleted URLs which user can edit.
+func (s *memoryStorage) RenameTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	for short, v := range s.storage {
+		if v.DeletedAt != nil || !s.member(v, userID).CanEdit() {
+			continue
+		}
+		if tags, ok := v.RenameTags(from, to); ok {
+			s.updateURL(short, func(v *entity.URL) { v.Tags = tags })
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// EachURLByUser call fn for every personal URL of user including deleted ones in order of creation.
+// Lock is released before fn is called, so fn may use storage.
+func (s *memoryStorage) EachURLByUser(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error {
+	urls := make([]*entity.URL, 0)
+	s.mu.RLock()
+	for _, v := range s.storage {
+		if v.UserID == userID && !v.OwnedByTeam() {
+			urls = append(urls, v)
+		}
+	}
+	s.mu.RUnlock()
+	slices.SortFunc(urls, func(a, b *entity.URL) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Short, b.Short)
+	})
+
+	for _, v := range urls {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SearchURLsByUser find live URLs which user can view by substring or trigram similarity, most relevant first.
+func (s *memoryStorage) SearchURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	urls, total := searchURLs(s.search.Search(query, trigram.DefaultThreshold), s.storage, func(url *entity.URL) bool {
+		return s.member(url, userID).CanView()
+	}, limit, offset)
+
+	return urls, total, nil
+}
+
+// DeleteURLsByUser mark URLs as deleted if user can edit them.
 func (s *memoryStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, short := range batch {
+		v, ok := s.storage[short]
+		if !ok || v.DeletedAt != nil || !s.member(v, userID).CanEdit() {
+			continue
+		}
+		s.updateURL(short, func(v *entity.URL) { v.DeletedAt = &now })
+	}
+
+	return nil
+}
+
+// IncrementDailyUsage increase counter of URLs created by user per day.
+func (s *memoryStorage) IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dailyUsage[dailyUsageKey{userID: userID, day: day}] += n
+
 	return nil
 }
 
+// ReserveDailyUsage increase counter of URLs created by user per day if it does not exceed limit.
+func (s *memoryStorage) ReserveDailyUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+	n int,
+	limit int,
+) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := dailyUsageKey{userID: userID, day: day}
+	if limit > 0 && s.dailyUsage[key]+n > limit {
+		return false, nil
+	}
+	s.dailyUsage[key] += n
+
+	return true, nil
+}
+
+// GetQuotaUsage get URLs created by user per day and total active URLs of user.
+func (s *memoryStorage) GetQuotaUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*entity.QuotaUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := &entity.QuotaUsage{
+		CreatedToday: s.dailyUsage[dailyUsageKey{userID: userID, day: day}],
+	}
+	for _, v := range s.storage {
+		if v.UserID == userID && v.DeletedAt == nil {
+			usage.Active++
+		}
+	}
+
+	return usage, nil
+}
+
 // Ping not implemented.
 func (s *memoryStorage) Ping(ctx context.Context) error {
 	return nil
@@ -105,7 +405,26 @@ func (s *memoryStorage) Ping(ctx context.Context) error {
 
 // Truncate clear memory storage.
 func (s *memoryStorage) Truncate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	clear(s.storage)
+	clear(s.dailyUsage)
+	clear(s.apiKeys)
+	clear(s.accounts)
+	clear(s.sessions)
+	clear(s.orgs)
+	clear(s.teams)
+	clear(s.members)
+	s.idempotencyMu.Lock()
+	clear(s.idempotency)
+	s.idempotencyMu.Unlock()
+	s.search.Reset()
+	s.variantClicks.Range(func(k, _ any) bool {
+		s.variantClicks.Delete(k)
+
+		return true
+	})
 }
 
 // Close not implemented.
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddAccount save account in memory.
+func (s *memoryStorage) AddAccount(ctx context.Context, account *entity.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[account.Login]; ok {
+		return customerror.ErrAccountAlreadyExists
+	}
+	s.accounts[account.Login] = account
+
+	return nil
+}
+
+// GetAccountByLogin get account by login.
+func (s *memoryStorage) GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.accounts[login], nil
+}
+
+// GetAllAccounts get accounts of all users.
+func (s *memoryStorage) GetAllAccounts(ctx context.Context) ([]*entity.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*entity.Account, 0, len(s.accounts))
+	for _, v := range s.accounts {
+		res = append(res, v)
+	}
+	slices.SortFunc(res, func(a, b *entity.Account) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return res, nil
+}
+
+// AddSession save session in memory.
+func (s *memoryStorage) AddSession(ctx context.Context, session *entity.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.Hash] = session
+
+	return nil
+}
+
+// GetSessionByHash get session by hash.
+func (s *memoryStorage) GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sessions[hash], nil
+}
+
+// DeleteSession mark session as deleted.
+func (s *memoryStorage) DeleteSession(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[hash]; ok && session.DeletedAt == nil {
+		now := time.Now().UTC()
+		deleted := *session
+		deleted.DeletedAt = &now
+		s.sessions[hash] = &deleted
+	}
+
+	return nil
+}
+
+// GetAllSessions get sessions of all users.
+func (s *memoryStorage) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*entity.Session, 0, len(s.sessions))
+	for _, v := range s.sessions {
+		res = append(res, v)
+	}
+	slices.SortFunc(res, func(a, b *entity.Session) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return res, nil
+}
+
+// HasSessions report whether user ever had session.
+func (s *memoryStorage) HasSessions(ctx context.Context, userID uuid.UUID) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.sessions {
+		if v.UserID == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ReassignURLs move all URLs of one user to another.
+func (s *memoryStorage) ReassignURLs(ctx context.Context, fromUserID uuid.UUID, toUserID uuid.UUID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reassigned := 0
+	for short, v := range s.storage {
+		if v.UserID == fromUserID {
+			s.updateURL(short, func(v *entity.URL) { v.UserID = toUserID })
+			reassigned++
+		}
+	}
+
+	return reassigned, nil
+}
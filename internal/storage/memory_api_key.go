@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/internal/customerror"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+// AddAPIKey save API key in memory.
+func (s *memoryStorage) AddAPIKey(ctx context.Context, key *entity.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys[key.ID] = key
+
+	return nil
+}
+
+// GetAPIKeyByHash get API key by hash.
+func (s *memoryStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.apiKeys {
+		if v.Hash == hash {
+			return v, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// GetAPIKeysByUser get all API keys of user.
+func (s *memoryStorage) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*entity.APIKey, 0)
+	for _, v := range s.apiKeys {
+		if v.UserID == userID {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res, nil
+}
+
+// GetAllAPIKeys get API keys of all users.
+func (s *memoryStorage) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*entity.APIKey, 0, len(s.apiKeys))
+	for _, v := range s.apiKeys {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res, nil
+}
+
+// RevokeAPIKey mark API key of user as revoked.
+func (s *memoryStorage) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return customerror.ErrAPIKeyNotFound
+	}
+	now := time.Now().UTC()
+	revoked := *key
+	revoked.RevokedAt = &now
+	s.apiKeys[id] = &revoked
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+type idempotencyKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+// ReserveIdempotencyKey save record of started request if key of user has no live record.
+// Expired records of all users are purged.
+func (s *memoryStorage) ReserveIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (*entity.IdempotencyRecord, error) {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	now := time.Now()
+	maps.DeleteFunc(s.idempotency, func(_ idempotencyKey, v *entity.IdempotencyRecord) bool {
+		return v.IsExpired(now)
+	})
+	k := idempotencyKey{userID: record.UserID, key: record.Key}
+	if v, ok := s.idempotency[k]; ok {
+		return v, nil
+	}
+	s.idempotency[k] = record
+
+	return nil, nil //nolint:nilnil
+}
+
+// ExtendIdempotencyKey prolong reservation of request in progress.
+func (s *memoryStorage) ExtendIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	return s.replaceIdempotencyRecord(record, func(v *entity.IdempotencyRecord) bool { return !v.IsCompleted() }), nil
+}
+
+// CompleteIdempotencyKey save response of request which holds reservation of key.
+func (s *memoryStorage) CompleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	return s.replaceIdempotencyRecord(record, func(*entity.IdempotencyRecord) bool { return true }), nil
+}
+
+// DeleteIdempotencyKey delete reservation made by record, so key can be used again.
+func (s *memoryStorage) DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	k := idempotencyKey{userID: record.UserID, key: record.Key}
+	if v, ok := s.idempotency[k]; ok && v.SameReservation(record) {
+		delete(s.idempotency, k)
+	}
+
+	return nil
+}
+
+// replaceIdempotencyRecord save record instead of stored one of the same reservation which matches fn.
+func (s *memoryStorage) replaceIdempotencyRecord(
+	record *entity.IdempotencyRecord,
+	fn func(v *entity.IdempotencyRecord) bool,
+) bool {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	k := idempotencyKey{userID: record.UserID, key: record.Key}
+	v, ok := s.idempotency[k]
+	if !ok || !v.SameReservation(record) || !fn(v) {
+		return false
+	}
+	stored := *record
+	s.idempotency[k] = &stored
+
+	return true
+}
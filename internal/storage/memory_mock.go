@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagafonov/shortener/internal/contract"
@@ -29,6 +30,20 @@ type MemoryStorageMock struct {
 	getAllURLsByUserError  error
 
 	deleteURLsByUserError error
+
+	incrementDailyUsageError error
+
+	reserveDailyUsageExceeded bool
+	reserveDailyUsageError    error
+
+	getQuotaUsageEntity *entity.QuotaUsage
+	getQuotaUsageError  error
+
+	consumeClickResponseOk    bool
+	consumeClickResponseError error
+
+	getVariantClicksResponse      map[string]int64
+	getVariantClicksResponseError error
 }
 
 // Constructor for MemoryStorageMock.
@@ -48,7 +63,12 @@ func (s *MemoryStorageMock) SetGetByHashResponse(e *entity.URL, err error) {
 }
 
 // GetByURL.
-func (s *MemoryStorageMock) GetByURL(ctx context.Context, url string) (*entity.URL, error) {
+func (s *MemoryStorageMock) GetByURL(
+	ctx context.Context,
+	url string,
+	userID uuid.UUID,
+	domain string,
+) (*entity.URL, error) {
 	return s.getByURLResponseEntity, s.getByURLResponseError
 }
 
@@ -74,6 +94,11 @@ func (s *MemoryStorageMock) GetAll(ctx context.Context) ([]*entity.URL, error) {
 	return s.getAllResponseEntity, s.getAllResponseError
 }
 
+// GetLiveURLs.
+func (s *MemoryStorageMock) GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error) {
+	return liveURLsPage(s.getAllResponseEntity, after, limit), s.getAllResponseError
+}
+
 // AddBatch.
 func (s *MemoryStorageMock) AddBatch(ctx context.Context, b []*entity.URL) (int, error) {
 	return s.getAddBatchResponseTotalCreated, s.getAddBatchResponseError
@@ -90,16 +115,44 @@ func (s *MemoryStorageMock) GetAllURLsByUser(
 	ctx context.Context,
 	userID uuid.UUID,
 	baseURL string,
+	tag string,
 ) ([]*entity.URL, error) {
 	return s.getAllURLsByUserEntity, s.getAllURLsByUserError
 }
 
+// RenameTags.
+func (s *MemoryStorageMock) RenameTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	return 0, nil
+}
+
 // SetGetAllURLsByUserResponse.
 func (s *MemoryStorageMock) SetGetAllURLsByUserResponse(u []*entity.URL, err error) {
 	s.getAllURLsByUserEntity = u
 	s.getAllURLsByUserError = err
 }
 
+// EachURLByUser.
+func (s *MemoryStorageMock) EachURLByUser(ctx context.Context, userID uuid.UUID, fn func(url *entity.URL) error) error {
+	for _, v := range s.getAllURLsByUserEntity {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return s.getAllURLsByUserError
+}
+
+// SearchURLsByUser.
+func (s *MemoryStorageMock) SearchURLsByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	query string,
+	limit int,
+	offset int,
+) ([]*entity.URL, int, error) {
+	return s.getAllURLsByUserEntity, len(s.getAllURLsByUserEntity), s.getAllURLsByUserError
+}
+
 // DeleteURLsByUser.
 func (s *MemoryStorageMock) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error {
 	return nil
@@ -110,6 +163,250 @@ func (s *MemoryStorageMock) SetDeleteURLsByUserResponse(err error) {
 	s.deleteURLsByUserError = err
 }
 
+// IncrementDailyUsage.
+func (s *MemoryStorageMock) IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error {
+	return s.incrementDailyUsageError
+}
+
+// SetIncrementDailyUsageResponse.
+func (s *MemoryStorageMock) SetIncrementDailyUsageResponse(err error) {
+	s.incrementDailyUsageError = err
+}
+
+// ReserveDailyUsage.
+func (s *MemoryStorageMock) ReserveDailyUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+	n int,
+	limit int,
+) (bool, error) {
+	return !s.reserveDailyUsageExceeded, s.reserveDailyUsageError
+}
+
+// SetReserveDailyUsageResponse.
+func (s *MemoryStorageMock) SetReserveDailyUsageResponse(ok bool, err error) {
+	s.reserveDailyUsageExceeded = !ok
+	s.reserveDailyUsageError = err
+}
+
+// GetQuotaUsage.
+func (s *MemoryStorageMock) GetQuotaUsage(
+	ctx context.Context,
+	userID uuid.UUID,
+	day time.Time,
+) (*entity.QuotaUsage, error) {
+	return s.getQuotaUsageEntity, s.getQuotaUsageError
+}
+
+// SetGetQuotaUsageResponse.
+func (s *MemoryStorageMock) SetGetQuotaUsageResponse(u *entity.QuotaUsage, err error) {
+	s.getQuotaUsageEntity = u
+	s.getQuotaUsageError = err
+}
+
+// AddAPIKey.
+func (s *MemoryStorageMock) AddAPIKey(ctx context.Context, key *entity.APIKey) error {
+	return nil
+}
+
+// GetAPIKeyByHash.
+func (s *MemoryStorageMock) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// GetAPIKeysByUser.
+func (s *MemoryStorageMock) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	return nil, nil
+}
+
+// GetAllAPIKeys.
+func (s *MemoryStorageMock) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	return nil, nil
+}
+
+// RevokeAPIKey.
+func (s *MemoryStorageMock) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return nil
+}
+
+// AddAccount.
+func (s *MemoryStorageMock) AddAccount(ctx context.Context, account *entity.Account) error {
+	return nil
+}
+
+// GetAccountByLogin.
+func (s *MemoryStorageMock) GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// GetAllAccounts.
+func (s *MemoryStorageMock) GetAllAccounts(ctx context.Context) ([]*entity.Account, error) {
+	return nil, nil
+}
+
+// AddSession.
+func (s *MemoryStorageMock) AddSession(ctx context.Context, session *entity.Session) error {
+	return nil
+}
+
+// GetSessionByHash.
+func (s *MemoryStorageMock) GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// DeleteSession.
+func (s *MemoryStorageMock) DeleteSession(ctx context.Context, hash string) error {
+	return nil
+}
+
+// GetAllSessions.
+func (s *MemoryStorageMock) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	return nil, nil
+}
+
+// HasSessions.
+func (s *MemoryStorageMock) HasSessions(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+// ReassignURLs.
+func (s *MemoryStorageMock) ReassignURLs(ctx context.Context, fromUserID uuid.UUID, toUserID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+// AddOrganization.
+func (s *MemoryStorageMock) AddOrganization(ctx context.Context, org *entity.Organization) error {
+	return nil
+}
+
+// GetOrganization.
+func (s *MemoryStorageMock) GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// AddTeam.
+func (s *MemoryStorageMock) AddTeam(ctx context.Context, team *entity.Team) error {
+	return nil
+}
+
+// GetTeam.
+func (s *MemoryStorageMock) GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// SetTeamMember.
+func (s *MemoryStorageMock) SetTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	return nil
+}
+
+// RemoveTeamMember.
+func (s *MemoryStorageMock) RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	return nil
+}
+
+// GetTeamMember.
+func (s *MemoryStorageMock) GetTeamMember(
+	ctx context.Context,
+	teamID uuid.UUID,
+	userID uuid.UUID,
+) (*entity.TeamMember, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// GetTeamMembers.
+func (s *MemoryStorageMock) GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	return nil, nil
+}
+
+// GetUserTeams.
+func (s *MemoryStorageMock) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	return nil, nil
+}
+
+// SetURLOwner.
+func (s *MemoryStorageMock) SetURLOwner(ctx context.Context, short string, userID uuid.UUID, teamID uuid.UUID) error {
+	return nil
+}
+
+// GetAllOrganizations.
+func (s *MemoryStorageMock) GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error) {
+	return nil, nil
+}
+
+// GetAllTeams.
+func (s *MemoryStorageMock) GetAllTeams(ctx context.Context) ([]*entity.Team, error) {
+	return nil, nil
+}
+
+// GetAllTeamMembers.
+func (s *MemoryStorageMock) GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error) {
+	return nil, nil
+}
+
+// GetVisibleByHash.
+func (s *MemoryStorageMock) GetVisibleByHash(ctx context.Context, hash string, userID uuid.UUID) (*entity.URL, error) {
+	return s.getByHashResponseEntity, s.getByHashResponseError
+}
+
+// UpdateURL.
+func (s *MemoryStorageMock) UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error {
+	return nil
+}
+
+// ConsumeClick.
+func (s *MemoryStorageMock) ConsumeClick(ctx context.Context, short string) (bool, error) {
+	return s.consumeClickResponseOk, s.consumeClickResponseError
+}
+
+// SetConsumeClickResponse.
+func (s *MemoryStorageMock) SetConsumeClickResponse(ok bool, err error) {
+	s.consumeClickResponseOk = ok
+	s.consumeClickResponseError = err
+}
+
+// AddVariantClick.
+func (s *MemoryStorageMock) AddVariantClick(ctx context.Context, short string, variant string) error {
+	return nil
+}
+
+// GetVariantClicks.
+func (s *MemoryStorageMock) GetVariantClicks(ctx context.Context, short string) (map[string]int64, error) {
+	return s.getVariantClicksResponse, s.getVariantClicksResponseError
+}
+
+// SetGetVariantClicksResponse.
+func (s *MemoryStorageMock) SetGetVariantClicksResponse(clicks map[string]int64, err error) {
+	s.getVariantClicksResponse = clicks
+	s.getVariantClicksResponseError = err
+}
+
+// ReserveIdempotencyKey.
+func (s *MemoryStorageMock) ReserveIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (*entity.IdempotencyRecord, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// ExtendIdempotencyKey.
+func (s *MemoryStorageMock) ExtendIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	return true, nil
+}
+
+// CompleteIdempotencyKey.
+func (s *MemoryStorageMock) CompleteIdempotencyKey(
+	ctx context.Context,
+	record *entity.IdempotencyRecord,
+) (bool, error) {
+	return true, nil
+}
+
+// DeleteIdempotencyKey.
+func (s *MemoryStorageMock) DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	return nil
+}
+
 // Ping.
 func (s *MemoryStorageMock) Ping(ctx context.Context) error { return nil }
 
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/vagafonov/shortener/pkg/entity"
+)
+
+type teamMemberKey struct {
+	teamID uuid.UUID
+	userID uuid.UUID
+}
+
+// AddOrganization save organization in memory.
+func (s *memoryStorage) AddOrganization(ctx context.Context, org *entity.Organization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orgs[org.ID] = org
+
+	return nil
+}
+
+// GetOrganization get organization by id.
+func (s *memoryStorage) GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.orgs[id], nil
+}
+
+// AddTeam save team in memory.
+func (s *memoryStorage) AddTeam(ctx context.Context, team *entity.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.teams[team.ID] = team
+
+	return nil
+}
+
+// GetTeam get team by id.
+func (s *memoryStorage) GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.teams[id], nil
+}
+
+// SetTeamMember add member to team or change role of existing member.
+func (s *memoryStorage) SetTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := teamMemberKey{teamID: member.TeamID, userID: member.UserID}
+	if existing, ok := s.members[key]; ok {
+		changed := *existing
+		changed.Role = member.Role
+		s.members[key] = &changed
+
+		return nil
+	}
+	s.members[key] = member
+
+	return nil
+}
+
+// RemoveTeamMember remove member from team.
+func (s *memoryStorage) RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.members, teamMemberKey{teamID: teamID, userID: userID})
+
+	return nil
+}
+
+// GetTeamMember get membership of user in team.
+func (s *memoryStorage) GetTeamMember(
+	ctx context.Context,
+	teamID uuid.UUID,
+	userID uuid.UUID,
+) (*entity.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.members[teamMemberKey{teamID: teamID, userID: userID}], nil
+}
+
+// GetTeamMembers get all members of team.
+func (s *memoryStorage) GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filterMembers(func(m *entity.TeamMember) bool { return m.TeamID == teamID }), nil
+}
+
+// GetUserTeams get all memberships of user.
+func (s *memoryStorage) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filterMembers(func(m *entity.TeamMember) bool { return m.UserID == userID }), nil
+}
+
+// SetURLOwner transfer URL to user or team. URL belongs to user when teamID is empty.
+func (s *memoryStorage) SetURLOwner(ctx context.Context, short string, userID uuid.UUID, teamID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateURL(short, func(v *entity.URL) {
+		v.UserID = userID
+		v.TeamID = teamID
+	})
+
+	return nil
+}
+
+// GetAllOrganizations get all organizations.
+func (s *memoryStorage) GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*entity.Organization, 0, len(s.orgs))
+	for _, v := range s.orgs {
+		res = append(res, v)
+	}
+	slices.SortFunc(res, func(a, b *entity.Organization) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return res, nil
+}
+
+// GetAllTeams get all teams.
+func (s *memoryStorage) GetAllTeams(ctx context.Context) ([]*entity.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*entity.Team, 0, len(s.teams))
+	for _, v := range s.teams {
+		res = append(res, v)
+	}
+	slices.SortFunc(res, func(a, b *entity.Team) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return res, nil
+}
+
+// GetAllTeamMembers get members of all teams.
+func (s *memoryStorage) GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filterMembers(func(*entity.TeamMember) bool { return true }), nil
+}
+
+func (s *memoryStorage) filterMembers(fn func(m *entity.TeamMember) bool) []*entity.TeamMember {
+	return filterMembers(s.members, fn)
+}
+
+// filterMembers return matched members ordered by time of joining.
+func filterMembers(
+	members map[teamMemberKey]*entity.TeamMember,
+	fn func(m *entity.TeamMember) bool,
+) []*entity.TeamMember {
+	res := make([]*entity.TeamMember, 0)
+	for _, v := range members {
+		if fn(v) {
+			res = append(res, v)
+		}
+	}
+	slices.SortFunc(res, func(a, b *entity.TeamMember) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.UserID.String(), b.UserID.String())
+	})
+
+	return res
+}
+
+// member return role of user for URL. Owner of personal URL is treated as admin. Caller must hold lock.
+func (s *memoryStorage) member(url *entity.URL, userID uuid.UUID) *entity.TeamMember {
+	return urlMember(url, userID, s.members[teamMemberKey{teamID: url.TeamID, userID: userID}])
+}
+
+// urlMember return role of user for URL by membership in team of URL.
+func urlMember(url *entity.URL, userID uuid.UUID, teamMember *entity.TeamMember) *entity.TeamMember {
+	if !url.OwnedByTeam() {
+		if url.UserID != userID {
+			return nil
+		}
+
+		return &entity.TeamMember{UserID: userID, Role: entity.RoleAdmin}
+	}
+
+	return teamMember
+}
+
+// isVisible check that URL can be followed by member, public URLs can be followed by anyone.
+func isVisible(url *entity.URL, member *entity.TeamMember) bool {
+	return !url.IsPrivate() || member.CanView()
+}
@@ -2,10 +2,16 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/internal/contract"
+	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/pkg/entity"
 )
 
@@ -26,7 +32,7 @@ func (s *MemoryStorageTestSuite) TestGetAll() {
 }
 
 func (s *MemoryStorageTestSuite) TestGetByHash() {
-	s.T().Skip("create later")
+	testGetByHash(&s.Suite, NewMemoryStorage())
 }
 
 func (s *MemoryStorageTestSuite) TestGetByURL() {
@@ -53,6 +59,23 @@ func (s *MemoryStorageTestSuite) TestAddBatch() {
 		s.Require().Equal(batchURLs, allURLs)
 		s.Require().NoError(err)
 	})
+
+	s.Run("existing key is not overwritten", func() {
+		otherID := uuid.New()
+		tc, err := ms.AddBatch(ctx, []*entity.URL{
+			{Short: "b", Original: "bbb", UserID: otherID},
+			{Short: "a", Original: "other", UserID: otherID},
+		})
+		s.Require().ErrorIs(err, customerror.ErrAlreadyExistsInStorage)
+		s.Require().Zero(tc)
+
+		url, err := ms.GetByHash(ctx, "a")
+		s.Require().NoError(err)
+		s.Require().Equal("aaa", url.Original)
+		url, err = ms.GetByHash(ctx, "b")
+		s.Require().NoError(err)
+		s.Require().Nil(url, "batch with existing key is not added")
+	})
 }
 
 func (s *MemoryStorageTestSuite) TestGetUserURLs() {
@@ -64,9 +87,286 @@ func (s *MemoryStorageTestSuite) TestGetUserURLs() {
 		entityURL, err := ms.Add(ctx, "***", "http://test.test", userID)
 		s.Require().NoError(err)
 
-		allURLs, err := ms.GetAllURLsByUser(ctx, userID, "")
+		allURLs, err := ms.GetAllURLsByUser(ctx, userID, "", "")
 		s.Require().NoError(err)
 		s.Require().Len(allURLs, 1)
 		s.Require().Equal([]*entity.URL{entityURL}, allURLs)
 	})
 }
+
+func (s *MemoryStorageTestSuite) TestGetQuotaUsage() {
+	ctx := context.Background()
+
+	s.Run("count created today and active URLs", func() {
+		ms := NewMemoryStorage()
+		userID := uuid.Must(uuid.NewUUID())
+		day := time.Now().UTC().Truncate(24 * time.Hour)
+		_, err := ms.Add(ctx, "a", "http://a.test", userID)
+		s.Require().NoError(err)
+		_, err = ms.Add(ctx, "b", "http://b.test", userID)
+		s.Require().NoError(err)
+		_, err = ms.Add(ctx, "c", "http://c.test", uuid.Must(uuid.NewUUID()))
+		s.Require().NoError(err)
+		s.Require().NoError(ms.IncrementDailyUsage(ctx, userID, day, 2))
+
+		usage, err := ms.GetQuotaUsage(ctx, userID, day)
+		s.Require().NoError(err)
+		s.Require().Equal(&entity.QuotaUsage{CreatedToday: 2, Active: 2}, usage)
+
+		usage, err = ms.GetQuotaUsage(ctx, userID, day.AddDate(0, 0, 1))
+		s.Require().NoError(err)
+		s.Require().Equal(&entity.QuotaUsage{CreatedToday: 0, Active: 2}, usage)
+	})
+
+	s.Run("reserve daily usage within limit", func() {
+		ms := NewMemoryStorage()
+		userID := uuid.New()
+		day := time.Now().UTC().Truncate(24 * time.Hour)
+
+		ok, err := ms.ReserveDailyUsage(ctx, userID, day, 2, 3)
+		s.Require().NoError(err)
+		s.Require().True(ok)
+		ok, err = ms.ReserveDailyUsage(ctx, userID, day, 2, 3)
+		s.Require().NoError(err)
+		s.Require().False(ok)
+		ok, err = ms.ReserveDailyUsage(ctx, userID, day, 5, 0)
+		s.Require().NoError(err)
+		s.Require().True(ok)
+
+		usage, err := ms.GetQuotaUsage(ctx, userID, day)
+		s.Require().NoError(err)
+		s.Require().Equal(7, usage.CreatedToday)
+	})
+}
+
+func (s *MemoryStorageTestSuite) TestPrivateURL() {
+	ctx := context.Background()
+	ms := NewMemoryStorage()
+	ownerID, memberID, strangerID := uuid.New(), uuid.New(), uuid.New()
+	_, err := ms.Add(ctx, "private", "http://private.test", ownerID)
+	s.Require().NoError(err)
+	_, err = ms.Add(ctx, "team", "http://team.test", ownerID)
+	s.Require().NoError(err)
+
+	visibility := entity.VisibilityPrivate
+	s.Require().NoError(ms.UpdateURL(ctx, "private", &entity.URLPatch{Visibility: &visibility}))
+	s.Require().NoError(ms.UpdateURL(ctx, "team", &entity.URLPatch{Visibility: &visibility}))
+	teamID := uuid.New()
+	s.Require().NoError(ms.SetURLOwner(ctx, "team", ownerID, teamID))
+	s.Require().NoError(ms.SetTeamMember(ctx, &entity.TeamMember{TeamID: teamID, UserID: memberID, Role: entity.RoleViewer}))
+
+	tests := []struct {
+		name    string
+		short   string
+		userID  uuid.UUID
+		visible bool
+	}{
+		{name: "owner", short: "private", userID: ownerID, visible: true},
+		{name: "stranger", short: "private", userID: strangerID, visible: false},
+		{name: "anonymous", short: "private", userID: uuid.Nil, visible: false},
+		{name: "team member", short: "team", userID: memberID, visible: true},
+		{name: "creator out of team", short: "team", userID: ownerID, visible: false},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			url, err := ms.GetVisibleByHash(ctx, test.short, test.userID)
+			s.Require().NoError(err)
+			s.Require().Equal(test.visible, url != nil)
+		})
+	}
+
+	s.Run("deleted private url is hidden from stranger", func() {
+		s.Require().NoError(ms.DeleteURLsByUser(ctx, ownerID, []string{"private"}))
+
+		url, err := ms.GetVisibleByHash(ctx, "private", strangerID)
+		s.Require().NoError(err)
+		s.Require().Nil(url)
+
+		_, err = ms.GetVisibleByHash(ctx, "private", ownerID)
+		s.Require().ErrorIs(err, customerror.ErrURLDeleted)
+	})
+}
+
+func (s *MemoryStorageTestSuite) TestMaxClicks() {
+	ctx := context.Background()
+	ms := NewMemoryStorage()
+	_, err := ms.Add(ctx, "once", "http://once.test", uuid.New())
+	s.Require().NoError(err)
+	_, err = ms.Add(ctx, "unlimited", "http://unlimited.test", uuid.New())
+	s.Require().NoError(err)
+	maxClicks := int64(3)
+	s.Require().NoError(ms.UpdateURL(ctx, "once", &entity.URLPatch{MaxClicks: &maxClicks}))
+
+	s.Require().Equal(int64(3), consumeConcurrently(ctx, ms, "once", 50))
+	s.Require().Equal(int64(50), consumeConcurrently(ctx, ms, "unlimited", 50))
+
+	url, err := ms.GetByHash(ctx, "once")
+	s.Require().NoError(err)
+	s.Require().Equal(int64(0), url.RemainingClicks())
+
+	ok, err := ms.ConsumeClick(ctx, "unknown")
+	s.Require().NoError(err)
+	s.Require().False(ok)
+}
+
+// testGetByHash check contract of GetByHash which is the same for all storages.
+func testGetByHash(s *suite.Suite, st contract.Storage) {
+	ctx := context.Background()
+	userID := uuid.New()
+	_, err := st.Add(ctx, "live", "http://live.test", userID)
+	s.Require().NoError(err)
+	_, err = st.Add(ctx, "private", "http://private.test", userID)
+	s.Require().NoError(err)
+	_, err = st.Add(ctx, "deleted", "http://deleted.test", userID)
+	s.Require().NoError(err)
+	visibility := entity.VisibilityPrivate
+	s.Require().NoError(st.UpdateURL(ctx, "private", &entity.URLPatch{Visibility: &visibility}))
+	s.Require().NoError(st.DeleteURLsByUser(ctx, userID, []string{"deleted"}))
+
+	url, err := st.GetByHash(ctx, "live")
+	s.Require().NoError(err)
+	s.Require().Equal("http://live.test", url.Original)
+
+	url, err = st.GetByHash(ctx, "private")
+	s.Require().NoError(err)
+	s.Require().True(url.IsPrivate())
+
+	url, err = st.GetByHash(ctx, "deleted")
+	s.Require().ErrorIs(err, customerror.ErrURLDeleted)
+	s.Require().Nil(url)
+
+	url, err = st.GetByHash(ctx, "unknown")
+	s.Require().NoError(err)
+	s.Require().Nil(url)
+}
+
+// consumeConcurrently follow URL n times at once and return number of successful clicks.
+func consumeConcurrently(ctx context.Context, st contract.Storage, short string, n int) int64 {
+	var wg sync.WaitGroup
+	var consumed atomic.Int64
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := st.ConsumeClick(ctx, short); err == nil && ok {
+				consumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return consumed.Load()
+}
+
+func (s *MemoryStorageTestSuite) TestVariantClicks() {
+	ctx := context.Background()
+	ms := NewMemoryStorage()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			variant := "a"
+			if i%4 == 0 {
+				variant = "b"
+			}
+			s.NoError(ms.AddVariantClick(ctx, "short", variant))
+		}(i)
+	}
+	wg.Wait()
+	s.Require().NoError(ms.AddVariantClick(ctx, "other", "a"))
+
+	clicks, err := ms.GetVariantClicks(ctx, "short")
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]int64{"a": 15, "b": 5}, clicks)
+}
+
+func (s *MemoryStorageTestSuite) TestConcurrentAccess() {
+	ctx := context.Background()
+	ms := NewMemoryStorage()
+	userID := uuid.New()
+	_, err := ms.Add(ctx, "shared", "http://shared.test", userID)
+	s.Require().NoError(err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(4)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = ms.Add(ctx, fmt.Sprintf("u%d", i), fmt.Sprintf("http://%d.test", i), userID)
+		}(i)
+		go func() {
+			defer wg.Done()
+			title := "title"
+			s.NoError(ms.UpdateURL(ctx, "shared", &entity.URLPatch{Title: &title}))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := ms.GetLiveURLs(ctx, "", 100)
+			s.NoError(err)
+		}()
+		go func() {
+			defer wg.Done()
+			url, err := ms.GetByHash(ctx, "shared")
+			s.NoError(err)
+			_ = url.Title
+			_, err = ms.ConsumeClick(ctx, "shared")
+			s.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	url, err := ms.GetByHash(ctx, "shared")
+	s.Require().NoError(err)
+	s.Require().Equal("title", url.Title)
+	s.Require().Equal(int64(20), url.Clicks)
+	all, err := ms.GetAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(all, 21)
+}
+
+func (s *MemoryStorageTestSuite) TestIdempotencyKeys() {
+	ctx := context.Background()
+	ms := NewMemoryStorage()
+	now := time.Now().UTC()
+	expired := &entity.IdempotencyRecord{UserID: uuid.New(), Key: "key", CreatedAt: now, ExpiresAt: now}
+	saved, err := ms.ReserveIdempotencyKey(ctx, expired)
+	s.Require().NoError(err)
+	s.Require().Nil(saved)
+
+	record := &entity.IdempotencyRecord{
+		UserID:      uuid.New(),
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	saved, err = ms.ReserveIdempotencyKey(ctx, record)
+	s.Require().NoError(err)
+	s.Require().Nil(saved)
+	s.Require().NotContains(
+		ms.(*memoryStorage).idempotency,
+		idempotencyKey{userID: expired.UserID, key: "key"},
+		"expired keys of other users are purged",
+	)
+
+	ok, err := ms.ExtendIdempotencyKey(ctx, &entity.IdempotencyRecord{
+		UserID:      record.UserID,
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   now.Add(time.Second),
+		ExpiresAt:   now.Add(time.Hour),
+	})
+	s.Require().NoError(err)
+	s.Require().False(ok, "only reservation of request can be extended")
+
+	extended := *record
+	extended.ExpiresAt = now.Add(time.Hour)
+	ok, err = ms.ExtendIdempotencyKey(ctx, &extended)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	saved, err = ms.ReserveIdempotencyKey(ctx, &entity.IdempotencyRecord{UserID: record.UserID, Key: "key"})
+	s.Require().NoError(err)
+	s.Require().Equal(extended.ExpiresAt, saved.ExpiresAt)
+}
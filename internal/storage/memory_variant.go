@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type variantClickKey struct {
+	short   string
+	variant string
+}
+
+// AddVariantClick count click on variant of URL. Counters are safe for concurrent redirects.
+func (s *memoryStorage) AddVariantClick(ctx context.Context, short string, variant string) error {
+	v, _ := s.variantClicks.LoadOrStore(variantClickKey{short: short, variant: variant}, new(atomic.Int64))
+	v.(*atomic.Int64).Add(1) //nolint:forcetypeassert
+
+	return nil
+}
+
+// GetVariantClicks get clicks on variants of URL.
+func (s *memoryStorage) GetVariantClicks(ctx context.Context, short string) (map[string]int64, error) {
+	res := make(map[string]int64)
+	s.variantClicks.Range(func(k, v any) bool {
+		if key := k.(variantClickKey); key.short == short { //nolint:forcetypeassert
+			res[key.variant] = v.(*atomic.Int64).Load() //nolint:forcetypeassert
+		}
+
+		return true
+	})
+
+	return res, nil
+}
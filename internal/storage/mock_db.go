@@ -7,6 +7,7 @@ package storage
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -51,6 +52,34 @@ func (mr *MockStorageMockRecorder) Add(ctx, hash, url, userID interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockStorage)(nil).Add), ctx, hash, url, userID)
 }
 
+// AddAPIKey mocks base method.
+func (m *MockStorage) AddAPIKey(ctx context.Context, key *entity.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockStorageMockRecorder) AddAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockStorage)(nil).AddAPIKey), ctx, key)
+}
+
+// AddAccount mocks base method.
+func (m *MockStorage) AddAccount(ctx context.Context, account *entity.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccount", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAccount indicates an expected call of AddAccount.
+func (mr *MockStorageMockRecorder) AddAccount(ctx, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccount", reflect.TypeOf((*MockStorage)(nil).AddAccount), ctx, account)
+}
+
 // AddBatch mocks base method.
 func (m *MockStorage) AddBatch(ctx context.Context, URLs []*entity.URL) (int, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +95,62 @@ func (mr *MockStorageMockRecorder) AddBatch(ctx, URLs interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBatch", reflect.TypeOf((*MockStorage)(nil).AddBatch), ctx, URLs)
 }
 
+// AddOrganization mocks base method.
+func (m *MockStorage) AddOrganization(ctx context.Context, org *entity.Organization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganization", ctx, org)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganization indicates an expected call of AddOrganization.
+func (mr *MockStorageMockRecorder) AddOrganization(ctx, org interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganization", reflect.TypeOf((*MockStorage)(nil).AddOrganization), ctx, org)
+}
+
+// AddSession mocks base method.
+func (m *MockStorage) AddSession(ctx context.Context, session *entity.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSession indicates an expected call of AddSession.
+func (mr *MockStorageMockRecorder) AddSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSession", reflect.TypeOf((*MockStorage)(nil).AddSession), ctx, session)
+}
+
+// AddTeam mocks base method.
+func (m *MockStorage) AddTeam(ctx context.Context, team *entity.Team) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTeam", ctx, team)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTeam indicates an expected call of AddTeam.
+func (mr *MockStorageMockRecorder) AddTeam(ctx, team interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTeam", reflect.TypeOf((*MockStorage)(nil).AddTeam), ctx, team)
+}
+
+// AddVariantClick mocks base method.
+func (m *MockStorage) AddVariantClick(ctx context.Context, short string, variant string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVariantClick", ctx, short, variant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddVariantClick indicates an expected call of AddVariantClick.
+func (mr *MockStorageMockRecorder) AddVariantClick(ctx, short, variant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVariantClick", reflect.TypeOf((*MockStorage)(nil).AddVariantClick), ctx, short, variant)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -80,6 +165,64 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
+// CompleteIdempotencyKey mocks base method.
+func (m *MockStorage) CompleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", ctx, record)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockStorageMockRecorder) CompleteIdempotencyKey(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).CompleteIdempotencyKey), ctx, record)
+}
+
+// ConsumeClick mocks base method.
+func (m *MockStorage) ConsumeClick(ctx context.Context, short string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeClick", ctx, short)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeClick indicates an expected call of ConsumeClick.
+func (mr *MockStorageMockRecorder) ConsumeClick(ctx, short interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeClick", reflect.TypeOf((*MockStorage)(nil).ConsumeClick), ctx, short)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockStorage) DeleteIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockStorageMockRecorder) DeleteIdempotencyKey(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).DeleteIdempotencyKey), ctx, record)
+}
+
+// DeleteSession mocks base method.
+func (m *MockStorage) DeleteSession(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockStorageMockRecorder) DeleteSession(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStorage)(nil).DeleteSession), ctx, hash)
+}
+
 // DeleteURLsByUser mocks base method.
 func (m *MockStorage) DeleteURLsByUser(ctx context.Context, userID uuid.UUID, batch []string) error {
 	m.ctrl.T.Helper()
@@ -94,6 +237,66 @@ func (mr *MockStorageMockRecorder) DeleteURLsByUser(ctx, userID, batch interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURLsByUser", reflect.TypeOf((*MockStorage)(nil).DeleteURLsByUser), ctx, userID, batch)
 }
 
+// ExtendIdempotencyKey mocks base method.
+func (m *MockStorage) ExtendIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtendIdempotencyKey", ctx, record)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExtendIdempotencyKey indicates an expected call of ExtendIdempotencyKey.
+func (mr *MockStorageMockRecorder) ExtendIdempotencyKey(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtendIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).ExtendIdempotencyKey), ctx, record)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(*entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockStorageMockRecorder) GetAPIKeyByHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockStorage)(nil).GetAPIKeyByHash), ctx, hash)
+}
+
+// GetAPIKeysByUser mocks base method.
+func (m *MockStorage) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]*entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeysByUser", ctx, userID)
+	ret0, _ := ret[0].([]*entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeysByUser indicates an expected call of GetAPIKeysByUser.
+func (mr *MockStorageMockRecorder) GetAPIKeysByUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeysByUser", reflect.TypeOf((*MockStorage)(nil).GetAPIKeysByUser), ctx, userID)
+}
+
+// GetAccountByLogin mocks base method.
+func (m *MockStorage) GetAccountByLogin(ctx context.Context, login string) (*entity.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByLogin", ctx, login)
+	ret0, _ := ret[0].(*entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByLogin indicates an expected call of GetAccountByLogin.
+func (mr *MockStorageMockRecorder) GetAccountByLogin(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByLogin", reflect.TypeOf((*MockStorage)(nil).GetAccountByLogin), ctx, login)
+}
+
 // GetAll mocks base method.
 func (m *MockStorage) GetAll(ctx context.Context) ([]*entity.URL, error) {
 	m.ctrl.T.Helper()
@@ -109,19 +312,154 @@ func (mr *MockStorageMockRecorder) GetAll(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockStorage)(nil).GetAll), ctx)
 }
 
+// GetAllAPIKeys mocks base method.
+func (m *MockStorage) GetAllAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAPIKeys", ctx)
+	ret0, _ := ret[0].([]*entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAPIKeys indicates an expected call of GetAllAPIKeys.
+func (mr *MockStorageMockRecorder) GetAllAPIKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAPIKeys", reflect.TypeOf((*MockStorage)(nil).GetAllAPIKeys), ctx)
+}
+
+// GetAllAccounts mocks base method.
+func (m *MockStorage) GetAllAccounts(ctx context.Context) ([]*entity.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAccounts", ctx)
+	ret0, _ := ret[0].([]*entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAccounts indicates an expected call of GetAllAccounts.
+func (mr *MockStorageMockRecorder) GetAllAccounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAccounts", reflect.TypeOf((*MockStorage)(nil).GetAllAccounts), ctx)
+}
+
+// GetAllSessions mocks base method.
+func (m *MockStorage) GetAllSessions(ctx context.Context) ([]*entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllSessions", ctx)
+	ret0, _ := ret[0].([]*entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSessions indicates an expected call of GetAllSessions.
+func (mr *MockStorageMockRecorder) GetAllSessions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSessions", reflect.TypeOf((*MockStorage)(nil).GetAllSessions), ctx)
+}
+
+// GetAllOrganizations mocks base method.
+func (m *MockStorage) GetAllOrganizations(ctx context.Context) ([]*entity.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllOrganizations", ctx)
+	ret0, _ := ret[0].([]*entity.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllOrganizations indicates an expected call of GetAllOrganizations.
+func (mr *MockStorageMockRecorder) GetAllOrganizations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOrganizations", reflect.TypeOf((*MockStorage)(nil).GetAllOrganizations), ctx)
+}
+
+// GetAllTeams mocks base method.
+func (m *MockStorage) GetAllTeams(ctx context.Context) ([]*entity.Team, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllTeams", ctx)
+	ret0, _ := ret[0].([]*entity.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllTeams indicates an expected call of GetAllTeams.
+func (mr *MockStorageMockRecorder) GetAllTeams(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTeams", reflect.TypeOf((*MockStorage)(nil).GetAllTeams), ctx)
+}
+
+// GetAllTeamMembers mocks base method.
+func (m *MockStorage) GetAllTeamMembers(ctx context.Context) ([]*entity.TeamMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllTeamMembers", ctx)
+	ret0, _ := ret[0].([]*entity.TeamMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllTeamMembers indicates an expected call of GetAllTeamMembers.
+func (mr *MockStorageMockRecorder) GetAllTeamMembers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTeamMembers", reflect.TypeOf((*MockStorage)(nil).GetAllTeamMembers), ctx)
+}
+
+// GetLiveURLs mocks base method.
+func (m *MockStorage) GetLiveURLs(ctx context.Context, after string, limit int) ([]*entity.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLiveURLs", ctx, after, limit)
+	ret0, _ := ret[0].([]*entity.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLiveURLs indicates an expected call of GetLiveURLs.
+func (mr *MockStorageMockRecorder) GetLiveURLs(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLiveURLs", reflect.TypeOf((*MockStorage)(nil).GetLiveURLs), ctx, after, limit)
+}
+
+// EachURLByUser mocks base method.
+func (m *MockStorage) EachURLByUser(ctx context.Context, userID uuid.UUID, fn func(*entity.URL) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachURLByUser", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachURLByUser indicates an expected call of EachURLByUser.
+func (mr *MockStorageMockRecorder) EachURLByUser(ctx, userID, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachURLByUser", reflect.TypeOf((*MockStorage)(nil).EachURLByUser), ctx, userID, fn)
+}
+
+// SearchURLsByUser mocks base method.
+func (m *MockStorage) SearchURLsByUser(ctx context.Context, userID uuid.UUID, query string, limit, offset int) ([]*entity.URL, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchURLsByUser", ctx, userID, query, limit, offset)
+	ret0, _ := ret[0].([]*entity.URL)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchURLsByUser indicates an expected call of SearchURLsByUser.
+func (mr *MockStorageMockRecorder) SearchURLsByUser(ctx, userID, query, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchURLsByUser", reflect.TypeOf((*MockStorage)(nil).SearchURLsByUser), ctx, userID, query, limit, offset)
+}
+
 // GetAllURLsByUser mocks base method.
-func (m *MockStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID, baseURL string) ([]*entity.URL, error) {
+func (m *MockStorage) GetAllURLsByUser(ctx context.Context, userID uuid.UUID, baseURL, tag string) ([]*entity.URL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllURLsByUser", ctx, userID, baseURL)
+	ret := m.ctrl.Call(m, "GetAllURLsByUser", ctx, userID, baseURL, tag)
 	ret0, _ := ret[0].([]*entity.URL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllURLsByUser indicates an expected call of GetAllURLsByUser.
-func (mr *MockStorageMockRecorder) GetAllURLsByUser(ctx, userID, baseURL interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetAllURLsByUser(ctx, userID, baseURL, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllURLsByUser", reflect.TypeOf((*MockStorage)(nil).GetAllURLsByUser), ctx, userID, baseURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllURLsByUser", reflect.TypeOf((*MockStorage)(nil).GetAllURLsByUser), ctx, userID, baseURL, tag)
 }
 
 // GetByHash mocks base method.
@@ -140,18 +478,182 @@ func (mr *MockStorageMockRecorder) GetByHash(ctx, hash interface{}) *gomock.Call
 }
 
 // GetByURL mocks base method.
-func (m *MockStorage) GetByURL(ctx context.Context, url string) (*entity.URL, error) {
+func (m *MockStorage) GetByURL(ctx context.Context, url string, userID uuid.UUID, domain string) (*entity.URL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByURL", ctx, url)
+	ret := m.ctrl.Call(m, "GetByURL", ctx, url, userID, domain)
 	ret0, _ := ret[0].(*entity.URL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByURL indicates an expected call of GetByURL.
-func (mr *MockStorageMockRecorder) GetByURL(ctx, url interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetByURL(ctx, url, userID, domain interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByURL", reflect.TypeOf((*MockStorage)(nil).GetByURL), ctx, url, userID, domain)
+}
+
+// GetOrganization mocks base method.
+func (m *MockStorage) GetOrganization(ctx context.Context, id uuid.UUID) (*entity.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", ctx, id)
+	ret0, _ := ret[0].(*entity.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockStorageMockRecorder) GetOrganization(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockStorage)(nil).GetOrganization), ctx, id)
+}
+
+// GetQuotaUsage mocks base method.
+func (m *MockStorage) GetQuotaUsage(ctx context.Context, userID uuid.UUID, day time.Time) (*entity.QuotaUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotaUsage", ctx, userID, day)
+	ret0, _ := ret[0].(*entity.QuotaUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotaUsage indicates an expected call of GetQuotaUsage.
+func (mr *MockStorageMockRecorder) GetQuotaUsage(ctx, userID, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotaUsage", reflect.TypeOf((*MockStorage)(nil).GetQuotaUsage), ctx, userID, day)
+}
+
+// GetSessionByHash mocks base method.
+func (m *MockStorage) GetSessionByHash(ctx context.Context, hash string) (*entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByHash", ctx, hash)
+	ret0, _ := ret[0].(*entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionByHash indicates an expected call of GetSessionByHash.
+func (mr *MockStorageMockRecorder) GetSessionByHash(ctx, hash interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByURL", reflect.TypeOf((*MockStorage)(nil).GetByURL), ctx, url)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByHash", reflect.TypeOf((*MockStorage)(nil).GetSessionByHash), ctx, hash)
+}
+
+// GetTeam mocks base method.
+func (m *MockStorage) GetTeam(ctx context.Context, id uuid.UUID) (*entity.Team, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeam", ctx, id)
+	ret0, _ := ret[0].(*entity.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeam indicates an expected call of GetTeam.
+func (mr *MockStorageMockRecorder) GetTeam(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeam", reflect.TypeOf((*MockStorage)(nil).GetTeam), ctx, id)
+}
+
+// GetTeamMember mocks base method.
+func (m *MockStorage) GetTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) (*entity.TeamMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeamMember", ctx, teamID, userID)
+	ret0, _ := ret[0].(*entity.TeamMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeamMember indicates an expected call of GetTeamMember.
+func (mr *MockStorageMockRecorder) GetTeamMember(ctx, teamID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamMember", reflect.TypeOf((*MockStorage)(nil).GetTeamMember), ctx, teamID, userID)
+}
+
+// GetTeamMembers mocks base method.
+func (m *MockStorage) GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]*entity.TeamMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeamMembers", ctx, teamID)
+	ret0, _ := ret[0].([]*entity.TeamMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeamMembers indicates an expected call of GetTeamMembers.
+func (mr *MockStorageMockRecorder) GetTeamMembers(ctx, teamID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamMembers", reflect.TypeOf((*MockStorage)(nil).GetTeamMembers), ctx, teamID)
+}
+
+// GetUserTeams mocks base method.
+func (m *MockStorage) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]*entity.TeamMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTeams", ctx, userID)
+	ret0, _ := ret[0].([]*entity.TeamMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTeams indicates an expected call of GetUserTeams.
+func (mr *MockStorageMockRecorder) GetUserTeams(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTeams", reflect.TypeOf((*MockStorage)(nil).GetUserTeams), ctx, userID)
+}
+
+// GetVariantClicks mocks base method.
+func (m *MockStorage) GetVariantClicks(ctx context.Context, short string) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVariantClicks", ctx, short)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVariantClicks indicates an expected call of GetVariantClicks.
+func (mr *MockStorageMockRecorder) GetVariantClicks(ctx, short interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVariantClicks", reflect.TypeOf((*MockStorage)(nil).GetVariantClicks), ctx, short)
+}
+
+// GetVisibleByHash mocks base method.
+func (m *MockStorage) GetVisibleByHash(ctx context.Context, hash string, userID uuid.UUID) (*entity.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVisibleByHash", ctx, hash, userID)
+	ret0, _ := ret[0].(*entity.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVisibleByHash indicates an expected call of GetVisibleByHash.
+func (mr *MockStorageMockRecorder) GetVisibleByHash(ctx, hash, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVisibleByHash", reflect.TypeOf((*MockStorage)(nil).GetVisibleByHash), ctx, hash, userID)
+}
+
+// HasSessions mocks base method.
+func (m *MockStorage) HasSessions(ctx context.Context, userID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasSessions", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasSessions indicates an expected call of HasSessions.
+func (mr *MockStorageMockRecorder) HasSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasSessions", reflect.TypeOf((*MockStorage)(nil).HasSessions), ctx, userID)
+}
+
+// IncrementDailyUsage mocks base method.
+func (m *MockStorage) IncrementDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementDailyUsage", ctx, userID, day, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementDailyUsage indicates an expected call of IncrementDailyUsage.
+func (mr *MockStorageMockRecorder) IncrementDailyUsage(ctx, userID, day, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementDailyUsage", reflect.TypeOf((*MockStorage)(nil).IncrementDailyUsage), ctx, userID, day, n)
 }
 
 // Ping mocks base method.
@@ -168,6 +670,122 @@ func (mr *MockStorageMockRecorder) Ping(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), ctx)
 }
 
+// ReassignURLs mocks base method.
+func (m *MockStorage) ReassignURLs(ctx context.Context, fromUserID, toUserID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignURLs", ctx, fromUserID, toUserID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReassignURLs indicates an expected call of ReassignURLs.
+func (mr *MockStorageMockRecorder) ReassignURLs(ctx, fromUserID, toUserID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignURLs", reflect.TypeOf((*MockStorage)(nil).ReassignURLs), ctx, fromUserID, toUserID)
+}
+
+// RemoveTeamMember mocks base method.
+func (m *MockStorage) RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveTeamMember", ctx, teamID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveTeamMember indicates an expected call of RemoveTeamMember.
+func (mr *MockStorageMockRecorder) RemoveTeamMember(ctx, teamID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTeamMember", reflect.TypeOf((*MockStorage)(nil).RemoveTeamMember), ctx, teamID, userID)
+}
+
+// RenameTags mocks base method.
+func (m *MockStorage) RenameTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTags", ctx, userID, from, to)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameTags indicates an expected call of RenameTags.
+func (mr *MockStorageMockRecorder) RenameTags(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTags", reflect.TypeOf((*MockStorage)(nil).RenameTags), ctx, userID, from, to)
+}
+
+// ReserveDailyUsage mocks base method.
+func (m *MockStorage) ReserveDailyUsage(ctx context.Context, userID uuid.UUID, day time.Time, n, limit int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveDailyUsage", ctx, userID, day, n, limit)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveDailyUsage indicates an expected call of ReserveDailyUsage.
+func (mr *MockStorageMockRecorder) ReserveDailyUsage(ctx, userID, day, n, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveDailyUsage", reflect.TypeOf((*MockStorage)(nil).ReserveDailyUsage), ctx, userID, day, n, limit)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockStorage) ReserveIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", ctx, record)
+	ret0, _ := ret[0].(*entity.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockStorageMockRecorder) ReserveIdempotencyKey(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).ReserveIdempotencyKey), ctx, record)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStorage) RevokeAPIKey(ctx context.Context, userID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStorageMockRecorder) RevokeAPIKey(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStorage)(nil).RevokeAPIKey), ctx, userID, id)
+}
+
+// SetTeamMember mocks base method.
+func (m *MockStorage) SetTeamMember(ctx context.Context, member *entity.TeamMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTeamMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTeamMember indicates an expected call of SetTeamMember.
+func (mr *MockStorageMockRecorder) SetTeamMember(ctx, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTeamMember", reflect.TypeOf((*MockStorage)(nil).SetTeamMember), ctx, member)
+}
+
+// SetURLOwner mocks base method.
+func (m *MockStorage) SetURLOwner(ctx context.Context, short string, userID uuid.UUID, teamID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetURLOwner", ctx, short, userID, teamID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetURLOwner indicates an expected call of SetURLOwner.
+func (mr *MockStorageMockRecorder) SetURLOwner(ctx, short, userID, teamID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetURLOwner", reflect.TypeOf((*MockStorage)(nil).SetURLOwner), ctx, short, userID, teamID)
+}
+
 // Truncate mocks base method.
 func (m *MockStorage) Truncate() {
 	m.ctrl.T.Helper()
@@ -179,3 +797,17 @@ func (mr *MockStorageMockRecorder) Truncate() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Truncate", reflect.TypeOf((*MockStorage)(nil).Truncate))
 }
+
+// UpdateURL mocks base method.
+func (m *MockStorage) UpdateURL(ctx context.Context, short string, patch *entity.URLPatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", ctx, short, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockStorageMockRecorder) UpdateURL(ctx, short, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockStorage)(nil).UpdateURL), ctx, short, patch)
+}
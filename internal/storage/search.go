@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/trigram"
+)
+
+// searchURLs select page of live URLs found by trigram index which user can view.
+// Total number of such URLs is returned too.
+func searchURLs(
+	matches []trigram.Match,
+	urls map[string]*entity.URL,
+	canView func(url *entity.URL) bool,
+	limit int,
+	offset int,
+) ([]*entity.URL, int) {
+	found := make([]*entity.URL, 0)
+	for _, m := range matches {
+		url, ok := urls[m.ID]
+		if !ok || url.DeletedAt != nil || !canView(url) {
+			continue
+		}
+		found = append(found, url)
+	}
+	if offset >= len(found) {
+		return []*entity.URL{}, len(found)
+	}
+
+	return found[offset:min(offset+limit, len(found))], len(found)
+}
@@ -0,0 +1,20 @@
+package validate
+
+// FieldError invalid field of request, Err is error of validation of field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError wrap error of validation with name of field.
+func fieldError(field string, err error) error {
+	return &FieldError{Field: field, Err: err}
+}
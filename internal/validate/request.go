@@ -4,14 +4,147 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"github.com/vagafonov/shortener/internal/customerror"
 	"github.com/vagafonov/shortener/internal/request"
+	"github.com/vagafonov/shortener/pkg/entity"
+	"github.com/vagafonov/shortener/pkg/useragent"
 )
 
-// ErrValidateEmpty error for empty input.
-var ErrValidateEmpty = errors.New("empty")
+// errors for body of request.
+var (
+	ErrValidateJSON  = customerror.New("invalid_json", "request body must be valid json")
+	ErrValidateEmpty = customerror.New("empty_request", "request must not be empty")
+)
+
+// errors for account request.
+var (
+	ErrValidateLogin    = customerror.New("invalid_login", "login must be from 3 to 64 characters")
+	ErrValidatePassword = customerror.New("invalid_password", "password must be from 8 to 72 bytes")
+)
+
+// errors for settings of URL.
+var (
+	ErrValidateTitle       = customerror.New("invalid_title", "title must be at most 255 characters")
+	ErrValidateDescription = customerror.New("invalid_description", "description must be at most 1000 characters")
+	ErrValidateNotes       = customerror.New("invalid_notes", "notes must be at most 10000 characters")
+	ErrValidateTags        = customerror.New(
+		"invalid_tags",
+		"tags must be at most 20 tags up to 50 characters without commas",
+	)
+	ErrValidateDomain      = customerror.New("unknown_domain", "domain is not registered")
+	ErrValidateVisibility  = customerror.New("invalid_visibility", "visibility must be public or private")
+	ErrValidateURLPassword = customerror.New("invalid_url_password", "password must be at most 72 bytes")
+	ErrValidateMaxClicks   = customerror.New("invalid_max_clicks", "max_clicks must not be negative")
+	ErrValidateRedirect    = customerror.New("invalid_redirect_status", "redirect_status must be 301, 302, 307 or 308")
+	ErrValidateQueryMode   = customerror.New("invalid_query_mode", "query_mode must be drop, keep or override")
+	ErrValidateUTM         = customerror.New(
+		"invalid_utm",
+		"utm may contain only utm_* parameters with values up to 255 characters",
+	)
+	ErrValidateDeviceRules = customerror.New(
+		"invalid_device_rules",
+		"device rules must have known os or device or bot condition and absolute url",
+	)
+	ErrValidateCountryRules = customerror.New(
+		"invalid_country_rules",
+		"country rules must have two letters country codes and http url",
+	)
+	ErrValidateVariants = customerror.New(
+		"invalid_variants",
+		"variants must have unique ids, positive weights and http urls",
+	)
+)
+
+// errors for search request.
+var (
+	ErrValidateSearchQuery = customerror.New(
+		"invalid_search_query",
+		"q must be from 1 to 200 characters with letters or digits",
+	)
+	ErrValidatePage = customerror.New("invalid_page", "limit must be from 1 to 100 and offset must not be negative")
+)
+
+// errors for rows of import file.
+var (
+	ErrValidateImportURL  = customerror.New("invalid_url", "url must be http or https url")
+	ErrValidateImportCode = customerror.New(
+		"invalid_code",
+		"code must be at most 64 letters, digits, dashes or underscores",
+	)
+)
+
+// ErrValidateExpand error for batch of expanded URLs.
+var ErrValidateExpand = customerror.New("invalid_expand_urls", "urls must be array of 1 to 100 short urls or codes")
+
+// errors for teams requests.
+var (
+	ErrValidateName     = customerror.New("invalid_name", "name must be from 1 to 255 characters")
+	ErrValidateTransfer = customerror.New("invalid_transfer", "either user_id or team_id must be set")
+)
+
+// limits for search request.
+const (
+	maxSearchQueryLength = 200
+	defaultSearchLimit   = 20
+	maxSearchLimit       = 100
+)
+
+// maxImportCodeLength limit for code of imported URL.
+const maxImportCodeLength = 64
+
+// maxExpandURLs limit for batch of expanded URLs.
+const maxExpandURLs = 100
+
+// maxNameLength limit for names of organizations and teams.
+const maxNameLength = 255
+
+// maxUTMValueLength limit for values of UTM template.
+const maxUTMValueLength = 255
+
+// limits for title, description, notes and tags of URL.
+const (
+	maxTitleLength       = 255
+	maxDescriptionLength = 1000
+	maxNotesLength       = 10000
+	maxTags              = 20
+	maxTagLength         = 50
+)
+
+// maxDeviceRules limit for number of device rules of URL.
+const maxDeviceRules = 20
+
+// maxCountryRules limit for number of country rules of URL.
+const maxCountryRules = 20
+
+// limits for variants of URL in A/B experiment.
+const (
+	minVariants        = 2
+	maxVariants        = 10
+	maxVariantIDLength = 64
+	maxVariantWeight   = 10000
+)
+
+// forbiddenRuleSchemes schemes which can execute code in browser instead of opening page or application.
+var forbiddenRuleSchemes = []string{"javascript", "data", "vbscript", "file"}
+
+// limits for account request.
+const (
+	minLoginLength    = 3
+	maxLoginLength    = 64
+	minPasswordLength = 8
+	// bcrypt ignores bytes after 72.
+	maxPasswordLength = 72
+)
 
 type validator struct {
 	logger *zerolog.Logger
@@ -23,15 +156,53 @@ func NewValidator(l *zerolog.Logger) *validator {
 }
 
 // ShortenRequest create ShortenRequest from input.
-func (v *validator) ShortenRequest(buf bytes.Buffer) *request.ShortenRequest {
+func (v *validator) ShortenRequest(buf bytes.Buffer) (*request.ShortenRequest, error) {
 	var shortenReq request.ShortenRequest
 	if err := json.Unmarshal(buf.Bytes(), &shortenReq); err != nil {
 		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal request")
 
-		return nil
+		return nil, ErrValidateJSON
+	}
+
+	if err := validateMetadata(
+		&shortenReq.Title,
+		&shortenReq.Description,
+		&shortenReq.Notes,
+		&shortenReq.Tags,
+	); err != nil {
+		return nil, err
+	}
+
+	if shortenReq.Visibility != "" && !entity.IsVisibility(shortenReq.Visibility) {
+		return nil, fieldError("visibility", ErrValidateVisibility)
+	}
+
+	if len(shortenReq.Password) > maxPasswordLength {
+		return nil, fieldError("password", ErrValidateURLPassword)
+	}
+
+	if shortenReq.MaxClicks < 0 {
+		return nil, fieldError("max_clicks", ErrValidateMaxClicks)
+	}
+
+	if shortenReq.Redirect != 0 && !entity.IsRedirectStatus(shortenReq.Redirect) {
+		return nil, fieldError("redirect_status", ErrValidateRedirect)
 	}
 
-	return &shortenReq
+	if shortenReq.QueryMode != "" && !entity.IsQueryMode(shortenReq.QueryMode) {
+		return nil, fieldError("query_mode", ErrValidateQueryMode)
+	}
+
+	if err := validateRules(
+		shortenReq.UTM,
+		shortenReq.DeviceRules,
+		shortenReq.CountryRules,
+		shortenReq.Variants,
+	); err != nil {
+		return nil, err
+	}
+
+	return &shortenReq, nil
 }
 
 // ShortenBatchRequest create ShortenBatchRequest from input.
@@ -40,18 +211,18 @@ func (v *validator) ShortenBatchRequest(ctx context.Context, buf bytes.Buffer) (
 	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
 		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal shorten batch request")
 
-		return nil, err
+		return nil, ErrValidateJSON
 	}
 	if len(req) == 0 {
 		return nil, ErrValidateEmpty
 	}
-	for _, v := range req {
+	for i, v := range req {
 		if v.CorrelationID == "" {
-			return nil, ErrValidateEmpty
+			return nil, fieldError(fmt.Sprintf("[%d].correlation_id", i), ErrValidateEmpty)
 		}
 
 		if v.OriginalURL == "" {
-			return nil, ErrValidateEmpty
+			return nil, fieldError(fmt.Sprintf("[%d].original_url", i), ErrValidateEmpty)
 		}
 	}
 
@@ -68,7 +239,7 @@ func (v *validator) DeleteUserURLsRequest(ctx context.Context, buf bytes.Buffer)
 			Str("request", buf.String()).
 			Msg("cannot unmarshal delete user URLs request")
 
-		return nil, err
+		return nil, ErrValidateJSON
 	}
 	if len(req) == 0 {
 		return nil, ErrValidateEmpty
@@ -76,3 +247,395 @@ func (v *validator) DeleteUserURLsRequest(ctx context.Context, buf bytes.Buffer)
 
 	return req, nil
 }
+
+// ExpandURLsRequest create slice of short URLs or codes which must be expanded.
+func (v *validator) ExpandURLsRequest(buf bytes.Buffer) ([]string, error) {
+	var req []string
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal expand request")
+
+		return nil, ErrValidateExpand
+	}
+	if len(req) == 0 || len(req) > maxExpandURLs {
+		return nil, ErrValidateExpand
+	}
+
+	return req, nil
+}
+
+// CreateAPIKeyRequest create CreateAPIKeyRequest from input.
+func (v *validator) CreateAPIKeyRequest(buf bytes.Buffer) (*request.CreateAPIKeyRequest, error) {
+	var req request.CreateAPIKeyRequest
+	if buf.Len() == 0 {
+		return &req, nil
+	}
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal create api key request")
+
+		return nil, ErrValidateJSON
+	}
+
+	return &req, nil
+}
+
+// AccountRequest create AccountRequest from input.
+func (v *validator) AccountRequest(buf bytes.Buffer) (*request.AccountRequest, error) {
+	var req request.AccountRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Msg("cannot unmarshal account request")
+
+		return nil, ErrValidateJSON
+	}
+
+	req.Login = strings.TrimSpace(req.Login)
+	if l := utf8.RuneCountInString(req.Login); l < minLoginLength || l > maxLoginLength {
+		return nil, fieldError("login", ErrValidateLogin)
+	}
+
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return nil, fieldError("password", ErrValidatePassword)
+	}
+
+	return &req, nil
+}
+
+// TeamRequest create TeamRequest from input.
+func (v *validator) TeamRequest(buf bytes.Buffer) (*request.TeamRequest, error) {
+	var req request.TeamRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal team request")
+
+		return nil, ErrValidateJSON
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if l := utf8.RuneCountInString(req.Name); l == 0 || l > maxNameLength {
+		return nil, fieldError("name", ErrValidateName)
+	}
+
+	return &req, nil
+}
+
+// TeamMemberRequest create TeamMemberRequest from input.
+func (v *validator) TeamMemberRequest(buf bytes.Buffer) (*request.TeamMemberRequest, error) {
+	var req request.TeamMemberRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal team member request")
+
+		return nil, ErrValidateJSON
+	}
+
+	return &req, nil
+}
+
+// TransferURLRequest create TransferURLRequest from input.
+func (v *validator) TransferURLRequest(buf bytes.Buffer) (*request.TransferURLRequest, error) {
+	var req request.TransferURLRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal transfer url request")
+
+		return nil, ErrValidateJSON
+	}
+
+	if (req.UserID == uuid.Nil) == (req.TeamID == uuid.Nil) {
+		return nil, ErrValidateTransfer
+	}
+
+	return &req, nil
+}
+
+// RenameTagRequest create RenameTagRequest from input, name of tag is normalized.
+func (v *validator) RenameTagRequest(buf bytes.Buffer) (*request.RenameTagRequest, error) {
+	var req request.RenameTagRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal rename tag request")
+
+		return nil, ErrValidateJSON
+	}
+
+	req.Name = strings.ToLower(strings.TrimSpace(req.Name))
+	if !isValidTag(req.Name) {
+		return nil, fieldError("name", ErrValidateTags)
+	}
+
+	return &req, nil
+}
+
+// MergeTagsRequest create MergeTagsRequest from input, tags are normalized.
+func (v *validator) MergeTagsRequest(buf bytes.Buffer) (*request.MergeTagsRequest, error) {
+	var req request.MergeTagsRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal merge tags request")
+
+		return nil, ErrValidateJSON
+	}
+
+	req.Tags = entity.NormalizeTags(req.Tags)
+	req.Into = strings.ToLower(strings.TrimSpace(req.Into))
+	if len(req.Tags) == 0 || len(req.Tags) > maxTags {
+		return nil, fieldError("tags", ErrValidateTags)
+	}
+	if !isValidTag(req.Into) {
+		return nil, fieldError("into", ErrValidateTags)
+	}
+
+	return &req, nil
+}
+
+// SearchURLsRequest create SearchURLsRequest from parameters of query, limit and offset are optional.
+func (v *validator) SearchURLsRequest(params url.Values) (*request.SearchURLsRequest, error) {
+	req := request.SearchURLsRequest{
+		Query: strings.TrimSpace(params.Get("q")),
+		Limit: defaultSearchLimit,
+	}
+	if utf8.RuneCountInString(req.Query) > maxSearchQueryLength || !strings.ContainsFunc(req.Query, isLetterOrDigit) {
+		return nil, fieldError("q", ErrValidateSearchQuery)
+	}
+
+	var err error
+	if s := params.Get("limit"); s != "" {
+		if req.Limit, err = strconv.Atoi(s); err != nil || req.Limit < 1 || req.Limit > maxSearchLimit {
+			return nil, fieldError("limit", ErrValidatePage)
+		}
+	}
+	if s := params.Get("offset"); s != "" {
+		if req.Offset, err = strconv.Atoi(s); err != nil || req.Offset < 0 {
+			return nil, fieldError("offset", ErrValidatePage)
+		}
+	}
+
+	return &req, nil
+}
+
+// ImportRow check row of import file, title and tags are normalized like settings of URL.
+func (v *validator) ImportRow(row *request.ImportRow) error {
+	dst, err := url.Parse(row.OriginalURL)
+	if err != nil || (dst.Scheme != "http" && dst.Scheme != "https") || dst.Host == "" {
+		return fieldError("original_url", ErrValidateImportURL)
+	}
+	if len(row.Code) > maxImportCodeLength || strings.IndexFunc(row.Code, isNotIDRune) >= 0 {
+		return fieldError("code", ErrValidateImportCode)
+	}
+
+	return validateMetadata(&row.Title, nil, nil, &row.Tags)
+}
+
+func isLetterOrDigit(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// UpdateURLRequest create URLPatch from input.
+func (v *validator) UpdateURLRequest(buf bytes.Buffer) (*entity.URLPatch, error) {
+	var req request.UpdateURLRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		v.logger.Warn().Str("error", err.Error()).Str("request", buf.String()).Msg("cannot unmarshal update url request")
+
+		return nil, ErrValidateJSON
+	}
+
+	if err := validateMetadata(req.Title, req.Description, req.Notes, &req.Tags); err != nil {
+		return nil, err
+	}
+
+	if req.Visibility != nil && !entity.IsVisibility(*req.Visibility) {
+		return nil, fieldError("visibility", ErrValidateVisibility)
+	}
+
+	if req.Password != nil && len(*req.Password) > maxPasswordLength {
+		return nil, fieldError("password", ErrValidateURLPassword)
+	}
+
+	if req.MaxClicks != nil && *req.MaxClicks < 0 {
+		return nil, fieldError("max_clicks", ErrValidateMaxClicks)
+	}
+
+	if req.Redirect != nil && *req.Redirect != 0 && !entity.IsRedirectStatus(*req.Redirect) {
+		return nil, fieldError("redirect_status", ErrValidateRedirect)
+	}
+
+	if req.QueryMode != nil && !entity.IsQueryMode(*req.QueryMode) {
+		return nil, fieldError("query_mode", ErrValidateQueryMode)
+	}
+
+	if err := validateRules(req.UTM, req.DeviceRules, req.CountryRules, req.Variants); err != nil {
+		return nil, err
+	}
+
+	return &entity.URLPatch{
+		Title:          req.Title,
+		Description:    req.Description,
+		Notes:          req.Notes,
+		Tags:           req.Tags,
+		Visibility:     req.Visibility,
+		Password:       req.Password,
+		MaxClicks:      req.MaxClicks,
+		RedirectStatus: req.Redirect,
+		QueryMode:      req.QueryMode,
+		UTM:            req.UTM,
+		DeviceRules:    req.DeviceRules,
+		CountryRules:   req.CountryRules,
+		Variants:       req.Variants,
+	}, nil
+}
+
+// validateMetadata trim title, description and notes, normalize tags and check their limits.
+// Nil values are not changed and pass validation.
+func validateMetadata(title *string, description *string, notes *string, tags *[]string) error {
+	if title != nil {
+		*title = strings.TrimSpace(*title)
+		if utf8.RuneCountInString(*title) > maxTitleLength || strings.IndexFunc(*title, unicode.IsControl) >= 0 {
+			return fieldError("title", ErrValidateTitle)
+		}
+	}
+	if description != nil {
+		*description = strings.TrimSpace(*description)
+		if utf8.RuneCountInString(*description) > maxDescriptionLength {
+			return fieldError("description", ErrValidateDescription)
+		}
+	}
+	if notes != nil {
+		*notes = strings.TrimSpace(*notes)
+		if utf8.RuneCountInString(*notes) > maxNotesLength {
+			return fieldError("notes", ErrValidateNotes)
+		}
+	}
+	if tags != nil && *tags != nil {
+		*tags = entity.NormalizeTags(*tags)
+		if len(*tags) > maxTags {
+			return fieldError("tags", ErrValidateTags)
+		}
+		for _, t := range *tags {
+			if !isValidTag(t) {
+				return fieldError("tags", ErrValidateTags)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isValidTag check that tag fits limits. Comma is forbidden, because tags are exported as comma separated list.
+func isValidTag(tag string) bool {
+	return tag != "" && utf8.RuneCountInString(tag) <= maxTagLength &&
+		!strings.ContainsRune(tag, ',') && strings.IndexFunc(tag, unicode.IsControl) < 0
+}
+
+// validateRules check UTM template, device rules, country rules and variants of URL.
+func validateRules(
+	utm map[string]string,
+	deviceRules []entity.DeviceRule,
+	countryRules []entity.CountryRule,
+	variants []entity.Variant,
+) error {
+	if err := validateUTM(utm); err != nil {
+		return fieldError("utm", err)
+	}
+	if err := validateDeviceRules(deviceRules); err != nil {
+		return fieldError("device_rules", err)
+	}
+	if err := validateCountryRules(countryRules); err != nil {
+		return fieldError("country_rules", err)
+	}
+	if err := validateVariants(variants); err != nil {
+		return fieldError("variants", err)
+	}
+
+	return nil
+}
+
+// validateUTM check that UTM template contains only known parameters with printable values.
+func validateUTM(utm map[string]string) error {
+	for k, val := range utm {
+		if !slices.Contains(entity.UTMParams, k) || utf8.RuneCountInString(val) > maxUTMValueLength {
+			return ErrValidateUTM
+		}
+		if strings.IndexFunc(val, unicode.IsControl) >= 0 {
+			return ErrValidateUTM
+		}
+	}
+
+	return nil
+}
+
+// validateDeviceRules check that every rule has condition and destination which can be opened by client.
+// Destination may be deep link to application, so any scheme except executable ones is allowed.
+func validateDeviceRules(rules []entity.DeviceRule) error {
+	if len(rules) > maxDeviceRules {
+		return ErrValidateDeviceRules
+	}
+	for _, r := range rules {
+		if r.OS == "" && r.Device == "" && r.Bot == nil {
+			return ErrValidateDeviceRules
+		}
+		if (r.OS != "" && !slices.Contains(useragent.AllOS, r.OS)) ||
+			(r.Device != "" && !slices.Contains(useragent.AllDevices, r.Device)) {
+			return ErrValidateDeviceRules
+		}
+		dst, err := url.Parse(r.URL)
+		if err != nil || !dst.IsAbs() || slices.Contains(forbiddenRuleSchemes, dst.Scheme) {
+			return ErrValidateDeviceRules
+		}
+	}
+
+	return nil
+}
+
+// validateCountryRules check that every rule has uppercase ISO 3166-1 alpha-2 codes or EU group
+// and destination is web page.
+func validateCountryRules(rules []entity.CountryRule) error {
+	if len(rules) > maxCountryRules {
+		return ErrValidateCountryRules
+	}
+	for _, r := range rules {
+		if len(r.Countries) == 0 {
+			return ErrValidateCountryRules
+		}
+		for _, c := range r.Countries {
+			if len(c) != 2 || strings.IndexFunc(c, isNotUpperLetter) >= 0 { //nolint:mnd,gomnd
+				return ErrValidateCountryRules
+			}
+		}
+		dst, err := url.Parse(r.URL)
+		if err != nil || (dst.Scheme != "http" && dst.Scheme != "https") || dst.Host == "" {
+			return ErrValidateCountryRules
+		}
+	}
+
+	return nil
+}
+
+func isNotUpperLetter(r rune) bool {
+	return r < 'A' || r > 'Z'
+}
+
+// validateVariants check variants of A/B experiment, empty list stops experiment.
+// Id of variant is kept in cookie, so it may contain only letters, digits, "-" and "_".
+func validateVariants(variants []entity.Variant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	if len(variants) < minVariants || len(variants) > maxVariants {
+		return ErrValidateVariants
+	}
+
+	ids := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if v.ID == "" || len(v.ID) > maxVariantIDLength || ids[v.ID] || strings.IndexFunc(v.ID, isNotIDRune) >= 0 {
+			return ErrValidateVariants
+		}
+		ids[v.ID] = true
+		if v.Weight <= 0 || v.Weight > maxVariantWeight {
+			return ErrValidateVariants
+		}
+		dst, err := url.Parse(v.URL)
+		if err != nil || (dst.Scheme != "http" && dst.Scheme != "https") || dst.Host == "" {
+			return ErrValidateVariants
+		}
+	}
+
+	return nil
+}
+
+func isNotIDRune(r rune) bool {
+	return !(r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+}
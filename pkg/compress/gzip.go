@@ -6,8 +6,6 @@ import (
 	"net/http"
 )
 
-const statusToGzip = 500
-
 type compressGzipWriter struct {
 	w  http.ResponseWriter
 	zw *gzip.Writer
@@ -33,12 +31,23 @@ func (c *compressGzipWriter) Write(p []byte) (int, error) {
 
 // WriteHeader set header Content-Encoding and write status.
 func (c *compressGzipWriter) WriteHeader(statusCode int) {
-	if statusCode < statusToGzip {
-		c.w.Header().Set("Content-Encoding", "gzip")
-	}
+	c.w.Header().Set("Content-Encoding", "gzip")
 	c.w.WriteHeader(statusCode)
 }
 
+// Flush send data compressed so far to client, so streamed response is not held in buffer of gzip.Writer.
+func (c *compressGzipWriter) Flush() {
+	if err := c.zw.Flush(); err != nil {
+		return
+	}
+	_ = http.NewResponseController(c.w).Flush()
+}
+
+// Unwrap return original http.ResponseWriter for http.ResponseController.
+func (c *compressGzipWriter) Unwrap() http.ResponseWriter {
+	return c.w
+}
+
 // Close закрывает gzip.Writer и досылает все данные из буфера.
 func (c *compressGzipWriter) Close() error {
 	return c.zw.Close()
@@ -3,26 +3,39 @@ package encrypting
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"errors"
 )
 
+// ErrCiphertextTooShort error for encrypted data shorter than nonce.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
 // Функция расшифровки.
 func Decrypt(encryptedData []byte, key []byte) (string, error) {
-	block, err := aes.NewCipher(key)
+	plaintext, err := open(encryptedData, key, nil)
 	if err != nil {
 		return "", err
 	}
 
+	return string(plaintext), nil
+}
+
+// open decrypt AES-GCM data with nonce in the beginning and check additional data.
+func open(encryptedData []byte, key []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
 	aesGCM, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	nonceSize := aesGCM.NonceSize()
-	nonce, ciphertext := encryptedData[:nonceSize], encryptedData[nonceSize:]
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
+	if len(encryptedData) < nonceSize+aesGCM.Overhead() {
+		return nil, ErrCiphertextTooShort
 	}
+	nonce, ciphertext := encryptedData[:nonceSize], encryptedData[nonceSize:]
 
-	return string(plaintext), nil
+	return aesGCM.Open(nil, nonce, ciphertext, additionalData)
 }
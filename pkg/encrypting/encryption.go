@@ -7,8 +7,11 @@ import (
 
 // Encrypt Encryption function.
 func Encrypt(stringToEncrypt string, key []byte) ([]byte, error) {
-	plaintext := []byte(stringToEncrypt)
+	return seal([]byte(stringToEncrypt), key, nil)
+}
 
+// seal encrypt data with AES-GCM and put nonce in the beginning of result.
+func seal(plaintext []byte, key []byte, additionalData []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -24,7 +27,7 @@ func Encrypt(stringToEncrypt string, key []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	ciphertext := aesGCM.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := aesGCM.Seal(nonce, nonce, plaintext, additionalData)
 
 	return ciphertext, nil
 }
@@ -0,0 +1,135 @@
+package encrypting
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// errors for keyring.
+var (
+	ErrUnknownKey   = errors.New("unknown key id")
+	ErrInvalidKeyID = errors.New("invalid key id")
+	ErrInvalidKey   = errors.New("invalid key length, must be 16, 24 or 32 bytes")
+)
+
+// Keyring set of AES keys identified by ID. Active key encrypts new data,
+// retired keys are used only for decryption, so keys can be rotated without
+// invalidating data encrypted before.
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewKeyring Constructor for Keyring.
+func NewKeyring(activeID string, activeKey []byte) *Keyring {
+	return &Keyring{
+		activeID: activeID,
+		keys:     map[string][]byte{activeID: activeKey},
+	}
+}
+
+// AddRetired add decrypt-only key.
+func (k *Keyring) AddRetired(id string, key []byte) {
+	if id == k.activeID {
+		return
+	}
+	k.keys[id] = key
+}
+
+// ActiveID return ID of active key.
+func (k *Keyring) ActiveID() string {
+	return k.activeID
+}
+
+// IDs return IDs of all keys, active key goes first.
+func (k *Keyring) IDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.activeID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return append([]string{k.activeID}, ids...)
+}
+
+// Validate check IDs and lengths of all keys.
+func (k *Keyring) Validate() error {
+	for id, key := range k.keys {
+		if id == "" || strings.ContainsAny(id, ". ") {
+			return fmt.Errorf("%w: %q", ErrInvalidKeyID, id)
+		}
+		switch len(key) {
+		case 16, 24, 32: //nolint:mnd,gomnd
+		default:
+			return fmt.Errorf("%w: key %q", ErrInvalidKey, id)
+		}
+	}
+
+	return nil
+}
+
+// Seal encrypt data with active key. Returns ID of key used.
+func (k *Keyring) Seal(plaintext []byte, additionalData []byte) (string, []byte, error) {
+	data, err := seal(plaintext, k.keys[k.activeID], additionalData)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return k.activeID, data, nil
+}
+
+// Open decrypt data with key by ID.
+func (k *Keyring) Open(id string, data []byte, additionalData []byte) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return open(data, key, additionalData)
+}
+
+// keyFile format of file with keys.
+type keyFile struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// LoadKeyring load keyring from JSON file with hex encoded keys:
+// {"active": "2024-10", "keys": {"2024-10": "...", "2024-04": "..."}}.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %w", err)
+	}
+	var f keyFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("cannot parse key file: %w", err)
+	}
+
+	active, ok := f.Keys[f.Active]
+	if !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, f.Active)
+	}
+	activeKey, err := hex.DecodeString(active)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode key %q: %w", f.Active, err)
+	}
+
+	kr := NewKeyring(f.Active, activeKey)
+	for id, v := range f.Keys {
+		key, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode key %q: %w", id, err)
+		}
+		kr.AddRetired(id, key)
+	}
+
+	return kr, kr.Validate()
+}
@@ -0,0 +1,67 @@
+package encrypting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type KeyringTestSuite struct {
+	suite.Suite
+}
+
+func TestKeyringTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyringTestSuite))
+}
+
+func (s *KeyringTestSuite) TestSealOpen() {
+	kr := NewKeyring("new", []byte("0123456789abcdef"))
+	kr.AddRetired("old", []byte("fedcba9876543210"))
+	s.Require().NoError(kr.Validate())
+	s.Require().Equal([]string{"new", "old"}, kr.IDs())
+
+	id, data, err := kr.Seal([]byte("data"), []byte("aad"))
+	s.Require().NoError(err)
+	s.Require().Equal("new", id)
+
+	plain, err := kr.Open(id, data, []byte("aad"))
+	s.Require().NoError(err)
+	s.Require().Equal([]byte("data"), plain)
+
+	_, err = kr.Open(id, data, []byte("other"))
+	s.Require().Error(err)
+
+	_, err = kr.Open("unknown", data, []byte("aad"))
+	s.Require().ErrorIs(err, ErrUnknownKey)
+}
+
+func (s *KeyringTestSuite) TestDecryptShortData() {
+	s.NotPanics(func() {
+		_, err := Decrypt([]byte{1, 2, 3}, []byte("0123456789abcdef"))
+		s.Require().ErrorIs(err, ErrCiphertextTooShort)
+	})
+}
+
+func (s *KeyringTestSuite) TestValidate() {
+	s.Require().ErrorIs(NewKeyring("k", []byte("short")).Validate(), ErrInvalidKey)
+	s.Require().ErrorIs(NewKeyring("k.1", []byte("0123456789abcdef")).Validate(), ErrInvalidKeyID)
+}
+
+func (s *KeyringTestSuite) TestLoadKeyring() {
+	path := filepath.Join(s.T().TempDir(), "keys.json")
+	err := os.WriteFile(path, []byte(`{
+		"active": "2024-10",
+		"keys": {
+			"2024-10": "30313233343536373839616263646566",
+			"2024-04": "66656463626139383736353433323130"
+		}
+	}`), 0o600)
+	s.Require().NoError(err)
+
+	kr, err := LoadKeyring(path)
+	s.Require().NoError(err)
+	s.Require().Equal("2024-10", kr.ActiveID())
+	s.Require().Equal([]string{"2024-10", "2024-04"}, kr.IDs())
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Account named user account. ID is used as owner of URLs.
+type Account struct {
+	ID           uuid.UUID `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Session server-side session of account. Only hash of the token is stored.
+type Session struct {
+	Hash      string     `json:"hash"`
+	UserID    uuid.UUID  `json:"userId"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	DeletedAt *time.Time `json:"deletedAt"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import "slices"
+
+// CountryGroupEU code of group of European Union countries which can be used in country rules.
+const CountryGroupEU = "EU"
+
+// EUCountries ISO 3166-1 alpha-2 codes of countries of European Union.
+var EUCountries = []string{
+	"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU",
+	"IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK",
+}
+
+// CountryRule alternate destination of URL for visitors from countries.
+type CountryRule struct {
+	// Countries ISO 3166-1 alpha-2 codes or CountryGroupEU.
+	Countries []string `json:"countries"`
+	URL       string   `json:"url"`
+}
+
+// Match check that country is one of countries of rule. Unknown country matches nothing.
+func (r *CountryRule) Match(country string) bool {
+	if country == "" {
+		return false
+	}
+	if slices.Contains(r.Countries, CountryGroupEU) && slices.Contains(EUCountries, country) {
+		return true
+	}
+
+	return slices.Contains(r.Countries, country)
+}
+
+// MatchCountryRule return first country rule matched by country or nil.
+func (u *URL) MatchCountryRule(country string) *CountryRule {
+	for i := range u.CountryRules {
+		if u.CountryRules[i].Match(country) {
+			return &u.CountryRules[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Query modes define what happens with query of request to short URL.
+const (
+	// QueryModeDrop query of request is dropped, empty value means the same.
+	QueryModeDrop = "drop"
+	// QueryModeKeep parameters of request are added, parameters of original URL win on conflict.
+	QueryModeKeep = "keep"
+	// QueryModeOverride parameters of request are added and replace parameters of original URL.
+	QueryModeOverride = "override"
+)
+
+// UTMParams names of parameters which can be set in UTM template of URL.
+var UTMParams = []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content"}
+
+// UTMShortPlaceholder is replaced with short code of URL in values of UTM template, domain is not included.
+const UTMShortPlaceholder = "{short}"
+
+// IsQueryMode check that query mode is known.
+func IsQueryMode(mode string) bool {
+	return mode == QueryModeDrop || mode == QueryModeKeep || mode == QueryModeOverride
+}
+
+// Destination return URL to redirect to: target with UTM template and query of request applied.
+// Target is returned as is when nothing is added to it. Query of target keeps its order and encoding,
+// added parameters are appended to it, parameters replaced by request are removed from it.
+func (u *URL) Destination(target string, query url.Values) (string, error) {
+	passthrough := len(query) > 0 && (u.QueryMode == QueryModeKeep || u.QueryMode == QueryModeOverride)
+	if len(u.UTM) == 0 && !passthrough {
+		return target, nil
+	}
+
+	dst, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	stored := dst.Query()
+	added := url.Values{}
+	// шаблон не перетирает метки, заданные в самой ссылке
+	for k, v := range u.UTM {
+		if !stored.Has(k) {
+			added.Set(k, strings.ReplaceAll(v, UTMShortPlaceholder, u.Code()))
+		}
+	}
+	if passthrough {
+		for k, v := range query {
+			if stored.Has(k) && u.QueryMode == QueryModeKeep {
+				continue
+			}
+			added[k] = v
+		}
+	}
+	// Encode экранирует значения, поэтому параметры запроса не могут внедрить заголовки в Location
+	dst.RawQuery = joinQuery(withoutParams(dst.RawQuery, added), added.Encode())
+
+	return dst.String(), nil
+}
+
+// withoutParams remove parameters with names of params from raw query, other parameters are kept as is.
+func withoutParams(rawQuery string, params url.Values) string {
+	kept := make([]string, 0)
+	for _, v := range strings.Split(rawQuery, "&") {
+		name, _, _ := strings.Cut(v, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if v != "" && !params.Has(name) {
+			kept = append(kept, v)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+// joinQuery join two raw queries, any of them can be empty.
+func joinQuery(a string, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+
+	return a + "&" + b
+}
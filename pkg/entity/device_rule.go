@@ -0,0 +1,29 @@
+package entity
+
+import "github.com/vagafonov/shortener/pkg/useragent"
+
+// DeviceRule alternate destination of URL for family of clients. Empty conditions match any client.
+type DeviceRule struct {
+	OS     string `json:"os,omitempty"`
+	Device string `json:"device,omitempty"`
+	Bot    *bool  `json:"bot,omitempty"`
+	URL    string `json:"url"`
+}
+
+// Match check that client satisfies all conditions of rule.
+func (r *DeviceRule) Match(c useragent.Client) bool {
+	return (r.OS == "" || r.OS == c.OS) &&
+		(r.Device == "" || r.Device == c.Device) &&
+		(r.Bot == nil || *r.Bot == c.Bot)
+}
+
+// MatchDeviceRule return first device rule matched by client or nil.
+func (u *URL) MatchDeviceRule(c useragent.Client) *DeviceRule {
+	for i := range u.DeviceRules {
+		if u.DeviceRules[i].Match(c) {
+			return &u.DeviceRules[i]
+		}
+	}
+
+	return nil
+}
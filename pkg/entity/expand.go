@@ -0,0 +1,12 @@
+package entity
+
+// statuses of expanded URL.
+const (
+	ExpandActive   = "active"
+	ExpandNotFound = "not_found"
+	ExpandDeleted  = "deleted"
+	// ExpandExhausted URL has been followed MaxClicks times.
+	ExpandExhausted = "exhausted"
+	// ExpandProtected URL is protected with password, its destination is hidden.
+	ExpandProtected = "password_protected"
+)
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord response of request sent by user with idempotency key. Record without status
+// belongs to request which is still in progress.
+type IdempotencyRecord struct {
+	UserID uuid.UUID `json:"userId"`
+	Key    string    `json:"key"`
+	// RequestHash hash of method, path and body of request, the same key cannot be used for other request.
+	RequestHash string    `json:"requestHash"`
+	Status      int       `json:"status,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// SameReservation check that records belong to one reservation of key, i.e. it was reserved
+// by the same request at the same time. Key reserved again after expiration is other reservation.
+func (r *IdempotencyRecord) SameReservation(other *IdempotencyRecord) bool {
+	return r.UserID == other.UserID && r.Key == other.Key &&
+		r.RequestHash == other.RequestHash && r.CreatedAt.Equal(other.CreatedAt)
+}
+
+// IsCompleted check that response of request is saved.
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.Status != 0
+}
+
+// IsExpired check that record is not live at moment now, expired key can be used again.
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Identity user authenticated by external identity provider.
+type Identity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	UserID  uuid.UUID
+}
@@ -0,0 +1,17 @@
+package entity
+
+// statuses of imported URL.
+const (
+	ImportCreated = "created"
+	// ImportRenamed URL is created with new code, because requested code is taken.
+	ImportRenamed = "renamed"
+	// ImportExists URL with the same destination already exists and is not created again.
+	ImportExists = "exists"
+	ImportFailed = "failed"
+)
+
+// ImportResult result of import of URL. URL is existing URL for status ImportExists.
+type ImportResult struct {
+	URL    *URL
+	Status string
+}
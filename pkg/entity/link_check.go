@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"net/http"
+	"time"
+)
+
+// LinkCheck result of checks of destination of URL by server.
+type LinkCheck struct {
+	// Status of last check, 0 when destination was unreachable.
+	Status    int       `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	// Failures number of consecutive failed checks.
+	Failures int `json:"failures"`
+	// Broken destination failed checks several times in a row.
+	Broken bool `json:"broken"`
+}
+
+// IsFailedCheck check that destination answered with error or was unreachable.
+// 429 means only that checker was throttled, so it is not a failure.
+func IsFailedCheck(status int) bool {
+	return status == 0 || (status >= http.StatusBadRequest && status != http.StatusTooManyRequests)
+}
+
+// NextLinkCheck result of check following previous one, previous is nil for first check.
+// Destination becomes broken after brokenAfter failed checks in a row and recovers after first successful one.
+func NextLinkCheck(prev *LinkCheck, status int, errMsg string, checkedAt time.Time, brokenAfter int) *LinkCheck {
+	next := &LinkCheck{Status: status, Error: errMsg, CheckedAt: checkedAt}
+	if !IsFailedCheck(status) {
+		return next
+	}
+	next.Failures = 1
+	if prev != nil {
+		next.Failures = prev.Failures + 1
+	}
+	next.Broken = next.Failures >= brokenAfter
+
+	return next
+}
+
+// IsBroken check that destination of URL failed checks several times in a row.
+func (u *URL) IsBroken() bool {
+	return u.LinkCheck != nil && u.LinkCheck.Broken
+}
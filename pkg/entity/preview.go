@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// LinkPreview metadata of destination page of URL fetched by server.
+type LinkPreview struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	SiteName    string    `json:"siteName,omitempty"`
+	Favicon     string    `json:"favicon,omitempty"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
@@ -0,0 +1,7 @@
+package entity
+
+// QuotaUsage current usage of link quotas by user.
+type QuotaUsage struct {
+	CreatedToday int
+	Active       int
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagStats tag with number of URLs marked with it.
+type TagStats struct {
+	Tag  string
+	URLs int
+}
+
+// NormalizeTags trim and lowercase tags, drop empty and repeated ones and sort the rest.
+func NormalizeTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			res = append(res, t)
+		}
+	}
+	slices.Sort(res)
+
+	return slices.Compact(res)
+}
+
+// HasTag check that URL is marked with tag.
+func (u *URL) HasTag(tag string) bool {
+	return slices.Contains(u.Tags, tag)
+}
+
+// RenameTags return tags of URL where tags from are replaced with tag to.
+// Several tags are merged into one, false is returned when URL has none of tags from.
+func (u *URL) RenameTags(from []string, to string) ([]string, bool) {
+	if !slices.ContainsFunc(u.Tags, func(t string) bool { return slices.Contains(from, t) }) {
+		return nil, false
+	}
+
+	res := make([]string, 0, len(u.Tags)+1)
+	for _, t := range u.Tags {
+		if !slices.Contains(from, t) {
+			res = append(res, t)
+		}
+	}
+
+	return NormalizeTags(append(res, to)), true
+}
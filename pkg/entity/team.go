@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team member roles.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// AllRoles list of all team member roles ordered by privileges.
+var AllRoles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+// Organization entity. Organization groups teams, only owner can create teams in it.
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uuid.UUID `json:"ownerId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Team entity.
+type Team struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// TeamMember entity. Member without role is removed from team.
+type TeamMember struct {
+	TeamID    uuid.UUID `json:"teamId"`
+	UserID    uuid.UUID `json:"userId"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsRole check that role is known.
+func IsRole(role string) bool {
+	return slices.Contains(AllRoles, role)
+}
+
+// HasRole check that member role grants privileges of role.
+func (m *TeamMember) HasRole(role string) bool {
+	if m == nil || !IsRole(role) {
+		return false
+	}
+
+	return slices.Index(AllRoles, m.Role) >= slices.Index(AllRoles, role)
+}
+
+// CanView check that member can view team links.
+func (m *TeamMember) CanView() bool {
+	return m.HasRole(RoleViewer)
+}
+
+// CanEdit check that member can edit and delete team links.
+func (m *TeamMember) CanEdit() bool {
+	return m.HasRole(RoleEditor)
+}
@@ -1,17 +1,207 @@
 package entity
 
 import (
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// URL visibility. Private URL redirects only its owner or members of its team, empty value means public.
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// RedirectStatuses statuses of redirect which can be set for URL, 0 means default status of server.
+var RedirectStatuses = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
 // URL entity.
 type URL struct {
-	ID        string     `json:"id"`
-	UUID      uuid.UUID  `json:"uuid"`
-	Short     string     `json:"short"`
-	Original  string     `json:"original"`
-	UserID    uuid.UUID  `json:"userId"`
-	DeletedAt *time.Time `json:"deletedAt"`
+	ID             string            `json:"id"`
+	UUID           uuid.UUID         `json:"uuid"`
+	Short          string            `json:"short"`
+	Original       string            `json:"original"`
+	Title          string            `json:"title,omitempty"`
+	Description    string            `json:"description,omitempty"`
+	Notes          string            `json:"notes,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Preview        *LinkPreview      `json:"preview,omitempty"`
+	LinkCheck      *LinkCheck        `json:"linkCheck,omitempty"`
+	UserID         uuid.UUID         `json:"userId"`
+	TeamID         uuid.UUID         `json:"teamId"`
+	Visibility     string            `json:"visibility,omitempty"`
+	PasswordHash   string            `json:"passwordHash,omitempty"`
+	MaxClicks      int64             `json:"maxClicks,omitempty"`
+	Clicks         int64             `json:"clicks,omitempty"`
+	RedirectStatus int               `json:"redirectStatus,omitempty"`
+	QueryMode      string            `json:"queryMode,omitempty"`
+	UTM            map[string]string `json:"utm,omitempty"`
+	DeviceRules    []DeviceRule      `json:"deviceRules,omitempty"`
+	CountryRules   []CountryRule     `json:"countryRules,omitempty"`
+	Variants       []Variant         `json:"variants,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	DeletedAt      *time.Time        `json:"deletedAt"`
+}
+
+// ShortKey key of short URL in storage. Every branded domain has own namespace of codes,
+// so code of branded domain is prefixed with its host, code of default domain is the key itself.
+func ShortKey(domain string, code string) string {
+	if domain == "" {
+		return code
+	}
+
+	return domain + "/" + code
+}
+
+// Domain host of branded domain of URL, empty for default domain.
+func (u *URL) Domain() string {
+	if i := strings.LastIndexByte(u.Short, '/'); i >= 0 {
+		return u.Short[:i]
+	}
+
+	return ""
+}
+
+// Code code of URL in path of short URL.
+func (u *URL) Code() string {
+	return u.Short[strings.LastIndexByte(u.Short, '/')+1:]
+}
+
+// SearchText text of URL matched by search: short key, destination, title, description and notes.
+func (u *URL) SearchText() string {
+	return strings.Join([]string{u.Short, u.Original, u.Title, u.Description, u.Notes}, " ")
+}
+
+// OwnedByTeam check that URL belongs to team instead of user.
+func (u *URL) OwnedByTeam() bool {
+	return u.TeamID != uuid.Nil
+}
+
+// IsPrivate check that URL is visible only to its owner.
+func (u *URL) IsPrivate() bool {
+	return u.Visibility == VisibilityPrivate
+}
+
+// IsVisibility check that visibility is known.
+func IsVisibility(visibility string) bool {
+	return visibility == VisibilityPublic || visibility == VisibilityPrivate
+}
+
+// HasPassword check that URL is protected with password. Only bcrypt hash of password is stored.
+func (u *URL) HasPassword() bool {
+	return u.PasswordHash != ""
+}
+
+// HasClicksLimit check that URL can be followed only MaxClicks times, 0 means unlimited.
+func (u *URL) HasClicksLimit() bool {
+	return u.MaxClicks > 0
+}
+
+// RemainingClicks number of times URL with limit can be followed yet.
+func (u *URL) RemainingClicks() int64 {
+	return max(u.MaxClicks-u.Clicks, 0)
+}
+
+// IsRedirectStatus check that status of redirect can be set for URL.
+func IsRedirectStatus(status int) bool {
+	return slices.Contains(RedirectStatuses, status)
+}
+
+// IsPermanentRedirect check that browsers may cache redirect with status.
+func IsPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// URLPatch changes of URL settings editable by owner. Nil fields are not changed.
+type URLPatch struct {
+	Title       *string
+	Description *string
+	Notes       *string
+	// Tags nil means unchanged, empty slice removes all tags.
+	Tags []string
+	// Preview metadata of destination fetched by server, it is not editable by user.
+	Preview *LinkPreview
+	// LinkCheck result of check of destination by server, it is not editable by user.
+	LinkCheck  *LinkCheck
+	Visibility *string
+	// Password plain password, empty value removes protection. Service replaces it with PasswordHash.
+	Password     *string
+	PasswordHash *string
+	MaxClicks    *int64
+	// RedirectStatus 0 resets status to default of server.
+	RedirectStatus *int
+	QueryMode      *string
+	// UTM nil means unchanged, empty map removes template.
+	UTM map[string]string
+	// DeviceRules nil means unchanged, empty slice removes rules.
+	DeviceRules []DeviceRule
+	// CountryRules nil means unchanged, empty slice removes rules.
+	CountryRules []CountryRule
+	// Variants nil means unchanged, empty slice stops experiment.
+	Variants []Variant
+}
+
+// IsEmpty check that patch does not change anything.
+func (p *URLPatch) IsEmpty() bool {
+	return p.Title == nil && p.Description == nil && p.Notes == nil && p.Tags == nil &&
+		p.Preview == nil && p.LinkCheck == nil && p.Visibility == nil && p.Password == nil &&
+		p.PasswordHash == nil && p.MaxClicks == nil && p.RedirectStatus == nil && p.QueryMode == nil &&
+		p.UTM == nil && p.DeviceRules == nil && p.CountryRules == nil && p.Variants == nil
+}
+
+// Apply change URL settings.
+func (p *URLPatch) Apply(u *URL) {
+	if p.Title != nil {
+		u.Title = *p.Title
+	}
+	if p.Description != nil {
+		u.Description = *p.Description
+	}
+	if p.Notes != nil {
+		u.Notes = *p.Notes
+	}
+	if p.Tags != nil {
+		u.Tags = p.Tags
+	}
+	if p.Preview != nil {
+		u.Preview = p.Preview
+	}
+	if p.LinkCheck != nil {
+		u.LinkCheck = p.LinkCheck
+	}
+	if p.Visibility != nil {
+		u.Visibility = *p.Visibility
+	}
+	if p.PasswordHash != nil {
+		u.PasswordHash = *p.PasswordHash
+	}
+	if p.MaxClicks != nil {
+		u.MaxClicks = *p.MaxClicks
+	}
+	if p.RedirectStatus != nil {
+		u.RedirectStatus = *p.RedirectStatus
+	}
+	if p.QueryMode != nil {
+		u.QueryMode = *p.QueryMode
+	}
+	if p.UTM != nil {
+		u.UTM = p.UTM
+	}
+	if p.DeviceRules != nil {
+		u.DeviceRules = p.DeviceRules
+	}
+	if p.CountryRules != nil {
+		u.CountryRules = p.CountryRules
+	}
+	if p.Variants != nil {
+		u.Variants = p.Variants
+	}
 }
@@ -0,0 +1,58 @@
+package entity
+
+import "hash/fnv"
+
+// Variant alternate destination of URL in A/B experiment.
+// Visitors are split between variants proportionally to their weights.
+type Variant struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// VariantStats clicks on variant of URL.
+type VariantStats struct {
+	Variant
+	Clicks int64 `json:"clicks"`
+}
+
+// VariantClick click on variant of URL.
+type VariantClick struct {
+	Short   string `json:"short"`
+	Variant string `json:"variant"`
+}
+
+// Variant get variant of URL by id.
+func (u *URL) Variant(id string) *Variant {
+	for i := range u.Variants {
+		if u.Variants[i].ID == id {
+			return &u.Variants[i]
+		}
+	}
+
+	return nil
+}
+
+// ChooseVariant choose variant by weights for visitor identified with key.
+// The same key always gets the same variant while variants are not changed.
+func (u *URL) ChooseVariant(key string) *Variant {
+	total := 0
+	for _, v := range u.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(u.Short + "|" + key))
+	point := int(h.Sum64() % uint64(total)) //nolint:gosec
+	for i := range u.Variants {
+		point -= u.Variants[i].Weight
+		if point < 0 {
+			return &u.Variants[i]
+		}
+	}
+
+	return nil
+}
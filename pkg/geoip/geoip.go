@@ -0,0 +1,146 @@
+// Package geoip resolves country of IP address with local file of networks.
+//
+// File is CSV with network in CIDR notation and ISO 3166-1 alpha-2 country code,
+// lines starting with # and header line "network,..." are skipped:
+//
+//	network,country
+//	192.0.2.0/24,DE
+//	2001:db8::/32,FR
+package geoip
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errInvalidRecord  = errors.New("record must contain network and country")
+	errInvalidCountry = errors.New("country must be two letters code")
+)
+
+// DB database of networks loaded from file. It is safe for concurrent use and can be reloaded.
+type DB struct {
+	path    string
+	trie    atomic.Pointer[trie]
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// Open load database from file.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path}
+	if err := db.load(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Country return country code of address, empty when it is unknown.
+func (db *DB) Country(addr netip.Addr) string {
+	if db == nil || !addr.IsValid() {
+		return ""
+	}
+
+	return db.trie.Load().lookup(addr.Unmap())
+}
+
+// Reload load file again if it was modified. Old data stays in use when new file is invalid.
+func (db *DB) Reload() (bool, error) {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, err
+	}
+	db.mu.Lock()
+	modified := !info.ModTime().Equal(db.modTime)
+	db.mu.Unlock()
+	if !modified {
+		return false, nil
+	}
+
+	return true, db.load()
+}
+
+// Watch reload file every interval until context is done.
+func (db *DB) Watch(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := db.Reload(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (db *DB) load() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	f, err := os.Open(db.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	t, err := parse(f)
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %w", db.path, err)
+	}
+	db.trie.Store(t)
+	db.modTime = info.ModTime()
+
+	return nil
+}
+
+func parse(r io.Reader) (*trie, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	t := &trie{}
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && record[0] == "network" {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) < 2 { //nolint:mnd,gomnd
+			return nil, fmt.Errorf("line %d: %w", line, errInvalidRecord)
+		}
+
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		country := strings.ToUpper(strings.TrimSpace(record[1]))
+		if len(country) != 2 { //nolint:mnd,gomnd
+			return nil, fmt.Errorf("line %d: %w", line, errInvalidCountry)
+		}
+		t.insert(prefix.Masked(), country)
+	}
+}
@@ -0,0 +1,83 @@
+package geoip
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type GeoIPTestSuite struct {
+	suite.Suite
+}
+
+func TestGeoIPTestSuite(t *testing.T) {
+	suite.Run(t, new(GeoIPTestSuite))
+}
+
+func (s *GeoIPTestSuite) TestParse() {
+	t, err := parse(strings.NewReader(`network,country
+# comment
+10.0.0.0/8,US
+10.1.0.0/16,de
+10.1.2.0/24,FR
+2001:db8::/32,NL
+`))
+	s.Require().NoError(err)
+
+	tests := []struct {
+		addr    string
+		country string
+	}{
+		{addr: "10.200.0.1", country: "US"},
+		{addr: "10.1.200.1", country: "DE"},
+		{addr: "10.1.2.3", country: "FR"},
+		{addr: "11.0.0.1", country: ""},
+		{addr: "2001:db8:1::1", country: "NL"},
+		{addr: "2001:db9::1", country: ""},
+	}
+	for _, test := range tests {
+		s.Run(test.addr, func() {
+			s.Require().Equal(test.country, t.lookup(netip.MustParseAddr(test.addr)))
+		})
+	}
+
+	_, err = parse(strings.NewReader("10.0.0.0/33,US\n"))
+	s.Require().Error(err)
+	_, err = parse(strings.NewReader("10.0.0.0/8,USA\n"))
+	s.Require().ErrorIs(err, errInvalidCountry)
+}
+
+func (s *GeoIPTestSuite) TestReload() {
+	path := filepath.Join(s.T().TempDir(), "geoip.csv")
+	s.Require().NoError(os.WriteFile(path, []byte("192.0.2.0/24,DE\n"), 0o600))
+
+	db, err := Open(path)
+	s.Require().NoError(err)
+	addr := netip.MustParseAddr("::ffff:192.0.2.1")
+	s.Require().Equal("DE", db.Country(addr))
+
+	reloaded, err := db.Reload()
+	s.Require().NoError(err)
+	s.Require().False(reloaded, "file is not modified")
+
+	s.Require().NoError(os.WriteFile(path, []byte("192.0.2.0/24,FR\n"), 0o600))
+	s.Require().NoError(os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	reloaded, err = db.Reload()
+	s.Require().NoError(err)
+	s.Require().True(reloaded)
+	s.Require().Equal("FR", db.Country(addr))
+
+	s.Require().NoError(os.WriteFile(path, []byte("broken\n"), 0o600))
+	s.Require().NoError(os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	_, err = db.Reload()
+	s.Require().Error(err)
+	s.Require().Equal("FR", db.Country(addr), "invalid file does not replace data")
+
+	var nilDB *DB
+	s.Require().Empty(nilDB.Country(addr))
+}
@@ -0,0 +1,57 @@
+package geoip
+
+import "net/netip"
+
+// node node of binary prefix trie, one level per bit of address.
+type node struct {
+	children [2]*node
+	country  string
+}
+
+// trie longest prefix match of networks to countries.
+// IPv4 networks are stored as IPv4-mapped IPv6 networks, so one trie serves both families.
+type trie struct {
+	root node
+}
+
+// v4MappedBits length of IPv4-mapped IPv6 prefix ::ffff:0:0/96.
+const v4MappedBits = 96
+
+func (t *trie) insert(prefix netip.Prefix, country string) {
+	addr, bits := prefix.Addr(), prefix.Bits()
+	if addr.Is4() {
+		bits += v4MappedBits
+	}
+	b := addr.As16()
+
+	n := &t.root
+	for i := 0; i < bits; i++ {
+		bit := bitAt(b, i)
+		if n.children[bit] == nil {
+			n.children[bit] = &node{}
+		}
+		n = n.children[bit]
+	}
+	n.country = country
+}
+
+func (t *trie) lookup(addr netip.Addr) string {
+	b := addr.As16()
+	country := ""
+	n := &t.root
+	for i := 0; n != nil; i++ {
+		if n.country != "" {
+			country = n.country
+		}
+		if i == len(b)*8 {
+			break
+		}
+		n = n.children[bitAt(b, i)]
+	}
+
+	return country
+}
+
+func bitAt(b [16]byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1 //nolint:mnd,gomnd
+}
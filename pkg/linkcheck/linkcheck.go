@@ -0,0 +1,225 @@
+// Package linkcheck probes destinations of links with limited concurrency and backoff per host.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/preview"
+)
+
+// ErrUnsupportedURL destination is not http or https URL.
+var ErrUnsupportedURL = errors.New("unsupported url of destination")
+
+// defaults of Checker.
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultPerHost    = 2
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = 5 * time.Minute
+	maxDrainBytes     = 4 << 10
+	maxBackoffShift   = 20
+	userAgent         = "Mozilla/5.0 (compatible; ShortenerLinkCheck/1.0)"
+)
+
+// Options settings of Checker, zero values mean defaults.
+type Options struct {
+	// Timeout of one probe.
+	Timeout time.Duration
+	// PerHost maximum number of simultaneous probes of one host.
+	PerHost int
+	// Backoff delay of next probe of host after it failed or asked to slow down, it is doubled for every next failure.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// AllowPrivate allow loopback and private addresses, only for tests.
+	AllowPrivate bool
+}
+
+// host state of probes of one host.
+type host struct {
+	slots    chan struct{}
+	users    int
+	failures int
+	until    time.Time
+}
+
+// Checker probe destinations. Every host gets limited number of simultaneous probes
+// and is not probed for a while after network errors, 429 and 503 responses.
+type Checker struct {
+	client     *http.Client
+	perHost    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// NewChecker Constructor for Checker.
+func NewChecker(opt Options) *Checker {
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultTimeout
+	}
+	if opt.PerHost <= 0 {
+		opt.PerHost = defaultPerHost
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = defaultBackoff
+	}
+	if opt.MaxBackoff <= 0 {
+		opt.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Checker{
+		client:     preview.NewClient(opt.Timeout, opt.AllowPrivate),
+		perHost:    opt.PerHost,
+		backoff:    opt.Backoff,
+		maxBackoff: opt.MaxBackoff,
+		hosts:      make(map[string]*host),
+	}
+}
+
+// Check probe destination with HEAD request, GET request is made when server does not accept HEAD.
+// Status of final response after redirects is returned, error means destination is unreachable.
+func (c *Checker) Check(ctx context.Context, rawURL string) (int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return 0, ErrUnsupportedURL
+	}
+
+	key := strings.ToLower(u.Host)
+	h, err := c.acquire(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	status, retryAfter, err := c.probe(ctx, rawURL)
+	if ctx.Err() == nil {
+		c.record(h, status, retryAfter, err)
+	}
+	c.leave(key, h, true)
+
+	return status, err
+}
+
+// acquire take slot of host and wait until its backoff is over.
+func (c *Checker) acquire(ctx context.Context, key string) (*host, error) {
+	c.mu.Lock()
+	h, ok := c.hosts[key]
+	if !ok {
+		h = &host{slots: make(chan struct{}, c.perHost)}
+		c.hosts[key] = h
+	}
+	h.users++
+	c.mu.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		c.leave(key, h, false)
+
+		return nil, ctx.Err()
+	}
+
+	for {
+		c.mu.Lock()
+		wait := time.Until(h.until)
+		c.mu.Unlock()
+		if wait <= 0 {
+			return h, nil
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			c.leave(key, h, true)
+
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// record update backoff of host by result of probe.
+func (c *Checker) record(h *host, status int, retryAfter time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil && status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		h.failures = 0
+		h.until = time.Time{}
+
+		return
+	}
+	h.failures++
+	backoff := c.backoff << min(h.failures-1, maxBackoffShift)
+	h.until = time.Now().Add(min(max(backoff, retryAfter), c.maxBackoff))
+}
+
+// leave free slot of host. Host without users and active backoff is forgotten,
+// so map does not grow with every checked host.
+func (c *Checker) leave(key string, h *host, hasSlot bool) {
+	if hasSlot {
+		<-h.slots
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h.users--
+	if h.users == 0 && !h.until.After(time.Now()) {
+		delete(c.hosts, key)
+	}
+}
+
+// probe request destination, status and delay requested by Retry-After header are returned.
+func (c *Checker) probe(ctx context.Context, rawURL string) (int, time.Duration, error) {
+	resp, err := c.do(ctx, http.MethodHead, rawURL)
+	if err != nil {
+		return 0, 0, err
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp.StatusCode, 0, nil
+	}
+	// many servers reject HEAD or answer it differently, so GET decides
+	resp, err = c.do(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return resp.StatusCode, retryAfter(resp.Header.Get("Retry-After")), nil
+}
+
+// do make request and discard body of response.
+func (c *Checker) do(ctx context.Context, method string, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// retryAfter parse delay in seconds from Retry-After header, dates are not supported.
+func retryAfter(v string) time.Duration {
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,124 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/pkg/preview"
+)
+
+type LinkCheckTestSuite struct {
+	suite.Suite
+}
+
+func TestLinkCheckTestSuite(t *testing.T) {
+	suite.Run(t, new(LinkCheckTestSuite))
+}
+
+func (s *LinkCheckTestSuite) TestCheck() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := NewChecker(Options{AllowPrivate: true})
+	ctx := context.Background()
+	for path, expected := range map[string]int{
+		"/ok":      http.StatusOK,
+		"/no-head": http.StatusOK,
+		"/moved":   http.StatusGone,
+		"/missing": http.StatusNotFound,
+	} {
+		status, err := c.Check(ctx, srv.URL+path)
+		s.Require().NoError(err, path)
+		s.Require().Equal(expected, status, path)
+	}
+
+	_, err := c.Check(ctx, "ftp://example.com/file")
+	s.Require().ErrorIs(err, ErrUnsupportedURL)
+
+	_, err = NewChecker(Options{}).Check(ctx, srv.URL+"/ok")
+	s.Require().ErrorIs(err, preview.ErrForbiddenAddress)
+}
+
+func (s *LinkCheckTestSuite) TestPerHostLimit() {
+	var inFlight, maxInFlight atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c := NewChecker(Options{PerHost: 2, AllowPrivate: true})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := c.Check(context.Background(), srv.URL)
+			s.NoError(err)
+			s.Equal(http.StatusOK, status)
+		}()
+	}
+	wg.Wait()
+
+	s.Require().Equal(int64(2), maxInFlight.Load())
+	s.Require().Empty(c.hosts)
+}
+
+func (s *LinkCheckTestSuite) TestBackoff() {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	backoff := 100 * time.Millisecond
+	c := NewChecker(Options{Backoff: backoff, AllowPrivate: true})
+	status, err := c.Check(context.Background(), srv.URL)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusServiceUnavailable, status)
+
+	start := time.Now()
+	status, err = c.Check(context.Background(), srv.URL)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().GreaterOrEqual(time.Since(start), backoff)
+
+	s.Run("cancel while waiting", func() {
+		calls.Store(0)
+		_, err = c.Check(context.Background(), srv.URL)
+		s.Require().NoError(err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = c.Check(ctx, srv.URL)
+		s.Require().ErrorIs(err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,283 @@
+// Package oidc implements relying party of OpenID Connect authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// maxResponseSize limit of provider responses.
+	maxResponseSize = 1 << 20
+	// clockLeeway allowed difference of clocks with provider.
+	clockLeeway = time.Minute
+	// maxMissingKeys limit of remembered unknown key ids.
+	maxMissingKeys = 100
+)
+
+// ErrProvider error response of identity provider.
+var ErrProvider = errors.New("identity provider error")
+
+// Config of relying party.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"` //nolint:tagliatelle
+	TokenEndpoint         string `json:"token_endpoint"`         //nolint:tagliatelle
+	JWKSURI               string `json:"jwks_uri"`               //nolint:tagliatelle
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"` //nolint:tagliatelle
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"` //nolint:tagliatelle
+}
+
+// Client relying party of one identity provider. Provider metadata and keys are fetched lazily and cached.
+// When ID token is signed by unknown key, keys are fetched again, so rotation of provider keys is supported.
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu       sync.Mutex
+	metadata *providerMetadata
+	keys     map[string]*rsa.PublicKey
+	// missingKeys key ids not found after refresh of keys, they do not trigger refresh again.
+	missingKeys map[string]struct{}
+}
+
+// NewClient Constructor for Client.
+func NewClient(cfg Config, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid"}
+	}
+
+	return &Client{
+		cfg:         cfg,
+		httpClient:  httpClient,
+		now:         time.Now,
+		missingKeys: make(map[string]struct{}),
+	}
+}
+
+// AuthCodeURL return URL of provider authorization endpoint with PKCE S256 challenge for verifier.
+func (c *Client) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	metadata, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange exchange authorization code for ID token and verify it.
+func (c *Client) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Claims, error) {
+	metadata, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", c.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	var resp tokenResponse
+	status, err := c.do(req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot exchange code: %w", err)
+	}
+	if status != http.StatusOK || resp.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrProvider, resp.Error, resp.ErrorDescription)
+	}
+
+	return c.Verify(ctx, resp.IDToken, nonce)
+}
+
+// Verify check signature and claims of ID token.
+func (c *Client) Verify(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	t, err := parseToken(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if t.header.Alg != algRS256 {
+		return nil, ErrTokenAlg
+	}
+
+	key, err := c.key(ctx, t.header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.verifySignature(key); err != nil {
+		return nil, err
+	}
+	if err = t.claims.validate(c.cfg.Issuer, c.cfg.ClientID, nonce, c.now(), clockLeeway); err != nil {
+		return nil, err
+	}
+
+	return &t.claims, nil
+}
+
+// key return provider key by id, keys are fetched again when key is unknown.
+func (c *Client) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	key, ok := c.keys[kid]
+	_, missing := c.missingKeys[kid]
+	c.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if missing {
+		return nil, ErrUnknownKey
+	}
+
+	if err := c.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, ok = c.keys[kid]; ok {
+		return key, nil
+	}
+	if len(c.missingKeys) >= maxMissingKeys {
+		clear(c.missingKeys)
+	}
+	c.missingKeys[kid] = struct{}{}
+
+	return nil, ErrUnknownKey
+}
+
+// refreshKeys fetch JWKS of provider.
+func (c *Client) refreshKeys(ctx context.Context) error {
+	metadata, err := c.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+
+	var set JWKS
+	status, err := c.do(req, &set)
+	if err != nil {
+		return fmt.Errorf("cannot fetch jwks: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%w: jwks status %d", ErrProvider, status)
+	}
+
+	keys, err := set.publicKeys()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	clear(c.missingKeys)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// discover fetch provider metadata once.
+func (c *Client) discover(ctx context.Context) (*providerMetadata, error) {
+	c.mu.Lock()
+	metadata := c.metadata
+	c.mu.Unlock()
+	if metadata != nil {
+		return metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.cfg.Issuer, "/")+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata = &providerMetadata{}
+	status, err := c.do(req, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("cannot discover provider: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery status %d", ErrProvider, status)
+	}
+	if metadata.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrProvider, metadata.Issuer)
+	}
+
+	c.mu.Lock()
+	c.metadata = metadata
+	c.mu.Unlock()
+
+	return metadata, nil
+}
+
+// do send request and decode JSON response.
+func (c *Client) do(req *http.Request, v any) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err = json.Unmarshal(data, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, err
+	}
+
+	return resp.StatusCode, nil
+}
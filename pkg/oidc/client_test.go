@@ -0,0 +1,168 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vagafonov/shortener/pkg/oidc"
+	"github.com/vagafonov/shortener/pkg/oidc/oidctest"
+)
+
+const redirectURL = "http://shortener.local/api/user/oidc/callback"
+
+type ClientTestSuite struct {
+	suite.Suite
+	idp    *oidctest.Provider
+	client *oidc.Client
+}
+
+func TestClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	s.idp = oidctest.NewProvider("shortener", "secret")
+	s.client = oidc.NewClient(oidc.Config{
+		Issuer:       s.idp.Issuer(),
+		ClientID:     "shortener",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	}, nil)
+}
+
+func (s *ClientTestSuite) TearDownTest() {
+	s.idp.Close()
+}
+
+// authorize pass user through provider and return authorization code.
+func (s *ClientTestSuite) authorize(state string, nonce string, verifier string) string {
+	authURL, err := s.client.AuthCodeURL(context.Background(), state, nonce, verifier)
+	s.Require().NoError(err)
+
+	httpClient := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := httpClient.Get(authURL) //nolint:noctx
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	s.Require().NoError(err)
+	s.Require().True(strings.HasPrefix(location.String(), redirectURL))
+	s.Require().Equal(state, location.Query().Get("state"))
+
+	return location.Query().Get("code")
+}
+
+func (s *ClientTestSuite) TestCodeFlow() {
+	ctx := context.Background()
+	verifier, err := oidc.RandomString()
+	s.Require().NoError(err)
+	s.idp.SetSubject("alice", "alice@example.com")
+
+	code := s.authorize("state", "nonce", verifier)
+	claims, err := s.client.Exchange(ctx, code, verifier, "nonce")
+	s.Require().NoError(err)
+	s.Require().Equal("alice", claims.Subject)
+	s.Require().Equal("alice@example.com", claims.Email)
+
+	s.Run("code is single use", func() {
+		_, err := s.client.Exchange(ctx, code, verifier, "nonce")
+		s.Require().ErrorIs(err, oidc.ErrProvider)
+	})
+
+	s.Run("wrong verifier", func() {
+		code := s.authorize("state", "nonce", verifier)
+		_, err := s.client.Exchange(ctx, code, verifier+"x", "nonce")
+		s.Require().ErrorIs(err, oidc.ErrProvider)
+	})
+
+	s.Run("wrong nonce", func() {
+		code := s.authorize("state", "nonce", verifier)
+		_, err := s.client.Exchange(ctx, code, verifier, "other")
+		s.Require().ErrorIs(err, oidc.ErrTokenClaims)
+	})
+}
+
+func (s *ClientTestSuite) TestKeyRotation() {
+	ctx := context.Background()
+	oldToken, err := s.idp.SignIDToken(s.idp.Claims("alice", "nonce"))
+	s.Require().NoError(err)
+	_, err = s.client.Verify(ctx, oldToken, "nonce")
+	s.Require().NoError(err)
+
+	s.Run("new key is fetched", func() {
+		s.idp.RotateKey(true)
+		token, err := s.idp.SignIDToken(s.idp.Claims("alice", "nonce"))
+		s.Require().NoError(err)
+		_, err = s.client.Verify(ctx, token, "nonce")
+		s.Require().NoError(err)
+		_, err = s.client.Verify(ctx, oldToken, "nonce")
+		s.Require().NoError(err)
+	})
+
+	s.Run("removed key is not accepted", func() {
+		s.idp.RotateKey(false)
+		token, err := s.idp.SignIDToken(s.idp.Claims("alice", "nonce"))
+		s.Require().NoError(err)
+		_, err = s.client.Verify(ctx, token, "nonce")
+		s.Require().NoError(err)
+		_, err = s.client.Verify(ctx, oldToken, "nonce")
+		s.Require().ErrorIs(err, oidc.ErrUnknownKey)
+	})
+}
+
+func (s *ClientTestSuite) TestInvalidTokens() {
+	ctx := context.Background()
+	valid, err := s.idp.SignIDToken(s.idp.Claims("alice", "nonce"))
+	s.Require().NoError(err)
+	parts := strings.Split(valid, ".")
+
+	expired := s.idp.Claims("alice", "nonce")
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	expiredToken, err := s.idp.SignIDToken(expired)
+	s.Require().NoError(err)
+
+	audience := s.idp.Claims("alice", "nonce")
+	audience.Audience = oidc.Audience{"other"}
+	audienceToken, err := s.idp.SignIDToken(audience)
+	s.Require().NoError(err)
+
+	issuer := s.idp.Claims("alice", "nonce")
+	issuer.Issuer = "https://evil.example.com"
+	issuerToken, err := s.idp.SignIDToken(issuer)
+	s.Require().NoError(err)
+
+	tamperedClaims := s.idp.Claims("mallory", "nonce")
+	tampered, err := s.idp.SignIDToken(tamperedClaims)
+	s.Require().NoError(err)
+	tampered = parts[0] + "." + strings.Split(tampered, ".")[1] + "." + parts[2]
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "malformed", token: "abc", err: oidc.ErrTokenMalformed},
+		{name: "alg none", token: none, err: oidc.ErrTokenAlg},
+		{name: "tampered payload", token: tampered, err: oidc.ErrTokenSignature},
+		{name: "expired", token: expiredToken, err: oidc.ErrTokenExpired},
+		{name: "wrong audience", token: audienceToken, err: oidc.ErrTokenClaims},
+		{name: "wrong issuer", token: issuerToken, err: oidc.ErrTokenClaims},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, err := s.client.Verify(ctx, tt.token, "nonce")
+			s.Require().ErrorIs(err, tt.err)
+		})
+	}
+}
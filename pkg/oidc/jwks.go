@@ -0,0 +1,69 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWK public key in JSON Web Key format. Only RSA keys are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS set of public keys of provider.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK create JWK from RSA public key.
+func NewJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: algRS256,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// publicKeys return RSA signing keys by key id, other keys are skipped.
+func (s *JWKS) publicKeys() (map[string]*rsa.PublicKey, error) {
+	keys := make(map[string]*rsa.PublicKey, len(s.Keys))
+	for _, v := range s.Keys {
+		if v.Kty != "RSA" || (v.Use != "" && v.Use != "sig") || (v.Alg != "" && v.Alg != algRS256) {
+			continue
+		}
+		key, err := v.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", v.Kid, err)
+		}
+		keys[v.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k *JWK) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, ErrInvalidKey
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
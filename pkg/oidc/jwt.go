@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const algRS256 = "RS256"
+
+// errors of ID token validation.
+var (
+	ErrTokenMalformed = errors.New("malformed id token")
+	ErrTokenAlg       = errors.New("unsupported id token algorithm")
+	ErrTokenSignature = errors.New("invalid id token signature")
+	ErrTokenClaims    = errors.New("invalid id token claims")
+	ErrTokenExpired   = errors.New("id token expired")
+	ErrUnknownKey     = errors.New("unknown id token signing key")
+	ErrInvalidKey     = errors.New("invalid provider key")
+)
+
+// Claims of ID token used by relying party.
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  Audience `json:"aud"`
+	AZP       string   `json:"azp,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+	Nonce     string   `json:"nonce,omitempty"`
+	Email     string   `json:"email,omitempty"`
+}
+
+// Audience "aud" claim, which may be a string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON decode audience from string or array.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// token parsed but not verified JWT.
+type token struct {
+	header    header
+	claims    Claims
+	signed    string
+	signature []byte
+}
+
+// parseToken decode JWT in compact serialization without verification.
+func parseToken(raw string) (*token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 { //nolint:mnd,gomnd
+		return nil, ErrTokenMalformed
+	}
+
+	var t token
+	if err := decodeSegment(parts[0], &t.header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := decodeSegment(parts[1], &t.claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	t.signed = parts[0] + "." + parts[1]
+	t.signature = signature
+
+	return &t, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// verifySignature check RS256 signature with key.
+func (t *token) verifySignature(key *rsa.PublicKey) error {
+	sum := sha256.Sum256([]byte(t.signed))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], t.signature); err != nil {
+		return ErrTokenSignature
+	}
+
+	return nil
+}
+
+// validate check issuer, audience, lifetime and nonce of ID token.
+func (c *Claims) validate(issuer string, clientID string, nonce string, now time.Time, leeway time.Duration) error {
+	if c.Issuer != issuer || c.Subject == "" || !slices.Contains(c.Audience, clientID) {
+		return ErrTokenClaims
+	}
+	if len(c.Audience) > 1 && c.AZP != clientID {
+		return ErrTokenClaims
+	}
+	if c.Nonce != nonce {
+		return ErrTokenClaims
+	}
+	if !now.Before(time.Unix(c.ExpiresAt, 0).Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if time.Unix(c.IssuedAt, 0).After(now.Add(leeway)) {
+		return ErrTokenClaims
+	}
+
+	return nil
+}
+
+// Sign create RS256 signed JWT with claims. Used by identity provider.
+func Sign(key *rsa.PrivateKey, kid string, claims any) (string, error) {
+	h, err := json.Marshal(map[string]string{"alg": algRS256, "kid": kid, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
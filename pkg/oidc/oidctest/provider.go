@@ -0,0 +1,265 @@
+// Package oidctest provides fake OpenID Connect identity provider for tests.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vagafonov/shortener/pkg/oidc"
+)
+
+const (
+	keyBits       = 2048
+	idTokenTTL    = 5 * time.Minute
+	codeChallenge = "S256"
+)
+
+type signingKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+type authRequest struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	subject     string
+}
+
+// Provider fake identity provider which approves every authorization request for current subject.
+type Provider struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu      sync.Mutex
+	keys    []signingKey
+	subject string
+	email   string
+	codes   map[string]authRequest
+	counter int
+}
+
+// NewProvider start fake identity provider for client.
+func NewProvider(clientID string, clientSecret string) *Provider {
+	p := &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		subject:      "user-1",
+		codes:        make(map[string]authRequest),
+	}
+	p.RotateKey(false)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+	p.Server = httptest.NewServer(mux)
+
+	return p
+}
+
+// Issuer return issuer identifier of provider.
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// Close stop provider.
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// SetSubject set user which approves next authorization requests.
+func (p *Provider) SetSubject(subject string, email string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subject = subject
+	p.email = email
+}
+
+// RotateKey generate new signing key. Previous keys are published in JWKS only when keepOld is true.
+func (p *Provider) RotateKey(keepOld bool) {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		panic(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counter++
+	k := signingKey{id: "key-" + strconv.Itoa(p.counter), key: key}
+	if keepOld {
+		p.keys = append(p.keys, k)
+	} else {
+		p.keys = []signingKey{k}
+	}
+}
+
+// SignIDToken sign claims with current key of provider.
+func (p *Provider) SignIDToken(claims any) (string, error) {
+	p.mu.Lock()
+	k := p.keys[len(p.keys)-1]
+	p.mu.Unlock()
+
+	return oidc.Sign(k.key, k.id, claims)
+}
+
+// Claims return valid claims of ID token for subject.
+func (p *Provider) Claims(subject string, nonce string) oidc.Claims {
+	now := time.Now()
+
+	return oidc.Claims{
+		Issuer:    p.Issuer(),
+		Subject:   subject,
+		Audience:  oidc.Audience{p.ClientID},
+		ExpiresAt: now.Add(idTokenTTL).Unix(),
+		IssuedAt:  now.Unix(),
+		Nonce:     nonce,
+	}
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{codeChallenge},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	set := oidc.JWKS{Keys: make([]oidc.JWK, len(p.keys))}
+	for i, v := range p.keys {
+		set.Keys[i] = oidc.NewJWK(v.id, &v.key.PublicKey)
+	}
+	p.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+
+		return
+	}
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" {
+		http.Error(w, "invalid client or response type", http.StatusBadRequest)
+
+		return
+	}
+	if q.Get("code_challenge_method") != codeChallenge || q.Get("code_challenge") == "" {
+		http.Error(w, "pkce required", http.StatusBadRequest)
+
+		return
+	}
+
+	code, err := oidc.RandomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	p.mu.Lock()
+	p.codes[code] = authRequest{
+		clientID:    p.ClientID,
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		subject:     p.subject,
+	}
+	p.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		writeTokenError(w, "invalid_request")
+
+		return
+	}
+	if !p.authenticateClient(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	req, ok := p.codes[code]
+	delete(p.codes, code)
+	email := p.email
+	p.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != req.redirectURI ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != req.challenge {
+		writeTokenError(w, "invalid_grant")
+
+		return
+	}
+
+	claims := p.Claims(req.subject, req.nonce)
+	claims.Email = email
+	idToken, err := p.SignIDToken(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// authenticateClient check client_secret_basic credentials, public client sends only client_id.
+func (p *Provider) authenticateClient(r *http.Request) bool {
+	if p.ClientSecret == "" {
+		return r.PostForm.Get("client_id") == p.ClientID
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	id, errID := url.QueryUnescape(id)
+	secret, errSecret := url.QueryUnescape(secret)
+
+	return errID == nil && errSecret == nil && id == p.ClientID && secret == p.ClientSecret
+}
+
+func writeTokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,28 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/vagafonov/shortener/pkg/encrypting"
+)
+
+// verifierBytes gives 43 characters of verifier, minimal length allowed by RFC 7636.
+const verifierBytes = 32
+
+// RandomString return URL safe random string, used for PKCE verifier, state and nonce.
+func RandomString() (string, error) {
+	b, err := encrypting.GenerateRandom(verifierBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge return S256 code challenge for PKCE verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,215 @@
+// Package preview fetches web pages and extracts their title, OpenGraph tags and favicon.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errors of fetching.
+var (
+	ErrForbiddenAddress = errors.New("address of destination is not public")
+	ErrNotHTML          = errors.New("destination is not html page")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// defaults of Fetcher.
+const (
+	defaultTimeout  = 5 * time.Second
+	defaultMaxBytes = 1 << 20
+	defaultBackoff  = 500 * time.Millisecond
+	maxRedirects    = 5
+	dialTimeout     = 3 * time.Second
+	userAgent       = "Mozilla/5.0 (compatible; ShortenerPreview/1.0)"
+)
+
+// nonPublicPrefixes special purpose networks which are not covered by methods of netip.Addr.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Options settings of Fetcher, zero values mean defaults.
+type Options struct {
+	// Timeout of one attempt including reading of body.
+	Timeout time.Duration
+	// MaxBytes only beginning of page up to this size is read.
+	MaxBytes int64
+	// Retries number of repeated attempts after network errors and 5xx or 429 responses.
+	Retries int
+	// Backoff delay before first retry, it is doubled for every next retry.
+	Backoff time.Duration
+	// AllowPrivate allow loopback and private addresses, only for tests.
+	AllowPrivate bool
+}
+
+// Fetcher fetch metadata of web pages. Connections to not public addresses are refused
+// after name resolution, so neither DNS names nor redirects can lead to internal services.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+	retries  int
+	backoff  time.Duration
+}
+
+// NewFetcher Constructor for Fetcher.
+func NewFetcher(opt Options) *Fetcher {
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultTimeout
+	}
+	if opt.MaxBytes <= 0 {
+		opt.MaxBytes = defaultMaxBytes
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = defaultBackoff
+	}
+
+	return &Fetcher{
+		client:   NewClient(opt.Timeout, opt.AllowPrivate),
+		maxBytes: opt.MaxBytes,
+		retries:  opt.Retries,
+		backoff:  opt.Backoff,
+	}
+}
+
+// NewClient HTTP client which refuses connections to not public addresses after name resolution
+// and follows only limited number of redirects to http and https URLs.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if !allowPrivate {
+		dialer.Control = func(network string, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !IsPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   dialTimeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   1,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return ErrTooManyRedirects
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrForbiddenAddress
+			}
+
+			return nil
+		},
+	}
+}
+
+// IsPublicAddr check that address is routable in internet.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fetch download page and extract its metadata. Temporary failures are retried with backoff.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	backoff := f.backoff
+	for attempt := 0; ; attempt++ {
+		m, err := f.fetch(ctx, rawURL)
+		if err == nil || attempt >= f.retries || !isTemporary(err) {
+			return m, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// statusError response with unexpected status.
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.status)
+}
+
+// isTemporary check that attempt may succeed later.
+func isTemporary(err error) bool {
+	if errors.Is(err, ErrForbiddenAddress) || errors.Is(err, ErrNotHTML) || errors.Is(err, ErrTooManyRedirects) ||
+		errors.Is(err, context.Canceled) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status >= http.StatusInternalServerError || se.status == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, ErrForbiddenAddress
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &statusError{status: resp.StatusCode}
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+			return nil, fmt.Errorf("%w: %s", ErrNotHTML, ct)
+		}
+	}
+
+	var page strings.Builder
+	if _, err = io.Copy(&page, io.LimitReader(resp.Body, f.maxBytes)); err != nil {
+		return nil, err
+	}
+
+	return Parse(page.String(), resp.Request.URL), nil
+}
@@ -0,0 +1,188 @@
+package preview
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// Metadata preview of web page.
+type Metadata struct {
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+	Favicon     string
+}
+
+// Parse extract title, OpenGraph tags and favicon from head of HTML page.
+// Relative URLs are resolved against base, favicon defaults to /favicon.ico of base.
+func Parse(page string, base *url.URL) *Metadata {
+	m := &Metadata{}
+	var title, description string
+	for i := 0; i < len(page); {
+		start := strings.IndexByte(page[i:], '<')
+		if start < 0 {
+			break
+		}
+		i += start
+		if strings.HasPrefix(page[i:], "<!--") {
+			end := strings.Index(page[i:], "-->")
+			if end < 0 {
+				break
+			}
+			i += end + len("-->")
+
+			continue
+		}
+
+		end := strings.IndexByte(page[i:], '>')
+		if end < 0 {
+			break
+		}
+		tag := page[i+1 : i+end]
+		i += end + 1
+
+		name, attrs := parseTag(tag)
+		switch name {
+		case "title":
+			if closing := indexFold(page[i:], "</title"); closing >= 0 && title == "" {
+				title = normalizeText(page[i : i+closing])
+			}
+		case "script", "style":
+			if closing := indexFold(page[i:], "</"+name); closing >= 0 {
+				i += closing
+			}
+		case "meta":
+			applyMeta(m, attrs, &description)
+		case "link":
+			if m.Favicon == "" && isIconRel(attrs["rel"]) {
+				m.Favicon = attrs["href"]
+			}
+		case "/head", "body":
+			i = len(page)
+		}
+	}
+
+	if m.Title == "" {
+		m.Title = title
+	}
+	if m.Description == "" {
+		m.Description = description
+	}
+	if m.Favicon == "" {
+		m.Favicon = "/favicon.ico"
+	}
+	m.Image = resolve(base, m.Image)
+	m.Favicon = resolve(base, m.Favicon)
+
+	return m
+}
+
+func applyMeta(m *Metadata, attrs map[string]string, description *string) {
+	content := normalizeText(attrs["content"])
+	if content == "" {
+		return
+	}
+	key := attrs["property"]
+	if key == "" {
+		key = attrs["name"]
+	}
+	switch strings.ToLower(key) {
+	case "og:title":
+		m.Title = content
+	case "og:description":
+		m.Description = content
+	case "og:image", "og:image:url":
+		if m.Image == "" {
+			m.Image = content
+		}
+	case "og:site_name":
+		m.SiteName = content
+	case "description":
+		*description = content
+	}
+}
+
+func isIconRel(rel string) bool {
+	for _, v := range strings.Fields(strings.ToLower(rel)) {
+		if v == "icon" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTag return lowercase name of tag and its attributes with unescaped values.
+func parseTag(tag string) (string, map[string]string) {
+	tag = strings.TrimSuffix(tag, "/")
+	name, rest := tag, ""
+	if i := strings.IndexAny(tag, " \t\n\r"); i >= 0 {
+		name, rest = tag[:i], tag[i:]
+	}
+	attrs := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		end := strings.IndexAny(rest, "= \t\n\r")
+		if end < 0 {
+			attrs[strings.ToLower(rest)] = ""
+
+			break
+		}
+		key := strings.ToLower(rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t\n\r")
+		if !strings.HasPrefix(rest, "=") {
+			attrs[key] = ""
+
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\n\r")
+
+		var value string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			closing := strings.IndexByte(rest[1:], rest[0])
+			if closing < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:closing+1], rest[closing+2:]
+			}
+		} else {
+			closing := strings.IndexAny(rest, " \t\n\r")
+			if closing < 0 {
+				closing = len(rest)
+			}
+			value, rest = rest[:closing], rest[closing:]
+		}
+		attrs[key] = html.UnescapeString(value)
+	}
+
+	return strings.ToLower(name), attrs
+}
+
+// normalizeText unescape text and collapse whitespaces.
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+func resolve(base *url.URL, ref string) string {
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	return u.String()
+}
+
+// indexFold index of first case-insensitive occurrence of ASCII substr in s.
+func indexFold(s string, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,162 @@
+package preview
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const page = `<!DOCTYPE html>
+<html><head>
+<meta charset="utf-8">
+<title>
+  Example &amp; Co
+</title>
+<!-- <title>commented</title> -->
+<meta name="description" content="Plain description">
+<meta property="og:title" content="Example OG">
+<meta property='og:image' content=/img/cover.png>
+<meta property="og:site_name" content="Example">
+<script>var s = "<meta property=\"og:description\" content=\"from script\">";</script>
+<link rel="shortcut icon" href="/static/favicon.png">
+</head>
+<body><meta property="og:description" content="from body"></body>
+</html>`
+
+type PreviewTestSuite struct {
+	suite.Suite
+}
+
+func TestPreviewTestSuite(t *testing.T) {
+	suite.Run(t, new(PreviewTestSuite))
+}
+
+func (s *PreviewTestSuite) TestParse() {
+	base, err := url.Parse("https://example.com/articles/1")
+	s.Require().NoError(err)
+
+	s.Require().Equal(&Metadata{
+		Title:       "Example OG",
+		Description: "Plain description",
+		Image:       "https://example.com/img/cover.png",
+		SiteName:    "Example",
+		Favicon:     "https://example.com/static/favicon.png",
+	}, Parse(page, base))
+
+	s.Require().Equal(&Metadata{
+		Title:   "Example & Co",
+		Favicon: "https://example.com/favicon.ico",
+	}, Parse(`<TITLE>Example &amp; Co</TITLE><link rel=stylesheet href=/a.css>`, base))
+
+	s.Require().Equal(&Metadata{Favicon: "https://example.com/favicon.ico"}, Parse(`<title>unclosed`, base))
+}
+
+func (s *PreviewTestSuite) TestFetch() { //nolint:funlen
+	var failures atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page)) //nolint:errcheck
+	})
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if failures.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		w.Write([]byte(`<title>Recovered</title>`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		failures.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(strings.Repeat(" ", 2048) + `<title>Too far</title>`)) //nolint:errcheck
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	f := NewFetcher(Options{
+		Timeout:      100 * time.Millisecond,
+		MaxBytes:     1024,
+		Retries:      2,
+		Backoff:      time.Millisecond,
+		AllowPrivate: true,
+	})
+
+	s.Run("page", func() {
+		m, err := f.Fetch(ctx, srv.URL+"/redirect")
+		s.Require().NoError(err)
+		s.Require().Equal("Example OG", m.Title)
+		s.Require().Equal(srv.URL+"/static/favicon.png", m.Favicon)
+	})
+
+	s.Run("retries", func() {
+		failures.Store(0)
+		m, err := f.Fetch(ctx, srv.URL+"/flaky")
+		s.Require().NoError(err)
+		s.Require().Equal("Recovered", m.Title)
+		s.Require().Equal(int64(3), failures.Load())
+
+		failures.Store(0)
+		_, err = f.Fetch(ctx, srv.URL+"/missing")
+		s.Require().Error(err)
+		s.Require().Equal(int64(1), failures.Load(), "client errors are not retried")
+	})
+
+	s.Run("limits", func() {
+		_, err := f.Fetch(ctx, srv.URL+"/image")
+		s.Require().ErrorIs(err, ErrNotHTML)
+
+		m, err := f.Fetch(ctx, srv.URL+"/huge")
+		s.Require().NoError(err)
+		s.Require().Empty(m.Title)
+
+		_, err = f.Fetch(ctx, srv.URL+"/slow")
+		s.Require().Error(err)
+	})
+
+	s.Run("private address", func() {
+		_, err := NewFetcher(Options{}).Fetch(ctx, srv.URL+"/page")
+		s.Require().ErrorIs(err, ErrForbiddenAddress)
+
+		_, err = NewFetcher(Options{}).Fetch(ctx, "file:///etc/passwd")
+		s.Require().ErrorIs(err, ErrForbiddenAddress)
+	})
+}
+
+func (s *PreviewTestSuite) TestIsPublicAddr() {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800::1":       true,
+		"127.0.0.1":          false,
+		"10.1.2.3":           false,
+		"169.254.169.254":    false,
+		"100.64.0.1":         false,
+		"::ffff:192.168.0.1": false,
+		"::1":                false,
+		"fd00::1":            false,
+		"0.0.0.0":            false,
+	} {
+		s.Require().Equal(public, IsPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
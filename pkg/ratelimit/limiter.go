@@ -0,0 +1,72 @@
+// Package ratelimit limits number of attempts by key in fixed time window.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type window struct {
+	attempts int
+	resetAt  time.Time
+}
+
+// Limiter allows limited number of attempts by key per window.
+// Attempt is counted before it is made, so parallel requests cannot exceed limit.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	period    time.Duration
+	windows   map[string]*window
+	nextSweep time.Time
+}
+
+// NewLimiter Constructor for Limiter.
+func NewLimiter(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		period:  period,
+		windows: make(map[string]*window),
+	}
+}
+
+// Attempt count attempt by key. If limit is reached returns false and time until next attempt is allowed.
+func (l *Limiter) Attempt(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &window{resetAt: now.Add(l.period)}
+		l.windows[key] = w
+	}
+	if w.attempts >= l.limit {
+		return false, w.resetAt.Sub(now)
+	}
+	w.attempts++
+
+	return true, 0
+}
+
+// Reset forget attempts by key, e.g. after successful one.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.windows, key)
+}
+
+// sweep remove expired windows once per period, so map does not grow with keys which are not used anymore.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Before(l.nextSweep) {
+		return
+	}
+	for k, w := range l.windows {
+		if !now.Before(w.resetAt) {
+			delete(l.windows, k)
+		}
+	}
+	l.nextSweep = now.Add(l.period)
+}
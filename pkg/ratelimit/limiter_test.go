@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LimiterTestSuite struct {
+	suite.Suite
+}
+
+func TestLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(LimiterTestSuite))
+}
+
+func (s *LimiterTestSuite) TestAttempt() {
+	now := time.Now()
+	l := NewLimiter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Attempt("a", now)
+		s.Require().True(ok)
+	}
+	ok, retryAfter := l.Attempt("a", now.Add(10*time.Second))
+	s.Require().False(ok)
+	s.Require().Equal(50*time.Second, retryAfter)
+
+	ok, _ = l.Attempt("b", now)
+	s.Require().True(ok, "keys are limited separately")
+
+	ok, _ = l.Attempt("a", now.Add(time.Minute))
+	s.Require().True(ok, "window is over")
+
+	l.Reset("a")
+	ok, _ = l.Attempt("a", now.Add(time.Minute))
+	s.Require().True(ok)
+}
+
+func (s *LimiterTestSuite) TestParallelAttempts() {
+	now := time.Now()
+	l := NewLimiter(5, time.Minute)
+
+	var mu sync.Mutex
+	allowed := 0
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := l.Attempt("key", now); ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	s.Require().Equal(5, allowed)
+}
+
+func (s *LimiterTestSuite) TestSweep() {
+	now := time.Now()
+	l := NewLimiter(1, time.Minute)
+	l.Attempt("a", now)
+	l.Attempt("b", now)
+
+	l.Attempt("c", now.Add(2*time.Minute))
+	s.Require().Len(l.windows, 1)
+}
@@ -0,0 +1,189 @@
+// Package trigram indexes texts by trigrams of their words for substring and fuzzy search.
+// Trigrams are built like in pg_trgm: words are lowercased and padded with two spaces before and one after.
+package trigram
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultThreshold minimal share of trigrams of query found in text, the same as word similarity threshold of pg_trgm.
+const DefaultThreshold = 0.6
+
+// Match found text and its relevance. Texts containing query as substring have score above 1.
+type Match struct {
+	ID    string
+	Score float64
+}
+
+// Index trigram index of texts by their ids, it is safe for concurrent use.
+type Index struct {
+	mu    sync.RWMutex
+	texts map[string]string
+	grams map[string]map[string]struct{}
+}
+
+// NewIndex Constructor for Index.
+func NewIndex() *Index {
+	return &Index{
+		texts: make(map[string]string),
+		grams: make(map[string]map[string]struct{}),
+	}
+}
+
+// Add index text by id, previous text of id is replaced.
+func (x *Index) Add(id string, text string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	text = strings.ToLower(text)
+	x.texts[id] = text
+	for _, g := range Trigrams(text) {
+		ids, ok := x.grams[g]
+		if !ok {
+			ids = make(map[string]struct{})
+			x.grams[g] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// Remove forget text by id.
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+}
+
+// Reset forget all texts.
+func (x *Index) Reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	clear(x.texts)
+	clear(x.grams)
+}
+
+func (x *Index) remove(id string) {
+	text, ok := x.texts[id]
+	if !ok {
+		return
+	}
+	delete(x.texts, id)
+	for _, g := range Trigrams(text) {
+		delete(x.grams[g], id)
+		if len(x.grams[g]) == 0 {
+			delete(x.grams, g)
+		}
+	}
+}
+
+// Search find texts containing query or sharing at least threshold of trigrams of query.
+// Score is share of trigrams of query found in text plus 1 for substring match,
+// matches are ordered by score and then by id.
+func (x *Index) Search(query string, threshold float64) []Match {
+	query = strings.ToLower(strings.TrimSpace(query))
+	queryGrams := Trigrams(query)
+	if len(queryGrams) == 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	shared := make(map[string]int)
+	for _, g := range queryGrams {
+		for id := range x.grams[g] {
+			shared[id]++
+		}
+	}
+	scores := make(map[string]float64)
+	for id, n := range shared {
+		if score := float64(n) / float64(len(queryGrams)); score >= threshold {
+			scores[id] = score
+		}
+	}
+	for id := range x.substringCandidates(query) {
+		if strings.Contains(x.texts[id], query) {
+			scores[id] = 1 + float64(shared[id])/float64(len(queryGrams))
+		}
+	}
+
+	res := make([]Match, 0, len(scores))
+	for id, score := range scores {
+		res = append(res, Match{ID: id, Score: score})
+	}
+	slices.SortFunc(res, func(a, b Match) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+
+			return 1
+		}
+
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return res
+}
+
+// substringCandidates ids of texts which have all trigrams inside words of query.
+// Every text is a candidate when words of query are too short to have trigrams.
+func (x *Index) substringCandidates(query string) map[string]string {
+	var res map[string]string
+	for _, w := range words(query) {
+		runes := []rune(w)
+		for i := 0; i+3 <= len(runes); i++ {
+			ids := x.grams[string(runes[i:i+3])]
+			if res == nil {
+				res = make(map[string]string, len(ids))
+				for id := range ids {
+					res[id] = x.texts[id]
+				}
+
+				continue
+			}
+			for id := range res {
+				if _, ok := ids[id]; !ok {
+					delete(res, id)
+				}
+			}
+		}
+	}
+	if res == nil {
+		return x.texts
+	}
+
+	return res
+}
+
+// Trigrams unique trigrams of lowercased words of text.
+func Trigrams(text string) []string {
+	seen := make(map[string]struct{})
+	res := make([]string, 0)
+	for _, w := range words(strings.ToLower(text)) {
+		runes := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			g := string(runes[i : i+3])
+			if _, ok := seen[g]; ok {
+				continue
+			}
+			seen[g] = struct{}{}
+			res = append(res, g)
+		}
+	}
+
+	return res
+}
+
+// words split text by characters which are neither letters nor digits.
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,63 @@
+package trigram
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TrigramTestSuite struct {
+	suite.Suite
+}
+
+func TestTrigramTestSuite(t *testing.T) {
+	suite.Run(t, new(TrigramTestSuite))
+}
+
+func (s *TrigramTestSuite) TestTrigrams() {
+	s.Require().Equal([]string{"  c", " ca", "cat", "at "}, Trigrams("Cat"))
+	s.Require().Equal([]string{"  a", " a ", "  b", " b "}, Trigrams("a/b/a"))
+	s.Require().Empty(Trigrams(" ./ "))
+}
+
+func (s *TrigramTestSuite) TestSearch() {
+	x := NewIndex()
+	x.Add("docs", "https://example.com/docs Documentation")
+	x.Add("maps", "https://google.com/maps Google Maps")
+	x.Add("mail", "https://mail.google.com Gmail")
+
+	ids := func(matches []Match) []string {
+		res := make([]string, len(matches))
+		for i, m := range matches {
+			res[i] = m.ID
+		}
+
+		return res
+	}
+
+	s.Run("substring matches rank first", func() {
+		s.Require().Equal([]string{"maps", "mail"}, ids(x.Search("Google Maps", DefaultThreshold)))
+		s.Require().Equal([]string{"mail", "maps"}, ids(x.Search("oogle", DefaultThreshold)))
+		s.Require().Equal([]string{"mail"}, ids(x.Search("ai", DefaultThreshold)))
+	})
+
+	s.Run("fuzzy matches", func() {
+		matches := x.Search("documntation", DefaultThreshold)
+		s.Require().Equal([]string{"docs"}, ids(matches))
+		s.Require().Less(matches[0].Score, 1.0)
+		s.Require().Empty(x.Search("documntation", 0.9))
+	})
+
+	s.Run("replaced and removed texts", func() {
+		x.Add("docs", "https://example.com/guide")
+		s.Require().Empty(x.Search("documentation", DefaultThreshold))
+		s.Require().Equal([]string{"docs"}, ids(x.Search("guide", DefaultThreshold)))
+
+		x.Remove("maps")
+		s.Require().Equal([]string{"mail"}, ids(x.Search("google", DefaultThreshold)))
+
+		x.Reset()
+		s.Require().Empty(x.Search("mail", DefaultThreshold))
+		s.Require().Empty(x.Search("", DefaultThreshold))
+	})
+}
@@ -0,0 +1,90 @@
+// Package useragent detects families of clients by User-Agent header.
+package useragent
+
+import "strings"
+
+// Operating systems.
+const (
+	OSIOS     = "ios"
+	OSAndroid = "android"
+	OSWindows = "windows"
+	OSMacOS   = "macos"
+	OSLinux   = "linux"
+	OSOther   = "other"
+)
+
+// Device classes.
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// AllOS known operating systems.
+var AllOS = []string{OSIOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSOther}
+
+// AllDevices known device classes.
+var AllDevices = []string{DeviceMobile, DeviceTablet, DeviceDesktop}
+
+// botMarkers parts of User-Agent of crawlers, link previews and HTTP libraries.
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "preview", "facebookexternalhit",
+	"curl", "wget", "python-requests", "go-http-client", "headless",
+}
+
+// Client family of client.
+type Client struct {
+	OS     string
+	Device string
+	Bot    bool
+}
+
+// Parse detect family of client by User-Agent. Client without User-Agent is considered a bot.
+func Parse(ua string) Client {
+	ua = strings.ToLower(ua)
+
+	return Client{
+		OS:     parseOS(ua),
+		Device: parseDevice(ua),
+		Bot:    ua == "" || containsAny(ua, botMarkers...),
+	}
+}
+
+func parseOS(ua string) string {
+	switch {
+	case containsAny(ua, "iphone", "ipad", "ipod"):
+		return OSIOS
+	case strings.Contains(ua, "android"):
+		return OSAndroid
+	case strings.Contains(ua, "windows"):
+		return OSWindows
+	case containsAny(ua, "macintosh", "mac os x"):
+		return OSMacOS
+	case containsAny(ua, "linux", "x11"):
+		return OSLinux
+	default:
+		return OSOther
+	}
+}
+
+func parseDevice(ua string) string {
+	switch {
+	// планшеты на Android не пишут Mobile в User-Agent
+	case containsAny(ua, "ipad", "tablet") || (strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return DeviceTablet
+	case containsAny(ua, "mobi", "iphone", "ipod"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, v := range substrs {
+		if strings.Contains(s, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UserAgentTestSuite struct {
+	suite.Suite
+}
+
+func TestUserAgentTestSuite(t *testing.T) {
+	suite.Run(t, new(UserAgentTestSuite))
+}
+
+func (s *UserAgentTestSuite) TestParse() {
+	tests := []struct {
+		name string
+		ua   string
+		exp  Client
+	}{
+		{
+			name: "iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148",
+			exp:  Client{OS: OSIOS, Device: DeviceMobile},
+		},
+		{
+			name: "ipad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148",
+			exp:  Client{OS: OSIOS, Device: DeviceTablet},
+		},
+		{
+			name: "android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/124.0 Mobile Safari/537.36",
+			exp:  Client{OS: OSAndroid, Device: DeviceMobile},
+		},
+		{
+			name: "android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 Chrome/124.0 Safari/537.36",
+			exp:  Client{OS: OSAndroid, Device: DeviceTablet},
+		},
+		{
+			name: "windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/124.0 Safari/537.36",
+			exp:  Client{OS: OSWindows, Device: DeviceDesktop},
+		},
+		{
+			name: "macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 Version/17.4 Safari/605.1.15",
+			exp:  Client{OS: OSMacOS, Device: DeviceDesktop},
+		},
+		{
+			name: "crawler",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			exp:  Client{OS: OSOther, Device: DeviceDesktop, Bot: true},
+		},
+		{
+			name: "empty",
+			ua:   "",
+			exp:  Client{OS: OSOther, Device: DeviceDesktop, Bot: true},
+		},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.Require().Equal(test.exp, Parse(test.ua))
+		})
+	}
+}